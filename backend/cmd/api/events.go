@@ -0,0 +1,29 @@
+package main
+
+import (
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/infrastructure/events"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"gorm.io/gorm"
+)
+
+// setupEvents creates the domain event bus and subscribes its handlers
+func setupEvents(db *gorm.DB) *events.Bus {
+	bus := events.NewBus()
+
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	onboardingRepo := repository.NewOnboardingRepository(db)
+
+	onboardingService := application.NewOnboardingService(db, tenantRepo, userRepo, onboardingRepo, repository.NewChatwootProvisioningRepository(db), application.NewChatwootClientFactory(repository.NewTenantIntegrationRepository(db)))
+
+	// Complete onboarding checklist steps
+	onboardingService.Subscribe(bus)
+
+	// Meter conversations, messages and bot runs from the Chatwoot webhooks
+	usageService := application.NewUsageService(db, repository.NewUsageRepository(db))
+	usageService.Subscribe(bus)
+
+	return bus
+}
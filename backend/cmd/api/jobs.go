@@ -0,0 +1,81 @@
+package main
+
+import (
+	"time"
+
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/infrastructure/billing"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/infrastructure/scheduler"
+	"github.com/widia/widia-connect/internal/infrastructure/storage"
+	"gorm.io/gorm"
+)
+
+// setupJobs registers the background jobs run by the API process. The billing
+// provider is the one used by the routes.
+func setupJobs(db *gorm.DB, billingProvider billing.Provider) *scheduler.Scheduler {
+	jobs := scheduler.New()
+
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	subscriptionEventRepo := repository.NewSubscriptionEventRepository(db)
+	usageRepo := repository.NewUsageRepository(db)
+	exportRepo := repository.NewTenantExportRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
+	onboardingRepo := repository.NewOnboardingRepository(db)
+	scheduleRepo := repository.NewUserScheduleRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+
+	subscriptionService := application.NewSubscriptionService(db, tenantRepo, userRepo, subscriptionEventRepo)
+	usageService := application.NewUsageService(db, usageRepo)
+	store := storage.NewFromConfig()
+	exportService := application.NewExportService(db, tenantRepo, userRepo, exportRepo, store)
+	offboardingService := application.NewOffboardingService(db, tenantRepo, userRepo, auditRepo, store, billingProvider)
+	chatwootClients := application.NewChatwootClientFactory(repository.NewTenantIntegrationRepository(db))
+	onboardingService := application.NewOnboardingService(db, tenantRepo, userRepo, onboardingRepo, repository.NewChatwootProvisioningRepository(db), chatwootClients)
+	presenceService := application.NewPresenceService(db, userRepo, scheduleRepo, teamRepo, roleService, application.NewChatwootLoadCounter(db, chatwootClients), chatwootClients)
+	mailSettingsRepo := repository.NewTenantMailSettingsRepository(db)
+	emailOutboxService := application.NewEmailOutboxService(repository.NewEmailOutboxRepository(db), application.NewTenantMailer(mailSettingsRepo, email.NewMailerFromEnv()))
+	mailSettingsService := application.NewMailSettingsService(db, tenantRepo, userRepo, mailSettingsRepo, email.NewMailerFromEnv())
+	emailDeliveryService := application.NewEmailDeliveryService(db, repository.NewEmailDeliveryRepository(db), repository.NewEmailOutboxRepository(db))
+	provisioningService := application.NewChatwootProvisioningService(db, repository.NewChatwootProvisioningRepository(db), tenantRepo, userRepo)
+	chatwootWebhookService := application.NewChatwootWebhookService(repository.NewChatwootWebhookRepository(db), repository.NewTenantIntegrationRepository(db))
+
+	// Expire trials, suspend overdue tenants and send expiry reminders
+	jobs.Every("subscription-lifecycle", 24*time.Hour, subscriptionService.RunDailyChecks)
+
+	// Drop hourly usage rollups past their retention
+	jobs.Every("usage-retention", 24*time.Hour, usageService.RunRetention)
+
+	// Resume interrupted tenant exports and delete expired archives
+	jobs.Every("tenant-exports", 5*time.Minute, exportService.RunSweep)
+
+	// Permanently delete tenants whose deletion grace period ended
+	jobs.Every("tenant-purge", 24*time.Hour, offboardingService.RunPurge)
+
+	// Remind trial tenants of the onboarding steps still pending
+	jobs.Every("onboarding-nudges", 24*time.Hour, onboardingService.RunNudges)
+
+	// Mark offline the users whose presence heartbeat stopped
+	jobs.Every("presence-expiry", time.Minute, presenceService.RunExpiry)
+
+	// Deliver the emails of the outbox, retrying failures with backoff
+	jobs.Every("email-outbox", 15*time.Second, emailOutboxService.RunDispatch)
+
+	// Check the SMTP relays of tenants; failing relays fall back to the platform
+	jobs.Every("mail-relay-health", 10*time.Minute, mailSettingsService.RunHealthChecks)
+
+	// Record the bounces and complaints returned to the bounce mailbox
+	jobs.Every("bounce-mailbox", time.Minute, emailDeliveryService.RunMailboxScan)
+
+	// Create the Chatwoot accounts of new tenants, compensating failed provisionings
+	jobs.Every("chatwoot-provisioning", 30*time.Second, provisioningService.RunProvisioning)
+
+	// Publish again the Chatwoot webhooks left pending and delete old deliveries
+	jobs.Every("chatwoot-webhooks", time.Minute, chatwootWebhookService.RunSweep)
+
+	return jobs
+}
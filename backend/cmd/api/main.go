@@ -11,8 +11,14 @@ import (
 	"github.com/gofiber/fiber/v3/middleware/logger"
 	"github.com/gofiber/fiber/v3/middleware/recover"
 	"github.com/spf13/viper"
+	"github.com/widia/widia-connect/internal/application"
 	"github.com/widia/widia-connect/internal/infrastructure/database"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/events"
+	"github.com/widia/widia-connect/internal/infrastructure/metering"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
 	"github.com/widia/widia-connect/internal/interfaces/http/handlers"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
 	"github.com/widia/widia-connect/internal/interfaces/http/routes"
 )
 
@@ -44,6 +50,26 @@ func main() {
 		log.Fatal("Failed to run migrations:", err)
 	}
 	
+	// Usage metering, flushed in batches in the background
+	meter := metering.New(repository.NewUsageRepository(db))
+	metering.SetDefault(meter)
+	meter.Start()
+	
+	// Domain events dispatched in process; webhook events are published by
+	// background workers
+	bus := setupEvents(db)
+	events.SetDefault(bus)
+	eventQueue := events.NewQueue(bus, 1000)
+	events.SetDefaultQueue(eventQueue)
+	eventQueue.Start(4)
+	
+	// Emails are stored in the outbox and delivered by a background job
+	mailer := application.NewTenantMailer(repository.NewTenantMailSettingsRepository(db), email.NewMailerFromEnv())
+	email.SetDefaultQueue(application.NewEmailOutboxService(repository.NewEmailOutboxRepository(db), mailer))
+	
+	// Addresses that bounced or complained are skipped before every send
+	email.SetSuppressionList(application.NewEmailDeliveryService(db, repository.NewEmailDeliveryRepository(db), repository.NewEmailOutboxRepository(db)))
+	
 	// Create fiber app
 	app := fiber.New(fiber.Config{
 		AppName:      "SaaS Sales AI API",
@@ -53,11 +79,13 @@ func main() {
 	// Global middlewares
 	app.Use(logger.New())
 	app.Use(recover.New())
+	app.Use(middleware.MeterAPICalls())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: []string{viper.GetString("CORS_ORIGINS")},
 		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Tenant-ID"},
 		AllowMethods: []string{"GET", "HEAD", "PUT", "PATCH", "POST", "DELETE", "OPTIONS"},
 		AllowCredentials: true,
+		ExposeHeaders: []string{middleware.HeaderTenantRedirect},
 	}))
 	
 	// Health check
@@ -78,6 +106,34 @@ func main() {
 	// Protected routes - pass api group, routes will handle their own middleware
 	routes.SetupTenantRoutes(api, db)
 	routes.SetupUserRoutes(api, db)
+	routes.SetupOwnershipRoutes(api, db)
+	routes.SetupRoleRoutes(api, db)
+	routes.SetupTeamRoutes(api, db)
+	routes.SetupAssignmentRoutes(api, db)
+	routes.SetupPresenceRoutes(api, db)
+	routes.SetupFeatureRoutes(api, db)
+	routes.SetupPlanRoutes(api, db)
+	routes.SetupSubscriptionRoutes(api, db)
+	routes.SetupBillingRoutes(api, db)
+	routes.SetupUsageRoutes(api, db)
+	routes.SetupExportRoutes(api, db)
+	routes.SetupImportRoutes(api, db)
+	routes.SetupOffboardingRoutes(api, db)
+	routes.SetupBrandingRoutes(api, db)
+	routes.SetupEmailTemplateRoutes(api, db)
+	routes.SetupMailSettingsRoutes(api, db)
+	routes.SetupEmailDeliveryRoutes(api, db)
+	routes.SetupIntegrationRoutes(api, db)
+	routes.SetupOnboardingRoutes(api, db)
+	routes.SetupFileRoutes(api, db)
+	routes.SetupEmailOutboxRoutes(api, db)
+	
+	// External service webhooks (signature authenticated)
+	routes.SetupWebhookRoutes(app, db)
+	
+	// Background jobs
+	jobs := setupJobs(db, routes.BillingProvider())
+	jobs.Start()
 	
 	// Graceful shutdown
 	c := make(chan os.Signal, 1)
@@ -96,4 +152,11 @@ func main() {
 	if err := app.Listen(":" + port); err != nil {
 		log.Fatal("Server failed to start:", err)
 	}
+	
+	// Listen returns once the server is shut down; finish the background work
+	// before the process exits
+	jobs.Stop()
+	eventQueue.Close()
+	meter.Close()
+	log.Println("Server stopped")
 }
\ No newline at end of file
@@ -0,0 +1,204 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAssignedWorkNotFound = errors.New("assigned work not found")
+	ErrAssignmentForbidden  = errors.New("not allowed to manage the assignments of this user")
+	ErrInvalidAssignee      = errors.New("assignee must be an active user of the tenant")
+)
+
+// Page sizes of the conversation and lead lists
+const (
+	DefaultAssignedWorkPageSize = 25
+	MaxAssignedWorkPageSize     = 100
+)
+
+// AssignedWorkQuery pages through the rows of an assignable table
+type AssignedWorkQuery struct {
+	// OpenOnly keeps the rows still being worked on
+	OpenOnly bool
+	Limit    int
+	Offset   int
+}
+
+// AssignmentService lists and assigns the conversations and leads of a
+// tenant within the team scope of the current user
+type AssignmentService struct {
+	db          *gorm.DB
+	userRepo    domain.UserRepository
+	teamService *TeamService
+	chatwoot    ChatwootClients
+}
+
+// NewAssignmentService creates the assignment service. chatwootClients may be
+// nil to leave Chatwoot untouched.
+func NewAssignmentService(
+	db *gorm.DB,
+	userRepo domain.UserRepository,
+	teamService *TeamService,
+	chatwootClients ChatwootClients,
+) *AssignmentService {
+	return &AssignmentService{
+		db:          db,
+		userRepo:    userRepo,
+		teamService: teamService,
+		chatwoot:    chatwootClients,
+	}
+}
+
+// List returns the rows of a table the user can see: every row for roles
+// with conversations.read_all, otherwise the rows of their teammates and the
+// unassigned ones. Tables that do not exist yet are empty.
+func (s *AssignmentService) List(table domain.AssignableTable, tenantID, userID uuid.UUID, role string, query AssignedWorkQuery) ([]map[string]interface{}, error) {
+	rows := []map[string]interface{}{}
+	columns, err := tableColumns(s.db, table.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(columns, "tenant_id") || !containsString(columns, table.AssigneeColumn) {
+		return rows, nil
+	}
+
+	scope, err := s.teamService.Scope(tenantID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultAssignedWorkPageSize
+	}
+	limit = min(limit, MaxAssignedWorkPageSize)
+	order := "id"
+	if containsString(columns, "created_at") {
+		order = "created_at DESC, id"
+	}
+
+	db := s.db.Table(quoteIdent(table.Name)).
+		Where("tenant_id = ?", tenantID).
+		Scopes(scope.Apply(quoteIdent(table.AssigneeColumn)))
+	if query.OpenOnly {
+		db = db.Where(table.OpenCondition)
+	}
+	if err := db.Order(order).Limit(limit).Offset(max(query.Offset, 0)).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", table.Name, err)
+	}
+	return rows, nil
+}
+
+// Assign hands a row the actor can see to a user, or back to the routing
+// queue when assigneeID is nil. Taking work from or giving work to another
+// user requires CanManageAssignment; anyone may pick up an unassigned row or
+// release their own. The Chatwoot conversation of the row, if any, follows on
+// a best effort basis.
+func (s *AssignmentService) Assign(table domain.AssignableTable, tenantID, actorID uuid.UUID, actorRole, rowID string, assigneeID *uuid.UUID) error {
+	columns, err := tableColumns(s.db, table.Name)
+	if err != nil {
+		return err
+	}
+	if !containsString(columns, "tenant_id") || !containsString(columns, table.AssigneeColumn) {
+		return ErrAssignedWorkNotFound
+	}
+	chatwootColumn := ""
+	if table.ChatwootColumn != "" && containsString(columns, table.ChatwootColumn) {
+		chatwootColumn = table.ChatwootColumn
+	}
+
+	scope, err := s.teamService.Scope(tenantID, actorID, actorRole)
+	if err != nil {
+		return err
+	}
+
+	selected := "id::text AS id, " + quoteIdent(table.AssigneeColumn) + " AS assigned_to"
+	if chatwootColumn != "" {
+		selected += ", " + quoteIdent(chatwootColumn) + " AS chatwoot_id"
+	}
+	var rows []struct {
+		ID         string
+		AssignedTo *uuid.UUID
+		ChatwootID *int
+	}
+	if err := s.db.Table(quoteIdent(table.Name)).
+		Select(selected).
+		Where("tenant_id = ? AND id::text = ?", tenantID, rowID).
+		Scopes(scope.Apply(quoteIdent(table.AssigneeColumn))).
+		Limit(1).
+		Scan(&rows).Error; err != nil {
+		return fmt.Errorf("failed to find %s %s: %w", table.Name, rowID, err)
+	}
+	if len(rows) == 0 {
+		return ErrAssignedWorkNotFound
+	}
+	row := rows[0]
+
+	if row.AssignedTo != nil {
+		if err := s.checkAssignment(tenantID, actorID, actorRole, *row.AssignedTo); err != nil {
+			return err
+		}
+	}
+	var assignee *domain.User
+	if assigneeID != nil {
+		if err := s.checkAssignment(tenantID, actorID, actorRole, *assigneeID); err != nil {
+			return err
+		}
+		assignee, err = s.userRepo.FindByID(*assigneeID)
+		if err != nil || assignee.TenantID != tenantID || !assignee.IsActive {
+			return ErrInvalidAssignee
+		}
+	}
+
+	if err := s.db.Table(quoteIdent(table.Name)).
+		Where("id::text = ?", row.ID).
+		Update(table.AssigneeColumn, assigneeID).Error; err != nil {
+		return fmt.Errorf("failed to assign %s %s: %w", table.Name, row.ID, err)
+	}
+
+	if row.ChatwootID != nil {
+		s.syncChatwoot(tenantID, *row.ChatwootID, assignee)
+	}
+	return nil
+}
+
+// checkAssignment allows the actor to act on their own work and, through
+// CanManageAssignment, on the work of the users they manage
+func (s *AssignmentService) checkAssignment(tenantID, actorID uuid.UUID, actorRole string, userID uuid.UUID) error {
+	if userID == actorID {
+		return nil
+	}
+	allowed, err := s.teamService.CanManageAssignment(tenantID, actorID, actorRole, userID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrAssignmentForbidden
+	}
+	return nil
+}
+
+// syncChatwoot hands the Chatwoot conversation to the agent of the assignee,
+// or unassigns it when there is none
+func (s *AssignmentService) syncChatwoot(tenantID uuid.UUID, conversationID int, assignee *domain.User) {
+	client := chatwootFor(s.chatwoot, tenantID)
+	if client == nil {
+		return
+	}
+
+	var err error
+	if assignee != nil && assignee.ChatwootAgentID != nil {
+		err = client.AssignAgent(conversationID, *assignee.ChatwootAgentID)
+	} else {
+		err = client.UnassignConversation(conversationID)
+	}
+	if err != nil {
+		log.Printf("Failed to assign Chatwoot conversation %d: %v", conversationID, err)
+	}
+}
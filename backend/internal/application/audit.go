@@ -0,0 +1,26 @@
+package application
+
+import (
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+)
+
+// AuditContext identifies where a request came from in the audit log
+type AuditContext struct {
+	IPAddress string
+	UserAgent string
+}
+
+func newAuditLog(tenantID uuid.UUID, userID *uuid.UUID, action string, meta AuditContext, changes domain.JSON) *domain.AuditLog {
+	entry := &domain.AuditLog{
+		TenantID:  tenantID,
+		UserID:    userID,
+		Action:    action,
+		Changes:   changes,
+		UserAgent: meta.UserAgent,
+	}
+	if meta.IPAddress != "" {
+		entry.IPAddress = &meta.IPAddress
+	}
+	return entry
+}
@@ -4,11 +4,13 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/widia/widia-connect/internal/domain"
 	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/metering"
 	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
 	"gorm.io/gorm"
 )
@@ -224,11 +226,6 @@ func (s *AuthService) RequestPasswordReset(email string, tenantSlug string) (str
 		return "", nil
 	}
 	
-	// Invalidate existing tokens for this user
-	if s.resetTokenRepo != nil {
-		s.resetTokenRepo.InvalidateUserTokens(user.ID)
-	}
-	
 	// Generate secure random token
 	tokenBytes := make([]byte, 32)
 	if _, err := rand.Read(tokenBytes); err != nil {
@@ -245,27 +242,25 @@ func (s *AuthService) RequestPasswordReset(email string, tenantSlug string) (str
 		Used:      false,
 	}
 	
-	if s.resetTokenRepo != nil {
-		if err := s.resetTokenRepo.Create(resetToken); err != nil {
-			return "", err
+	// The token and its email are stored together, the outbox worker delivers the email
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Invalidate existing tokens for this user
+		if err := tx.Model(&domain.PasswordResetToken{}).
+			Where("user_id = ? AND used = false AND expires_at > ?", user.ID, time.Now()).
+			Update("used", true).Error; err != nil {
+			return err
 		}
+		
+		if err := tx.Create(resetToken).Error; err != nil {
+			return err
+		}
+		
+		return s.emailService.WithQueue(txOutbox{tx: tx}).SendPasswordResetEmail(emailBranding(&tenant).WithLocale(user.Locale), user.Email, user.Name, token)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create password reset: %w", err)
 	}
-	
-	// Send password reset email
-	if s.emailService != nil {
-		go func() {
-			println("Attempting to send password reset email to:", user.Email)
-			if err := s.emailService.SendPasswordResetEmail(user.Email, user.Name, token); err != nil {
-				// Log error but don't fail the request
-				// In production, you'd want proper logging here
-				println("Failed to send password reset email:", err.Error())
-			} else {
-				println("Password reset email sent successfully to:", user.Email)
-			}
-		}()
-	} else {
-		println("Email service is not configured")
-	}
+	metering.Record(user.TenantID, domain.MetricEmailsSent, 1)
 	
 	return token, nil
 }
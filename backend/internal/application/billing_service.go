@@ -0,0 +1,416 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/billing"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBillingAccountNotFound  = errors.New("billing account not found")
+	ErrPlanNotBillable         = errors.New("plan has no provider price")
+	ErrSubscriptionExists      = errors.New("tenant already has a subscription")
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+)
+
+// billingWebhookClaimTimeout is the time after which an event still being
+// processed, by a request that did not finish, can be processed again
+const billingWebhookClaimTimeout = 10 * time.Minute
+
+// providerStatuses maps provider subscription states to the tenant lifecycle
+var providerStatuses = map[string]string{
+	"trialing":           domain.SubscriptionActive,
+	"active":             domain.SubscriptionActive,
+	"past_due":           domain.SubscriptionPastDue,
+	"unpaid":             domain.SubscriptionPastDue,
+	"incomplete":         domain.SubscriptionPastDue,
+	"canceled":           domain.SubscriptionCanceled,
+	"incomplete_expired": domain.SubscriptionCanceled,
+}
+
+type BillingService struct {
+	db                  *gorm.DB
+	provider            billing.Provider
+	tenantRepo          domain.TenantRepository
+	planRepo            domain.PlanRepository
+	accountRepo         domain.BillingAccountRepository
+	invoiceRepo         domain.InvoiceRepository
+	webhookRepo         domain.BillingWebhookEventRepository
+	subscriptionService *SubscriptionService
+}
+
+func NewBillingService(
+	db *gorm.DB,
+	provider billing.Provider,
+	tenantRepo domain.TenantRepository,
+	planRepo domain.PlanRepository,
+	accountRepo domain.BillingAccountRepository,
+	invoiceRepo domain.InvoiceRepository,
+	webhookRepo domain.BillingWebhookEventRepository,
+	subscriptionService *SubscriptionService,
+) *BillingService {
+	return &BillingService{
+		db:                  db,
+		provider:            provider,
+		tenantRepo:          tenantRepo,
+		planRepo:            planRepo,
+		accountRepo:         accountRepo,
+		invoiceRepo:         invoiceRepo,
+		webhookRepo:         webhookRepo,
+		subscriptionService: subscriptionService,
+	}
+}
+
+// GetAccount returns the billing account of a tenant
+func (s *BillingService) GetAccount(tenantID uuid.UUID) (*domain.BillingAccount, error) {
+	account, err := s.accountRepo.FindByTenant(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBillingAccountNotFound
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+// EnsureCustomer returns the tenant billing account, creating the provider customer on first use
+func (s *BillingService) EnsureCustomer(ctx context.Context, tenantID uuid.UUID, email string) (*domain.BillingAccount, error) {
+	account, err := s.accountRepo.FindByTenant(tenantID)
+	if err == nil {
+		return account, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+
+	customer, err := s.provider.CreateCustomer(ctx, billing.CustomerParams{
+		Email: email,
+		Name:  tenant.Name,
+		Metadata: map[string]string{
+			"tenant_id":   tenant.ID.String(),
+			"tenant_slug": tenant.Slug,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create billing customer: %w", err)
+	}
+
+	account = &domain.BillingAccount{
+		TenantID:   tenant.ID,
+		Provider:   s.provider.Name(),
+		CustomerID: customer.ID,
+	}
+	if err := s.accountRepo.Save(account); err != nil {
+		return nil, fmt.Errorf("failed to save billing account: %w", err)
+	}
+
+	return account, nil
+}
+
+// CreateCheckoutSession starts a hosted checkout for the given plan
+func (s *BillingService) CreateCheckoutSession(ctx context.Context, tenantID uuid.UUID, email, planCode, successURL, cancelURL string) (*billing.CheckoutSession, error) {
+	plan, err := s.billablePlan(planCode)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.EnsureCustomer(ctx, tenantID, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.provider.CreateCheckoutSession(ctx, billing.CheckoutParams{
+		CustomerID:        account.CustomerID,
+		PriceID:           *plan.ProviderPriceID,
+		SuccessURL:        successURL,
+		CancelURL:         cancelURL,
+		ClientReferenceID: tenantID.String(),
+	})
+}
+
+// CreateSubscription subscribes the tenant directly, using the payment method on file
+func (s *BillingService) CreateSubscription(ctx context.Context, tenantID uuid.UUID, email, planCode string) (*domain.BillingAccount, error) {
+	plan, err := s.billablePlan(planCode)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.EnsureCustomer(ctx, tenantID, email)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.SubscriptionID != nil && account.Status != "canceled" {
+		return nil, ErrSubscriptionExists
+	}
+
+	subscription, err := s.provider.CreateSubscription(ctx, account.CustomerID, *plan.ProviderPriceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	// The provider also sends a webhook; applying the result here keeps the
+	// tenant consistent when the webhook is delayed
+	if err := s.applySubscription(account, subscription, false); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// ListInvoices returns the invoices of a tenant recorded from webhooks
+func (s *BillingService) ListInvoices(tenantID uuid.UUID, limit int) ([]*domain.Invoice, error) {
+	return s.invoiceRepo.FindByTenant(tenantID, limit)
+}
+
+// SignatureHeader is the request header carrying the webhook signature
+func (s *BillingService) SignatureHeader() string {
+	return s.provider.SignatureHeader()
+}
+
+// HandleWebhook verifies and applies a provider event. Events already processed,
+// or being processed, are acknowledged without side effects; failed events are
+// retried.
+func (s *BillingService) HandleWebhook(payload []byte, signature string) (duplicate bool, err error) {
+	event, err := s.provider.ParseWebhook(payload, signature)
+	if err != nil {
+		if errors.Is(err, billing.ErrInvalidSignature) {
+			return false, ErrInvalidWebhookSignature
+		}
+		return false, err
+	}
+
+	// The event is recorded before it is processed, so concurrent deliveries
+	// of the same event are processed once
+	record := &domain.BillingWebhookEvent{
+		Provider: s.provider.Name(),
+		EventID:  event.ID,
+		Type:     event.Type,
+		Status:   domain.WebhookEventProcessing,
+		Payload:  string(payload),
+	}
+	created, err := s.webhookRepo.Create(record)
+	if err != nil {
+		return false, fmt.Errorf("failed to record webhook event: %w", err)
+	}
+	if !created {
+		record, err = s.webhookRepo.Claim(s.provider.Name(), event.ID, time.Now().Add(-billingWebhookClaimTimeout))
+		if err != nil {
+			return false, fmt.Errorf("failed to claim webhook event: %w", err)
+		}
+		if record == nil {
+			return true, nil
+		}
+	}
+
+	processErr := s.processEvent(event)
+
+	now := time.Now()
+	record.ProcessedAt = &now
+	if processErr != nil {
+		record.Status = domain.WebhookEventFailed
+		record.Error = processErr.Error()
+	} else {
+		record.Status = domain.WebhookEventProcessed
+		record.Error = ""
+	}
+
+	if err := s.webhookRepo.Save(record); err != nil {
+		return false, fmt.Errorf("failed to record webhook event: %w", err)
+	}
+
+	return false, processErr
+}
+
+func (s *BillingService) processEvent(event *billing.Event) error {
+	switch {
+	case event.Checkout != nil:
+		return s.handleCheckoutCompleted(event.Checkout)
+	case event.Subscription != nil:
+		account, err := s.accountForCustomer(event.Subscription.CustomerID)
+		if err != nil {
+			return err
+		}
+		return s.applySubscription(account, event.Subscription, event.Type == billing.EventSubscriptionDeleted)
+	case event.Invoice != nil:
+		return s.handleInvoice(event.Type, event.Invoice)
+	default:
+		log.Printf("Ignoring billing event %s of type %s", event.ID, event.Type)
+		return nil
+	}
+}
+
+func (s *BillingService) handleCheckoutCompleted(session *billing.CheckoutSession) error {
+	account, err := s.accountForCustomer(session.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	if session.ClientReferenceID != "" && session.ClientReferenceID != account.TenantID.String() {
+		return fmt.Errorf("checkout session %s does not belong to tenant %s", session.ID, account.TenantID)
+	}
+
+	if session.SubscriptionID == "" {
+		return nil
+	}
+
+	account.SubscriptionID = &session.SubscriptionID
+	return s.accountRepo.Save(account)
+}
+
+// applySubscription stores the provider subscription and drives the tenant
+// lifecycle, period end and plan from it
+func (s *BillingService) applySubscription(account *domain.BillingAccount, subscription *billing.Subscription, deleted bool) error {
+	status := subscription.Status
+	if deleted {
+		status = "canceled"
+	}
+
+	account.SubscriptionID = &subscription.ID
+	account.Status = status
+	account.CancelAtPeriodEnd = subscription.CancelAtPeriodEnd
+	if subscription.PriceID != "" {
+		account.PriceID = subscription.PriceID
+	}
+	if !subscription.CurrentPeriodEnd.IsZero() {
+		periodEnd := subscription.CurrentPeriodEnd
+		account.CurrentPeriodEnd = &periodEnd
+	}
+
+	if err := s.accountRepo.Save(account); err != nil {
+		return fmt.Errorf("failed to save billing account: %w", err)
+	}
+
+	tenant, err := s.tenantRepo.FindByID(account.TenantID)
+	if err != nil {
+		return err
+	}
+
+	if subscription.PriceID != "" && !deleted {
+		if err := s.syncPlan(tenant, subscription.PriceID); err != nil {
+			return err
+		}
+	}
+
+	lifecycleStatus, ok := providerStatuses[status]
+	if !ok {
+		return nil
+	}
+
+	return s.syncStatus(tenant, lifecycleStatus, account.CurrentPeriodEnd, fmt.Sprintf("billing subscription %s", status))
+}
+
+func (s *BillingService) handleInvoice(eventType string, providerInvoice *billing.Invoice) error {
+	account, err := s.accountForCustomer(providerInvoice.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	invoice := &domain.Invoice{
+		TenantID:          account.TenantID,
+		ProviderInvoiceID: providerInvoice.ID,
+		Number:            providerInvoice.Number,
+		Status:            providerInvoice.Status,
+		AmountDue:         providerInvoice.AmountDue,
+		AmountPaid:        providerInvoice.AmountPaid,
+		Currency:          providerInvoice.Currency,
+		HostedURL:         providerInvoice.HostedURL,
+		PDFURL:            providerInvoice.PDFURL,
+		IssuedAt:          providerInvoice.CreatedAt,
+	}
+	if !providerInvoice.PeriodStart.IsZero() {
+		invoice.PeriodStart = &providerInvoice.PeriodStart
+	}
+	if !providerInvoice.PeriodEnd.IsZero() {
+		invoice.PeriodEnd = &providerInvoice.PeriodEnd
+	}
+
+	if err := s.invoiceRepo.Upsert(invoice); err != nil {
+		return fmt.Errorf("failed to save invoice: %w", err)
+	}
+
+	tenant, err := s.tenantRepo.FindByID(account.TenantID)
+	if err != nil {
+		return err
+	}
+
+	switch eventType {
+	case billing.EventInvoicePaid:
+		return s.syncStatus(tenant, domain.SubscriptionActive, invoice.PeriodEnd, "invoice paid")
+	case billing.EventInvoicePaymentFailed:
+		return s.syncStatus(tenant, domain.SubscriptionPastDue, nil, "invoice payment failed")
+	}
+
+	return nil
+}
+
+// syncStatus moves the tenant lifecycle when allowed. Transitions the lifecycle
+// rejects (e.g. a late past_due for a canceled tenant) are logged and skipped so
+// the provider does not retry them forever.
+func (s *BillingService) syncStatus(tenant *domain.Tenant, status string, endsAt *time.Time, reason string) error {
+	err := s.subscriptionService.transition(tenant, status, endsAt, reason, nil)
+	if errors.Is(err, ErrInvalidSubscriptionTransition) {
+		log.Printf("Skipping billing transition of tenant %s from %s to %s", tenant.ID, tenant.SubscriptionStatus, status)
+		return nil
+	}
+	return err
+}
+
+func (s *BillingService) syncPlan(tenant *domain.Tenant, priceID string) error {
+	plan, err := s.planRepo.FindByProviderPriceID(priceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("No plan found for billing price %s", priceID)
+			return nil
+		}
+		return err
+	}
+
+	if tenant.PlanID != nil && *tenant.PlanID == plan.ID {
+		return nil
+	}
+
+	return s.db.Model(&domain.Tenant{}).Where("id = ?", tenant.ID).Update("plan_id", plan.ID).Error
+}
+
+func (s *BillingService) accountForCustomer(customerID string) (*domain.BillingAccount, error) {
+	account, err := s.accountRepo.FindByCustomer(s.provider.Name(), customerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBillingAccountNotFound
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *BillingService) billablePlan(planCode string) (*domain.Plan, error) {
+	plan, err := s.planRepo.FindByCode(planCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlanNotFound
+		}
+		return nil, err
+	}
+
+	if plan.ProviderPriceID == nil || *plan.ProviderPriceID == "" {
+		return nil, ErrPlanNotBillable
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,331 @@
+package application
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/storage"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidColor     = errors.New("colors must be hex values like #1a2b3c")
+	ErrInvalidBrandName = errors.New("display name must be a single line")
+	ErrBrandingTooLong  = errors.New("branding field is too long")
+	ErrInvalidLogo      = errors.New("logo must be a PNG, JPEG or WebP image")
+	ErrLogoTooLarge     = errors.New("logo is too large")
+	ErrLogoNotFound     = errors.New("logo not found")
+)
+
+// Branding limits
+const (
+	MaxLogoSize             = 1 << 20
+	maxBrandingNameLength   = 100
+	maxBrandingFooterLength = 500
+)
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// logoExtensions maps the accepted logo content types to file extensions
+var logoExtensions = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpg",
+	"image/webp": "webp",
+}
+
+// Branding is the public view of a tenant branding with defaults applied
+type Branding struct {
+	DisplayName    string `json:"display_name"`
+	LogoURL        string `json:"logo_url,omitempty"`
+	PrimaryColor   string `json:"primary_color"`
+	SecondaryColor string `json:"secondary_color"`
+	SupportEmail   string `json:"support_email,omitempty"`
+	Footer         string `json:"footer,omitempty"`
+}
+
+// BrandingUpdate holds the fields to change; nil fields are kept and empty
+// strings reset a field to the platform default
+type BrandingUpdate struct {
+	DisplayName    *string `json:"display_name"`
+	PrimaryColor   *string `json:"primary_color"`
+	SecondaryColor *string `json:"secondary_color"`
+	SupportEmail   *string `json:"support_email"`
+	Footer         *string `json:"footer"`
+}
+
+type BrandingService struct {
+	db            *gorm.DB
+	tenantRepo    domain.TenantRepository
+	tenantService *TenantService
+	storage       storage.Storage
+}
+
+func NewBrandingService(
+	db *gorm.DB,
+	tenantRepo domain.TenantRepository,
+	tenantService *TenantService,
+	store storage.Storage,
+) *BrandingService {
+	return &BrandingService{
+		db:            db,
+		tenantRepo:    tenantRepo,
+		tenantService: tenantService,
+		storage:       store,
+	}
+}
+
+// GetBranding returns the branding of a tenant
+func (s *BrandingService) GetBranding(tenantID uuid.UUID) (*Branding, error) {
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return publicBranding(tenant), nil
+}
+
+// GetPublicBranding returns the branding of a tenant by slug, following
+// former slugs of renamed tenants
+func (s *BrandingService) GetPublicBranding(slug string) (*Branding, error) {
+	tenant, _, err := s.tenantService.ResolveSlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	return publicBranding(tenant), nil
+}
+
+// UpdateBranding validates and stores the branding fields
+func (s *BrandingService) UpdateBranding(tenantID uuid.UUID, update BrandingUpdate) (*Branding, error) {
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	branding := tenant.Branding()
+
+	if update.DisplayName != nil {
+		if len(*update.DisplayName) > maxBrandingNameLength {
+			return nil, ErrBrandingTooLong
+		}
+		// The name is used as the sender name of the emails
+		if strings.ContainsAny(*update.DisplayName, "\r\n") {
+			return nil, ErrInvalidBrandName
+		}
+		branding.DisplayName = *update.DisplayName
+	}
+	if update.PrimaryColor != nil {
+		if *update.PrimaryColor != "" && !hexColorPattern.MatchString(*update.PrimaryColor) {
+			return nil, ErrInvalidColor
+		}
+		branding.PrimaryColor = *update.PrimaryColor
+	}
+	if update.SecondaryColor != nil {
+		if *update.SecondaryColor != "" && !hexColorPattern.MatchString(*update.SecondaryColor) {
+			return nil, ErrInvalidColor
+		}
+		branding.SecondaryColor = *update.SecondaryColor
+	}
+	if update.SupportEmail != nil {
+		if *update.SupportEmail != "" && !isValidEmail(*update.SupportEmail) {
+			return nil, ErrInvalidEmail
+		}
+		branding.SupportEmail = *update.SupportEmail
+	}
+	if update.Footer != nil {
+		if len(*update.Footer) > maxBrandingFooterLength {
+			return nil, ErrBrandingTooLong
+		}
+		branding.Footer = *update.Footer
+	}
+
+	if err := s.save(tenant, branding); err != nil {
+		return nil, err
+	}
+	return publicBranding(tenant), nil
+}
+
+// UploadLogo stores a new logo and replaces the previous one
+func (s *BrandingService) UploadLogo(ctx context.Context, tenantID uuid.UUID, r io.Reader) (*Branding, error) {
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Trust the content, not the declared type
+	buffered := bufio.NewReaderSize(io.LimitReader(r, MaxLogoSize+1), 512)
+	head, _ := buffered.Peek(512)
+	contentType := http.DetectContentType(head)
+	ext, ok := logoExtensions[contentType]
+	if !ok {
+		return nil, ErrInvalidLogo
+	}
+
+	key := fmt.Sprintf("branding/%s/logo-%d.%s", tenantID, time.Now().UnixNano(), ext)
+	size, err := s.storage.Put(ctx, key, buffered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store logo: %w", err)
+	}
+	if size > MaxLogoSize {
+		_ = s.storage.Delete(ctx, key)
+		return nil, ErrLogoTooLarge
+	}
+
+	branding := tenant.Branding()
+	previous := branding.LogoKey
+	branding.LogoKey = key
+	branding.LogoContentType = contentType
+
+	if err := s.save(tenant, branding); err != nil {
+		_ = s.storage.Delete(ctx, key)
+		return nil, err
+	}
+
+	if previous != "" {
+		if err := s.storage.Delete(ctx, previous); err != nil {
+			log.Printf("Failed to delete previous logo of tenant %s: %v", tenantID, err)
+		}
+	}
+
+	return publicBranding(tenant), nil
+}
+
+// RemoveLogo deletes the tenant logo
+func (s *BrandingService) RemoveLogo(ctx context.Context, tenantID uuid.UUID) (*Branding, error) {
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	branding := tenant.Branding()
+	if branding.LogoKey == "" {
+		return publicBranding(tenant), nil
+	}
+
+	key := branding.LogoKey
+	branding.LogoKey = ""
+	branding.LogoContentType = ""
+	if err := s.save(tenant, branding); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Delete(ctx, key); err != nil {
+		log.Printf("Failed to delete logo of tenant %s: %v", tenantID, err)
+	}
+
+	return publicBranding(tenant), nil
+}
+
+// OpenLogo returns the logo of a tenant by slug and its content type
+func (s *BrandingService) OpenLogo(ctx context.Context, slug string) (io.ReadCloser, string, error) {
+	tenant, _, err := s.tenantService.ResolveSlug(slug)
+	if err != nil {
+		return nil, "", err
+	}
+
+	branding := tenant.Branding()
+	if branding.LogoKey == "" {
+		return nil, "", ErrLogoNotFound
+	}
+
+	reader, err := s.storage.Open(ctx, branding.LogoKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, "", ErrLogoNotFound
+		}
+		return nil, "", err
+	}
+
+	return reader, branding.LogoContentType, nil
+}
+
+func (s *BrandingService) save(tenant *domain.Tenant, branding domain.TenantBranding) error {
+	if tenant.Settings == nil {
+		tenant.Settings = domain.JSON{}
+	}
+	tenant.Settings[domain.BrandingSettingsKey] = branding
+
+	if err := s.db.Model(&domain.Tenant{}).Where("id = ?", tenant.ID).Update("settings", tenant.Settings).Error; err != nil {
+		return fmt.Errorf("failed to save branding: %w", err)
+	}
+	return nil
+}
+
+func (s *BrandingService) findTenant(tenantID uuid.UUID) (*domain.Tenant, error) {
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+	return tenant, nil
+}
+
+// publicBranding applies the platform defaults to the tenant branding
+func publicBranding(tenant *domain.Tenant) *Branding {
+	brand := emailBranding(tenant)
+	return &Branding{
+		DisplayName:    brand.Name,
+		LogoURL:        brand.LogoURL,
+		PrimaryColor:   brand.PrimaryColor,
+		SecondaryColor: brand.SecondaryColor,
+		SupportEmail:   brand.SupportEmail,
+		Footer:         brand.Footer,
+	}
+}
+
+// emailBranding returns the branding used in the emails sent on behalf of a tenant
+func emailBranding(tenant *domain.Tenant) email.Branding {
+	branding := tenant.Branding()
+	settings := tenant.EmailSettings()
+
+	overrides := make(map[string]email.TemplateOverride, len(settings.Templates))
+	for name, override := range settings.Templates {
+		overrides[name] = email.TemplateOverride{Subject: override.Subject, Intro: override.Intro}
+	}
+
+	return email.Branding{
+		TenantID:       tenant.ID,
+		Name:           branding.DisplayName,
+		LogoURL:        brandingLogoURL(tenant, branding),
+		PrimaryColor:   branding.PrimaryColor,
+		SecondaryColor: branding.SecondaryColor,
+		SupportEmail:   branding.SupportEmail,
+		Footer:         branding.Footer,
+		Locale:         settings.Locale,
+		Overrides:      overrides,
+	}.WithDefaults()
+}
+
+// brandingLogoURL returns the public URL of the tenant logo. The key digest
+// changes with every upload so clients do not keep a stale logo.
+func brandingLogoURL(tenant *domain.Tenant, branding domain.TenantBranding) string {
+	if branding.LogoKey == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(branding.LogoKey))
+	return fmt.Sprintf("%s/api/public/tenants/%s/branding/logo?v=%s",
+		publicAPIURL(), tenant.Slug, hex.EncodeToString(sum[:4]))
+}
+
+// publicAPIURL is the externally reachable base URL of the API
+func publicAPIURL() string {
+	if url := viper.GetString("API_URL"); url != "" {
+		return url
+	}
+	return fmt.Sprintf("http://localhost:%s", viper.GetString("PORT"))
+}
@@ -0,0 +1,102 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/secrets"
+	"github.com/widia/widia-connect/pkg/chatwoot"
+	"gorm.io/gorm"
+)
+
+// ChatwootClients resolves the Chatwoot client of a tenant, such as the
+// tenant of the current request
+type ChatwootClients interface {
+	// ForTenant returns the client of the tenant account, nil when the tenant
+	// has not connected Chatwoot
+	ForTenant(tenantID uuid.UUID) (*chatwoot.Client, error)
+}
+
+// ChatwootClientFactory builds the Chatwoot clients of tenants from their
+// integration records, reusing a client until its record changes
+type ChatwootClientFactory struct {
+	repo domain.TenantIntegrationRepository
+	box  *secrets.Box
+
+	mu      sync.Mutex
+	clients map[uuid.UUID]cachedChatwootClient
+}
+
+type cachedChatwootClient struct {
+	// version is the update time of the integration the client was built from
+	version time.Time
+	client  *chatwoot.Client
+}
+
+func NewChatwootClientFactory(repo domain.TenantIntegrationRepository) *ChatwootClientFactory {
+	return newChatwootClientFactory(repo, secrets.NewBoxFromConfig())
+}
+
+func newChatwootClientFactory(repo domain.TenantIntegrationRepository, box *secrets.Box) *ChatwootClientFactory {
+	return &ChatwootClientFactory{
+		repo:    repo,
+		box:     box,
+		clients: make(map[uuid.UUID]cachedChatwootClient),
+	}
+}
+
+func (f *ChatwootClientFactory) ForTenant(tenantID uuid.UUID) (*chatwoot.Client, error) {
+	integration, err := f.repo.FindByTenant(tenantID, domain.IntegrationChatwoot)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	f.mu.Lock()
+	cached, ok := f.clients[tenantID]
+	f.mu.Unlock()
+	if ok && cached.version.Equal(integration.UpdatedAt) {
+		return cached.client, nil
+	}
+
+	client, err := chatwootClient(f.box, integration)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.clients[tenantID] = cachedChatwootClient{version: integration.UpdatedAt, client: client}
+	f.mu.Unlock()
+	return client, nil
+}
+
+// chatwootClient builds the client of a Chatwoot integration
+func chatwootClient(box *secrets.Box, integration *domain.TenantIntegration) (*chatwoot.Client, error) {
+	token, err := box.Decrypt(integration.CredentialsEncrypted, integration.CredentialsContext())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt Chatwoot access token: %w", err)
+	}
+	return chatwoot.NewClient(integration.BaseURL, integration.AccountID, token), nil
+}
+
+// chatwootFor returns the Chatwoot client of a tenant for best effort
+// mirroring, nil when the tenant has not connected Chatwoot or its
+// integration cannot be loaded
+func chatwootFor(clients ChatwootClients, tenantID uuid.UUID) *chatwoot.Client {
+	if clients == nil {
+		return nil
+	}
+	client, err := clients.ForTenant(tenantID)
+	if err != nil {
+		log.Printf("Failed to load the Chatwoot integration of tenant %s: %v", tenantID, err)
+		return nil
+	}
+	return client
+}
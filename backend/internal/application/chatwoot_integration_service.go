@@ -0,0 +1,207 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/events"
+	"github.com/widia/widia-connect/internal/infrastructure/secrets"
+	"github.com/widia/widia-connect/pkg/chatwoot"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidChatwootSettings  = errors.New("chatwoot requires an http or https base URL, a positive account ID and an access token")
+	ErrChatwootNotConnected     = errors.New("chatwoot is not connected")
+	ErrChatwootConnectionFailed = errors.New("could not connect to chatwoot")
+)
+
+// ChatwootConnectRequest connects a tenant to its Chatwoot account
+type ChatwootConnectRequest struct {
+	// BaseURL defaults to CHATWOOT_BASE_URL
+	BaseURL   string `json:"base_url"`
+	AccountID int    `json:"account_id"`
+	// AccessToken keeps the stored token when empty
+	AccessToken string `json:"access_token"`
+	// WebhookSecret is the secret Chatwoot signs the webhooks with; the stored
+	// secret is kept when empty
+	WebhookSecret string `json:"webhook_secret"`
+}
+
+// ChatwootIntegration is the view of the Chatwoot connection of a tenant
+type ChatwootIntegration struct {
+	*domain.TenantIntegration
+	// Account is the Chatwoot account reached by the check of the request
+	Account *chatwoot.ProfileAccount `json:"account,omitempty"`
+	// WebhookURL is the URL to set on the webhook of the Chatwoot account
+	WebhookURL string `json:"webhook_url"`
+	// WebhookSigned is set when webhooks are checked with a secret rather than
+	// the token of the URL
+	WebhookSigned bool `json:"webhook_signed"`
+}
+
+// ChatwootIntegrationService lets tenant admins connect their Chatwoot
+// account with an access token, stored encrypted, and check the connection
+type ChatwootIntegrationService struct {
+	repo domain.TenantIntegrationRepository
+	box  *secrets.Box
+}
+
+func NewChatwootIntegrationService(repo domain.TenantIntegrationRepository) *ChatwootIntegrationService {
+	return &ChatwootIntegrationService{
+		repo: repo,
+		box:  secrets.NewBoxFromConfig(),
+	}
+}
+
+// Get returns the Chatwoot connection of a tenant
+func (s *ChatwootIntegrationService) Get(tenantID uuid.UUID) (*ChatwootIntegration, error) {
+	integration, err := s.find(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return newChatwootIntegration(integration, nil), nil
+}
+
+// Connect checks the account and token against Chatwoot and stores them.
+// Nothing is stored when the check fails.
+func (s *ChatwootIntegrationService) Connect(tenantID, actorID uuid.UUID, req ChatwootConnectRequest) (*ChatwootIntegration, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(req.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = strings.TrimRight(viper.GetString("CHATWOOT_BASE_URL"), "/")
+	}
+	if parsed, err := url.Parse(baseURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, ErrInvalidChatwootSettings
+	}
+	if req.AccountID <= 0 {
+		return nil, ErrInvalidChatwootSettings
+	}
+
+	integration, err := s.find(tenantID)
+	if err != nil && !errors.Is(err, ErrChatwootNotConnected) {
+		return nil, err
+	}
+	if integration == nil {
+		integration = &domain.TenantIntegration{TenantID: tenantID, Provider: domain.IntegrationChatwoot}
+	}
+
+	token := strings.TrimSpace(req.AccessToken)
+	if token == "" {
+		if integration.CredentialsEncrypted == "" {
+			return nil, ErrInvalidChatwootSettings
+		}
+		if token, err = s.box.Decrypt(integration.CredentialsEncrypted, integration.CredentialsContext()); err != nil {
+			return nil, fmt.Errorf("failed to decrypt Chatwoot access token: %w", err)
+		}
+	}
+
+	account, err := checkChatwoot(chatwoot.NewClient(baseURL, req.AccountID, token))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrChatwootConnectionFailed, err)
+	}
+
+	sealed, err := s.box.Encrypt(token, integration.CredentialsContext())
+	if err != nil {
+		return nil, err
+	}
+	if secret := strings.TrimSpace(req.WebhookSecret); secret != "" {
+		if integration.WebhookSecretEncrypted, err = s.box.Encrypt(secret, integration.WebhookSecretContext()); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	integration.BaseURL = baseURL
+	integration.AccountID = req.AccountID
+	integration.CredentialsEncrypted = sealed
+	integration.Status = domain.IntegrationConnected
+	integration.LastError = ""
+	integration.LastCheckedAt = &now
+	integration.ConnectedBy = &actorID
+	if err := s.repo.Save(integration); err != nil {
+		return nil, fmt.Errorf("failed to save Chatwoot integration: %w", err)
+	}
+
+	events.Publish(context.Background(), events.Event{
+		Name:     domain.EventChannelConnected,
+		TenantID: tenantID,
+		ActorID:  &actorID,
+		Payload: map[string]interface{}{
+			"provider":   domain.IntegrationChatwoot,
+			"account_id": integration.AccountID,
+		},
+	})
+
+	return newChatwootIntegration(integration, account), nil
+}
+
+// Test checks the stored connection and records the outcome; a failed check
+// is reported in the status and last error of the integration
+func (s *ChatwootIntegrationService) Test(tenantID uuid.UUID) (*ChatwootIntegration, error) {
+	integration, err := s.find(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var account *chatwoot.ProfileAccount
+	client, checkErr := chatwootClient(s.box, integration)
+	if checkErr == nil {
+		account, checkErr = checkChatwoot(client)
+	}
+
+	if err := s.repo.RecordCheck(tenantID, domain.IntegrationChatwoot, checkErr, time.Now()); err != nil {
+		return nil, err
+	}
+	if integration, err = s.find(tenantID); err != nil {
+		return nil, err
+	}
+	return newChatwootIntegration(integration, account), nil
+}
+
+// Disconnect deletes the Chatwoot connection of a tenant; teams and presence
+// are no longer mirrored
+func (s *ChatwootIntegrationService) Disconnect(tenantID uuid.UUID) error {
+	if _, err := s.find(tenantID); err != nil {
+		return err
+	}
+	return s.repo.Delete(tenantID, domain.IntegrationChatwoot)
+}
+
+func (s *ChatwootIntegrationService) find(tenantID uuid.UUID) (*domain.TenantIntegration, error) {
+	integration, err := s.repo.FindByTenant(tenantID, domain.IntegrationChatwoot)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChatwootNotConnected
+		}
+		return nil, err
+	}
+	return integration, nil
+}
+
+func newChatwootIntegration(integration *domain.TenantIntegration, account *chatwoot.ProfileAccount) *ChatwootIntegration {
+	return &ChatwootIntegration{
+		TenantIntegration: integration,
+		Account:           account,
+		WebhookURL:        chatwootWebhookURL(integration),
+		WebhookSigned:     integration.WebhookSecretEncrypted != "",
+	}
+}
+
+// checkChatwoot verifies that the access token of the client reaches its account
+func checkChatwoot(client *chatwoot.Client) (*chatwoot.ProfileAccount, error) {
+	profile, err := client.GetProfile()
+	if err != nil {
+		return nil, err
+	}
+	account := profile.Account(client.AccountID)
+	if account == nil {
+		return nil, fmt.Errorf("access token cannot reach account %d", client.AccountID)
+	}
+	return account, nil
+}
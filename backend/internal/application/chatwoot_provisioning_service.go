@@ -0,0 +1,378 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/events"
+	"github.com/widia/widia-connect/internal/infrastructure/secrets"
+	"github.com/widia/widia-connect/pkg/chatwoot"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProvisioningNotFound     = errors.New("chatwoot provisioning not found")
+	ErrProvisioningNotRetryable = errors.New("only failed chatwoot provisionings can be retried")
+)
+
+// chatwootProvisioningBatch is the number of provisionings claimed at once by the worker
+const chatwootProvisioningBatch = 10
+
+// ChatwootProvisioningEnabled reports whether new tenants get a Chatwoot
+// account, which needs the token of a Chatwoot platform app
+func ChatwootProvisioningEnabled() bool {
+	return viper.GetString("CHATWOOT_PLATFORM_TOKEN") != ""
+}
+
+// ChatwootProvisioningService runs the saga creating the Chatwoot account of
+// new tenants: the account, the tenant admin as an agent, a default API inbox
+// and the connection of the tenant. Failed steps are retried with backoff;
+// once they keep failing the account is deleted again.
+type ChatwootProvisioningService struct {
+	db         *gorm.DB
+	repo       domain.ChatwootProvisioningRepository
+	tenantRepo domain.TenantRepository
+	userRepo   domain.UserRepository
+	platform   *chatwoot.PlatformClient
+	box        *secrets.Box
+}
+
+func NewChatwootProvisioningService(
+	db *gorm.DB,
+	repo domain.ChatwootProvisioningRepository,
+	tenantRepo domain.TenantRepository,
+	userRepo domain.UserRepository,
+) *ChatwootProvisioningService {
+	return &ChatwootProvisioningService{
+		db:         db,
+		repo:       repo,
+		tenantRepo: tenantRepo,
+		userRepo:   userRepo,
+		platform:   chatwoot.NewPlatformClient(viper.GetString("CHATWOOT_BASE_URL"), viper.GetString("CHATWOOT_PLATFORM_TOKEN")),
+		box:        secrets.NewBoxFromConfig(),
+	}
+}
+
+// Get returns the provisioning of a tenant
+func (s *ChatwootProvisioningService) Get(tenantID uuid.UUID) (*domain.ChatwootProvisioning, error) {
+	provisioning, err := s.repo.FindByTenant(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProvisioningNotFound
+		}
+		return nil, err
+	}
+	return provisioning, nil
+}
+
+// Retry schedules a failed provisioning for an immediate try with a fresh set
+// of attempts. It resumes from the failed step when the compensation could not
+// remove the resources already created.
+func (s *ChatwootProvisioningService) Retry(tenantID uuid.UUID) (*domain.ChatwootProvisioning, error) {
+	provisioning, err := s.Get(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if provisioning.Status != domain.ProvisioningFailed {
+		return nil, ErrProvisioningNotRetryable
+	}
+
+	if provisioning.AccountID == nil {
+		provisioning.Step = domain.ProvisioningStepCreateAccount
+	}
+	provisioning.Status = domain.ProvisioningPending
+	provisioning.Attempts = 0
+	provisioning.NextAttemptAt = time.Now()
+	provisioning.LastError = ""
+	if err := s.repo.Update(provisioning); err != nil {
+		return nil, err
+	}
+	return provisioning, nil
+}
+
+// Process runs the provisionings due for a try and returns how many were run
+func (s *ChatwootProvisioningService) Process(ctx context.Context) (int, error) {
+	provisionings, err := s.repo.ClaimDue(time.Now(), chatwootProvisioningBatch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim chatwoot provisionings: %w", err)
+	}
+
+	for _, provisioning := range provisionings {
+		if err := ctx.Err(); err != nil {
+			// Release the claimed provisionings for the next run
+			provisioning.LockedUntil = nil
+			if err := s.repo.Update(provisioning); err != nil {
+				log.Printf("Failed to release Chatwoot provisioning of tenant %s: %v", provisioning.TenantID, err)
+			}
+			continue
+		}
+		s.run(provisioning)
+	}
+	return len(provisionings), nil
+}
+
+// RunProvisioning runs due provisionings until none is left or the context ends
+func (s *ChatwootProvisioningService) RunProvisioning(ctx context.Context) error {
+	for ctx.Err() == nil {
+		count, err := s.Process(ctx)
+		if err != nil {
+			return err
+		}
+		if count < chatwootProvisioningBatch {
+			return nil
+		}
+	}
+	return nil
+}
+
+// run tries the next steps of a claimed provisioning, or its compensation,
+// and records the outcome
+func (s *ChatwootProvisioningService) run(p *domain.ChatwootProvisioning) {
+	compensating := p.Status == domain.ProvisioningCompensating
+
+	var err error
+	if compensating {
+		err = s.compensate(p)
+	} else {
+		err = s.advance(p)
+	}
+
+	now := time.Now()
+	p.LockedUntil = nil
+	switch {
+	case err == nil && compensating:
+		// The error of the failed step is kept to show why the provisioning failed
+		p.Status = domain.ProvisioningFailed
+		p.Attempts = 0
+	case err == nil:
+		p.Status = domain.ProvisioningCompleted
+		p.Attempts = 0
+		p.LastError = ""
+		p.CompletedAt = &now
+	default:
+		p.Attempts++
+		p.LastError = fmt.Sprintf("%s: %v", p.Step, err)
+		if compensating {
+			p.LastError = "compensation failed, " + p.LastError
+		}
+
+		switch {
+		case p.Attempts < domain.ChatwootProvisioningMaxAttempts:
+			p.NextAttemptAt = now.Add(domain.ChatwootProvisioningRetryDelay(p.Attempts))
+		case compensating:
+			// The resources left are kept on the provisioning for a retry by hand
+			p.Status = domain.ProvisioningFailed
+			log.Printf("Chatwoot provisioning of tenant %s could not be compensated after %d attempts: %v", p.TenantID, p.Attempts, err)
+		default:
+			log.Printf("Chatwoot provisioning of tenant %s failed at %s after %d attempts, compensating: %v", p.TenantID, p.Step, p.Attempts, err)
+			p.Status = domain.ProvisioningCompensating
+			p.Attempts = 0
+			p.NextAttemptAt = now
+		}
+	}
+
+	if err := s.repo.Update(p); err != nil {
+		log.Printf("Failed to update Chatwoot provisioning of tenant %s: %v", p.TenantID, err)
+	}
+}
+
+// advance runs the steps left. Every step is skipped or repeated safely when
+// its resource already exists, and the provisioning is saved after each one
+// so a resource is never created twice.
+func (s *ChatwootProvisioningService) advance(p *domain.ChatwootProvisioning) error {
+	tenant, err := s.tenantRepo.FindByID(p.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load tenant: %w", err)
+	}
+
+	for {
+		next := ""
+		switch p.Step {
+		case domain.ProvisioningStepCreateAccount:
+			if p.AccountID == nil {
+				account, err := s.platform.CreateAccount(tenant.Name)
+				if err != nil {
+					return err
+				}
+				p.AccountID = &account.ID
+			}
+			next = domain.ProvisioningStepCreateAgent
+
+		case domain.ProvisioningStepCreateAgent:
+			if p.AgentID == nil {
+				user, err := s.userRepo.FindByID(p.UserID)
+				if err != nil {
+					return fmt.Errorf("failed to load tenant admin: %w", err)
+				}
+				password, err := chatwootPassword()
+				if err != nil {
+					return err
+				}
+				// Chatwoot returns the existing user when the admin already has one
+				agent, err := s.platform.CreateUser(chatwoot.CreatePlatformUserRequest{
+					Name:     user.Name,
+					Email:    user.Email,
+					Password: password,
+				})
+				if err != nil {
+					return err
+				}
+				p.AgentID = &agent.ID
+			}
+			if err := s.platform.AddAccountUser(*p.AccountID, *p.AgentID, chatwoot.RoleAdministrator); err != nil {
+				return err
+			}
+			next = domain.ProvisioningStepCreateInbox
+
+		case domain.ProvisioningStepCreateInbox:
+			if p.InboxID == nil {
+				client, err := s.agentClient(p)
+				if err != nil {
+					return err
+				}
+				inbox, err := defaultInbox(client, tenant.Name)
+				if err != nil {
+					return err
+				}
+				p.InboxID = &inbox.ID
+			}
+			next = domain.ProvisioningStepConnect
+
+		case domain.ProvisioningStepConnect:
+			if err := s.connect(p); err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("unknown step %q", p.Step)
+		}
+
+		if next == "" {
+			return nil
+		}
+		p.Step = next
+		if err := s.repo.Update(p); err != nil {
+			return fmt.Errorf("failed to save provisioning: %w", err)
+		}
+	}
+}
+
+// connect stores the IDs on the tenant and connects the tenant to the account
+// with the access token of the agent. A connection made by hand in the
+// meantime is kept.
+func (s *ChatwootProvisioningService) connect(p *domain.ChatwootProvisioning) error {
+	client, err := s.agentClient(p)
+	if err != nil {
+		return err
+	}
+
+	integration := &domain.TenantIntegration{
+		TenantID:    p.TenantID,
+		Provider:    domain.IntegrationChatwoot,
+		BaseURL:     client.BaseURL,
+		AccountID:   client.AccountID,
+		Status:      domain.IntegrationConnected,
+		ConnectedBy: &p.UserID,
+	}
+	if integration.CredentialsEncrypted, err = s.box.Encrypt(client.APIKey, integration.CredentialsContext()); err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.Tenant{}).Where("id = ?", p.TenantID).Updates(map[string]interface{}{
+			"chatwoot_account_id": *p.AccountID,
+			"chatwoot_agent_id":   *p.AgentID,
+			"chatwoot_inbox_id":   *p.InboxID,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to store Chatwoot IDs: %w", err)
+		}
+
+		var connected int64
+		if err := tx.Model(&domain.TenantIntegration{}).
+			Where("tenant_id = ? AND provider = ?", p.TenantID, domain.IntegrationChatwoot).
+			Count(&connected).Error; err != nil {
+			return err
+		}
+		if connected > 0 {
+			return nil
+		}
+		return tx.Create(integration).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	events.Publish(context.Background(), events.Event{
+		Name:     domain.EventChannelConnected,
+		TenantID: p.TenantID,
+		ActorID:  &p.UserID,
+		Payload: map[string]interface{}{
+			"provider":   domain.IntegrationChatwoot,
+			"account_id": *p.AccountID,
+			"inbox_id":   *p.InboxID,
+		},
+	})
+	return nil
+}
+
+// compensate deletes the account, which removes its inbox and the membership
+// of the agent. The agent user is kept: Chatwoot shares users by email, so it
+// may belong to other accounts of the same admin.
+func (s *ChatwootProvisioningService) compensate(p *domain.ChatwootProvisioning) error {
+	if p.AccountID != nil {
+		if err := s.platform.DeleteAccount(*p.AccountID); err != nil && !chatwoot.IsNotFound(err) {
+			return err
+		}
+	}
+	p.AccountID = nil
+	p.AgentID = nil
+	p.InboxID = nil
+	return nil
+}
+
+// agentClient returns the account API client of the agent, whose access
+// token is read back from the platform so it is never stored by the saga
+func (s *ChatwootProvisioningService) agentClient(p *domain.ChatwootProvisioning) (*chatwoot.Client, error) {
+	agent, err := s.platform.GetUser(*p.AgentID)
+	if err != nil {
+		return nil, err
+	}
+	return chatwoot.NewClient(s.platform.BaseURL, *p.AccountID, agent.AccessToken), nil
+}
+
+// defaultInbox returns the API inbox named after the tenant, creating it
+// unless an earlier try already did
+func defaultInbox(client *chatwoot.Client, name string) (*chatwoot.Inbox, error) {
+	inboxes, err := client.ListInboxes()
+	if err != nil {
+		return nil, err
+	}
+	for i := range inboxes {
+		if inboxes[i].Name == name {
+			return &inboxes[i], nil
+		}
+	}
+	return client.CreateInbox(chatwoot.CreateInboxRequest{
+		Name:    name,
+		Channel: "api",
+	})
+}
+
+// chatwootPassword returns a random password meeting the Chatwoot rules; the
+// admin signs in to Chatwoot through the app rather than with it
+func chatwootPassword() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf) + "Aa1!", nil
+}
@@ -0,0 +1,210 @@
+package application
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/events"
+	"github.com/widia/widia-connect/internal/infrastructure/secrets"
+	"github.com/widia/widia-connect/pkg/chatwoot"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidChatwootEvent = errors.New("invalid chatwoot webhook event")
+
+const (
+	// chatwootWebhookTolerance bounds the age of a signed webhook
+	chatwootWebhookTolerance = 5 * time.Minute
+	// chatwootWebhookSweepBatch is the number of pending deliveries published again at once
+	chatwootWebhookSweepBatch = 100
+)
+
+// ChatwootWebhookRequest is a webhook request received for a tenant
+type ChatwootWebhookRequest struct {
+	Body      []byte
+	Signature string
+	Timestamp string
+	Delivery  string
+	// Token authenticates the request when the tenant set no webhook secret
+	Token string
+}
+
+// ChatwootWebhookService receives the webhooks of the Chatwoot accounts of
+// tenants and publishes their events in the background, so the bot, the CRM
+// and analytics can subscribe to them on the event bus
+type ChatwootWebhookService struct {
+	repo            domain.ChatwootWebhookRepository
+	integrationRepo domain.TenantIntegrationRepository
+	box             *secrets.Box
+}
+
+func NewChatwootWebhookService(repo domain.ChatwootWebhookRepository, integrationRepo domain.TenantIntegrationRepository) *ChatwootWebhookService {
+	return &ChatwootWebhookService{
+		repo:            repo,
+		integrationRepo: integrationRepo,
+		box:             secrets.NewBoxFromConfig(),
+	}
+}
+
+// HandleWebhook authenticates a webhook, stores it once and queues its event.
+// It reports duplicate for a delivery already received. Events that are not
+// decoded are accepted and dropped.
+func (s *ChatwootWebhookService) HandleWebhook(tenantID uuid.UUID, req ChatwootWebhookRequest) (duplicate bool, err error) {
+	integration, err := s.integrationRepo.FindByTenant(tenantID, domain.IntegrationChatwoot)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrChatwootNotConnected
+		}
+		return false, err
+	}
+	if err := s.authenticate(integration, req); err != nil {
+		return false, err
+	}
+
+	event, err := chatwoot.ParseWebhookEvent(req.Body)
+	if err != nil {
+		if errors.Is(err, chatwoot.ErrUnsupportedWebhookEvent) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%w: %v", ErrInvalidChatwootEvent, err)
+	}
+	if event.AccountID != integration.AccountID {
+		return false, fmt.Errorf("%w: account %d is not connected", ErrInvalidChatwootEvent, event.AccountID)
+	}
+
+	deliveryID := req.Delivery
+	if deliveryID == "" {
+		sum := sha256.Sum256(req.Body)
+		deliveryID = hex.EncodeToString(sum[:])
+	}
+	delivery := &domain.ChatwootWebhookDelivery{
+		TenantID:   tenantID,
+		DeliveryID: deliveryID,
+		Event:      event.Event,
+		Status:     domain.ChatwootWebhookPending,
+		Payload:    string(req.Body),
+	}
+	created, err := s.repo.Create(delivery)
+	if err != nil {
+		return false, fmt.Errorf("failed to record chatwoot webhook: %w", err)
+	}
+	if !created {
+		return true, nil
+	}
+
+	s.publish(delivery, event)
+	return false, nil
+}
+
+// RunSweep publishes again the deliveries left pending, because the queue was
+// full or the process stopped, and deletes the deliveries past their retention
+func (s *ChatwootWebhookService) RunSweep(ctx context.Context) error {
+	now := time.Now()
+	deliveries, err := s.repo.ClaimPending(now.Add(-domain.ChatwootWebhookRedispatchAfter), now, chatwootWebhookSweepBatch)
+	if err != nil {
+		return fmt.Errorf("failed to claim pending chatwoot webhooks: %w", err)
+	}
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+		event, err := chatwoot.ParseWebhookEvent([]byte(delivery.Payload))
+		if err != nil {
+			log.Printf("Dropping chatwoot webhook %s of tenant %s: %v", delivery.ID, delivery.TenantID, err)
+			if err := s.repo.MarkPublished(delivery.ID, 0, now); err != nil {
+				return err
+			}
+			continue
+		}
+		s.publish(delivery, event)
+	}
+
+	deleted, err := s.repo.DeletePublishedBefore(now.Add(-domain.ChatwootWebhookRetention))
+	if err != nil {
+		return fmt.Errorf("failed to delete chatwoot webhooks: %w", err)
+	}
+	if len(deliveries) > 0 || deleted > 0 {
+		log.Printf("Chatwoot webhooks: %d published again, %d deleted", len(deliveries), deleted)
+	}
+	return nil
+}
+
+// publish queues the event of a delivery and records the outcome of its
+// handlers. A refused event stays pending for the sweep.
+func (s *ChatwootWebhookService) publish(delivery *domain.ChatwootWebhookDelivery, event *chatwoot.WebhookEvent) {
+	accepted := events.Enqueue(events.Event{
+		Name:     "chatwoot." + event.Event,
+		TenantID: delivery.TenantID,
+		Payload: map[string]interface{}{
+			domain.ChatwootEventPayloadKey: event,
+		},
+	}, func(failed int) {
+		if err := s.repo.MarkPublished(delivery.ID, failed, time.Now()); err != nil {
+			log.Printf("Failed to update chatwoot webhook %s: %v", delivery.ID, err)
+		}
+	})
+	if !accepted {
+		log.Printf("Event queue refused chatwoot webhook %s of tenant %s, left for the sweep", delivery.ID, delivery.TenantID)
+	}
+}
+
+// authenticate checks the signature of the request against the webhook secret
+// of the tenant or, without one, the token of its webhook URL
+func (s *ChatwootWebhookService) authenticate(integration *domain.TenantIntegration, req ChatwootWebhookRequest) error {
+	if integration.WebhookSecretEncrypted != "" {
+		secret, err := s.box.Decrypt(integration.WebhookSecretEncrypted, integration.WebhookSecretContext())
+		if err != nil {
+			return fmt.Errorf("failed to decrypt Chatwoot webhook secret: %w", err)
+		}
+		if err := chatwoot.VerifyWebhookSignature(req.Body, req.Signature, req.Timestamp, secret, chatwootWebhookTolerance, time.Now()); err != nil {
+			return ErrInvalidWebhookSignature
+		}
+		return nil
+	}
+
+	token := chatwootWebhookToken(integration.TenantID)
+	if token == "" || !hmac.Equal([]byte(req.Token), []byte(token)) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// ChatwootEvent returns the decoded webhook of an event published for a
+// Chatwoot webhook, nil for other events
+func ChatwootEvent(event events.Event) *chatwoot.WebhookEvent {
+	webhook, _ := event.Payload[domain.ChatwootEventPayloadKey].(*chatwoot.WebhookEvent)
+	return webhook
+}
+
+// chatwootWebhookToken is the token of the webhook URL of a tenant, derived
+// from CHATWOOT_WEBHOOK_SECRET; empty when the secret is not configured
+func chatwootWebhookToken(tenantID uuid.UUID) string {
+	secret := viper.GetString("CHATWOOT_WEBHOOK_SECRET")
+	if secret == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(tenantID.String()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// chatwootWebhookURL is the URL to set on the webhook of the Chatwoot account
+// of a tenant; it carries the token unless the tenant set a webhook secret
+func chatwootWebhookURL(integration *domain.TenantIntegration) string {
+	url := fmt.Sprintf("%s/webhooks/chatwoot/%s", publicAPIURL(), integration.TenantID)
+	if integration.WebhookSecretEncrypted == "" {
+		if token := chatwootWebhookToken(integration.TenantID); token != "" {
+			url += "?token=" + token
+		}
+	}
+	return url
+}
@@ -0,0 +1,142 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/events"
+)
+
+var (
+	ErrDomainNotSet                   = errors.New("tenant has no custom domain")
+	ErrDomainVerificationNotRequested = errors.New("domain verification was not requested")
+	ErrDomainNotVerified              = errors.New("verification record not found")
+)
+
+// domainVerificationPrefix is the DNS label holding the verification TXT record
+const domainVerificationPrefix = "_widia-verification"
+
+// DomainVerificationChallenge tells the tenant which TXT record to publish
+type DomainVerificationChallenge struct {
+	Domain      string     `json:"domain"`
+	RecordType  string     `json:"record_type"`
+	RecordName  string     `json:"record_name"`
+	RecordValue string     `json:"record_value"`
+	Verified    bool       `json:"verified"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
+}
+
+// RequestDomainVerification returns the TXT record proving ownership of the
+// tenant domain, creating a new token when the domain changed
+func (s *TenantService) RequestDomainVerification(tenantID uuid.UUID) (*DomainVerificationChallenge, error) {
+	tenant, err := s.GetTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tenant.Domain == nil || *tenant.Domain == "" {
+		return nil, ErrDomainNotSet
+	}
+
+	verification := tenant.DomainVerification()
+	if verification == nil || verification.Domain != *tenant.Domain {
+		token := make([]byte, 16)
+		if _, err := rand.Read(token); err != nil {
+			return nil, fmt.Errorf("failed to generate verification token: %w", err)
+		}
+		verification = &domain.DomainVerification{
+			Domain: *tenant.Domain,
+			Token:  hex.EncodeToString(token),
+		}
+		if err := s.saveDomainVerification(tenant, verification); err != nil {
+			return nil, err
+		}
+	}
+
+	return domainChallenge(verification), nil
+}
+
+// VerifyDomain checks the TXT record of the tenant domain and marks it verified
+func (s *TenantService) VerifyDomain(ctx context.Context, tenantID uuid.UUID, actorID *uuid.UUID) (*DomainVerificationChallenge, error) {
+	tenant, err := s.GetTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tenant.Domain == nil || *tenant.Domain == "" {
+		return nil, ErrDomainNotSet
+	}
+
+	verification := tenant.DomainVerification()
+	if verification == nil || verification.Domain != *tenant.Domain {
+		return nil, ErrDomainVerificationNotRequested
+	}
+	if verification.VerifiedAt != nil {
+		return domainChallenge(verification), nil
+	}
+
+	challenge := domainChallenge(verification)
+	records, err := net.DefaultResolver.LookupTXT(ctx, challenge.RecordName)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && (dnsErr.IsNotFound || dnsErr.IsTemporary) {
+			return nil, ErrDomainNotVerified
+		}
+		return nil, fmt.Errorf("failed to look up verification record: %w", err)
+	}
+
+	found := false
+	for _, record := range records {
+		if strings.TrimSpace(record) == challenge.RecordValue {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, ErrDomainNotVerified
+	}
+
+	now := time.Now()
+	verification.VerifiedAt = &now
+	if err := s.saveDomainVerification(tenant, verification); err != nil {
+		return nil, err
+	}
+
+	events.Publish(ctx, events.Event{
+		Name:     domain.EventDomainVerified,
+		TenantID: tenantID,
+		ActorID:  actorID,
+		Payload:  map[string]interface{}{"domain": verification.Domain},
+	})
+
+	return domainChallenge(verification), nil
+}
+
+func (s *TenantService) saveDomainVerification(tenant *domain.Tenant, verification *domain.DomainVerification) error {
+	if tenant.Settings == nil {
+		tenant.Settings = domain.JSON{}
+	}
+	tenant.Settings[domain.DomainVerificationSettingsKey] = verification
+
+	if err := s.db.Model(&domain.Tenant{}).Where("id = ?", tenant.ID).Update("settings", tenant.Settings).Error; err != nil {
+		return fmt.Errorf("failed to save domain verification: %w", err)
+	}
+	return nil
+}
+
+func domainChallenge(verification *domain.DomainVerification) *DomainVerificationChallenge {
+	return &DomainVerificationChallenge{
+		Domain:      verification.Domain,
+		RecordType:  "TXT",
+		RecordName:  fmt.Sprintf("%s.%s", domainVerificationPrefix, verification.Domain),
+		RecordValue: fmt.Sprintf("widia-verification=%s", verification.Token),
+		Verified:    verification.VerifiedAt != nil,
+		VerifiedAt:  verification.VerifiedAt,
+	}
+}
@@ -0,0 +1,437 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/billing"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidDeliveryEvent = errors.New("delivery events need a recipient and a type: a hard or soft bounce, or a complaint")
+	ErrSuppressionNotFound  = errors.New("address is not on the suppression list")
+)
+
+const (
+	// emailWebhookTolerance is how old a signed delivery webhook may be
+	emailWebhookTolerance = 5 * time.Minute
+	// deliveryLogLimit caps the events and emails of a delivery log
+	deliveryLogLimit = 100
+)
+
+// EmailDeliveryNotification is a bounce or complaint posted to the delivery
+// webhook by the email provider
+type EmailDeliveryNotification struct {
+	// ID identifies the notification at the provider; redeliveries are ignored
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	BounceType string `json:"bounce_type"`
+	Recipient  string `json:"recipient"`
+	Diagnostic string `json:"diagnostic"`
+	// MessageID is the Message-ID header of the email the notification is
+	// about, used to find its tenant
+	MessageID  string     `json:"message_id"`
+	TenantID   *uuid.UUID `json:"tenant_id"`
+	OccurredAt *time.Time `json:"occurred_at"`
+}
+
+// DeliveredEmail summarizes an outbox email in a delivery log
+type DeliveredEmail struct {
+	ID        uuid.UUID  `json:"id"`
+	Subject   string     `json:"subject"`
+	Status    string     `json:"status"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"last_error,omitempty"`
+	SentAt    *time.Time `json:"sent_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// RecipientDeliveryLog is what happened to the emails of a tenant to an address
+type RecipientDeliveryLog struct {
+	Recipient string `json:"recipient"`
+	// Suppression is set when the address no longer receives emails
+	Suppression *domain.EmailSuppression     `json:"suppression"`
+	Events      []*domain.EmailDeliveryEvent `json:"events"`
+	Emails      []DeliveredEmail             `json:"emails"`
+}
+
+// EmailDeliveryService records the bounces and complaints about sent emails,
+// from the provider webhook and from mail returned to the bounce mailbox, and
+// keeps the suppression list of each tenant checked before every send
+type EmailDeliveryService struct {
+	db            *gorm.DB
+	repo          domain.EmailDeliveryRepository
+	outboxRepo    domain.EmailOutboxRepository
+	webhookSecret string
+	// mailbox collects returned mail, nil when BOUNCE_MAILDIR is not set
+	mailbox *email.Mailbox
+}
+
+func NewEmailDeliveryService(db *gorm.DB, repo domain.EmailDeliveryRepository, outboxRepo domain.EmailOutboxRepository) *EmailDeliveryService {
+	service := &EmailDeliveryService{
+		db:            db,
+		repo:          repo,
+		outboxRepo:    outboxRepo,
+		webhookSecret: viper.GetString("EMAIL_WEBHOOK_SECRET"),
+	}
+	if dir := viper.GetString("BOUNCE_MAILDIR"); dir != "" {
+		service.mailbox = email.NewMailbox(dir)
+	}
+	return service
+}
+
+// SignatureHeader is the request header carrying the delivery webhook signature
+func (s *EmailDeliveryService) SignatureHeader() string {
+	return "X-Webhook-Signature"
+}
+
+// HandleWebhook verifies a batch of notifications signed like the billing
+// webhooks and records them. It returns the number of new events.
+func (s *EmailDeliveryService) HandleWebhook(payload []byte, signature string) (int, error) {
+	if err := billing.VerifySignature(payload, signature, s.webhookSecret, emailWebhookTolerance, time.Now()); err != nil {
+		return 0, ErrInvalidWebhookSignature
+	}
+
+	var body struct {
+		Events []EmailDeliveryNotification `json:"events"`
+	}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return 0, ErrInvalidDeliveryEvent
+	}
+
+	events := make([]*domain.EmailDeliveryEvent, 0, len(body.Events))
+	for _, notification := range body.Events {
+		event, err := notificationEvent(notification)
+		if err != nil {
+			return 0, err
+		}
+		events = append(events, event)
+	}
+
+	recorded := 0
+	for _, event := range events {
+		created, err := s.record(event)
+		if err != nil {
+			return recorded, err
+		}
+		if created {
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+// IngestReport records the bounces or the complaint of a delivery status
+// notification or feedback report. It returns the number of new events.
+func (s *EmailDeliveryService) IngestReport(r io.Reader) (int, error) {
+	report, err := email.ParseReport(r)
+	if err != nil {
+		return 0, err
+	}
+
+	emailID := parseEmailID(report.MessageID)
+	recorded := 0
+	for _, recipient := range report.Recipients {
+		event := &domain.EmailDeliveryEvent{
+			Recipient:  recipient.Address,
+			Source:     domain.DeliverySourceDSN,
+			Diagnostic: recipient.Diagnostic,
+			EmailID:    emailID,
+			OccurredAt: time.Now(),
+		}
+		switch {
+		case report.Complaint:
+			event.Type = domain.DeliveryEventComplaint
+		case !recipient.Failed():
+			// Delivered or relayed, nothing to record
+			continue
+		case recipient.Permanent():
+			event.Type, event.BounceType = domain.DeliveryEventBounce, domain.BounceHard
+		default:
+			event.Type, event.BounceType = domain.DeliveryEventBounce, domain.BounceSoft
+		}
+		if event.Diagnostic == "" {
+			event.Diagnostic = recipient.Status
+		}
+
+		created, err := s.record(event)
+		if err != nil {
+			return recorded, err
+		}
+		if created {
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+// RunMailboxScan records the reports delivered to the bounce mailbox since
+// the last scan. Messages that are not reports are skipped.
+func (s *EmailDeliveryService) RunMailboxScan(ctx context.Context) error {
+	if s.mailbox == nil {
+		return nil
+	}
+
+	names, err := s.mailbox.Unread()
+	if err != nil {
+		return err
+	}
+
+	recorded := 0
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return nil
+		}
+
+		file, err := s.mailbox.Open(name)
+		if err != nil {
+			log.Printf("Failed to open returned mail %s: %v", name, err)
+			continue
+		}
+		count, err := s.IngestReport(file)
+		file.Close()
+		if err != nil {
+			if !errors.Is(err, email.ErrNotDeliveryReport) {
+				// Keep the message for the next scan
+				log.Printf("Failed to record returned mail %s: %v", name, err)
+				continue
+			}
+			log.Printf("Skipped returned mail %s: not a delivery report", name)
+		}
+		recorded += count
+
+		if err := s.mailbox.MarkRead(name); err != nil {
+			log.Printf("Failed to mark returned mail %s as read: %v", name, err)
+		}
+	}
+
+	if recorded > 0 {
+		log.Printf("Bounce mailbox: %d delivery events recorded", recorded)
+	}
+	return nil
+}
+
+// Suppressed reports whether the emails of the tenant must not be sent to the
+// address, implementing email.SuppressionList. Skipped emails are recorded in
+// the delivery log.
+func (s *EmailDeliveryService) Suppressed(tenantID uuid.UUID, address string) (bool, error) {
+	suppressed, err := s.repo.IsSuppressed(tenantID, normalizeRecipient(address))
+	if err != nil || !suppressed {
+		return false, err
+	}
+
+	if _, err := s.repo.CreateEvent(&domain.EmailDeliveryEvent{
+		TenantID:   &tenantID,
+		Recipient:  normalizeRecipient(address),
+		Type:       domain.DeliveryEventSuppressed,
+		Source:     domain.DeliverySourceSender,
+		OccurredAt: time.Now(),
+	}); err != nil {
+		log.Printf("Failed to record suppressed email to %s: %v", address, err)
+	}
+	return true, nil
+}
+
+// ListEvents returns the latest delivery events of a tenant, optionally of one type
+func (s *EmailDeliveryService) ListEvents(tenantID uuid.UUID, eventType string, limit int) ([]*domain.EmailDeliveryEvent, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	events, err := s.repo.ListEvents(domain.EmailDeliveryFilter{TenantID: &tenantID, Type: eventType, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []*domain.EmailDeliveryEvent{}
+	}
+	return events, nil
+}
+
+// RecipientLog returns the emails sent by a tenant to an address, their
+// delivery events and whether the address is suppressed
+func (s *EmailDeliveryService) RecipientLog(tenantID uuid.UUID, address string) (*RecipientDeliveryLog, error) {
+	recipient := normalizeRecipient(address)
+	if !isValidEmail(recipient) {
+		return nil, ErrInvalidEmail
+	}
+
+	result := &RecipientDeliveryLog{Recipient: recipient, Emails: []DeliveredEmail{}}
+
+	suppression, err := s.repo.FindSuppression(tenantID, recipient)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	result.Suppression = suppression
+
+	result.Events, err = s.repo.ListEvents(domain.EmailDeliveryFilter{TenantID: &tenantID, Recipient: recipient, Limit: deliveryLogLimit})
+	if err != nil {
+		return nil, err
+	}
+	if result.Events == nil {
+		result.Events = []*domain.EmailDeliveryEvent{}
+	}
+
+	messages, err := s.outboxRepo.List(domain.EmailOutboxFilter{TenantID: &tenantID, To: recipient, Limit: deliveryLogLimit})
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range messages {
+		result.Emails = append(result.Emails, DeliveredEmail{
+			ID:        msg.ID,
+			Subject:   msg.Subject,
+			Status:    msg.Status,
+			Attempts:  msg.Attempts,
+			LastError: msg.LastError,
+			SentAt:    msg.SentAt,
+			CreatedAt: msg.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// ListSuppressions returns the suppression list of a tenant, newest first
+func (s *EmailDeliveryService) ListSuppressions(tenantID uuid.UUID) ([]*domain.EmailSuppression, error) {
+	suppressions, err := s.repo.ListSuppressions(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if suppressions == nil {
+		suppressions = []*domain.EmailSuppression{}
+	}
+	return suppressions, nil
+}
+
+// AddSuppression stops the emails of a tenant to an address, at the request
+// of an admin
+func (s *EmailDeliveryService) AddSuppression(tenantID uuid.UUID, address, detail string) (*domain.EmailSuppression, error) {
+	recipient := normalizeRecipient(address)
+	if !isValidEmail(recipient) {
+		return nil, ErrInvalidEmail
+	}
+
+	if err := s.repo.Suppress(&domain.EmailSuppression{
+		TenantID: tenantID,
+		Email:    recipient,
+		Reason:   domain.SuppressionManual,
+		Detail:   strings.TrimSpace(detail),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to suppress address: %w", err)
+	}
+	return s.repo.FindSuppression(tenantID, recipient)
+}
+
+// RemoveSuppression lets the emails of a tenant reach an address again, such
+// as after a user fixed their mailbox, and clears the bounce flag of its users
+func (s *EmailDeliveryService) RemoveSuppression(tenantID uuid.UUID, address string) error {
+	recipient := normalizeRecipient(address)
+	if err := s.repo.DeleteSuppression(tenantID, recipient); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSuppressionNotFound
+		}
+		return err
+	}
+
+	return s.db.Model(&domain.User{}).
+		Where("tenant_id = ? AND LOWER(email) = ?", tenantID, recipient).
+		Update("email_bounced_at", nil).Error
+}
+
+// record stores an event, attributing it to the tenant of the email it is
+// about, and suppresses the recipient of hard bounces and complaints
+func (s *EmailDeliveryService) record(event *domain.EmailDeliveryEvent) (bool, error) {
+	event.Recipient = normalizeRecipient(event.Recipient)
+	if event.TenantID == nil && event.EmailID != nil {
+		if msg, err := s.outboxRepo.FindByID(*event.EmailID); err == nil {
+			event.TenantID = msg.TenantID
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Sent by another system sharing the sender address
+			event.EmailID = nil
+		} else {
+			return false, err
+		}
+	}
+
+	created, err := s.repo.CreateEvent(event)
+	if err != nil || !created {
+		return false, err
+	}
+	if !event.Suppresses() || event.TenantID == nil {
+		return true, nil
+	}
+
+	reason := domain.SuppressionHardBounce
+	if event.Type == domain.DeliveryEventComplaint {
+		reason = domain.SuppressionComplaint
+	}
+	if err := s.repo.Suppress(&domain.EmailSuppression{
+		TenantID: *event.TenantID,
+		Email:    event.Recipient,
+		Reason:   reason,
+		Detail:   event.Diagnostic,
+	}); err != nil {
+		return true, fmt.Errorf("failed to suppress address: %w", err)
+	}
+
+	// Flag the users of the tenant with this address in the user list
+	err = s.db.Model(&domain.User{}).
+		Where("tenant_id = ? AND LOWER(email) = ? AND email_bounced_at IS NULL", *event.TenantID, event.Recipient).
+		Update("email_bounced_at", event.OccurredAt).Error
+	return true, err
+}
+
+func notificationEvent(n EmailDeliveryNotification) (*domain.EmailDeliveryEvent, error) {
+	if !isValidEmail(n.Recipient) {
+		return nil, ErrInvalidDeliveryEvent
+	}
+
+	event := &domain.EmailDeliveryEvent{
+		TenantID:   n.TenantID,
+		Recipient:  n.Recipient,
+		Type:       n.Type,
+		Source:     domain.DeliverySourceWebhook,
+		Diagnostic: n.Diagnostic,
+		EmailID:    parseEmailID(email.MessageIDLocalPart(n.MessageID)),
+		ExternalID: n.ID,
+		OccurredAt: time.Now(),
+	}
+	if n.OccurredAt != nil {
+		event.OccurredAt = *n.OccurredAt
+	}
+
+	switch n.Type {
+	case domain.DeliveryEventBounce:
+		if n.BounceType != domain.BounceHard && n.BounceType != domain.BounceSoft {
+			return nil, ErrInvalidDeliveryEvent
+		}
+		event.BounceType = n.BounceType
+	case domain.DeliveryEventComplaint:
+	default:
+		return nil, ErrInvalidDeliveryEvent
+	}
+	return event, nil
+}
+
+// parseEmailID returns the outbox email a Message-ID refers to, nil for
+// emails that were not sent from the outbox
+func parseEmailID(messageID string) *uuid.UUID {
+	id, err := uuid.Parse(messageID)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+func normalizeRecipient(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
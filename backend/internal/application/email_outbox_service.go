@@ -0,0 +1,223 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmailNotFound     = errors.New("email not found")
+	ErrEmailNotRetryable = errors.New("only failed or dead emails can be retried")
+)
+
+// emailDispatchBatch is the number of emails claimed at once by the worker
+const emailDispatchBatch = 50
+
+// EmailOutboxStats counts the outbox emails in each state
+type EmailOutboxStats struct {
+	Counts map[string]int64 `json:"counts"`
+}
+
+// EmailOutboxService stores emails in the outbox and delivers them in the
+// background, retrying failures with exponential backoff
+type EmailOutboxService struct {
+	repo   domain.EmailOutboxRepository
+	mailer email.Mailer
+}
+
+func NewEmailOutboxService(repo domain.EmailOutboxRepository, mailer email.Mailer) *EmailOutboxService {
+	return &EmailOutboxService{
+		repo:   repo,
+		mailer: mailer,
+	}
+}
+
+// Enqueue stores a message in the outbox, implementing email.Queue
+func (s *EmailOutboxService) Enqueue(msg *email.Message) error {
+	return s.repo.Create(newOutboxMessage(msg))
+}
+
+// Dispatch delivers the emails due for delivery and returns how many were
+// sent and how many failed
+func (s *EmailOutboxService) Dispatch(ctx context.Context) (int, int, error) {
+	messages, err := s.repo.ClaimDue(time.Now(), emailDispatchBatch)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to claim emails: %w", err)
+	}
+
+	sent, failed := 0, 0
+	for _, msg := range messages {
+		if err := ctx.Err(); err != nil {
+			// Release the claimed emails for the next run
+			msg.Status = domain.EmailPending
+			if err := s.repo.Update(msg); err != nil {
+				log.Printf("Failed to release email %s: %v", msg.ID, err)
+			}
+			continue
+		}
+
+		if s.deliver(ctx, msg) {
+			sent++
+		} else {
+			failed++
+		}
+	}
+	return sent, failed, nil
+}
+
+// RunDispatch delivers due emails until the outbox is drained or the context ends
+func (s *EmailOutboxService) RunDispatch(ctx context.Context) error {
+	for ctx.Err() == nil {
+		sent, failed, err := s.Dispatch(ctx)
+		if err != nil {
+			return err
+		}
+		if sent > 0 || failed > 0 {
+			log.Printf("Email outbox: %d sent, %d failed", sent, failed)
+		}
+		if sent+failed < emailDispatchBatch {
+			return nil
+		}
+	}
+	return nil
+}
+
+// List returns the outbox emails matching the filter
+func (s *EmailOutboxService) List(filter domain.EmailOutboxFilter) ([]*domain.EmailOutboxMessage, error) {
+	if filter.Limit <= 0 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+	messages, err := s.repo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	if messages == nil {
+		messages = []*domain.EmailOutboxMessage{}
+	}
+	return messages, nil
+}
+
+// Get returns an outbox email
+func (s *EmailOutboxService) Get(id uuid.UUID) (*domain.EmailOutboxMessage, error) {
+	msg, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEmailNotFound
+		}
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Stats counts the outbox emails in each state
+func (s *EmailOutboxService) Stats() (*EmailOutboxStats, error) {
+	counts, err := s.repo.CountByStatus()
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range []string{domain.EmailPending, domain.EmailSending, domain.EmailSent, domain.EmailFailed, domain.EmailDead} {
+		if _, ok := counts[status]; !ok {
+			counts[status] = 0
+		}
+	}
+	return &EmailOutboxStats{Counts: counts}, nil
+}
+
+// Retry schedules a failed or dead email for immediate delivery with a fresh
+// set of attempts
+func (s *EmailOutboxService) Retry(id uuid.UUID) (*domain.EmailOutboxMessage, error) {
+	msg, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Status != domain.EmailFailed && msg.Status != domain.EmailDead {
+		return nil, ErrEmailNotRetryable
+	}
+
+	msg.Status = domain.EmailPending
+	msg.Attempts = 0
+	msg.NextAttemptAt = time.Now()
+	if err := s.repo.Update(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// deliver sends a claimed email and records the outcome
+func (s *EmailOutboxService) deliver(ctx context.Context, msg *domain.EmailOutboxMessage) bool {
+	err := s.mailer.Send(ctx, outboxEmail(msg))
+	now := time.Now()
+	msg.Attempts++
+
+	if err == nil {
+		msg.Status = domain.EmailSent
+		msg.SentAt = &now
+		msg.LastError = ""
+	} else {
+		msg.LastError = err.Error()
+		if msg.Attempts >= domain.EmailMaxAttempts {
+			msg.Status = domain.EmailDead
+			log.Printf("Email %s to %s is dead after %d attempts: %v", msg.ID, msg.ToAddress, msg.Attempts, err)
+		} else {
+			msg.Status = domain.EmailFailed
+			msg.NextAttemptAt = now.Add(domain.EmailRetryDelay(msg.Attempts))
+		}
+	}
+
+	if err := s.repo.Update(msg); err != nil {
+		log.Printf("Failed to update email %s: %v", msg.ID, err)
+	}
+	return msg.Status == domain.EmailSent
+}
+
+// txOutbox writes emails to the outbox in the transaction of the change that
+// triggered them, so they are sent if and only if the change is committed
+type txOutbox struct {
+	tx *gorm.DB
+}
+
+func (q txOutbox) Enqueue(msg *email.Message) error {
+	return q.tx.Create(newOutboxMessage(msg)).Error
+}
+
+func newOutboxMessage(msg *email.Message) *domain.EmailOutboxMessage {
+	row := &domain.EmailOutboxMessage{
+		FromAddress:   msg.From,
+		ToAddress:     msg.To,
+		ReplyTo:       msg.ReplyTo,
+		Subject:       msg.Subject,
+		TextBody:      msg.Text,
+		HTMLBody:      msg.HTML,
+		Status:        domain.EmailPending,
+		NextAttemptAt: time.Now(),
+	}
+	if msg.TenantID != uuid.Nil {
+		tenantID := msg.TenantID
+		row.TenantID = &tenantID
+	}
+	return row
+}
+
+func outboxEmail(row *domain.EmailOutboxMessage) *email.Message {
+	msg := &email.Message{
+		ID:      row.ID.String(),
+		From:    row.FromAddress,
+		To:      row.ToAddress,
+		ReplyTo: row.ReplyTo,
+		Subject: row.Subject,
+		Text:    row.TextBody,
+		HTML:    row.HTMLBody,
+	}
+	if row.TenantID != nil {
+		msg.TenantID = *row.TenantID
+	}
+	return msg
+}
@@ -0,0 +1,182 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmailTemplateNotFound   = errors.New("email template not found")
+	ErrInvalidTemplateOverride = errors.New("subject and intro must be valid templates using the available placeholders")
+)
+
+// EmailTemplate describes a template with its default texts in the tenant
+// locale and the tenant override, if any
+type EmailTemplate struct {
+	Name           string                        `json:"name"`
+	DefaultSubject string                        `json:"default_subject"`
+	DefaultIntro   string                        `json:"default_intro"`
+	Override       *domain.EmailTemplateOverride `json:"override,omitempty"`
+}
+
+// EmailTemplateSettings is the email configuration of a tenant
+type EmailTemplateSettings struct {
+	Locale       string          `json:"locale"`
+	Locales      []string        `json:"locales"`
+	Placeholders []string        `json:"placeholders"`
+	Templates    []EmailTemplate `json:"templates"`
+}
+
+// EmailTemplateService lets tenant admins choose the locale of their emails,
+// customize the subject and intro of each template and preview them
+type EmailTemplateService struct {
+	db           *gorm.DB
+	tenantRepo   domain.TenantRepository
+	emailService *email.EmailService
+}
+
+func NewEmailTemplateService(db *gorm.DB, tenantRepo domain.TenantRepository) *EmailTemplateService {
+	return &EmailTemplateService{
+		db:           db,
+		tenantRepo:   tenantRepo,
+		emailService: email.NewEmailService(),
+	}
+}
+
+// GetSettings returns the email settings of a tenant
+func (s *EmailTemplateService) GetSettings(tenantID uuid.UUID) (*EmailTemplateSettings, error) {
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return emailTemplateSettings(tenant), nil
+}
+
+// UpdateLocale sets the locale of the emails of users who did not choose one;
+// an empty locale resets it to the platform default
+func (s *EmailTemplateService) UpdateLocale(tenantID uuid.UUID, locale string) (*EmailTemplateSettings, error) {
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if locale != "" {
+		locale = email.NormalizeLocale(locale)
+		if locale == "" {
+			return nil, ErrInvalidLocale
+		}
+	}
+
+	settings := tenant.EmailSettings()
+	settings.Locale = locale
+	if err := s.save(tenant, settings); err != nil {
+		return nil, err
+	}
+	return emailTemplateSettings(tenant), nil
+}
+
+// UpdateOverride replaces the subject and intro of a template; empty fields
+// restore the default text
+func (s *EmailTemplateService) UpdateOverride(tenantID uuid.UUID, name string, override domain.EmailTemplateOverride) (*EmailTemplateSettings, error) {
+	if !email.IsTemplate(name) {
+		return nil, ErrEmailTemplateNotFound
+	}
+	if err := email.ValidateOverride(email.TemplateOverride{Subject: override.Subject, Intro: override.Intro}); err != nil {
+		return nil, ErrInvalidTemplateOverride
+	}
+
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := tenant.EmailSettings()
+	if settings.Templates == nil {
+		settings.Templates = make(map[string]domain.EmailTemplateOverride)
+	}
+	if override.Subject == "" && override.Intro == "" {
+		delete(settings.Templates, name)
+	} else {
+		settings.Templates[name] = override
+	}
+
+	if err := s.save(tenant, settings); err != nil {
+		return nil, err
+	}
+	return emailTemplateSettings(tenant), nil
+}
+
+// Preview renders a template with sample data, the tenant branding and
+// overrides, in the given locale or the tenant locale
+func (s *EmailTemplateService) Preview(tenantID uuid.UUID, name, locale string) (*email.Rendered, error) {
+	if !email.IsTemplate(name) {
+		return nil, ErrEmailTemplateNotFound
+	}
+	if locale != "" && email.NormalizeLocale(locale) == "" {
+		return nil, ErrInvalidLocale
+	}
+
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.emailService.Preview(name, emailBranding(tenant).WithLocale(locale))
+}
+
+func (s *EmailTemplateService) save(tenant *domain.Tenant, settings domain.TenantEmailSettings) error {
+	if tenant.Settings == nil {
+		tenant.Settings = domain.JSON{}
+	}
+	tenant.Settings[domain.EmailSettingsKey] = settings
+
+	if err := s.db.Model(&domain.Tenant{}).Where("id = ?", tenant.ID).Update("settings", tenant.Settings).Error; err != nil {
+		return fmt.Errorf("failed to save email settings: %w", err)
+	}
+	return nil
+}
+
+func (s *EmailTemplateService) findTenant(tenantID uuid.UUID) (*domain.Tenant, error) {
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+	return tenant, nil
+}
+
+func emailTemplateSettings(tenant *domain.Tenant) *EmailTemplateSettings {
+	settings := tenant.EmailSettings()
+
+	locale := email.NormalizeLocale(settings.Locale)
+	if locale == "" {
+		locale = email.DefaultLocale
+	}
+
+	result := &EmailTemplateSettings{
+		Locale:       locale,
+		Locales:      email.Locales,
+		Placeholders: email.Placeholders,
+		Templates:    make([]EmailTemplate, 0, len(email.Templates)),
+	}
+	for _, name := range email.Templates {
+		subject, intro := email.DefaultText(name, locale)
+		template := EmailTemplate{
+			Name:           name,
+			DefaultSubject: subject,
+			DefaultIntro:   intro,
+		}
+		if override, ok := settings.Templates[name]; ok {
+			template.Override = &override
+		}
+		result.Templates = append(result.Templates, template)
+	}
+	return result
+}
@@ -0,0 +1,329 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/metering"
+	"github.com/widia/widia-connect/internal/infrastructure/storage"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExportNotFound   = errors.New("export not found")
+	ErrExportInProgress = errors.New("an export is already in progress")
+	ErrExportNotReady   = errors.New("export is not available for download")
+)
+
+// Export timings
+const (
+	ExportDownloadURLTTL = 15 * time.Minute
+	exportStuckAfter     = time.Hour
+)
+
+type ExportService struct {
+	db           *gorm.DB
+	tenantRepo   domain.TenantRepository
+	userRepo     domain.UserRepository
+	exportRepo   domain.TenantExportRepository
+	storage      storage.Storage
+	emailService *email.EmailService
+	retention    time.Duration
+}
+
+func NewExportService(
+	db *gorm.DB,
+	tenantRepo domain.TenantRepository,
+	userRepo domain.UserRepository,
+	exportRepo domain.TenantExportRepository,
+	store storage.Storage,
+) *ExportService {
+	retentionDays := viper.GetInt("EXPORT_RETENTION_DAYS")
+	if retentionDays <= 0 {
+		retentionDays = 7
+	}
+
+	return &ExportService{
+		db:           db,
+		tenantRepo:   tenantRepo,
+		userRepo:     userRepo,
+		exportRepo:   exportRepo,
+		storage:      store,
+		emailService: email.NewEmailService(),
+		retention:    time.Duration(retentionDays) * 24 * time.Hour,
+	}
+}
+
+// RequestExport queues a new export of the tenant and starts it in the background
+func (s *ExportService) RequestExport(tenantID, requestedBy uuid.UUID) (*domain.TenantExport, error) {
+	if _, err := s.tenantRepo.FindByID(tenantID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+
+	exports, err := s.exportRepo.FindByTenant(tenantID, 5)
+	if err != nil {
+		return nil, err
+	}
+	for _, export := range exports {
+		if export.Status == domain.ExportPending || export.Status == domain.ExportRunning {
+			return nil, ErrExportInProgress
+		}
+	}
+
+	export := &domain.TenantExport{
+		TenantID:      tenantID,
+		RequestedBy:   &requestedBy,
+		Status:        domain.ExportPending,
+		FormatVersion: domain.ExportFormatVersion,
+	}
+	if err := s.exportRepo.Create(export); err != nil {
+		return nil, err
+	}
+
+	// The sweep job picks the export up if this process stops before finishing
+	go func() {
+		if err := s.Process(context.Background(), export.ID); err != nil {
+			log.Printf("Failed to export tenant %s: %v", tenantID, err)
+		}
+	}()
+
+	return export, nil
+}
+
+// ListExports returns the latest exports of a tenant
+func (s *ExportService) ListExports(tenantID uuid.UUID) ([]*domain.TenantExport, error) {
+	return s.exportRepo.FindByTenant(tenantID, 20)
+}
+
+// GetExport returns an export of the tenant
+func (s *ExportService) GetExport(tenantID, exportID uuid.UUID) (*domain.TenantExport, error) {
+	export, err := s.exportRepo.FindByID(exportID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+
+	if export.TenantID != tenantID {
+		return nil, ErrExportNotFound
+	}
+
+	return export, nil
+}
+
+// DownloadURL returns a short lived signed URL to the export archive
+func (s *ExportService) DownloadURL(tenantID, exportID uuid.UUID) (string, time.Time, error) {
+	export, err := s.GetExport(tenantID, exportID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if export.Status != domain.ExportCompleted {
+		return "", time.Time{}, ErrExportNotReady
+	}
+
+	url, err := s.storage.SignedURL(export.StorageKey, ExportDownloadURLTTL)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return url, time.Now().Add(ExportDownloadURLTTL), nil
+}
+
+// Process builds the archive of a pending export. It does nothing if another
+// worker already claimed the export.
+func (s *ExportService) Process(ctx context.Context, exportID uuid.UUID) error {
+	claimed, err := s.exportRepo.Claim(exportID, domain.ExportPending, domain.ExportRunning)
+	if err != nil || !claimed {
+		return err
+	}
+
+	export, err := s.exportRepo.FindByID(exportID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	export.StartedAt = &now
+
+	if err := s.build(ctx, export); err != nil {
+		export.Status = domain.ExportFailed
+		export.Error = err.Error()
+		if updateErr := s.exportRepo.Update(export); updateErr != nil {
+			log.Printf("Failed to record export %s failure: %v", export.ID, updateErr)
+		}
+		return err
+	}
+
+	completedAt := time.Now()
+	expiresAt := completedAt.Add(s.retention)
+	export.Status = domain.ExportCompleted
+	export.Error = ""
+	export.CompletedAt = &completedAt
+	export.ExpiresAt = &expiresAt
+	if err := s.exportRepo.Update(export); err != nil {
+		return err
+	}
+
+	if err := s.notifyRequester(export); err != nil {
+		log.Printf("Failed to send export ready email for %s: %v", export.ID, err)
+	}
+
+	return nil
+}
+
+// RunSweep is the scheduler entry point: it restarts exports interrupted by a
+// restart, runs queued exports and deletes archives past their retention
+func (s *ExportService) RunSweep(ctx context.Context) error {
+	now := time.Now()
+
+	stuck, err := s.exportRepo.FindByStatus(domain.ExportRunning, now.Add(-exportStuckAfter))
+	if err != nil {
+		return err
+	}
+	for _, export := range stuck {
+		if _, err := s.exportRepo.Claim(export.ID, domain.ExportRunning, domain.ExportPending); err != nil {
+			return err
+		}
+	}
+
+	pending, err := s.exportRepo.FindByStatus(domain.ExportPending, now)
+	if err != nil {
+		return err
+	}
+	for _, export := range pending {
+		if err := s.Process(ctx, export.ID); err != nil {
+			log.Printf("Failed to export tenant %s: %v", export.TenantID, err)
+		}
+	}
+
+	expired, err := s.exportRepo.FindExpired(now)
+	if err != nil {
+		return err
+	}
+	for _, export := range expired {
+		if err := s.storage.Delete(ctx, export.StorageKey); err != nil {
+			log.Printf("Failed to delete export archive %s: %v", export.ID, err)
+			continue
+		}
+		export.Status = domain.ExportExpired
+		if err := s.exportRepo.Update(export); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// build writes the archive to a temporary file and stores it
+func (s *ExportService) build(ctx context.Context, export *domain.TenantExport) error {
+	tenant, err := s.tenantRepo.FindByID(export.TenantID)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "tenant-export-*.zip")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	manifest := &domain.ExportManifest{
+		FormatVersion: domain.ExportFormatVersion,
+		TenantID:      tenant.ID,
+		TenantSlug:    tenant.Slug,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	// Read every table from the same snapshot
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		archive := newArchiveWriter(tmp, manifest)
+
+		if err := archive.writeTable(archiveTenantTable,
+			tx.Table(archiveTenantTable).Where("id = ?", tenant.ID)); err != nil {
+			return err
+		}
+
+		for _, table := range domain.TenantDataTables {
+			columns, err := tableColumns(tx, table.Name)
+			if err != nil {
+				return err
+			}
+			if len(columns) == 0 || !containsString(columns, "tenant_id") {
+				continue
+			}
+
+			selected := make([]string, 0, len(columns))
+			for _, column := range columns {
+				if !containsString(table.Omit, column) {
+					selected = append(selected, quoteIdent(column))
+				}
+			}
+
+			query := tx.Table(quoteIdent(table.Name)).Select(selected).Where("tenant_id = ?", tenant.ID)
+			if err := archive.writeTable(table.Name, query); err != nil {
+				return err
+			}
+		}
+
+		return archive.close()
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	key := fmt.Sprintf("exports/%s/%s.zip", tenant.ID, export.ID)
+	size, err := s.storage.Put(ctx, key, io.TeeReader(tmp, hash))
+	if err != nil {
+		return fmt.Errorf("failed to store archive: %w", err)
+	}
+
+	export.StorageKey = key
+	export.SizeBytes = size
+	export.Checksum = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+func (s *ExportService) notifyRequester(export *domain.TenantExport) error {
+	if export.RequestedBy == nil {
+		return nil
+	}
+
+	user, err := s.userRepo.FindByID(*export.RequestedBy)
+	if err != nil {
+		return err
+	}
+
+	tenant, err := s.tenantRepo.FindByID(export.TenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.emailService.SendTenantExportReady(emailBranding(tenant).WithLocale(user.Locale), user.Email, user.Name, tenant.Name, *export.ExpiresAt); err != nil {
+		return err
+	}
+	metering.Record(tenant.ID, domain.MetricEmailsSent, 1)
+	return nil
+}
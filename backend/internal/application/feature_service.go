@@ -0,0 +1,202 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownFeature = errors.New("unknown feature flag")
+)
+
+// Sources of a resolved flag value
+const (
+	FeatureSourcePlan     = "plan"
+	FeatureSourceOverride = "override"
+	FeatureSourceRollout  = "rollout"
+)
+
+// ResolvedFeature is the effective value of a flag for a tenant
+type ResolvedFeature struct {
+	Key     string `json:"key"`
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"`
+}
+
+type FeatureService struct {
+	db           *gorm.DB
+	tenantRepo   domain.TenantRepository
+	overrideRepo domain.FeatureOverrideRepository
+	planRepo     domain.PlanRepository
+}
+
+func NewFeatureService(
+	db *gorm.DB,
+	tenantRepo domain.TenantRepository,
+	overrideRepo domain.FeatureOverrideRepository,
+	planRepo domain.PlanRepository,
+) *FeatureService {
+	return &FeatureService{
+		db:           db,
+		tenantRepo:   tenantRepo,
+		overrideRepo: overrideRepo,
+		planRepo:     planRepo,
+	}
+}
+
+// IsEnabled resolves a single flag for a tenant
+func (s *FeatureService) IsEnabled(tenantID uuid.UUID, feature string) (bool, error) {
+	flag, ok := domain.FindFeatureFlag(feature)
+	if !ok {
+		return false, ErrUnknownFeature
+	}
+
+	tenant, err := s.getTenant(tenantID)
+	if err != nil {
+		return false, err
+	}
+
+	plan, err := resolveTenantPlan(s.planRepo, tenant)
+	if err != nil {
+		return false, err
+	}
+
+	override, err := s.overrideRepo.FindByTenantAndFeature(tenantID, feature)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	return resolveFeature(flag, tenant.ID, plan, override).Enabled, nil
+}
+
+// GetEffectiveFlags resolves every flag of the catalog for a tenant
+func (s *FeatureService) GetEffectiveFlags(tenantID uuid.UUID) ([]ResolvedFeature, error) {
+	tenant, err := s.getTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := resolveTenantPlan(s.planRepo, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := s.overrideRepo.FindByTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	byFeature := make(map[string]*domain.TenantFeatureOverride, len(overrides))
+	for _, override := range overrides {
+		byFeature[override.Feature] = override
+	}
+
+	flags := make([]ResolvedFeature, 0, len(domain.FeatureCatalog))
+	for _, flag := range domain.FeatureCatalog {
+		flags = append(flags, resolveFeature(flag, tenant.ID, plan, byFeature[flag.Key]))
+	}
+
+	return flags, nil
+}
+
+// ListOverrides returns the overrides configured for a tenant
+func (s *FeatureService) ListOverrides(tenantID uuid.UUID) ([]*domain.TenantFeatureOverride, error) {
+	if _, err := s.getTenant(tenantID); err != nil {
+		return nil, err
+	}
+	return s.overrideRepo.FindByTenant(tenantID)
+}
+
+// SetOverride forces a flag on or off for a tenant (super admin action)
+func (s *FeatureService) SetOverride(tenantID uuid.UUID, feature string, enabled bool, reason string, updatedBy uuid.UUID) (*domain.TenantFeatureOverride, error) {
+	if _, ok := domain.FindFeatureFlag(feature); !ok {
+		return nil, ErrUnknownFeature
+	}
+
+	if _, err := s.getTenant(tenantID); err != nil {
+		return nil, err
+	}
+
+	override := &domain.TenantFeatureOverride{
+		TenantID:  tenantID,
+		Feature:   feature,
+		Enabled:   enabled,
+		Reason:    reason,
+		UpdatedBy: &updatedBy,
+	}
+
+	if err := s.overrideRepo.Upsert(override); err != nil {
+		return nil, fmt.Errorf("failed to save feature override: %w", err)
+	}
+
+	return override, nil
+}
+
+// DeleteOverride removes a tenant override so the flag falls back to plan and rollout
+func (s *FeatureService) DeleteOverride(tenantID uuid.UUID, feature string) error {
+	if _, ok := domain.FindFeatureFlag(feature); !ok {
+		return ErrUnknownFeature
+	}
+	return s.overrideRepo.Delete(tenantID, feature)
+}
+
+func (s *FeatureService) getTenant(tenantID uuid.UUID) (*domain.Tenant, error) {
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+	return tenant, nil
+}
+
+// resolveFeature applies plan default, tenant override and percentage rollout, in that order
+func resolveFeature(flag domain.FeatureFlag, tenantID uuid.UUID, plan *domain.Plan, override *domain.TenantFeatureOverride) ResolvedFeature {
+	resolved := ResolvedFeature{
+		Key:     flag.Key,
+		Enabled: plan.HasFeature(flag.Key),
+		Source:  FeatureSourcePlan,
+	}
+
+	if override != nil {
+		resolved.Enabled = override.Enabled
+		resolved.Source = FeatureSourceOverride
+		return resolved
+	}
+
+	if !resolved.Enabled && inRollout(tenantID, flag.Key, rolloutPercentage(flag)) {
+		resolved.Enabled = true
+		resolved.Source = FeatureSourceRollout
+	}
+
+	return resolved
+}
+
+// rolloutPercentage lets operators widen a rollout through FEATURE_ROLLOUT_<KEY>
+func rolloutPercentage(flag domain.FeatureFlag) int {
+	key := "FEATURE_ROLLOUT_" + strings.ToUpper(flag.Key)
+	if viper.IsSet(key) {
+		return viper.GetInt(key)
+	}
+	return flag.RolloutPercentage
+}
+
+// inRollout places a tenant in a stable bucket between 0 and 99 for each flag
+func inRollout(tenantID uuid.UUID, feature string, percentage int) bool {
+	if percentage <= 0 {
+		return false
+	}
+	if percentage >= 100 {
+		return true
+	}
+	bucket := crc32.ChecksumIEEE([]byte(tenantID.String()+":"+feature)) % 100
+	return int(bucket) < percentage
+}
@@ -0,0 +1,587 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidArchive  = errors.New("invalid tenant archive")
+	ErrImportConflicts = errors.New("import has conflicts")
+
+	errDryRunRollback = errors.New("dry run")
+)
+
+// unusablePasswordHash never matches a bcrypt comparison; imported users
+// must reset their password since archives do not carry password hashes
+const unusablePasswordHash = "!"
+
+// ImportOptions select where an archive is imported
+type ImportOptions struct {
+	// TargetTenantID merges the archive into an existing tenant; when nil a new tenant is created
+	TargetTenantID *uuid.UUID
+	// Slug and Name of the new tenant, defaulting to the archived ones
+	Slug   string
+	Name   string
+	DryRun bool
+}
+
+// ImportConflict is a row of the archive that clashes with existing data
+type ImportConflict struct {
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+// ImportTableReport is the outcome of importing one table
+type ImportTableReport struct {
+	Table   string `json:"table"`
+	Rows    int64  `json:"rows"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ImportReport describes what an import did, or would do in dry-run mode
+type ImportReport struct {
+	DryRun                    bool                `json:"dry_run"`
+	SourceTenantID            uuid.UUID           `json:"source_tenant_id"`
+	TenantID                  uuid.UUID           `json:"tenant_id"`
+	TenantSlug                string              `json:"tenant_slug"`
+	NewTenant                 bool                `json:"new_tenant"`
+	Tables                    []ImportTableReport `json:"tables"`
+	Conflicts                 []ImportConflict    `json:"conflicts"`
+	Warnings                  []string            `json:"warnings"`
+	UsersRequirePasswordReset int64               `json:"users_require_password_reset"`
+}
+
+type ImportService struct {
+	db           *gorm.DB
+	tenantRepo   domain.TenantRepository
+	planRepo     domain.PlanRepository
+	quotaService *QuotaService
+}
+
+func NewImportService(db *gorm.DB, tenantRepo domain.TenantRepository, planRepo domain.PlanRepository, quotaService *QuotaService) *ImportService {
+	return &ImportService{
+		db:           db,
+		tenantRepo:   tenantRepo,
+		planRepo:     planRepo,
+		quotaService: quotaService,
+	}
+}
+
+// Import loads a tenant archive, giving every row a new ID and rewriting the
+// references between rows. Everything runs in one transaction, so a failed
+// or dry-run import leaves no data behind.
+func (s *ImportService) Import(ctx context.Context, r io.ReaderAt, size int64, opts ImportOptions) (*ImportReport, error) {
+	archive, err := openArchive(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.readTenantRow(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{
+		DryRun:         opts.DryRun,
+		SourceTenantID: archive.manifest.TenantID,
+		Tables:         []ImportTableReport{},
+		Conflicts:      []ImportConflict{},
+		Warnings:       []string{},
+	}
+
+	ids, err := s.mapIDs(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.TargetTenantID != nil {
+		tenant, err := s.tenantRepo.FindByID(*opts.TargetTenantID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrTenantNotFound
+			}
+			return nil, err
+		}
+		report.TenantID = tenant.ID
+		report.TenantSlug = tenant.Slug
+	} else {
+		report.NewTenant = true
+		report.TenantID = uuid.New()
+		report.TenantSlug = opts.Slug
+		if report.TenantSlug == "" {
+			report.TenantSlug, _ = source["slug"].(string)
+		}
+	}
+	ids[archive.manifest.TenantID.String()] = report.TenantID.String()
+
+	if err := s.checkConflicts(archive, report); err != nil {
+		return nil, err
+	}
+	if len(report.Conflicts) > 0 {
+		if opts.DryRun {
+			return report, nil
+		}
+		return report, ErrImportConflicts
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if report.NewTenant {
+			if err := s.insertTenant(tx, source, opts, report); err != nil {
+				return err
+			}
+		}
+
+		for _, table := range domain.TenantDataTables {
+			if err := s.importTable(tx, archive, table, ids, report); err != nil {
+				return err
+			}
+		}
+
+		if opts.DryRun {
+			return errDryRunRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRunRollback) {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *ImportService) readTenantRow(archive *archiveReader) (map[string]interface{}, error) {
+	entry, ok := archive.file(archiveTenantTable)
+	if !ok || entry.Rows != 1 {
+		return nil, fmt.Errorf("%w: archive must contain exactly one tenant", ErrInvalidArchive)
+	}
+
+	var tenant map[string]interface{}
+	err := archive.eachRow(entry, func(row map[string]interface{}) error {
+		tenant = row
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if id, _ := tenant["id"].(string); id != archive.manifest.TenantID.String() {
+		return nil, fmt.Errorf("%w: tenant does not match the manifest", ErrInvalidArchive)
+	}
+
+	return tenant, nil
+}
+
+// mapIDs assigns a new ID to every row of the archive
+func (s *ImportService) mapIDs(archive *archiveReader) (map[string]string, error) {
+	ids := make(map[string]string)
+
+	for _, entry := range archive.manifest.Files {
+		if entry.Table == archiveTenantTable {
+			continue
+		}
+
+		err := archive.eachRow(entry, func(row map[string]interface{}) error {
+			if id, ok := row["id"].(string); ok {
+				if _, err := uuid.Parse(id); err == nil {
+					ids[id] = uuid.New().String()
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
+
+// checkConflicts reports archive rows that clash with unique data of the target
+func (s *ImportService) checkConflicts(archive *archiveReader, report *ImportReport) error {
+	if report.NewTenant {
+		if !isValidSlug(report.TenantSlug) {
+			report.Conflicts = append(report.Conflicts, ImportConflict{
+				Type:    "slug",
+				Value:   report.TenantSlug,
+				Message: "slug is not valid",
+			})
+		} else if domain.IsReservedSlug(report.TenantSlug) {
+			report.Conflicts = append(report.Conflicts, ImportConflict{
+				Type:    "slug",
+				Value:   report.TenantSlug,
+				Message: "slug is reserved",
+			})
+		} else if exists, err := s.tenantRepo.ExistsBySlug(report.TenantSlug); err != nil {
+			return err
+		} else if exists {
+			report.Conflicts = append(report.Conflicts, ImportConflict{
+				Type:    "slug",
+				Value:   report.TenantSlug,
+				Message: "slug is in use by a tenant or as a former slug",
+			})
+		}
+		return nil
+	}
+
+	// Merging into an existing tenant: the imported users take seats of its
+	// plan, and the imported tables must not clash with its unique data
+	if entry, ok := archive.file("users"); ok && entry.Rows > 0 {
+		if err := s.quotaService.Check(report.TenantID, domain.QuotaSeats, entry.Rows); err != nil {
+			var quotaErr *QuotaExceededError
+			if !errors.As(err, &quotaErr) {
+				return err
+			}
+			report.Conflicts = append(report.Conflicts, ImportConflict{
+				Type:    "seats",
+				Value:   strconv.FormatInt(entry.Rows, 10),
+				Message: fmt.Sprintf("%d users exceed the %d seats of the %s plan, %d are in use", entry.Rows, quotaErr.Limit, quotaErr.Plan, quotaErr.Usage),
+			})
+		}
+	}
+
+	for _, table := range domain.TenantDataTables {
+		if table.ExportOnly || (!table.OnePerTenant && len(table.Unique) == 0) {
+			continue
+		}
+		entry, ok := archive.file(table.Name)
+		if !ok || entry.Rows == 0 {
+			continue
+		}
+		types, err := tableColumnTypes(s.db, table.Name)
+		if err != nil {
+			return err
+		}
+		if len(types) == 0 {
+			continue
+		}
+
+		if table.OnePerTenant {
+			var count int64
+			if err := s.db.Table(quoteIdent(table.Name)).Where("tenant_id = ?", report.TenantID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				report.Conflicts = append(report.Conflicts, ImportConflict{
+					Type:    table.Name,
+					Message: fmt.Sprintf("the target tenant already has %s", table.Name),
+				})
+			}
+			continue
+		}
+
+		if err := s.checkUniqueConflicts(archive, entry, table, types, report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// uniqueConflictBatch is the number of archive rows looked up at once
+const uniqueConflictBatch = 500
+
+// checkUniqueConflicts reports the rows of the target tenant holding the
+// unique values of archive rows. A single text column is compared ignoring
+// case, as emails and names are.
+func (s *ImportService) checkUniqueConflicts(archive *archiveReader, entry domain.ExportManifestFile, table domain.TenantTable, types map[string]string, report *ImportReport) error {
+	columns := make([]string, len(table.Unique))
+	for i, column := range table.Unique {
+		if _, ok := types[column]; !ok {
+			return nil
+		}
+		columns[i] = quoteIdent(column)
+	}
+	ignoreCase := len(columns) == 1 && isTextType(types[table.Unique[0]])
+
+	var keys [][]interface{}
+	err := archive.eachRow(entry, func(row map[string]interface{}) error {
+		key := make([]interface{}, len(table.Unique))
+		for i, column := range table.Unique {
+			value := row[column]
+			if value == nil {
+				// NULLs never conflict
+				return nil
+			}
+			if text, ok := value.(string); ok && ignoreCase {
+				value = strings.ToLower(text)
+			}
+			key[i] = value
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(keys); start += uniqueConflictBatch {
+		batch := keys[start:min(start+uniqueConflictBatch, len(keys))]
+
+		query := s.db.Table(quoteIdent(table.Name)).
+			Select(strings.Join(columns, ", ")).
+			Where("tenant_id = ?", report.TenantID)
+		if ignoreCase {
+			values := make([]interface{}, len(batch))
+			for i, key := range batch {
+				values[i] = key[0]
+			}
+			query = query.Where(fmt.Sprintf("LOWER(%s) IN ?", columns[0]), values)
+		} else {
+			query = query.Where(fmt.Sprintf("(%s) IN ?", strings.Join(columns, ", ")), batch)
+		}
+
+		var existing []map[string]interface{}
+		if err := query.Find(&existing).Error; err != nil {
+			return err
+		}
+
+		for _, row := range existing {
+			values := make([]string, len(table.Unique))
+			for i, column := range table.Unique {
+				values[i] = fmt.Sprint(row[column])
+			}
+			report.Conflicts = append(report.Conflicts, ImportConflict{
+				Type:    table.Name,
+				Value:   strings.Join(values, ", "),
+				Message: fmt.Sprintf("%s with this %s already exists in the target tenant", table.Name, strings.Join(table.Unique, ", ")),
+			})
+		}
+	}
+
+	return nil
+}
+
+func (s *ImportService) insertTenant(tx *gorm.DB, source map[string]interface{}, opts ImportOptions, report *ImportReport) error {
+	types, err := tableColumnTypes(tx, archiveTenantTable)
+	if err != nil {
+		return err
+	}
+
+	record := make(map[string]interface{})
+	for column, value := range source {
+		if _, ok := types[column]; ok {
+			record[column] = importValue(types[column], value)
+		}
+	}
+
+	record["id"] = report.TenantID.String()
+	record["slug"] = report.TenantSlug
+	record["deleted_at"] = nil
+	// The archive may have been exported while the tenant waited for its
+	// purge; the imported tenant starts active
+	if _, ok := types["status"]; ok {
+		record["status"] = domain.TenantStatusActive
+	}
+	for _, column := range []string{"deletion_requested_at", "deletion_scheduled_at", "deletion_requested_by"} {
+		if _, ok := types[column]; ok {
+			record[column] = nil
+		}
+	}
+	// The Chatwoot account stays with the archived tenant; integrations are
+	// connected again after the import
+	for _, column := range []string{"chatwoot_account_id", "chatwoot_agent_id", "chatwoot_inbox_id"} {
+		if _, ok := record[column]; ok {
+			record[column] = nil
+		}
+	}
+	if opts.Name != "" {
+		record["name"] = opts.Name
+	}
+
+	if domainName, ok := record["domain"].(string); ok && domainName != "" {
+		if exists, err := s.tenantRepo.ExistsByDomain(domainName); err != nil {
+			return err
+		} else if exists {
+			record["domain"] = nil
+			report.Warnings = append(report.Warnings, fmt.Sprintf("domain %s is in use and was not imported", domainName))
+		}
+	}
+
+	// Plans are global; keep the plan only when it exists in this environment
+	if planID, ok := record["plan_id"].(string); ok {
+		id, err := uuid.Parse(planID)
+		if err == nil {
+			_, err = s.planRepo.FindByID(id)
+		}
+		if err != nil {
+			record["plan_id"] = nil
+			report.Warnings = append(report.Warnings, "tenant plan does not exist here; the default plan applies")
+		}
+	}
+
+	if err := tx.Table(archiveTenantTable).Create(record).Error; err != nil {
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	report.Tables = append(report.Tables, ImportTableReport{Table: archiveTenantTable, Rows: 1})
+	return nil
+}
+
+func (s *ImportService) importTable(tx *gorm.DB, archive *archiveReader, table domain.TenantTable, ids map[string]string, report *ImportReport) error {
+	entry, ok := archive.file(table.Name)
+	if !ok {
+		return nil
+	}
+
+	if table.ExportOnly {
+		report.Tables = append(report.Tables, ImportTableReport{Table: table.Name, Skipped: true, Reason: "export only"})
+		return nil
+	}
+
+	types, err := tableColumnTypes(tx, table.Name)
+	if err != nil {
+		return err
+	}
+	if len(types) == 0 {
+		report.Tables = append(report.Tables, ImportTableReport{Table: table.Name, Skipped: true, Reason: "table does not exist"})
+		return nil
+	}
+
+	var rows, droppedRefs int64
+	droppedColumns := make(map[string]bool)
+
+	err = archive.eachRow(entry, func(row map[string]interface{}) error {
+		record, dropped, refs := importRecord(table, types, ids, report.TenantID, row)
+		for _, column := range dropped {
+			droppedColumns[column] = true
+		}
+		droppedRefs += refs
+
+		if table.Name == "users" {
+			if _, ok := record["password_hash"]; !ok {
+				record["password_hash"] = unusablePasswordHash
+				report.UsersRequirePasswordReset++
+			}
+			// The tenant being merged into already has its owner
+			if !report.NewTenant && record["role"] == domain.RoleOwner {
+				record["role"] = domain.RoleAdmin
+			}
+		}
+
+		if err := tx.Table(quoteIdent(table.Name)).Create(record).Error; err != nil {
+			return fmt.Errorf("failed to import %s: %w", table.Name, err)
+		}
+		rows++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for column := range droppedColumns {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("column %s.%s does not exist and was not imported", table.Name, column))
+	}
+	if droppedRefs > 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%d reference(s) in %s point outside the archive and were cleared", droppedRefs, table.Name))
+	}
+
+	report.Tables = append(report.Tables, ImportTableReport{Table: table.Name, Rows: rows})
+	return nil
+}
+
+// importRecord builds the record inserted for an archive row: the row moves
+// to the target tenant, its references to rows of the archive are rewritten
+// and the Reset columns of the table are applied. It returns the columns
+// missing from the table and the number of references to rows outside the
+// archive, which are cleared.
+func importRecord(table domain.TenantTable, types map[string]string, ids map[string]string, tenantID uuid.UUID, row map[string]interface{}) (map[string]interface{}, []string, int64) {
+	record := make(map[string]interface{}, len(row))
+	var dropped []string
+	var droppedRefs int64
+
+	for column, value := range row {
+		dataType, ok := types[column]
+		if !ok {
+			dropped = append(dropped, column)
+			continue
+		}
+
+		if dataType == "uuid" {
+			if old, ok := value.(string); ok {
+				if mapped, ok := ids[old]; ok {
+					value = mapped
+				} else {
+					value = nil
+					droppedRefs++
+				}
+			}
+		}
+
+		record[column] = importValue(dataType, value)
+	}
+
+	if _, ok := types["tenant_id"]; ok {
+		record["tenant_id"] = tenantID.String()
+	}
+
+	for column, value := range table.Reset {
+		if _, ok := types[column]; ok {
+			record[column] = value
+		}
+	}
+
+	return record, dropped, droppedRefs
+}
+
+// importValue converts a decoded JSON value into a value for a column
+func importValue(dataType string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	switch dataType {
+	case "json", "jsonb":
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil
+		}
+		return string(encoded)
+	case "ARRAY":
+		if items, ok := value.([]interface{}); ok {
+			return postgresArray(items)
+		}
+	}
+
+	if number, ok := value.(json.Number); ok {
+		return number.String()
+	}
+
+	return value
+}
+
+// postgresArray formats a JSON array as a Postgres array literal
+func postgresArray(items []interface{}) string {
+	elements := make([]string, 0, len(items))
+	for _, item := range items {
+		if item == nil {
+			elements = append(elements, "NULL")
+			continue
+		}
+		text := fmt.Sprint(item)
+		text = strings.ReplaceAll(text, `\`, `\\`)
+		text = strings.ReplaceAll(text, `"`, `\"`)
+		elements = append(elements, `"`+text+`"`)
+	}
+	return "{" + strings.Join(elements, ",") + "}"
+}
+
+// isTextType reports whether a column holds text
+func isTextType(dataType string) bool {
+	switch dataType {
+	case "text", "character varying", "character":
+		return true
+	}
+	return false
+}
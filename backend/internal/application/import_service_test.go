@@ -0,0 +1,48 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+)
+
+func TestImportRecordClearsPlatformAccess(t *testing.T) {
+	users := domain.TenantDataTables[0]
+	if users.Name != "users" {
+		t.Fatalf("expected users first, got %s", users.Name)
+	}
+
+	sourceID, sourceTenantID := uuid.New().String(), uuid.New().String()
+	tenantID := uuid.New()
+	ids := map[string]string{sourceID: uuid.New().String(), sourceTenantID: tenantID.String()}
+	types := map[string]string{
+		"id":                "uuid",
+		"tenant_id":         "uuid",
+		"email":             "character varying",
+		"is_platform_admin": "boolean",
+		"chatwoot_agent_id": "integer",
+	}
+
+	record, dropped, refs := importRecord(users, types, ids, tenantID, map[string]interface{}{
+		"id":                sourceID,
+		"tenant_id":         sourceTenantID,
+		"email":             "ana@acme.test",
+		"is_platform_admin": true,
+		"chatwoot_agent_id": float64(12),
+		"unknown":           "value",
+	})
+
+	if record["is_platform_admin"] != false {
+		t.Errorf("expected is_platform_admin to be false, got %v", record["is_platform_admin"])
+	}
+	if record["chatwoot_agent_id"] != nil {
+		t.Errorf("expected chatwoot_agent_id to be cleared, got %v", record["chatwoot_agent_id"])
+	}
+	if record["id"] != ids[sourceID] || record["tenant_id"] != tenantID.String() {
+		t.Errorf("expected the row to be remapped, got %v", record)
+	}
+	if len(dropped) != 1 || dropped[0] != "unknown" || refs != 0 {
+		t.Errorf("unexpected dropped columns %v and references %d", dropped, refs)
+	}
+}
@@ -0,0 +1,437 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/secrets"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidMailMode      = errors.New("mode must be platform or smtp")
+	ErrInvalidSMTPSettings  = errors.New("SMTP relay requires a host, a port among 25, 465, 587 and 2525 and a TLS mode of none, starttls or tls")
+	ErrSMTPHostNotAllowed   = errors.New("SMTP relay host must resolve to public addresses")
+	ErrInvalidSender        = errors.New("sender must be a valid email address and a single line name")
+	ErrSenderRequired       = errors.New("a sender address is required to send through an SMTP relay")
+	ErrRelayNotConfigured   = errors.New("tenant does not send through an SMTP relay")
+	ErrSenderNotPending     = errors.New("no sender address is waiting for verification")
+	ErrInvalidSenderToken   = errors.New("invalid or expired sender verification token")
+	ErrInvalidTestRecipient = errors.New("invalid test email recipient")
+)
+
+// maxSenderNameLength bounds the display name of the sender
+const maxSenderNameLength = 100
+
+// smtpRelayPorts are the ports a tenant relay may use, so relays cannot be
+// used to probe other services
+var smtpRelayPorts = []int{25, 465, 587, 2525}
+
+// MailSettings is the view of the mail settings of a tenant
+type MailSettings struct {
+	*domain.TenantMailSettings
+	HasPassword bool `json:"has_password"`
+	// UsingRelay tells whether emails currently go through the tenant relay
+	UsingRelay bool `json:"using_relay"`
+}
+
+// MailSettingsUpdate replaces the mail settings of a tenant
+type MailSettingsUpdate struct {
+	Mode         string `json:"mode"`
+	SMTPHost     string `json:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port"`
+	SMTPTLSMode  string `json:"smtp_tls_mode"`
+	SMTPUsername string `json:"smtp_username"`
+	// SMTPPassword keeps the stored password when nil
+	SMTPPassword *string `json:"smtp_password"`
+	FromEmail    string  `json:"from_email"`
+	FromName     string  `json:"from_name"`
+}
+
+// MailTestResult reports the delivery of a test email
+type MailTestResult struct {
+	Transport string `json:"transport"`
+	Recipient string `json:"recipient"`
+	Delivered bool   `json:"delivered"`
+	Error     string `json:"error,omitempty"`
+}
+
+// MailSettingsService manages the SMTP relay and sender address tenants use
+// to send their emails from their own domain
+type MailSettingsService struct {
+	db           *gorm.DB
+	tenantRepo   domain.TenantRepository
+	userRepo     domain.UserRepository
+	repo         domain.TenantMailSettingsRepository
+	box          *secrets.Box
+	mailer       *TenantMailer
+	emailService *email.EmailService
+}
+
+func NewMailSettingsService(
+	db *gorm.DB,
+	tenantRepo domain.TenantRepository,
+	userRepo domain.UserRepository,
+	repo domain.TenantMailSettingsRepository,
+	platform email.Mailer,
+) *MailSettingsService {
+	box := secrets.NewBoxFromConfig()
+	return &MailSettingsService{
+		db:           db,
+		tenantRepo:   tenantRepo,
+		userRepo:     userRepo,
+		repo:         repo,
+		box:          box,
+		mailer:       newTenantMailer(repo, box, platform),
+		emailService: email.NewEmailService(),
+	}
+}
+
+// GetSettings returns the mail settings of a tenant, the platform defaults
+// when it did not configure them
+func (s *MailSettingsService) GetSettings(tenantID uuid.UUID) (*MailSettings, error) {
+	settings, err := s.findSettings(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return mailSettingsView(settings), nil
+}
+
+// UpdateSettings validates and stores the mail settings. A new sender address
+// is sent a verification email; a changed relay is checked right away.
+func (s *MailSettingsService) UpdateSettings(ctx context.Context, tenantID, actorID uuid.UUID, update MailSettingsUpdate) (*MailSettings, error) {
+	update.SMTPHost = strings.TrimSpace(update.SMTPHost)
+	update.FromEmail = strings.ToLower(strings.TrimSpace(update.FromEmail))
+	update.FromName = strings.TrimSpace(update.FromName)
+	if err := validateMailSettings(update); err != nil {
+		return nil, err
+	}
+	if update.Mode == domain.MailModeSMTP {
+		if err := email.CheckPublicHost(ctx, update.SMTPHost); err != nil {
+			log.Printf("Refused SMTP relay host %q of tenant %s: %v", update.SMTPHost, tenantID, err)
+			return nil, ErrSMTPHostNotAllowed
+		}
+	}
+
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.findSettings(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	relayChanged := settings.Mode != update.Mode ||
+		settings.SMTPHost != update.SMTPHost ||
+		settings.SMTPPort != update.SMTPPort ||
+		settings.SMTPTLSMode != update.SMTPTLSMode ||
+		settings.SMTPUsername != update.SMTPUsername ||
+		update.SMTPPassword != nil
+	senderChanged := settings.FromEmail != update.FromEmail
+
+	settings.Mode = update.Mode
+	settings.SMTPHost = update.SMTPHost
+	settings.SMTPPort = update.SMTPPort
+	settings.SMTPTLSMode = update.SMTPTLSMode
+	settings.SMTPUsername = update.SMTPUsername
+	settings.FromEmail = update.FromEmail
+	settings.FromName = update.FromName
+	if update.Mode == domain.MailModePlatform {
+		settings.SMTPHost, settings.SMTPPort, settings.SMTPTLSMode, settings.SMTPUsername = "", 0, "", ""
+		settings.SMTPPasswordEncrypted = ""
+	} else if update.SMTPPassword != nil {
+		settings.SMTPPasswordEncrypted = ""
+		if *update.SMTPPassword != "" {
+			sealed, err := s.box.Encrypt(*update.SMTPPassword, tenantID.String())
+			if err != nil {
+				return nil, err
+			}
+			settings.SMTPPasswordEncrypted = sealed
+		}
+	}
+	if relayChanged {
+		settings.HealthStatus = domain.MailHealthUnknown
+		settings.ConsecutiveFailures = 0
+		settings.LastError = ""
+		settings.LastCheckedAt = nil
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if senderChanged {
+			settings.SenderVerifiedAt = nil
+			settings.SenderVerificationTokenHash = ""
+			settings.SenderVerificationExpiresAt = nil
+			if settings.FromEmail != "" {
+				if err := s.requestVerification(tx, tenant, actorID, settings); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Save(settings).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if relayChanged && settings.Mode == domain.MailModeSMTP {
+		return s.CheckHealth(ctx, tenantID)
+	}
+	return mailSettingsView(settings), nil
+}
+
+// ResetSettings goes back to the platform transport and sender
+func (s *MailSettingsService) ResetSettings(tenantID uuid.UUID) error {
+	return s.repo.Delete(tenantID)
+}
+
+// ResendVerification sends a new verification email for the sender address
+func (s *MailSettingsService) ResendVerification(tenantID, actorID uuid.UUID) (*MailSettings, error) {
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.findSettings(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.FromEmail == "" || settings.SenderVerified() {
+		return nil, ErrSenderNotPending
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.requestVerification(tx, tenant, actorID, settings); err != nil {
+			return err
+		}
+		return tx.Save(settings).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mailSettingsView(settings), nil
+}
+
+// VerifySender confirms a sender address with the token of its verification email
+func (s *MailSettingsService) VerifySender(token string) (*MailSettings, error) {
+	settings, err := s.repo.FindByVerificationTokenHash(hashTransferToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidSenderToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if settings.SenderVerificationExpiresAt == nil || now.After(*settings.SenderVerificationExpiresAt) {
+		return nil, ErrInvalidSenderToken
+	}
+
+	settings.SenderVerifiedAt = &now
+	settings.SenderVerificationTokenHash = ""
+	settings.SenderVerificationExpiresAt = nil
+	if err := s.repo.Save(settings); err != nil {
+		return nil, err
+	}
+	return mailSettingsView(settings), nil
+}
+
+// CheckHealth connects to the tenant relay and records the outcome
+func (s *MailSettingsService) CheckHealth(ctx context.Context, tenantID uuid.UUID) (*MailSettings, error) {
+	settings, err := s.findSettings(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Mode != domain.MailModeSMTP {
+		return nil, ErrRelayNotConfigured
+	}
+
+	if err := s.checkRelay(ctx, settings); err != nil {
+		log.Printf("SMTP relay of tenant %s failed its health check: %v", tenantID, err)
+	}
+	return s.GetSettings(tenantID)
+}
+
+// RunHealthChecks checks the relays of all tenants, so failing relays recover
+// and healthy ones that broke stop being used
+func (s *MailSettingsService) RunHealthChecks(ctx context.Context) error {
+	relays, err := s.repo.ListRelays()
+	if err != nil {
+		return fmt.Errorf("failed to list SMTP relays: %w", err)
+	}
+
+	for _, settings := range relays {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := s.checkRelay(ctx, settings); err != nil {
+			log.Printf("SMTP relay of tenant %s failed its health check: %v", settings.TenantID, err)
+		}
+	}
+	return nil
+}
+
+// SendTest sends a test email right away, to the given address or to the
+// user. With a relay configured the email goes through it, even when it is
+// failing, so admins see the relay error.
+func (s *MailSettingsService) SendTest(ctx context.Context, tenantID, userID uuid.UUID, to string) (*MailTestResult, error) {
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	settings, err := s.findSettings(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	to = strings.ToLower(strings.TrimSpace(to))
+	if to == "" {
+		to = user.Email
+	}
+	if !isValidEmail(to) {
+		return nil, ErrInvalidTestRecipient
+	}
+
+	result := &MailTestResult{Transport: domain.MailModePlatform, Recipient: to}
+	var mailer email.Mailer = s.mailer
+	if settings.Mode == domain.MailModeSMTP {
+		result.Transport = domain.MailModeSMTP
+		mailer = relaySender{settings: settings, box: s.box}
+	}
+
+	brand := emailBranding(tenant).WithLocale(user.Locale)
+	sendErr := s.emailService.WithMailer(mailer).SendTestEmail(brand, to, user.Name, tenant.Name)
+	if settings.Mode == domain.MailModeSMTP {
+		if err := s.repo.RecordHealth(tenantID, sendErr, time.Now()); err != nil {
+			log.Printf("Failed to record SMTP relay health of tenant %s: %v", tenantID, err)
+		}
+	}
+
+	result.Delivered = sendErr == nil
+	if sendErr != nil {
+		result.Error = sendErr.Error()
+	}
+	return result, nil
+}
+
+func (s *MailSettingsService) checkRelay(ctx context.Context, settings *domain.TenantMailSettings) error {
+	relay, err := relayMailer(s.box, settings)
+	if err == nil {
+		err = relay.Verify(ctx)
+	}
+	if recordErr := s.repo.RecordHealth(settings.TenantID, err, time.Now()); recordErr != nil {
+		log.Printf("Failed to record SMTP relay health of tenant %s: %v", settings.TenantID, recordErr)
+	}
+	return err
+}
+
+// requestVerification issues a verification token for the sender address and
+// queues the verification email in the transaction
+func (s *MailSettingsService) requestVerification(tx *gorm.DB, tenant *domain.Tenant, actorID uuid.UUID, settings *domain.TenantMailSettings) error {
+	token, err := generateTransferToken()
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(domain.SenderVerificationExpiry)
+	settings.SenderVerificationTokenHash = hashTransferToken(token)
+	settings.SenderVerificationExpiresAt = &expiresAt
+
+	actorName := tenant.Name
+	if actor, err := s.userRepo.FindByID(actorID); err == nil {
+		actorName = actor.Name
+	}
+
+	// The mailbox owner may not be a user, so the email uses the tenant locale
+	return s.emailService.WithQueue(txOutbox{tx: tx}).SendSenderVerification(emailBranding(tenant), settings.FromEmail, actorName, tenant.Name, token, expiresAt)
+}
+
+func (s *MailSettingsService) findSettings(tenantID uuid.UUID) (*domain.TenantMailSettings, error) {
+	settings, err := s.repo.FindByTenant(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &domain.TenantMailSettings{
+				TenantID:     tenantID,
+				Mode:         domain.MailModePlatform,
+				HealthStatus: domain.MailHealthUnknown,
+			}, nil
+		}
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *MailSettingsService) findTenant(tenantID uuid.UUID) (*domain.Tenant, error) {
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+	return tenant, nil
+}
+
+func validateMailSettings(update MailSettingsUpdate) error {
+	switch update.Mode {
+	case domain.MailModePlatform:
+	case domain.MailModeSMTP:
+		if update.SMTPHost == "" || strings.ContainsAny(update.SMTPHost, " \t\r\n/:") ||
+			!slices.Contains(smtpRelayPorts, update.SMTPPort) || !email.IsTLSMode(update.SMTPTLSMode) {
+			return ErrInvalidSMTPSettings
+		}
+		if update.FromEmail == "" {
+			return ErrSenderRequired
+		}
+	default:
+		return ErrInvalidMailMode
+	}
+
+	if update.FromEmail != "" && !isValidEmail(update.FromEmail) {
+		return ErrInvalidSender
+	}
+	// Both end up in the From header
+	if len(update.FromName) > maxSenderNameLength || strings.ContainsAny(update.FromName+update.SMTPUsername, "\r\n") {
+		return ErrInvalidSender
+	}
+	return nil
+}
+
+func mailSettingsView(settings *domain.TenantMailSettings) *MailSettings {
+	return &MailSettings{
+		TenantMailSettings: settings,
+		HasPassword:        settings.SMTPPasswordEncrypted != "",
+		UsingRelay:         settings.UsesRelay(),
+	}
+}
+
+// relayMailer returns the SMTP transport of a tenant relay
+func relayMailer(box *secrets.Box, settings *domain.TenantMailSettings) (*email.SMTPMailer, error) {
+	password := ""
+	if settings.SMTPPasswordEncrypted != "" {
+		var err error
+		password, err = box.Decrypt(settings.SMTPPasswordEncrypted, settings.TenantID.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt SMTP password: %w", err)
+		}
+	}
+
+	relay := email.NewSMTPMailer(settings.SMTPHost, strconv.Itoa(settings.SMTPPort), settings.SMTPUsername, password)
+	relay.TLSMode = settings.SMTPTLSMode
+	relay.PublicOnly = true
+	return relay, nil
+}
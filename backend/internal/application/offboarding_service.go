@@ -0,0 +1,492 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/billing"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/metering"
+	"github.com/widia/widia-connect/internal/infrastructure/storage"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTenantPendingDeletion    = errors.New("tenant is scheduled for deletion")
+	ErrTenantNotPendingDeletion = errors.New("tenant is not scheduled for deletion")
+	ErrRestoreWindowClosed      = errors.New("restore window has closed")
+	ErrRestoreNotAllowed        = errors.New("only the tenant owner can restore the tenant")
+)
+
+// purgeExtraTables are tenant scoped tables that are not part of the export
+// registry but must be removed on purge
+var purgeExtraTables = []string{
+	"tenant_exports",
+	"billing_accounts",
+	"tenant_slug_aliases",
+	"tenant_onboarding_steps",
+	"onboarding_nudges",
+	"ownership_transfers",
+	"email_outbox",
+	"tenant_mail_settings",
+	"email_delivery_events",
+	"email_suppressions",
+	"tenant_integrations",
+	"chatwoot_provisionings",
+	"chatwoot_webhook_deliveries",
+}
+
+// DeletionCertificate is written to the audit log when a tenant is purged
+type DeletionCertificate struct {
+	CertificateID       uuid.UUID        `json:"certificate_id"`
+	TenantID            uuid.UUID        `json:"tenant_id"`
+	TenantSlug          string           `json:"tenant_slug"`
+	TenantName          string           `json:"tenant_name"`
+	DeletionRequestedAt *time.Time       `json:"deletion_requested_at"`
+	DeletionRequestedBy *uuid.UUID       `json:"deletion_requested_by"`
+	PurgedAt            time.Time        `json:"purged_at"`
+	RowsDeleted         map[string]int64 `json:"rows_deleted"`
+	FilesDeleted        int              `json:"files_deleted"`
+	Digest              string           `json:"digest"`
+}
+
+type OffboardingService struct {
+	db           *gorm.DB
+	tenantRepo   domain.TenantRepository
+	userRepo     domain.UserRepository
+	auditRepo    domain.AuditLogRepository
+	storage      storage.Storage
+	provider     billing.Provider
+	emailService *email.EmailService
+	gracePeriod  time.Duration
+}
+
+func NewOffboardingService(
+	db *gorm.DB,
+	tenantRepo domain.TenantRepository,
+	userRepo domain.UserRepository,
+	auditRepo domain.AuditLogRepository,
+	store storage.Storage,
+	provider billing.Provider,
+) *OffboardingService {
+	graceDays := viper.GetInt("TENANT_DELETION_GRACE_DAYS")
+	if graceDays <= 0 {
+		graceDays = 30
+	}
+
+	return &OffboardingService{
+		db:           db,
+		tenantRepo:   tenantRepo,
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		storage:      store,
+		provider:     provider,
+		emailService: email.NewEmailService(),
+		gracePeriod:  time.Duration(graceDays) * 24 * time.Hour,
+	}
+}
+
+// RequestDeletion schedules the tenant for deletion after the grace period.
+// The requester confirms with their password; every user is deactivated and
+// all refresh tokens are revoked.
+func (s *OffboardingService) RequestDeletion(tenantID, userID uuid.UUID, password, reason string, meta AuditContext) (*domain.Tenant, error) {
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tenant.IsPendingDeletion() {
+		return nil, ErrTenantPendingDeletion
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, ErrUserNotFound
+	}
+
+	// The role of the token may predate an ownership transfer
+	if user.Role != domain.RoleOwner {
+		return nil, ErrOwnerRequired
+	}
+
+	if !user.CheckPassword(password) {
+		return nil, ErrWrongPassword
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(s.gracePeriod)
+
+	var deactivated []uuid.UUID
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.User{}).
+			Where("tenant_id = ? AND is_active = ?", tenantID, true).
+			Pluck("id", &deactivated).Error; err != nil {
+			return err
+		}
+
+		if len(deactivated) > 0 {
+			if err := tx.Model(&domain.User{}).Where("id IN ?", deactivated).Update("is_active", false).Error; err != nil {
+				return fmt.Errorf("failed to deactivate users: %w", err)
+			}
+		}
+
+		if err := tx.Model(&domain.RefreshToken{}).
+			Where("revoked = false AND user_id IN (?)", tx.Model(&domain.User{}).Select("id").Where("tenant_id = ?", tenantID)).
+			Updates(map[string]interface{}{
+				"revoked":    true,
+				"revoked_at": now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+
+		if err := tx.Model(&domain.Tenant{}).Where("id = ?", tenantID).Updates(map[string]interface{}{
+			"status":                domain.TenantStatusPendingDeletion,
+			"deletion_requested_at": now,
+			"deletion_scheduled_at": scheduledAt,
+			"deletion_requested_by": userID,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to schedule tenant deletion: %w", err)
+		}
+
+		// The deactivated users are recorded so a restore only reactivates them
+		userIDs := make([]string, len(deactivated))
+		for i, id := range deactivated {
+			userIDs[i] = id.String()
+		}
+
+		return tx.Create(newAuditLog(tenantID, &userID, domain.AuditTenantDeletionRequested, meta, domain.JSON{
+			"reason":               reason,
+			"scheduled_for":        scheduledAt.UTC().Format(time.RFC3339),
+			"deactivated_user_ids": userIDs,
+		})).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tenant.Status = domain.TenantStatusPendingDeletion
+	tenant.DeletionRequestedAt = &now
+	tenant.DeletionScheduledAt = &scheduledAt
+	tenant.DeletionRequestedBy = &userID
+
+	s.notifyAdmins(tenant, deactivated)
+
+	return tenant, nil
+}
+
+// Restore cancels a scheduled deletion while the grace period is still open
+// and reactivates the users deactivated by the request
+func (s *OffboardingService) Restore(tenantID uuid.UUID, actorID *uuid.UUID, meta AuditContext) (*domain.Tenant, error) {
+	tenant, err := s.findTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tenant.IsPendingDeletion() {
+		return nil, ErrTenantNotPendingDeletion
+	}
+
+	if tenant.DeletionScheduledAt != nil && !time.Now().Before(*tenant.DeletionScheduledAt) {
+		return nil, ErrRestoreWindowClosed
+	}
+
+	var userIDs []uuid.UUID
+	request, err := s.auditRepo.FindLatestByAction(tenantID, domain.AuditTenantDeletionRequested)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if request != nil {
+		userIDs = auditUserIDs(request.Changes["deactivated_user_ids"])
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if len(userIDs) > 0 {
+			if err := tx.Model(&domain.User{}).
+				Where("tenant_id = ? AND id IN ?", tenantID, userIDs).
+				Update("is_active", true).Error; err != nil {
+				return fmt.Errorf("failed to reactivate users: %w", err)
+			}
+		}
+
+		if err := tx.Model(&domain.Tenant{}).Where("id = ?", tenantID).Updates(map[string]interface{}{
+			"status":                domain.TenantStatusActive,
+			"deletion_requested_at": nil,
+			"deletion_scheduled_at": nil,
+			"deletion_requested_by": nil,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to restore tenant: %w", err)
+		}
+
+		return tx.Create(newAuditLog(tenantID, actorID, domain.AuditTenantRestored, meta, domain.JSON{
+			"reactivated_users": len(userIDs),
+		})).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tenant.Status = domain.TenantStatusActive
+	tenant.DeletionRequestedAt = nil
+	tenant.DeletionScheduledAt = nil
+	tenant.DeletionRequestedBy = nil
+	return tenant, nil
+}
+
+// RestoreWithCredentials lets the owner, or the user who requested the
+// deletion, restore a tenant without a session since their account is inactive
+func (s *OffboardingService) RestoreWithCredentials(tenantSlug, email, password string, meta AuditContext) (*domain.Tenant, error) {
+	tenant, err := s.tenantRepo.FindBySlug(tenantSlug)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	user, err := s.userRepo.FindByEmailAndTenant(email, tenant.ID)
+	if err != nil || !user.CheckPassword(password) {
+		return nil, ErrInvalidCredentials
+	}
+
+	if !tenant.IsPendingDeletion() {
+		return nil, ErrTenantNotPendingDeletion
+	}
+
+	requestedBy := tenant.DeletionRequestedBy != nil && *tenant.DeletionRequestedBy == user.ID
+	if user.Role != "owner" && !requestedBy {
+		return nil, ErrRestoreNotAllowed
+	}
+
+	return s.Restore(tenant.ID, &user.ID, meta)
+}
+
+// Purge permanently deletes all rows owned by the tenant and its stored
+// files. The tenant row itself is anonymized and soft deleted so that the
+// deletion certificate written to the audit log keeps a valid reference.
+func (s *OffboardingService) Purge(ctx context.Context, tenant *domain.Tenant) (*DeletionCertificate, error) {
+	if !tenant.IsPendingDeletion() {
+		return nil, ErrTenantNotPendingDeletion
+	}
+
+	if err := s.cancelBilling(ctx, tenant.ID); err != nil {
+		return nil, fmt.Errorf("failed to cancel subscription: %w", err)
+	}
+
+	files, err := s.deleteFiles(ctx, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete stored files: %w", err)
+	}
+
+	certificate := &DeletionCertificate{
+		CertificateID:       uuid.New(),
+		TenantID:            tenant.ID,
+		TenantSlug:          tenant.Slug,
+		TenantName:          tenant.Name,
+		DeletionRequestedAt: tenant.DeletionRequestedAt,
+		DeletionRequestedBy: tenant.DeletionRequestedBy,
+		RowsDeleted:         make(map[string]int64),
+		FilesDeleted:        files,
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Delete in reverse dependency order so no row outlives what it references
+		tables := make([]string, 0, len(domain.TenantDataTables)+len(purgeExtraTables))
+		tables = append(tables, purgeExtraTables...)
+		for i := len(domain.TenantDataTables) - 1; i >= 0; i-- {
+			tables = append(tables, domain.TenantDataTables[i].Name)
+		}
+
+		for _, table := range tables {
+			columns, err := tableColumns(tx, table)
+			if err != nil {
+				return err
+			}
+			if len(columns) == 0 || !containsString(columns, "tenant_id") {
+				continue
+			}
+
+			result := tx.Exec("DELETE FROM "+quoteIdent(table)+" WHERE tenant_id = ?", tenant.ID)
+			if result.Error != nil {
+				return fmt.Errorf("failed to purge %s: %w", table, result.Error)
+			}
+			certificate.RowsDeleted[table] = result.RowsAffected
+		}
+
+		if err := tx.Model(&domain.Tenant{}).Where("id = ?", tenant.ID).Updates(map[string]interface{}{
+			"slug":     "purged-" + hex.EncodeToString(tenant.ID[:]),
+			"name":     "Purged tenant",
+			"domain":   nil,
+			"settings": domain.JSON{},
+			"status":   domain.TenantStatusPurged,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to anonymize tenant: %w", err)
+		}
+
+		if err := tx.Delete(&domain.Tenant{}, "id = ?", tenant.ID).Error; err != nil {
+			return err
+		}
+
+		certificate.PurgedAt = time.Now().UTC()
+		changes, err := certificate.seal()
+		if err != nil {
+			return err
+		}
+
+		entry := newAuditLog(tenant.ID, nil, domain.AuditTenantPurged, AuditContext{}, changes)
+		entry.EntityType = "tenant"
+		entry.EntityID = &tenant.ID
+		return tx.Create(entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return certificate, nil
+}
+
+// RunPurge is the scheduler entry point: it purges tenants whose grace period ended
+func (s *OffboardingService) RunPurge(ctx context.Context) error {
+	tenants, err := s.tenantRepo.FindDueForPurge(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, tenant := range tenants {
+		certificate, err := s.Purge(ctx, tenant)
+		if err != nil {
+			log.Printf("Failed to purge tenant %s: %v", tenant.ID, err)
+			continue
+		}
+		log.Printf("Purged tenant %s (certificate %s)", tenant.ID, certificate.CertificateID)
+	}
+
+	return nil
+}
+
+func (s *OffboardingService) findTenant(tenantID uuid.UUID) (*domain.Tenant, error) {
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+	return tenant, nil
+}
+
+// cancelBilling ends the provider subscription immediately so a purged tenant is never charged
+func (s *OffboardingService) cancelBilling(ctx context.Context, tenantID uuid.UUID) error {
+	if s.provider == nil {
+		return nil
+	}
+
+	var account domain.BillingAccount
+	if err := s.db.Where("tenant_id = ?", tenantID).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if account.SubscriptionID == nil || account.Status == "canceled" {
+		return nil
+	}
+
+	_, err := s.provider.CancelSubscription(ctx, *account.SubscriptionID, false)
+	return err
+}
+
+// deleteFiles removes the export archives and branding assets of the tenant
+func (s *OffboardingService) deleteFiles(ctx context.Context, tenant *domain.Tenant) (int, error) {
+	var keys []string
+	if err := s.db.Model(&domain.TenantExport{}).
+		Where("tenant_id = ? AND storage_key <> ''", tenant.ID).
+		Pluck("storage_key", &keys).Error; err != nil {
+		return 0, err
+	}
+
+	if logo := tenant.Branding().LogoKey; logo != "" {
+		keys = append(keys, logo)
+	}
+
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+func (s *OffboardingService) notifyAdmins(tenant *domain.Tenant, userIDs []uuid.UUID) {
+	users, err := s.userRepo.FindByTenant(tenant.ID)
+	if err != nil {
+		log.Printf("Failed to load users of tenant %s: %v", tenant.ID, err)
+		return
+	}
+
+	for _, user := range users {
+		if user.Role != "owner" && user.Role != "admin" {
+			continue
+		}
+		if !containsUUID(userIDs, user.ID) {
+			continue
+		}
+
+		if err := s.emailService.SendTenantDeletionScheduled(emailBranding(tenant).WithLocale(user.Locale), user.Email, user.Name, tenant.Name, *tenant.DeletionScheduledAt); err != nil {
+			log.Printf("Failed to send deletion notice to %s: %v", user.Email, err)
+			continue
+		}
+		metering.Record(tenant.ID, domain.MetricEmailsSent, 1)
+	}
+}
+
+// seal computes the certificate digest over its content and returns it as audit changes
+func (c *DeletionCertificate) seal() (domain.JSON, error) {
+	c.Digest = ""
+	content, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+	c.Digest = hex.EncodeToString(sum[:])
+
+	content, err = json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes domain.JSON
+	if err := json.Unmarshal(content, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// auditUserIDs reads a list of user ids stored in audit changes
+func auditUserIDs(value interface{}) []uuid.UUID {
+	items, _ := value.([]interface{})
+
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		s, _ := item.(string)
+		if id, err := uuid.Parse(s); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func containsUUID(values []uuid.UUID, value uuid.UUID) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
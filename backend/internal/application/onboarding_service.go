@@ -0,0 +1,367 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/events"
+	"github.com/widia/widia-connect/internal/infrastructure/metering"
+	"gorm.io/gorm"
+)
+
+// NudgeDays are the days after sign up when trial tenants with an incomplete
+// onboarding are reminded of the pending steps
+var NudgeDays = []int{2, 5, 10}
+
+// OnboardingStepStatus is a checklist step with its completion state
+type OnboardingStepStatus struct {
+	Key         string     `json:"key"`
+	Title       string     `json:"title"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// OnboardingProgress is the onboarding checklist of a tenant
+type OnboardingProgress struct {
+	Steps          []OnboardingStepStatus `json:"steps"`
+	CompletedCount int                    `json:"completed_count"`
+	TotalCount     int                    `json:"total_count"`
+	Completed      bool                   `json:"completed"`
+	// Chatwoot is the provisioning of the Chatwoot account of the tenant,
+	// absent when the tenant was not provisioned automatically
+	Chatwoot *domain.ChatwootProvisioning `json:"chatwoot,omitempty"`
+}
+
+type OnboardingService struct {
+	db               *gorm.DB
+	tenantRepo       domain.TenantRepository
+	userRepo         domain.UserRepository
+	onboardingRepo   domain.OnboardingRepository
+	provisioningRepo domain.ChatwootProvisioningRepository
+	chatwoot         ChatwootClients
+	emailService     *email.EmailService
+}
+
+func NewOnboardingService(
+	db *gorm.DB,
+	tenantRepo domain.TenantRepository,
+	userRepo domain.UserRepository,
+	onboardingRepo domain.OnboardingRepository,
+	provisioningRepo domain.ChatwootProvisioningRepository,
+	chatwootClients ChatwootClients,
+) *OnboardingService {
+	return &OnboardingService{
+		db:               db,
+		tenantRepo:       tenantRepo,
+		userRepo:         userRepo,
+		onboardingRepo:   onboardingRepo,
+		provisioningRepo: provisioningRepo,
+		chatwoot:         chatwootClients,
+		emailService:     email.NewEmailService(),
+	}
+}
+
+// Subscribe completes the checklist steps when their events are published
+func (s *OnboardingService) Subscribe(bus *events.Bus) {
+	for _, definition := range domain.OnboardingSteps {
+		step := definition.Key
+		bus.Subscribe(definition.Event, func(ctx context.Context, event events.Event) error {
+			return s.CompleteStep(event.TenantID, step)
+		})
+	}
+
+	// The bot runs in Chatwoot as an agent bot: a reply of the bot shows its
+	// flow is configured
+	bus.Subscribe(domain.EventChatwootMessageCreated, func(ctx context.Context, event events.Event) error {
+		webhook := ChatwootEvent(event)
+		if webhook == nil || webhook.Message == nil || !webhook.Message.SentByBot() {
+			return nil
+		}
+		bus.Publish(ctx, events.Event{
+			Name:     domain.EventBotFlowConfigured,
+			TenantID: event.TenantID,
+			Payload:  map[string]interface{}{"provider": domain.IntegrationChatwoot},
+		})
+		return nil
+	})
+}
+
+// CompleteStep marks a step as done. Once every step is done the tenant
+// settings are flagged with onboarding_completed.
+func (s *OnboardingService) CompleteStep(tenantID uuid.UUID, step string) error {
+	created, err := s.onboardingRepo.CompleteStep(tenantID, step, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to complete onboarding step %s: %w", step, err)
+	}
+	if !created {
+		return nil
+	}
+
+	steps, err := s.onboardingRepo.FindSteps(tenantID)
+	if err != nil {
+		return err
+	}
+	if len(completedSteps(steps)) < len(domain.OnboardingSteps) {
+		return nil
+	}
+
+	return s.db.Exec(`UPDATE tenants SET settings = jsonb_set(COALESCE(settings, '{}'::jsonb), ?, 'true'::jsonb) WHERE id = ?`,
+		fmt.Sprintf("{%s}", domain.OnboardingCompletedSettingsKey), tenantID).Error
+}
+
+// GetProgress returns the checklist of a tenant as recorded. It does not
+// write: the steps missed by the event handlers are caught up by the nudge job.
+func (s *OnboardingService) GetProgress(tenantID uuid.UUID) (*OnboardingProgress, error) {
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+
+	steps, err := s.onboardingRepo.FindSteps(tenant.ID)
+	if err != nil {
+		return nil, err
+	}
+	progress := buildProgress(steps)
+
+	provisioning, err := s.provisioningRepo.FindByTenant(tenantID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	progress.Chatwoot = provisioning
+	return progress, nil
+}
+
+// reconcile completes the steps whose state already exists but whose event
+// was never seen, such as tenants created before the checklist
+func (s *OnboardingService) reconcile(tenant *domain.Tenant) error {
+	if completed, ok := tenant.Settings[domain.OnboardingCompletedSettingsKey].(bool); ok && completed {
+		return nil
+	}
+
+	steps, err := s.onboardingRepo.FindSteps(tenant.ID)
+	if err != nil {
+		return err
+	}
+	done := completedSteps(steps)
+
+	if !done[domain.OnboardingInviteTeammate] {
+		count, err := s.userRepo.CountByTenant(tenant.ID)
+		if err != nil {
+			return err
+		}
+		if count > 1 {
+			if err := s.CompleteStep(tenant.ID, domain.OnboardingInviteTeammate); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !done[domain.OnboardingSetBusinessHours] {
+		if _, ok := tenant.Settings[domain.BusinessHoursSettingsKey]; ok {
+			if err := s.CompleteStep(tenant.ID, domain.OnboardingSetBusinessHours); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !done[domain.OnboardingConnectChannel] {
+		var connected int64
+		if err := s.db.Model(&domain.TenantIntegration{}).
+			Where("tenant_id = ? AND status = ?", tenant.ID, domain.IntegrationConnected).
+			Count(&connected).Error; err != nil {
+			return err
+		}
+		if connected > 0 || tenant.ChatwootInboxID != nil {
+			if err := s.CompleteStep(tenant.ID, domain.OnboardingConnectChannel); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !done[domain.OnboardingConfigureBotFlow] && s.hasAgentBot(tenant.ID) {
+		if err := s.CompleteStep(tenant.ID, domain.OnboardingConfigureBotFlow); err != nil {
+			return err
+		}
+	}
+
+	if !done[domain.OnboardingVerifyDomain] {
+		if verification := tenant.DomainVerification(); verification != nil && verification.VerifiedAt != nil {
+			if err := s.CompleteStep(tenant.ID, domain.OnboardingVerifyDomain); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasAgentBot reports whether the Chatwoot account of the tenant has an agent
+// bot. Chatwoot being unreachable leaves the step to be checked again later.
+func (s *OnboardingService) hasAgentBot(tenantID uuid.UUID) bool {
+	client := chatwootFor(s.chatwoot, tenantID)
+	if client == nil {
+		return false
+	}
+	bots, err := client.ListAgentBots()
+	if err != nil {
+		log.Printf("Failed to list the Chatwoot agent bots of tenant %s: %v", tenantID, err)
+		return false
+	}
+	return len(bots) > 0
+}
+
+// SendNudges emails the admins of trial tenants that have not finished the
+// checklist. Only the latest due nudge is sent, so a job that missed a day
+// does not send several emails at once.
+func (s *OnboardingService) SendNudges(now time.Time) (int, error) {
+	var tenants []*domain.Tenant
+	err := s.db.Where("subscription_status = ? AND status = ? AND created_at > ?",
+		domain.SubscriptionTrial, domain.TenantStatusActive, now.Add(-domain.TrialPeriod)).
+		Find(&tenants).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, tenant := range tenants {
+		day := nudgeDay(int(now.Sub(tenant.CreatedAt).Hours() / 24))
+		if day == 0 {
+			continue
+		}
+
+		latest, err := s.onboardingRepo.LatestNudgeDay(tenant.ID)
+		if err != nil {
+			return sent, err
+		}
+		if latest >= day {
+			continue
+		}
+
+		// Steps done before the checklist existed or whose event was lost
+		// must not be nudged; Chatwoot is only asked for tenants due a nudge
+		if err := s.reconcile(tenant); err != nil {
+			return sent, err
+		}
+		progress, err := s.GetProgress(tenant.ID)
+		if err != nil {
+			return sent, err
+		}
+		if progress.Completed {
+			continue
+		}
+
+		if err := s.notifyAdmins(tenant, progress); err != nil {
+			log.Printf("Failed to send onboarding nudge to tenant %s: %v", tenant.ID, err)
+			continue
+		}
+
+		if err := s.onboardingRepo.CreateNudge(&domain.OnboardingNudge{
+			TenantID: tenant.ID,
+			Day:      day,
+			SentAt:   now,
+		}); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// RunNudges is the scheduler entry point for the onboarding nudge job
+func (s *OnboardingService) RunNudges(ctx context.Context) error {
+	sent, err := s.SendNudges(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to send onboarding nudges: %w", err)
+	}
+
+	log.Printf("Onboarding: %d nudge(s) sent", sent)
+	return nil
+}
+
+func (s *OnboardingService) notifyAdmins(tenant *domain.Tenant, progress *OnboardingProgress) error {
+	var pending []string
+	for _, step := range progress.Steps {
+		if !step.Completed {
+			pending = append(pending, step.Title)
+		}
+	}
+
+	users, err := s.userRepo.FindByTenant(tenant.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if !user.IsActive || !domain.IsAdminRole(user.Role) {
+			continue
+		}
+		if err := s.emailService.SendOnboardingNudge(emailBranding(tenant).WithLocale(user.Locale), user.Email, user.Name, tenant.Name,
+			pending, progress.CompletedCount, progress.TotalCount); err != nil {
+			return err
+		}
+		metering.Record(tenant.ID, domain.MetricEmailsSent, 1)
+	}
+
+	return nil
+}
+
+func buildProgress(steps []*domain.OnboardingStep) *OnboardingProgress {
+	completedAt := make(map[string]time.Time, len(steps))
+	for _, step := range steps {
+		completedAt[step.Step] = step.CompletedAt
+	}
+
+	progress := &OnboardingProgress{
+		Steps:      make([]OnboardingStepStatus, 0, len(domain.OnboardingSteps)),
+		TotalCount: len(domain.OnboardingSteps),
+	}
+	for _, definition := range domain.OnboardingSteps {
+		status := OnboardingStepStatus{Key: definition.Key, Title: definition.Title}
+		if at, ok := completedAt[definition.Key]; ok {
+			status.Completed = true
+			status.CompletedAt = &at
+			progress.CompletedCount++
+		}
+		progress.Steps = append(progress.Steps, status)
+	}
+	progress.Completed = progress.CompletedCount == progress.TotalCount
+
+	return progress
+}
+
+// completedSteps returns the set of known steps that were completed
+func completedSteps(steps []*domain.OnboardingStep) map[string]bool {
+	known := make(map[string]bool, len(domain.OnboardingSteps))
+	for _, definition := range domain.OnboardingSteps {
+		known[definition.Key] = true
+	}
+
+	done := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		if known[step.Step] {
+			done[step.Step] = true
+		}
+	}
+	return done
+}
+
+// nudgeDay returns the latest nudge day reached after daysSinceSignup, or 0
+func nudgeDay(daysSinceSignup int) int {
+	day := 0
+	for _, d := range NudgeDays {
+		if daysSinceSignup >= d {
+			day = d
+		}
+	}
+	return day
+}
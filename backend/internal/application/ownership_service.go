@@ -0,0 +1,262 @@
+package application
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/metering"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOwnerRequired          = errors.New("only the tenant owner can do this")
+	ErrInvalidTransferTarget  = errors.New("ownership can only be transferred to another active user of the tenant")
+	ErrTransferNotFound       = errors.New("ownership transfer not found or expired")
+	ErrTransferTargetMismatch = errors.New("ownership transfer is addressed to another user")
+	ErrTransferOwnerChanged   = errors.New("the tenant owner changed since the transfer was requested")
+)
+
+type OwnershipService struct {
+	db           *gorm.DB
+	tenantRepo   domain.TenantRepository
+	userRepo     domain.UserRepository
+	transferRepo domain.OwnershipTransferRepository
+	emailService *email.EmailService
+}
+
+func NewOwnershipService(db *gorm.DB, tenantRepo domain.TenantRepository, userRepo domain.UserRepository, transferRepo domain.OwnershipTransferRepository) *OwnershipService {
+	return &OwnershipService{
+		db:           db,
+		tenantRepo:   tenantRepo,
+		userRepo:     userRepo,
+		transferRepo: transferRepo,
+		emailService: email.NewEmailService(),
+	}
+}
+
+// GetPendingTransfer returns the open ownership transfer of a tenant
+func (s *OwnershipService) GetPendingTransfer(tenantID uuid.UUID) (*domain.OwnershipTransfer, error) {
+	transfer, err := s.transferRepo.FindPending(tenantID, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// InitiateTransfer starts handing the ownership to another user of the
+// tenant. The owner confirms with their password and the target receives a
+// confirmation link by email; a previous pending transfer is cancelled.
+func (s *OwnershipService) InitiateTransfer(tenantID, ownerID, targetID uuid.UUID, password string, meta AuditContext) (*domain.OwnershipTransfer, error) {
+	// The role in the session may be stale, so the owner is checked against the database
+	owner, err := s.findOwner(tenantID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if !owner.CheckPassword(password) {
+		return nil, ErrWrongPassword
+	}
+
+	if targetID == ownerID {
+		return nil, ErrInvalidTransferTarget
+	}
+	target, err := s.userRepo.FindByID(targetID)
+	if err != nil || target.TenantID != tenantID || !target.IsActive {
+		return nil, ErrInvalidTransferTarget
+	}
+
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		return nil, ErrTenantNotFound
+	}
+
+	token, err := generateTransferToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	transfer := &domain.OwnershipTransfer{
+		TenantID:   tenantID,
+		FromUserID: owner.ID,
+		ToUserID:   target.ID,
+		TokenHash:  hashTransferToken(token),
+		Status:     domain.OwnershipTransferPending,
+		ExpiresAt:  now.Add(domain.OwnershipTransferExpiry),
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.OwnershipTransfer{}).
+			Where("tenant_id = ? AND status = ?", tenantID, domain.OwnershipTransferPending).
+			Updates(map[string]interface{}{
+				"status":       domain.OwnershipTransferCancelled,
+				"cancelled_at": now,
+				"updated_at":   now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to cancel previous transfers: %w", err)
+		}
+		if err := tx.Create(transfer).Error; err != nil {
+			return fmt.Errorf("failed to create ownership transfer: %w", err)
+		}
+		if err := tx.Create(newAuditLog(tenantID, &owner.ID, domain.AuditOwnershipTransferStarted, meta, domain.JSON{
+			"transfer_id": transfer.ID.String(),
+			"to_user_id":  target.ID.String(),
+		})).Error; err != nil {
+			return err
+		}
+
+		// The transfer cannot be accepted without its email, so both are stored together
+		return s.emailService.WithQueue(txOutbox{tx: tx}).SendOwnershipTransferRequest(emailBranding(tenant).WithLocale(target.Locale), target.Email, target.Name, tenant.Name, owner.Name, token, transfer.ExpiresAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	metering.Record(tenant.ID, domain.MetricEmailsSent, 1)
+
+	return transfer, nil
+}
+
+// CancelTransfer cancels the pending ownership transfer of a tenant
+func (s *OwnershipService) CancelTransfer(tenantID, ownerID uuid.UUID) error {
+	if _, err := s.findOwner(tenantID, ownerID); err != nil {
+		return err
+	}
+	if _, err := s.GetPendingTransfer(tenantID); err != nil {
+		return err
+	}
+	return s.transferRepo.CancelPending(tenantID, time.Now())
+}
+
+// AcceptTransfer completes a transfer with the emailed token. Only the target
+// can accept it; the roles are swapped in one transaction, the former owner
+// becoming an admin, and the sessions of the former owner are revoked.
+func (s *OwnershipService) AcceptTransfer(tenantID, userID uuid.UUID, token string, meta AuditContext) (*domain.OwnershipTransfer, error) {
+	transfer, err := s.transferRepo.FindByTokenHash(hashTransferToken(token))
+	if err != nil || transfer.TenantID != tenantID || !transfer.IsOpen(time.Now()) {
+		return nil, ErrTransferNotFound
+	}
+	if transfer.ToUserID != userID {
+		return nil, ErrTransferTargetMismatch
+	}
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Demote first so the tenant never has two owners
+		result := tx.Model(&domain.User{}).
+			Where("id = ? AND tenant_id = ? AND role = ?", transfer.FromUserID, tenantID, domain.RoleOwner).
+			Update("role", domain.RoleAdmin)
+		if result.Error != nil {
+			return fmt.Errorf("failed to demote owner: %w", result.Error)
+		}
+		if result.RowsAffected != 1 {
+			return ErrTransferOwnerChanged
+		}
+
+		result = tx.Model(&domain.User{}).
+			Where("id = ? AND tenant_id = ? AND is_active = ?", transfer.ToUserID, tenantID, true).
+			Update("role", domain.RoleOwner)
+		if result.Error != nil {
+			return fmt.Errorf("failed to promote new owner: %w", result.Error)
+		}
+		if result.RowsAffected != 1 {
+			return ErrInvalidTransferTarget
+		}
+
+		// The status condition makes a concurrent accept of the same transfer fail
+		result = tx.Model(&domain.OwnershipTransfer{}).
+			Where("id = ? AND status = ?", transfer.ID, domain.OwnershipTransferPending).
+			Updates(map[string]interface{}{
+				"status":      domain.OwnershipTransferAccepted,
+				"accepted_at": now,
+				"updated_at":  now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrTransferNotFound
+		}
+
+		// The former owner signs in again to get a session with the admin role
+		if err := tx.Model(&domain.RefreshToken{}).
+			Where("user_id = ? AND revoked = false", transfer.FromUserID).
+			Updates(map[string]interface{}{
+				"revoked":    true,
+				"revoked_at": now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+
+		return tx.Create(newAuditLog(tenantID, &userID, domain.AuditOwnershipTransferred, meta, domain.JSON{
+			"transfer_id":  transfer.ID.String(),
+			"from_user_id": transfer.FromUserID.String(),
+			"to_user_id":   transfer.ToUserID.String(),
+		})).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	transfer.Status = domain.OwnershipTransferAccepted
+	transfer.AcceptedAt = &now
+
+	s.notifyFormerOwner(transfer)
+
+	return transfer, nil
+}
+
+func (s *OwnershipService) findOwner(tenantID, userID uuid.UUID) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, ErrUserNotFound
+	}
+	if user.Role != domain.RoleOwner {
+		return nil, ErrOwnerRequired
+	}
+	return user, nil
+}
+
+func (s *OwnershipService) notifyFormerOwner(transfer *domain.OwnershipTransfer) {
+	tenant, err := s.tenantRepo.FindByID(transfer.TenantID)
+	if err != nil {
+		log.Printf("Failed to load tenant %s: %v", transfer.TenantID, err)
+		return
+	}
+	former, err := s.userRepo.FindByID(transfer.FromUserID)
+	if err != nil {
+		return
+	}
+	newOwner, err := s.userRepo.FindByID(transfer.ToUserID)
+	if err != nil {
+		return
+	}
+
+	if err := s.emailService.SendOwnershipTransferCompleted(emailBranding(tenant).WithLocale(former.Locale), former.Email, former.Name, tenant.Name, newOwner.Name); err != nil {
+		log.Printf("Failed to send ownership transfer notice to %s: %v", former.Email, err)
+		return
+	}
+	metering.Record(tenant.ID, domain.MetricEmailsSent, 1)
+}
+
+func generateTransferToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
+
+func hashTransferToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
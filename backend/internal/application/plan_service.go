@@ -0,0 +1,105 @@
+package application
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPlanNotFound = errors.New("plan not found")
+)
+
+type PlanService struct {
+	db         *gorm.DB
+	planRepo   domain.PlanRepository
+	tenantRepo domain.TenantRepository
+}
+
+func NewPlanService(db *gorm.DB, planRepo domain.PlanRepository, tenantRepo domain.TenantRepository) *PlanService {
+	return &PlanService{
+		db:         db,
+		planRepo:   planRepo,
+		tenantRepo: tenantRepo,
+	}
+}
+
+// ListPlans returns the plans available for subscription
+func (s *PlanService) ListPlans(publicOnly bool) ([]*domain.Plan, error) {
+	return s.planRepo.List(publicOnly)
+}
+
+// GetTenantPlan returns the plan a tenant is subscribed to
+func (s *PlanService) GetTenantPlan(tenantID uuid.UUID) (*domain.Plan, error) {
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+	return resolveTenantPlan(s.planRepo, tenant)
+}
+
+// ChangeTenantPlan moves a tenant to another plan
+func (s *PlanService) ChangeTenantPlan(tenantID uuid.UUID, planCode string) (*domain.Tenant, error) {
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+
+	plan, err := s.planRepo.FindByCode(planCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlanNotFound
+		}
+		return nil, err
+	}
+
+	tenant.PlanID = &plan.ID
+	tenant.Plan = plan
+	if err := s.tenantRepo.Update(tenant); err != nil {
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// defaultPlanCode is the plan assigned to new tenants and to tenants without a plan
+func defaultPlanCode() string {
+	if code := viper.GetString("DEFAULT_PLAN"); code != "" {
+		return code
+	}
+	return "trial"
+}
+
+// resolveTenantPlan loads the tenant plan, falling back to the default plan
+func resolveTenantPlan(planRepo domain.PlanRepository, tenant *domain.Tenant) (*domain.Plan, error) {
+	if tenant.Plan != nil {
+		return tenant.Plan, nil
+	}
+
+	var (
+		plan *domain.Plan
+		err  error
+	)
+	if tenant.PlanID != nil {
+		plan, err = planRepo.FindByID(*tenant.PlanID)
+	} else {
+		plan, err = planRepo.FindByCode(defaultPlanCode())
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPlanNotFound
+		}
+		return nil, err
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,26 @@
+package application
+
+import (
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+// PlatformAdmins resolves the users allowed on the /admin routes, which act
+// across tenants
+type PlatformAdmins struct {
+	db *gorm.DB
+}
+
+func NewPlatformAdmins(db *gorm.DB) *PlatformAdmins {
+	return &PlatformAdmins{db: db}
+}
+
+// IsPlatformAdmin reports whether the user is an active platform admin
+func (p *PlatformAdmins) IsPlatformAdmin(userID uuid.UUID) (bool, error) {
+	var count int64
+	err := p.db.Model(&domain.User{}).
+		Where("id = ? AND is_platform_admin = ? AND is_active = ?", userID, true, true).
+		Count(&count).Error
+	return count > 0, err
+}
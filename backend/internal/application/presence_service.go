@@ -0,0 +1,505 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/pkg/chatwoot"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPresence = errors.New("presence must be online, away, busy or offline")
+	ErrInvalidSchedule = errors.New("invalid schedule")
+)
+
+// defaultScheduleTimeZone is used by users that did not pick a time zone
+const defaultScheduleTimeZone = "America/Sao_Paulo"
+
+// AgentLoadCounter counts the open conversations assigned to agents, used to
+// respect their maximum concurrent conversations
+type AgentLoadCounter interface {
+	OpenConversations(tenantID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]int, error)
+}
+
+// ChatwootLoadCounter counts the open conversations assigned to the linked
+// Chatwoot agents of users in the account of their tenant. Tenants that have
+// not connected Chatwoot have no load.
+type ChatwootLoadCounter struct {
+	db       *gorm.DB
+	chatwoot ChatwootClients
+}
+
+func NewChatwootLoadCounter(db *gorm.DB, chatwootClients ChatwootClients) *ChatwootLoadCounter {
+	return &ChatwootLoadCounter{db: db, chatwoot: chatwootClients}
+}
+
+func (c *ChatwootLoadCounter) OpenConversations(tenantID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	load := make(map[uuid.UUID]int)
+	client, err := c.chatwoot.ForTenant(tenantID)
+	if err != nil || client == nil {
+		return load, err
+	}
+
+	var agents []struct {
+		ID              uuid.UUID
+		ChatwootAgentID int
+	}
+	if err := c.db.Model(&domain.User{}).
+		Select("id, chatwoot_agent_id").
+		Where("tenant_id = ? AND id IN ? AND chatwoot_agent_id IS NOT NULL", tenantID, userIDs).
+		Scan(&agents).Error; err != nil {
+		return nil, err
+	}
+	if len(agents) == 0 {
+		return load, nil
+	}
+
+	counts, err := client.CountOpenConversations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count Chatwoot conversations: %w", err)
+	}
+	for _, agent := range agents {
+		load[agent.ID] = counts[agent.ChatwootAgentID]
+	}
+	return load, nil
+}
+
+// PresenceView is the presence of a user as shown to clients
+type PresenceView struct {
+	UserID                     uuid.UUID  `json:"user_id"`
+	Name                       string     `json:"name"`
+	Presence                   string     `json:"presence"`
+	LastSeenAt                 *time.Time `json:"last_seen_at"`
+	WithinSchedule             bool       `json:"within_schedule"`
+	MaxConcurrentConversations int        `json:"max_concurrent_conversations"`
+}
+
+// AvailableAgent is an agent that can take a new conversation
+type AvailableAgent struct {
+	UserID                     uuid.UUID `json:"user_id"`
+	Name                       string    `json:"name"`
+	Email                      string    `json:"email"`
+	Role                       string    `json:"role"`
+	Presence                   string    `json:"presence"`
+	OpenConversations          int       `json:"open_conversations"`
+	MaxConcurrentConversations int       `json:"max_concurrent_conversations"`
+	ChatwootAgentID            *int      `json:"chatwoot_agent_id,omitempty"`
+}
+
+// ScheduleInput holds the working settings of a user; nil fields are kept
+type ScheduleInput struct {
+	TimeZone                   *string             `json:"time_zone"`
+	Weekly                     *domain.WeeklyHours `json:"weekly"`
+	Holidays                   []string            `json:"holidays"`
+	MaxConcurrentConversations *int                `json:"max_concurrent_conversations"`
+}
+
+// ScheduleView is the schedule of a user with their conversation limit
+type ScheduleView struct {
+	*domain.UserSchedule
+	Configured                 bool `json:"configured"`
+	MaxConcurrentConversations int  `json:"max_concurrent_conversations"`
+}
+
+type PresenceService struct {
+	db           *gorm.DB
+	userRepo     domain.UserRepository
+	scheduleRepo domain.UserScheduleRepository
+	teamRepo     domain.TeamRepository
+	roleService  *RoleService
+	load         AgentLoadCounter
+	chatwoot     ChatwootClients
+}
+
+// NewPresenceService creates the presence service. load may be nil to ignore
+// the conversation limits of agents, and chatwootClients to leave Chatwoot
+// untouched.
+func NewPresenceService(
+	db *gorm.DB,
+	userRepo domain.UserRepository,
+	scheduleRepo domain.UserScheduleRepository,
+	teamRepo domain.TeamRepository,
+	roleService *RoleService,
+	load AgentLoadCounter,
+	chatwootClients ChatwootClients,
+) *PresenceService {
+	return &PresenceService{
+		db:           db,
+		userRepo:     userRepo,
+		scheduleRepo: scheduleRepo,
+		teamRepo:     teamRepo,
+		roleService:  roleService,
+		load:         load,
+		chatwoot:     chatwootClients,
+	}
+}
+
+// Heartbeat keeps the presence of a user alive. An offline user comes back online.
+func (s *PresenceService) Heartbeat(userID uuid.UUID) (*PresenceView, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	previous := user.EffectivePresence(now)
+	if user.Presence == "" || user.Presence == domain.PresenceOffline {
+		user.Presence = domain.PresenceOnline
+	}
+	user.LastSeenAt = &now
+
+	if err := s.savePresence(user); err != nil {
+		return nil, err
+	}
+	if user.EffectivePresence(now) != previous {
+		s.syncChatwoot(user)
+	}
+
+	return s.presenceView(user, now)
+}
+
+// SetPresence changes the presence chosen by a user
+func (s *PresenceService) SetPresence(userID uuid.UUID, presence string) (*PresenceView, error) {
+	if !domain.IsValidPresence(presence) {
+		return nil, ErrInvalidPresence
+	}
+
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	previous := user.EffectivePresence(now)
+	user.Presence = presence
+	user.LastSeenAt = &now
+
+	if err := s.savePresence(user); err != nil {
+		return nil, err
+	}
+	if user.EffectivePresence(now) != previous {
+		s.syncChatwoot(user)
+	}
+
+	return s.presenceView(user, now)
+}
+
+// GetPresence returns the presence of a user
+func (s *PresenceService) GetPresence(userID uuid.UUID) (*PresenceView, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.presenceView(user, time.Now())
+}
+
+// ListPresence returns the presence of every active user of a tenant
+func (s *PresenceService) ListPresence(tenantID uuid.UUID) ([]*PresenceView, error) {
+	var users []*domain.User
+	if err := s.db.Where("tenant_id = ? AND is_active = ?", tenantID, true).
+		Order("name ASC").
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	schedules, err := s.scheduleRepo.FindByUsers(userIDs(users))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	views := make([]*PresenceView, 0, len(users))
+	for _, user := range users {
+		views = append(views, newPresenceView(user, schedules[user.ID], now))
+	}
+	return views, nil
+}
+
+// GetSchedule returns the schedule of a user. Users without a schedule are
+// considered within working hours at any time.
+func (s *PresenceService) GetSchedule(userID uuid.UUID) (*ScheduleView, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.findSchedule(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &ScheduleView{
+		UserSchedule:               schedule,
+		Configured:                 schedule != nil,
+		MaxConcurrentConversations: user.MaxConcurrentConversations,
+	}
+	if schedule == nil {
+		view.UserSchedule = &domain.UserSchedule{
+			TenantID: user.TenantID,
+			UserID:   user.ID,
+			TimeZone: defaultScheduleTimeZone,
+			Weekly:   domain.WeeklyHours{},
+			Holidays: domain.StringList{},
+		}
+	}
+	return view, nil
+}
+
+// UpdateSchedule changes the working hours, holidays and conversation limit of a user
+func (s *PresenceService) UpdateSchedule(userID uuid.UUID, input ScheduleInput) (*ScheduleView, error) {
+	view, err := s.GetSchedule(userID)
+	if err != nil {
+		return nil, err
+	}
+	schedule := view.UserSchedule
+
+	if input.TimeZone != nil {
+		if _, err := time.LoadLocation(*input.TimeZone); err != nil || *input.TimeZone == "" {
+			return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidSchedule, *input.TimeZone)
+		}
+		schedule.TimeZone = *input.TimeZone
+	}
+	if input.Weekly != nil {
+		if err := input.Weekly.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+		}
+		schedule.Weekly = *input.Weekly
+	}
+	if input.Holidays != nil {
+		holidays := domain.StringList{}
+		for _, day := range input.Holidays {
+			if _, err := time.Parse("2006-01-02", day); err != nil {
+				return nil, fmt.Errorf("%w: invalid holiday %q, expected YYYY-MM-DD", ErrInvalidSchedule, day)
+			}
+			if !holidays.Contains(day) {
+				holidays = append(holidays, day)
+			}
+		}
+		schedule.Holidays = holidays
+	}
+	if input.MaxConcurrentConversations != nil {
+		if *input.MaxConcurrentConversations < 0 {
+			return nil, fmt.Errorf("%w: max concurrent conversations cannot be negative", ErrInvalidSchedule)
+		}
+		if err := s.db.Model(&domain.User{}).Where("id = ?", userID).
+			Update("max_concurrent_conversations", *input.MaxConcurrentConversations).Error; err != nil {
+			return nil, err
+		}
+		view.MaxConcurrentConversations = *input.MaxConcurrentConversations
+	}
+
+	if input.TimeZone != nil || input.Weekly != nil || input.Holidays != nil {
+		if err := s.scheduleRepo.Save(schedule); err != nil {
+			return nil, fmt.Errorf("failed to save schedule: %w", err)
+		}
+		view.Configured = true
+	}
+
+	return view, nil
+}
+
+// AvailableAgents answers which agents can take a conversation right now:
+// active users whose role can reply to conversations, online, within their
+// working hours and below their concurrent conversations limit. When teamID
+// is set only members of that team are considered. The least loaded agents
+// come first.
+func (s *PresenceService) AvailableAgents(tenantID uuid.UUID, teamID *uuid.UUID) ([]*AvailableAgent, error) {
+	now := time.Now()
+
+	query := s.db.Where("tenant_id = ? AND is_active = ? AND presence = ? AND last_seen_at >= ?",
+		tenantID, true, domain.PresenceOnline, now.Add(-domain.PresenceTimeout))
+	if teamID != nil {
+		query = query.Where("id IN (?)", s.db.Model(&domain.TeamMember{}).
+			Select("user_id").
+			Where("team_id = ? AND tenant_id = ?", *teamID, tenantID))
+	}
+
+	var users []*domain.User
+	if err := query.Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	canReply := make(map[string]bool)
+	candidates := make([]*domain.User, 0, len(users))
+	for _, user := range users {
+		allowed, ok := canReply[user.Role]
+		if !ok {
+			var err error
+			allowed, err = s.roleService.HasPermissions(tenantID, user.Role, domain.PermConversationsReply)
+			if err != nil {
+				return nil, err
+			}
+			canReply[user.Role] = allowed
+		}
+		if allowed {
+			candidates = append(candidates, user)
+		}
+	}
+
+	ids := userIDs(candidates)
+	schedules, err := s.scheduleRepo.FindByUsers(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	load := map[uuid.UUID]int{}
+	if s.load != nil && len(ids) > 0 {
+		if load, err = s.load.OpenConversations(tenantID, ids); err != nil {
+			return nil, err
+		}
+	}
+
+	agents := []*AvailableAgent{}
+	for _, user := range candidates {
+		if schedule := schedules[user.ID]; schedule != nil && !schedule.IsWorking(now) {
+			continue
+		}
+		open := load[user.ID]
+		if user.MaxConcurrentConversations > 0 && open >= user.MaxConcurrentConversations {
+			continue
+		}
+		agents = append(agents, &AvailableAgent{
+			UserID:                     user.ID,
+			Name:                       user.Name,
+			Email:                      user.Email,
+			Role:                       user.Role,
+			Presence:                   user.Presence,
+			OpenConversations:          open,
+			MaxConcurrentConversations: user.MaxConcurrentConversations,
+			ChatwootAgentID:            user.ChatwootAgentID,
+		})
+	}
+
+	sort.SliceStable(agents, func(i, j int) bool {
+		if agents[i].OpenConversations != agents[j].OpenConversations {
+			return agents[i].OpenConversations < agents[j].OpenConversations
+		}
+		return agents[i].Name < agents[j].Name
+	})
+	return agents, nil
+}
+
+// ExpireStale marks offline the users whose heartbeat stopped
+func (s *PresenceService) ExpireStale(now time.Time) (int, error) {
+	var users []*domain.User
+	if err := s.db.Where("presence <> ? AND (last_seen_at IS NULL OR last_seen_at < ?)",
+		domain.PresenceOffline, now.Add(-domain.PresenceTimeout)).
+		Find(&users).Error; err != nil {
+		return 0, err
+	}
+	if len(users) == 0 {
+		return 0, nil
+	}
+
+	if err := s.db.Model(&domain.User{}).
+		Where("id IN ?", userIDs(users)).
+		Update("presence", domain.PresenceOffline).Error; err != nil {
+		return 0, err
+	}
+
+	for _, user := range users {
+		user.Presence = domain.PresenceOffline
+		s.syncChatwoot(user)
+	}
+	return len(users), nil
+}
+
+// RunExpiry is the scheduler job expiring stale presences
+func (s *PresenceService) RunExpiry(ctx context.Context) error {
+	expired, err := s.ExpireStale(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to expire presences: %w", err)
+	}
+
+	if expired > 0 {
+		log.Printf("Presence: %d user(s) went offline", expired)
+	}
+	return nil
+}
+
+func (s *PresenceService) findUser(userID uuid.UUID) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *PresenceService) findSchedule(userID uuid.UUID) (*domain.UserSchedule, error) {
+	schedule, err := s.scheduleRepo.FindByUser(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *PresenceService) savePresence(user *domain.User) error {
+	return s.db.Model(&domain.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"presence":     user.Presence,
+		"last_seen_at": user.LastSeenAt,
+	}).Error
+}
+
+func (s *PresenceService) presenceView(user *domain.User, now time.Time) (*PresenceView, error) {
+	schedule, err := s.findSchedule(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return newPresenceView(user, schedule, now), nil
+}
+
+// syncChatwoot mirrors the presence of a user linked to a Chatwoot agent.
+// Chatwoot has no away state, so away agents show as busy.
+func (s *PresenceService) syncChatwoot(user *domain.User) {
+	if user.ChatwootAgentID == nil {
+		return
+	}
+	client := chatwootFor(s.chatwoot, user.TenantID)
+	if client == nil {
+		return
+	}
+
+	availability := chatwoot.AvailabilityOffline
+	switch user.EffectivePresence(time.Now()) {
+	case domain.PresenceOnline:
+		availability = chatwoot.AvailabilityOnline
+	case domain.PresenceAway, domain.PresenceBusy:
+		availability = chatwoot.AvailabilityBusy
+	}
+
+	if err := client.UpdateAgentAvailability(*user.ChatwootAgentID, availability); err != nil {
+		log.Printf("Failed to sync presence of user %s with Chatwoot: %v", user.ID, err)
+	}
+}
+
+func newPresenceView(user *domain.User, schedule *domain.UserSchedule, now time.Time) *PresenceView {
+	return &PresenceView{
+		UserID:                     user.ID,
+		Name:                       user.Name,
+		Presence:                   user.EffectivePresence(now),
+		LastSeenAt:                 user.LastSeenAt,
+		WithinSchedule:             schedule == nil || schedule.IsWorking(now),
+		MaxConcurrentConversations: user.MaxConcurrentConversations,
+	}
+}
+
+func userIDs(users []*domain.User) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
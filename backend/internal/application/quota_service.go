@@ -0,0 +1,209 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrQuotaExceeded            = errors.New("quota exceeded for current plan")
+	ErrInboxLimitReached        = errors.New("inbox limit reached for current plan")
+	ErrConversationLimitReached = errors.New("monthly conversation limit reached for current plan")
+	ErrBotFlowLimitReached      = errors.New("bot flow limit reached for current plan")
+	ErrAPIKeyLimitReached       = errors.New("API key limit reached for current plan")
+	// ErrUserLimitReached is defined in user_service.go
+)
+
+// quotaErrors maps each resource to the error returned when its limit is hit
+var quotaErrors = map[domain.QuotaResource]error{
+	domain.QuotaSeats:                ErrUserLimitReached,
+	domain.QuotaInboxes:              ErrInboxLimitReached,
+	domain.QuotaMonthlyConversations: ErrConversationLimitReached,
+	domain.QuotaBotFlows:             ErrBotFlowLimitReached,
+	domain.QuotaAPIKeys:              ErrAPIKeyLimitReached,
+}
+
+// QuotaExceededError carries the usage and limit of the exhausted resource.
+// errors.Is matches both ErrQuotaExceeded and the resource error (e.g. ErrUserLimitReached).
+type QuotaExceededError struct {
+	Resource domain.QuotaResource
+	Usage    int64
+	Limit    int
+	Plan     string
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s (usage %d, limit %d)", e.Unwrap().Error(), e.Usage, e.Limit)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	if err, ok := quotaErrors[e.Resource]; ok {
+		return err
+	}
+	return ErrQuotaExceeded
+}
+
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// QuotaUsage reports the usage of a resource against the plan limit
+type QuotaUsage struct {
+	Resource  domain.QuotaResource `json:"resource"`
+	Used      int64                `json:"used"`
+	Limit     int                  `json:"limit"`
+	Remaining int64                `json:"remaining"`
+	Unlimited bool                 `json:"unlimited"`
+}
+
+// UsageCounter returns the current usage of a resource for a tenant
+type UsageCounter func(tenantID uuid.UUID) (int64, error)
+
+type QuotaService struct {
+	db         *gorm.DB
+	tenantRepo domain.TenantRepository
+	planRepo   domain.PlanRepository
+	counters   map[domain.QuotaResource]UsageCounter
+}
+
+func NewQuotaService(
+	db *gorm.DB,
+	tenantRepo domain.TenantRepository,
+	planRepo domain.PlanRepository,
+	userRepo domain.UserRepository,
+	usageRepo domain.UsageRepository,
+) *QuotaService {
+	s := &QuotaService{
+		db:         db,
+		tenantRepo: tenantRepo,
+		planRepo:   planRepo,
+		counters:   make(map[domain.QuotaResource]UsageCounter),
+	}
+
+	s.RegisterCounter(domain.QuotaSeats, userRepo.CountByTenant)
+	s.RegisterCounter(domain.QuotaMonthlyConversations, func(tenantID uuid.UUID) (int64, error) {
+		from, to := currentMonth(time.Now())
+		return usageRepo.Sum(tenantID, domain.MetricConversationsStarted, from, to)
+	})
+
+	return s
+}
+
+// RegisterCounter sets how the usage of a resource is measured.
+// Resources without a counter report zero usage.
+func (s *QuotaService) RegisterCounter(resource domain.QuotaResource, counter UsageCounter) {
+	s.counters[resource] = counter
+}
+
+// Check verifies that a tenant can add `additional` units of a resource
+func (s *QuotaService) Check(tenantID uuid.UUID, resource domain.QuotaResource, additional int64) error {
+	plan, err := s.tenantPlan(tenantID)
+	if err != nil {
+		return err
+	}
+
+	limit := plan.Limit(resource)
+	if limit == domain.UnlimitedQuota {
+		return nil
+	}
+
+	used, err := s.count(tenantID, resource)
+	if err != nil {
+		return err
+	}
+
+	if used+additional > int64(limit) {
+		return &QuotaExceededError{
+			Resource: resource,
+			Usage:    used,
+			Limit:    limit,
+			Plan:     plan.Code,
+		}
+	}
+
+	return nil
+}
+
+// GetUsage reports a single resource usage for a tenant
+func (s *QuotaService) GetUsage(tenantID uuid.UUID, resource domain.QuotaResource) (*QuotaUsage, error) {
+	plan, err := s.tenantPlan(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.usage(tenantID, plan, resource)
+}
+
+// GetAllUsage reports the usage of every limited resource for a tenant
+func (s *QuotaService) GetAllUsage(tenantID uuid.UUID) (*domain.Plan, []QuotaUsage, error) {
+	plan, err := s.tenantPlan(tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	usages := make([]QuotaUsage, 0, len(domain.QuotaResources))
+	for _, resource := range domain.QuotaResources {
+		usage, err := s.usage(tenantID, plan, resource)
+		if err != nil {
+			return nil, nil, err
+		}
+		usages = append(usages, *usage)
+	}
+
+	return plan, usages, nil
+}
+
+func (s *QuotaService) usage(tenantID uuid.UUID, plan *domain.Plan, resource domain.QuotaResource) (*QuotaUsage, error) {
+	used, err := s.count(tenantID, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &QuotaUsage{
+		Resource: resource,
+		Used:     used,
+		Limit:    plan.Limit(resource),
+	}
+
+	if usage.Limit == domain.UnlimitedQuota {
+		usage.Unlimited = true
+		usage.Remaining = -1
+	} else {
+		usage.Remaining = int64(usage.Limit) - used
+		if usage.Remaining < 0 {
+			usage.Remaining = 0
+		}
+	}
+
+	return usage, nil
+}
+
+func (s *QuotaService) count(tenantID uuid.UUID, resource domain.QuotaResource) (int64, error) {
+	counter, ok := s.counters[resource]
+	if !ok {
+		return 0, nil
+	}
+	return counter(tenantID)
+}
+
+func (s *QuotaService) tenantPlan(tenantID uuid.UUID) (*domain.Plan, error) {
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+	return resolveTenantPlan(s.planRepo, tenant)
+}
+
+// currentMonth returns the UTC calendar month containing now
+func currentMonth(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 1, 0)
+}
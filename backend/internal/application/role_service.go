@@ -0,0 +1,367 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRoleNotFound         = errors.New("role not found")
+	ErrRoleNameExists       = errors.New("role name already exists")
+	ErrInvalidRoleName      = errors.New("role name must be 2-50 lowercase letters, digits, hyphens or underscores")
+	ErrUnknownPermission    = errors.New("unknown permission")
+	ErrRoleInUse            = errors.New("role is assigned to users")
+	ErrPermissionEscalation = errors.New("cannot grant permissions you do not have")
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// RoleView is a system or custom role as listed to tenants
+type RoleView struct {
+	ID          *uuid.UUID `json:"id,omitempty"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Permissions []string   `json:"permissions"`
+	System      bool       `json:"system"`
+	UserCount   int64      `json:"user_count"`
+}
+
+// RoleInput holds the fields of a custom role; nil fields are kept on update
+type RoleInput struct {
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleService struct {
+	db       *gorm.DB
+	roleRepo domain.RoleRepository
+}
+
+func NewRoleService(db *gorm.DB, roleRepo domain.RoleRepository) *RoleService {
+	return &RoleService{
+		db:       db,
+		roleRepo: roleRepo,
+	}
+}
+
+// ListRoles returns the system roles followed by the tenant custom roles
+func (s *RoleService) ListRoles(tenantID uuid.UUID) ([]*RoleView, error) {
+	counts, err := s.countUsersByRole(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]*RoleView, 0, len(domain.SystemRoles))
+	for _, role := range domain.SystemRoles {
+		views = append(views, &RoleView{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+			System:      true,
+			UserCount:   counts[role.Name],
+		})
+	}
+
+	roles, err := s.roleRepo.FindByTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		view := customRoleView(role)
+		view.UserCount = counts[role.Name]
+		views = append(views, view)
+	}
+
+	return views, nil
+}
+
+// GetRole returns a custom role of the tenant
+func (s *RoleService) GetRole(tenantID, id uuid.UUID) (*RoleView, error) {
+	role, err := s.findRole(tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	return customRoleView(role), nil
+}
+
+// CreateRole creates a custom role. The actor can only grant permissions
+// their own role has.
+func (s *RoleService) CreateRole(tenantID uuid.UUID, actorRole string, input RoleInput) (*RoleView, error) {
+	if input.Name == nil || !isValidRoleName(*input.Name) {
+		return nil, ErrInvalidRoleName
+	}
+	if err := s.checkGrantable(tenantID, actorRole, input.Permissions); err != nil {
+		return nil, err
+	}
+
+	exists, err := s.RoleExists(tenantID, *input.Name)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrRoleNameExists
+	}
+
+	role := &domain.Role{
+		TenantID:    tenantID,
+		Name:        *input.Name,
+		Permissions: normalizePermissions(input.Permissions),
+	}
+	if input.Description != nil {
+		role.Description = *input.Description
+	}
+
+	if err := s.roleRepo.Create(role); err != nil {
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+	return customRoleView(role), nil
+}
+
+// UpdateRole changes a custom role. Renaming a role moves its users to the new name.
+func (s *RoleService) UpdateRole(tenantID, id uuid.UUID, actorRole string, input RoleInput) (*RoleView, error) {
+	role, err := s.findRole(tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	oldName := role.Name
+	if input.Name != nil && *input.Name != role.Name {
+		if !isValidRoleName(*input.Name) {
+			return nil, ErrInvalidRoleName
+		}
+		exists, err := s.RoleExists(tenantID, *input.Name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrRoleNameExists
+		}
+		role.Name = *input.Name
+	}
+	if input.Description != nil {
+		role.Description = *input.Description
+	}
+	if input.Permissions != nil {
+		if err := s.checkGrantable(tenantID, actorRole, input.Permissions); err != nil {
+			return nil, err
+		}
+		role.Permissions = normalizePermissions(input.Permissions)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(role).Error; err != nil {
+			return fmt.Errorf("failed to update role: %w", err)
+		}
+		if role.Name == oldName {
+			return nil
+		}
+		return tx.Model(&domain.User{}).
+			Where("tenant_id = ? AND role = ?", tenantID, oldName).
+			Update("role", role.Name).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return customRoleView(role), nil
+}
+
+// DeleteRole removes a custom role that is not assigned to any user
+func (s *RoleService) DeleteRole(tenantID, id uuid.UUID) error {
+	role, err := s.findRole(tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	var users int64
+	if err := s.db.Model(&domain.User{}).
+		Where("tenant_id = ? AND role = ?", tenantID, role.Name).
+		Count(&users).Error; err != nil {
+		return err
+	}
+	if users > 0 {
+		return ErrRoleInUse
+	}
+
+	return s.roleRepo.Delete(role.ID)
+}
+
+// RoleExists reports whether a role name is a system role or a custom role of the tenant
+func (s *RoleService) RoleExists(tenantID uuid.UUID, name string) (bool, error) {
+	if _, ok := domain.FindSystemRole(name); ok {
+		return true, nil
+	}
+
+	_, err := s.roleRepo.FindByName(tenantID, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Permissions returns the permissions granted by a role of the tenant. Unknown
+// roles grant nothing.
+func (s *RoleService) Permissions(tenantID uuid.UUID, name string) ([]string, error) {
+	if role, ok := domain.FindSystemRole(name); ok {
+		return role.Permissions, nil
+	}
+
+	role, err := s.roleRepo.FindByName(tenantID, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return role.Permissions, nil
+}
+
+// UserRole returns the current role of an active user of the tenant, or an
+// empty role when the user is gone or deactivated
+func (s *RoleService) UserRole(tenantID, userID uuid.UUID) (string, error) {
+	var roles []string
+	err := s.db.Model(&domain.User{}).
+		Where("id = ? AND tenant_id = ? AND is_active = ?", userID, tenantID, true).
+		Limit(1).
+		Pluck("role", &roles).Error
+	if err != nil || len(roles) == 0 {
+		return "", err
+	}
+	return roles[0], nil
+}
+
+// HasPermissions reports whether a role of the tenant grants every given permission
+func (s *RoleService) HasPermissions(tenantID uuid.UUID, role string, permissions ...string) (bool, error) {
+	granted, err := s.Permissions(tenantID, role)
+	if err != nil {
+		return false, err
+	}
+	return containsAll(granted, permissions), nil
+}
+
+// CheckAssign verifies that the actor may give a role to a user, which
+// requires holding every permission of that role
+func (s *RoleService) CheckAssign(tenantID uuid.UUID, actorRole, role string) error {
+	permissions, err := s.Permissions(tenantID, role)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := s.HasPermissions(tenantID, actorRole, permissions...)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrPermissionEscalation
+	}
+	return nil
+}
+
+func (s *RoleService) checkGrantable(tenantID uuid.UUID, actorRole string, permissions []string) error {
+	for _, permission := range permissions {
+		if !domain.IsKnownPermission(permission) {
+			return fmt.Errorf("%w: %s", ErrUnknownPermission, permission)
+		}
+	}
+
+	allowed, err := s.HasPermissions(tenantID, actorRole, permissions...)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrPermissionEscalation
+	}
+	return nil
+}
+
+func (s *RoleService) findRole(tenantID, id uuid.UUID) (*domain.Role, error) {
+	role, err := s.roleRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	if role.TenantID != tenantID {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func (s *RoleService) countUsersByRole(tenantID uuid.UUID) (map[string]int64, error) {
+	var rows []struct {
+		Role  string
+		Count int64
+	}
+	err := s.db.Model(&domain.User{}).
+		Select("role, COUNT(*) AS count").
+		Where("tenant_id = ?", tenantID).
+		Group("role").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Role] = row.Count
+	}
+	return counts, nil
+}
+
+func customRoleView(role *domain.Role) *RoleView {
+	id := role.ID
+	permissions := []string(role.Permissions)
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return &RoleView{
+		ID:          &id,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+	}
+}
+
+// isValidRoleName checks the format of a custom role name, which cannot
+// shadow a system role
+func isValidRoleName(name string) bool {
+	if !roleNamePattern.MatchString(name) || name == "super_admin" {
+		return false
+	}
+	_, system := domain.FindSystemRole(name)
+	return !system
+}
+
+// normalizePermissions removes duplicates and sorts the permissions
+func normalizePermissions(permissions []string) domain.StringList {
+	seen := make(map[string]bool, len(permissions))
+	normalized := domain.StringList{}
+	for _, permission := range permissions {
+		if !seen[permission] {
+			seen[permission] = true
+			normalized = append(normalized, permission)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func containsAll(granted, required []string) bool {
+	for _, permission := range required {
+		if !containsString(granted, permission) {
+			return false
+		}
+	}
+	return true
+}
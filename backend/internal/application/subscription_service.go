@@ -0,0 +1,268 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/metering"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidSubscriptionStatus     = errors.New("invalid subscription status")
+	ErrInvalidSubscriptionTransition = errors.New("subscription transition not allowed")
+)
+
+// ReminderDays are the days before expiry when admins are reminded
+var ReminderDays = []int{7, 3, 1}
+
+type SubscriptionService struct {
+	db           *gorm.DB
+	tenantRepo   domain.TenantRepository
+	userRepo     domain.UserRepository
+	eventRepo    domain.SubscriptionEventRepository
+	emailService *email.EmailService
+	pastDueGrace time.Duration
+}
+
+func NewSubscriptionService(
+	db *gorm.DB,
+	tenantRepo domain.TenantRepository,
+	userRepo domain.UserRepository,
+	eventRepo domain.SubscriptionEventRepository,
+) *SubscriptionService {
+	graceDays := viper.GetInt("PAST_DUE_GRACE_DAYS")
+	if graceDays <= 0 {
+		graceDays = 7
+	}
+
+	return &SubscriptionService{
+		db:           db,
+		tenantRepo:   tenantRepo,
+		userRepo:     userRepo,
+		eventRepo:    eventRepo,
+		emailService: email.NewEmailService(),
+		pastDueGrace: time.Duration(graceDays) * 24 * time.Hour,
+	}
+}
+
+// Transition moves a tenant subscription to a new state if the lifecycle allows it
+func (s *SubscriptionService) Transition(tenantID uuid.UUID, to string, endsAt *time.Time, reason string, actorID *uuid.UUID) (*domain.Tenant, error) {
+	if !domain.IsValidSubscriptionStatus(to) {
+		return nil, ErrInvalidSubscriptionStatus
+	}
+
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.transition(tenant, to, endsAt, reason, actorID); err != nil {
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+func (s *SubscriptionService) transition(tenant *domain.Tenant, to string, endsAt *time.Time, reason string, actorID *uuid.UUID) error {
+	from := tenant.SubscriptionStatus
+
+	// Staying in the same state only updates the period end
+	if from != to && !domain.CanTransitionSubscription(from, to) {
+		return ErrInvalidSubscriptionTransition
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"subscription_status": to,
+		}
+		if endsAt != nil {
+			updates["subscription_ends_at"] = endsAt
+		}
+
+		if err := tx.Model(&domain.Tenant{}).Where("id = ?", tenant.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update subscription status: %w", err)
+		}
+
+		if from != to {
+			event := &domain.SubscriptionEvent{
+				TenantID:   tenant.ID,
+				Type:       domain.SubscriptionEventTransition,
+				FromStatus: from,
+				ToStatus:   to,
+				Reason:     reason,
+				Metadata:   domain.JSON{},
+				ActorID:    actorID,
+			}
+			if err := tx.Create(event).Error; err != nil {
+				return fmt.Errorf("failed to record subscription event: %w", err)
+			}
+		}
+
+		tenant.SubscriptionStatus = to
+		if endsAt != nil {
+			tenant.SubscriptionEndsAt = endsAt
+		}
+		return nil
+	})
+}
+
+// GetHistory returns the latest lifecycle events of a tenant
+func (s *SubscriptionService) GetHistory(tenantID uuid.UUID, limit int) ([]*domain.SubscriptionEvent, error) {
+	return s.eventRepo.FindByTenant(tenantID, limit)
+}
+
+// ProcessExpirations moves tenants whose period ended to the next state:
+// trial and canceled become expired, past_due becomes suspended after the grace period
+func (s *SubscriptionService) ProcessExpirations(now time.Time) (int, error) {
+	moved := 0
+
+	expiring, err := s.tenantRepo.FindBySubscriptionStatus(
+		[]string{domain.SubscriptionTrial, domain.SubscriptionCanceled}, now)
+	if err != nil {
+		return moved, err
+	}
+
+	for _, tenant := range expiring {
+		reason := "trial period ended"
+		if tenant.SubscriptionStatus == domain.SubscriptionCanceled {
+			reason = "canceled subscription period ended"
+		}
+		if err := s.transition(tenant, domain.SubscriptionExpired, nil, reason, nil); err != nil {
+			log.Printf("Failed to expire tenant %s: %v", tenant.ID, err)
+			continue
+		}
+		moved++
+	}
+
+	overdue, err := s.tenantRepo.FindBySubscriptionStatus(
+		[]string{domain.SubscriptionPastDue}, now.Add(-s.pastDueGrace))
+	if err != nil {
+		return moved, err
+	}
+
+	for _, tenant := range overdue {
+		if err := s.transition(tenant, domain.SubscriptionSuspended, nil, "payment overdue after grace period", nil); err != nil {
+			log.Printf("Failed to suspend tenant %s: %v", tenant.ID, err)
+			continue
+		}
+		moved++
+	}
+
+	return moved, nil
+}
+
+// SendExpiryReminders emails tenant admins 7, 3 and 1 days before a trial ends.
+// Each reminder is recorded so it is only sent once per period.
+func (s *SubscriptionService) SendExpiryReminders(now time.Time) (int, error) {
+	horizon := now.Add(time.Duration(ReminderDays[0]) * 24 * time.Hour)
+
+	tenants, err := s.tenantRepo.FindBySubscriptionStatus([]string{domain.SubscriptionTrial}, horizon)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, tenant := range tenants {
+		endsAt := *tenant.SubscriptionEndsAt
+		if !endsAt.After(now) {
+			continue
+		}
+
+		daysLeft := int(math.Ceil(endsAt.Sub(now).Hours() / 24))
+		threshold := reminderThreshold(daysLeft)
+		if threshold == 0 {
+			continue
+		}
+
+		alreadySent, err := s.eventRepo.ReminderSent(tenant.ID, threshold, endsAt)
+		if err != nil {
+			return sent, err
+		}
+		if alreadySent {
+			continue
+		}
+
+		if err := s.notifyAdmins(tenant, daysLeft, endsAt); err != nil {
+			log.Printf("Failed to send expiry reminder to tenant %s: %v", tenant.ID, err)
+			continue
+		}
+
+		event := &domain.SubscriptionEvent{
+			TenantID:   tenant.ID,
+			Type:       domain.SubscriptionEventReminder,
+			FromStatus: tenant.SubscriptionStatus,
+			ToStatus:   tenant.SubscriptionStatus,
+			Metadata: domain.JSON{
+				"days_before": threshold,
+				"period_end":  endsAt.UTC().Format(time.RFC3339),
+			},
+		}
+		if err := s.eventRepo.Create(event); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// RunDailyChecks is the scheduler entry point for the lifecycle job
+func (s *SubscriptionService) RunDailyChecks(ctx context.Context) error {
+	now := time.Now()
+
+	moved, err := s.ProcessExpirations(now)
+	if err != nil {
+		return fmt.Errorf("failed to process expirations: %w", err)
+	}
+
+	sent, err := s.SendExpiryReminders(now)
+	if err != nil {
+		return fmt.Errorf("failed to send expiry reminders: %w", err)
+	}
+
+	log.Printf("Subscription lifecycle: %d tenant(s) transitioned, %d reminder(s) sent", moved, sent)
+	return nil
+}
+
+func (s *SubscriptionService) notifyAdmins(tenant *domain.Tenant, daysLeft int, endsAt time.Time) error {
+	users, err := s.userRepo.FindByTenant(tenant.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if !user.IsActive || (user.Role != "owner" && user.Role != "admin") {
+			continue
+		}
+		if err := s.emailService.SendSubscriptionExpiryReminder(emailBranding(tenant).WithLocale(user.Locale), user.Email, user.Name, tenant.Name, daysLeft, endsAt); err != nil {
+			return err
+		}
+		metering.Record(tenant.ID, domain.MetricEmailsSent, 1)
+	}
+
+	return nil
+}
+
+// reminderThreshold returns the smallest reminder day that still covers daysLeft,
+// so a job that missed the 7-day mark sends the 3-day reminder instead of both
+func reminderThreshold(daysLeft int) int {
+	threshold := 0
+	for _, days := range ReminderDays {
+		if daysLeft <= days {
+			threshold = days
+		}
+	}
+	return threshold
+}
@@ -0,0 +1,356 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/pkg/chatwoot"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTeamNotFound      = errors.New("team not found")
+	ErrTeamNameExists    = errors.New("team name already exists")
+	ErrInvalidTeamName   = errors.New("team name is required and must be at most 100 characters")
+	ErrTeamMemberMissing = errors.New("user is not a member of the team")
+	ErrChatwootDisabled  = errors.New("chatwoot integration is not configured")
+)
+
+// TeamInput holds the fields of a team; nil fields are kept on update
+type TeamInput struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+// TeamScope limits the conversations and leads a user can see to the ones
+// assigned to members of their teams
+type TeamScope struct {
+	All     bool        `json:"all"`
+	UserIDs []uuid.UUID `json:"user_ids"`
+}
+
+// Apply returns a GORM scope filtering rows by their assignee column.
+// Unassigned rows stay visible so agents can pick them up.
+func (s *TeamScope) Apply(column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if s.All {
+			return db
+		}
+		return db.Where(fmt.Sprintf("(%s IN ? OR %s IS NULL)", column, column), s.UserIDs)
+	}
+}
+
+type TeamService struct {
+	db          *gorm.DB
+	teamRepo    domain.TeamRepository
+	userRepo    domain.UserRepository
+	roleService *RoleService
+	chatwoot    ChatwootClients
+}
+
+// NewTeamService creates the team service. Teams are mirrored in the
+// Chatwoot account of tenants that connected one; chatwootClients may be nil
+// to disable mirroring.
+func NewTeamService(
+	db *gorm.DB,
+	teamRepo domain.TeamRepository,
+	userRepo domain.UserRepository,
+	roleService *RoleService,
+	chatwootClients ChatwootClients,
+) *TeamService {
+	return &TeamService{
+		db:          db,
+		teamRepo:    teamRepo,
+		userRepo:    userRepo,
+		roleService: roleService,
+		chatwoot:    chatwootClients,
+	}
+}
+
+// ListTeams returns the teams of a tenant with their members
+func (s *TeamService) ListTeams(tenantID uuid.UUID) ([]*domain.Team, error) {
+	return s.teamRepo.FindByTenant(tenantID)
+}
+
+// GetTeam returns a team of the tenant with its members
+func (s *TeamService) GetTeam(tenantID, teamID uuid.UUID) (*domain.Team, error) {
+	team, err := s.teamRepo.FindByID(teamID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, err
+	}
+	if team.TenantID != tenantID {
+		return nil, ErrTeamNotFound
+	}
+	return team, nil
+}
+
+// CreateTeam creates a team and mirrors it in Chatwoot
+func (s *TeamService) CreateTeam(tenantID uuid.UUID, input TeamInput) (*domain.Team, error) {
+	if input.Name == nil {
+		return nil, ErrInvalidTeamName
+	}
+	name, err := s.validateName(tenantID, uuid.Nil, *input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	team := &domain.Team{
+		TenantID: tenantID,
+		Name:     name,
+	}
+	if input.Description != nil {
+		team.Description = *input.Description
+	}
+
+	if err := s.teamRepo.Create(team); err != nil {
+		return nil, fmt.Errorf("failed to create team: %w", err)
+	}
+
+	s.syncBestEffort(team)
+	return team, nil
+}
+
+// UpdateTeam renames or describes a team
+func (s *TeamService) UpdateTeam(tenantID, teamID uuid.UUID, input TeamInput) (*domain.Team, error) {
+	team, err := s.GetTeam(tenantID, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Name != nil {
+		name, err := s.validateName(tenantID, team.ID, *input.Name)
+		if err != nil {
+			return nil, err
+		}
+		team.Name = name
+	}
+	if input.Description != nil {
+		team.Description = *input.Description
+	}
+
+	if err := s.teamRepo.Update(team); err != nil {
+		return nil, fmt.Errorf("failed to update team: %w", err)
+	}
+
+	s.syncBestEffort(team)
+	return team, nil
+}
+
+// DeleteTeam removes a team, its memberships and the Chatwoot team
+func (s *TeamService) DeleteTeam(tenantID, teamID uuid.UUID) error {
+	team, err := s.GetTeam(tenantID, teamID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.teamRepo.Delete(team.ID); err != nil {
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+
+	if team.ChatwootTeamID != nil {
+		if client := chatwootFor(s.chatwoot, tenantID); client != nil {
+			if err := client.DeleteTeam(*team.ChatwootTeamID); err != nil {
+				log.Printf("Failed to delete Chatwoot team %d of tenant %s: %v", *team.ChatwootTeamID, tenantID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// AddMember adds a user to a team or changes whether they lead it
+func (s *TeamService) AddMember(tenantID, teamID, userID uuid.UUID, isLead bool) (*domain.Team, error) {
+	team, err := s.GetTeam(tenantID, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, ErrUserNotFound
+	}
+
+	if err := s.teamRepo.SaveMember(&domain.TeamMember{
+		TenantID: tenantID,
+		TeamID:   team.ID,
+		UserID:   user.ID,
+		IsLead:   isLead,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add team member: %w", err)
+	}
+
+	return s.reloadAndSync(team.ID)
+}
+
+// RemoveMember removes a user from a team
+func (s *TeamService) RemoveMember(tenantID, teamID, userID uuid.UUID) (*domain.Team, error) {
+	team, err := s.GetTeam(tenantID, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.teamRepo.FindMember(team.ID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTeamMemberMissing
+		}
+		return nil, err
+	}
+
+	if err := s.teamRepo.RemoveMember(team.ID, userID); err != nil {
+		return nil, fmt.Errorf("failed to remove team member: %w", err)
+	}
+
+	return s.reloadAndSync(team.ID)
+}
+
+// ListUserTeams returns the memberships of a user
+func (s *TeamService) ListUserTeams(userID uuid.UUID) ([]*domain.TeamMember, error) {
+	return s.teamRepo.FindMemberships(userID)
+}
+
+// Scope returns the conversations and leads visibility of a user. Roles with
+// conversations.read_all see everything; others only see the work of their teammates.
+func (s *TeamService) Scope(tenantID, userID uuid.UUID, role string) (*TeamScope, error) {
+	all, err := s.roleService.HasPermissions(tenantID, role, domain.PermConversationsReadAll)
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		return &TeamScope{All: true, UserIDs: []uuid.UUID{}}, nil
+	}
+
+	ids, err := s.teamRepo.FindTeammateIDs(userID, false)
+	if err != nil {
+		return nil, err
+	}
+	return &TeamScope{UserIDs: ids}, nil
+}
+
+// CanManageAssignment reports whether the actor may assign work to or take
+// work from the assignee: roles with conversations.assign can for anyone of
+// the tenant, team leads can for the members of the teams they lead
+func (s *TeamService) CanManageAssignment(tenantID, actorID uuid.UUID, actorRole string, assigneeID uuid.UUID) (bool, error) {
+	assignee, err := s.userRepo.FindByID(assigneeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if assignee.TenantID != tenantID {
+		return false, nil
+	}
+
+	allowed, err := s.roleService.HasPermissions(tenantID, actorRole, domain.PermConversationsAssign)
+	if err != nil || allowed {
+		return allowed, err
+	}
+
+	led, err := s.teamRepo.FindTeammateIDs(actorID, true)
+	if err != nil {
+		return false, err
+	}
+	return containsUUID(led, assigneeID), nil
+}
+
+// SyncChatwoot mirrors a team and its members in Chatwoot. Members without a
+// linked Chatwoot agent are left out.
+func (s *TeamService) SyncChatwoot(tenantID, teamID uuid.UUID) (*domain.Team, error) {
+	var client *chatwoot.Client
+	if s.chatwoot != nil {
+		var err error
+		if client, err = s.chatwoot.ForTenant(tenantID); err != nil {
+			return nil, err
+		}
+	}
+	if client == nil {
+		return nil, ErrChatwootDisabled
+	}
+
+	team, err := s.GetTeam(tenantID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.syncChatwoot(client, team); err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+func (s *TeamService) syncChatwoot(client *chatwoot.Client, team *domain.Team) error {
+	request := chatwoot.TeamRequest{
+		Name:            team.Name,
+		Description:     team.Description,
+		AllowAutoAssign: true,
+	}
+
+	if team.ChatwootTeamID == nil {
+		created, err := client.CreateTeam(request)
+		if err != nil {
+			return fmt.Errorf("failed to create Chatwoot team: %w", err)
+		}
+		team.ChatwootTeamID = &created.ID
+		if err := s.db.Model(&domain.Team{}).Where("id = ?", team.ID).
+			Update("chatwoot_team_id", created.ID).Error; err != nil {
+			return err
+		}
+	} else if _, err := client.UpdateTeam(*team.ChatwootTeamID, request); err != nil {
+		return fmt.Errorf("failed to update Chatwoot team: %w", err)
+	}
+
+	agentIDs := []int{}
+	for _, member := range team.Members {
+		if member.User != nil && member.User.ChatwootAgentID != nil {
+			agentIDs = append(agentIDs, *member.User.ChatwootAgentID)
+		}
+	}
+	if err := client.SetTeamMembers(*team.ChatwootTeamID, agentIDs); err != nil {
+		return fmt.Errorf("failed to update Chatwoot team members: %w", err)
+	}
+	return nil
+}
+
+// syncBestEffort mirrors the team in Chatwoot without failing the request;
+// a failed sync can be retried through SyncChatwoot
+func (s *TeamService) syncBestEffort(team *domain.Team) {
+	client := chatwootFor(s.chatwoot, team.TenantID)
+	if client == nil {
+		return
+	}
+	if err := s.syncChatwoot(client, team); err != nil {
+		log.Printf("Failed to sync team %s with Chatwoot: %v", team.ID, err)
+	}
+}
+
+func (s *TeamService) reloadAndSync(teamID uuid.UUID) (*domain.Team, error) {
+	team, err := s.teamRepo.FindByID(teamID)
+	if err != nil {
+		return nil, err
+	}
+	s.syncBestEffort(team)
+	return team, nil
+}
+
+func (s *TeamService) validateName(tenantID, teamID uuid.UUID, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return "", ErrInvalidTeamName
+	}
+
+	var count int64
+	if err := s.db.Model(&domain.Team{}).
+		Where("tenant_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", tenantID, name, teamID).
+		Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return "", ErrTeamNameExists
+	}
+	return name, nil
+}
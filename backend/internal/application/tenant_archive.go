@@ -0,0 +1,318 @@
+package application
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+// Layout of a tenant archive
+const (
+	archiveManifestPath = "manifest.json"
+	archiveTenantTable  = "tenants"
+)
+
+func archiveDataPath(table string) string {
+	return "data/" + table + ".ndjson"
+}
+
+// tableColumns returns the columns of a table in the current schema; an
+// empty result means the table does not exist
+func tableColumns(tx *gorm.DB, table string) ([]string, error) {
+	var columns []string
+	err := tx.Raw(`
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ?
+		ORDER BY ordinal_position`, table).Scan(&columns).Error
+	return columns, err
+}
+
+// tableColumnTypes returns the data type of each column of a table
+func tableColumnTypes(tx *gorm.DB, table string) (map[string]string, error) {
+	var columns []struct {
+		ColumnName string
+		DataType   string
+	}
+	err := tx.Raw(`
+		SELECT column_name, data_type FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ?`, table).Scan(&columns).Error
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]string, len(columns))
+	for _, column := range columns {
+		types[column.ColumnName] = column.DataType
+	}
+	return types, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveWriter writes NDJSON files into a zip and records them in the manifest
+type archiveWriter struct {
+	zip      *zip.Writer
+	manifest *domain.ExportManifest
+}
+
+func newArchiveWriter(w io.Writer, manifest *domain.ExportManifest) *archiveWriter {
+	return &archiveWriter{zip: zip.NewWriter(w), manifest: manifest}
+}
+
+// writeTable dumps the rows selected by query as one JSON object per line
+func (a *archiveWriter) writeTable(table string, query *gorm.DB) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	path := archiveDataPath(table)
+	entry, err := a.zip.Create(path)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	encoder := json.NewEncoder(io.MultiWriter(entry, hash, counter))
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			record[column.Name()] = archiveValue(column, values[i])
+		}
+
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", table, err)
+	}
+
+	a.manifest.Files = append(a.manifest.Files, domain.ExportManifestFile{
+		Table:  table,
+		Path:   path,
+		Rows:   count,
+		Bytes:  counter.n,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	})
+
+	return nil
+}
+
+// close writes the manifest and finishes the zip
+func (a *archiveWriter) close() error {
+	entry, err := a.zip.Create(archiveManifestPath)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(a.manifest); err != nil {
+		return err
+	}
+
+	return a.zip.Close()
+}
+
+// archiveValue converts a scanned column into its JSON representation
+func archiveValue(column *sql.ColumnType, value interface{}) interface{} {
+	switch column.DatabaseTypeName() {
+	case "JSON", "JSONB":
+		switch v := value.(type) {
+		case []byte:
+			return json.RawMessage(append([]byte(nil), v...))
+		case string:
+			return json.RawMessage(v)
+		}
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC()
+	case []byte:
+		return string(v)
+	}
+
+	return value
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// quoteIdent quotes a SQL identifier coming from the table registry or an archive
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// archiveReader gives access to the verified files of a tenant archive
+type archiveReader struct {
+	manifest *domain.ExportManifest
+	files    map[string]*zip.File
+}
+
+// openArchive reads the manifest and verifies the size, row count and
+// checksum of every file it lists
+func openArchive(r io.ReaderAt, size int64) (*archiveReader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifestFile, ok := files[archiveManifestPath]
+	if !ok {
+		return nil, fmt.Errorf("%w: manifest.json is missing", ErrInvalidArchive)
+	}
+
+	var manifest domain.ExportManifest
+	if err := readZipJSON(manifestFile, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: invalid manifest: %v", ErrInvalidArchive, err)
+	}
+
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > domain.ExportFormatVersion {
+		return nil, fmt.Errorf("%w: unsupported format version %d", ErrInvalidArchive, manifest.FormatVersion)
+	}
+
+	for _, entry := range manifest.Files {
+		f, ok := files[entry.Path]
+		if !ok || entry.Path != archiveDataPath(entry.Table) {
+			return nil, fmt.Errorf("%w: file %s is missing", ErrInvalidArchive, entry.Path)
+		}
+
+		if err := verifyZipFile(f, entry); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, entry.Path, err)
+		}
+	}
+
+	return &archiveReader{manifest: &manifest, files: files}, nil
+}
+
+// file returns the manifest entry of a table
+func (a *archiveReader) file(table string) (domain.ExportManifestFile, bool) {
+	for _, entry := range a.manifest.Files {
+		if entry.Table == table {
+			return entry, true
+		}
+	}
+	return domain.ExportManifestFile{}, false
+}
+
+// eachRow decodes every row of a table file
+func (a *archiveReader) eachRow(entry domain.ExportManifestFile, fn func(row map[string]interface{}) error) error {
+	rc, err := a.files[entry.Path].Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	decoder := json.NewDecoder(rc)
+	decoder.UseNumber()
+	for {
+		var row map[string]interface{}
+		if err := decoder.Decode(&row); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidArchive, entry.Path, err)
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+func verifyZipFile(f *zip.File, entry domain.ExportManifestFile) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	hash := sha256.New()
+	lines := &lineCounter{}
+	written, err := io.Copy(io.MultiWriter(hash, lines), rc)
+	if err != nil {
+		return err
+	}
+
+	if written != entry.Bytes {
+		return fmt.Errorf("size mismatch (expected %d, got %d)", entry.Bytes, written)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != entry.SHA256 {
+		return fmt.Errorf("checksum mismatch")
+	}
+	if lines.n != entry.Rows {
+		return fmt.Errorf("row count mismatch (expected %d, got %d)", entry.Rows, lines.n)
+	}
+
+	return nil
+}
+
+func readZipJSON(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+type lineCounter struct {
+	n int64
+}
+
+func (w *lineCounter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b == '\n' {
+			w.n++
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,95 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/secrets"
+	"gorm.io/gorm"
+)
+
+// TenantMailer delivers the emails of each tenant with its mail settings:
+// through its SMTP relay while the relay is healthy, otherwise through the
+// platform transport, from the tenant sender address once it is verified
+type TenantMailer struct {
+	repo     domain.TenantMailSettingsRepository
+	box      *secrets.Box
+	platform email.Mailer
+}
+
+// NewTenantMailer wraps the platform transport with the tenant mail settings
+func NewTenantMailer(repo domain.TenantMailSettingsRepository, platform email.Mailer) *TenantMailer {
+	return newTenantMailer(repo, secrets.NewBoxFromConfig(), platform)
+}
+
+func newTenantMailer(repo domain.TenantMailSettingsRepository, box *secrets.Box, platform email.Mailer) *TenantMailer {
+	return &TenantMailer{repo: repo, box: box, platform: platform}
+}
+
+func (m *TenantMailer) Send(ctx context.Context, msg *email.Message) error {
+	if msg.TenantID == uuid.Nil {
+		return m.platform.Send(ctx, msg)
+	}
+
+	settings, err := m.repo.FindByTenant(msg.TenantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return m.platform.Send(ctx, msg)
+		}
+		return err
+	}
+
+	if settings.UsesRelay() {
+		err := relaySender{settings: settings, box: m.box}.Send(ctx, msg)
+		if recordErr := m.repo.RecordHealth(settings.TenantID, err, time.Now()); recordErr != nil {
+			log.Printf("Failed to record SMTP relay health of tenant %s: %v", settings.TenantID, recordErr)
+		}
+		if err == nil {
+			return nil
+		}
+		log.Printf("SMTP relay of tenant %s failed, sending through the platform: %v", settings.TenantID, err)
+	}
+
+	// The platform only sends from tenant addresses their owners confirmed
+	if settings.SenderVerified() {
+		out := *msg
+		out.From = senderAddress(settings, msg.From)
+		return m.platform.Send(ctx, &out)
+	}
+	return m.platform.Send(ctx, msg)
+}
+
+// relaySender sends through the SMTP relay of a tenant, from its sender address
+type relaySender struct {
+	settings *domain.TenantMailSettings
+	box      *secrets.Box
+}
+
+func (r relaySender) Send(ctx context.Context, msg *email.Message) error {
+	relay, err := relayMailer(r.box, r.settings)
+	if err != nil {
+		return err
+	}
+
+	out := *msg
+	out.From = senderAddress(r.settings, msg.From)
+	return relay.Send(ctx, &out)
+}
+
+// senderAddress returns the From header with the tenant sender address,
+// keeping the display name of the original From unless the tenant set one
+func senderAddress(settings *domain.TenantMailSettings, from string) string {
+	name := settings.FromName
+	if name == "" {
+		if address, err := mail.ParseAddress(from); err == nil {
+			name = address.Name
+		}
+	}
+	return (&mail.Address{Name: name, Address: settings.FromEmail}).String()
+}
@@ -1,6 +1,7 @@
 package application
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -8,7 +9,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/spf13/viper"
 	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/events"
 	"gorm.io/gorm"
 )
 
@@ -18,23 +21,40 @@ var (
 	ErrTenantDomainExists = errors.New("tenant domain already exists")
 	ErrInvalidSlug        = errors.New("invalid slug format")
 	ErrInvalidDomain      = errors.New("invalid domain format")
+	ErrReservedSlug       = errors.New("slug is reserved")
 )
 
 type TenantService struct {
-	db         *gorm.DB
-	tenantRepo domain.TenantRepository
-	userRepo   domain.UserRepository
+	db           *gorm.DB
+	tenantRepo   domain.TenantRepository
+	userRepo     domain.UserRepository
+	planRepo     domain.PlanRepository
+	aliasRepo    domain.TenantSlugAliasRepository
+	quotaService *QuotaService
+	aliasTTL     time.Duration
 }
 
 func NewTenantService(
 	db *gorm.DB,
 	tenantRepo domain.TenantRepository,
 	userRepo domain.UserRepository,
+	planRepo domain.PlanRepository,
+	aliasRepo domain.TenantSlugAliasRepository,
+	quotaService *QuotaService,
 ) *TenantService {
+	aliasDays := viper.GetInt("TENANT_SLUG_ALIAS_DAYS")
+	if aliasDays <= 0 {
+		aliasDays = 90
+	}
+
 	return &TenantService{
-		db:         db,
-		tenantRepo: tenantRepo,
-		userRepo:   userRepo,
+		db:           db,
+		tenantRepo:   tenantRepo,
+		userRepo:     userRepo,
+		planRepo:     planRepo,
+		aliasRepo:    aliasRepo,
+		quotaService: quotaService,
+		aliasTTL:     time.Duration(aliasDays) * 24 * time.Hour,
 	}
 }
 
@@ -44,8 +64,11 @@ func (s *TenantService) CreateTenant(name, slug, adminEmail, adminPassword, admi
 	if !isValidSlug(slug) {
 		return nil, nil, ErrInvalidSlug
 	}
+	if domain.IsReservedSlug(slug) {
+		return nil, nil, ErrReservedSlug
+	}
 
-	// Check if slug exists
+	// Check if slug exists, as a tenant slug or the alias of a renamed tenant
 	exists, err := s.tenantRepo.ExistsBySlug(slug)
 	if err != nil {
 		return nil, nil, err
@@ -54,6 +77,12 @@ func (s *TenantService) CreateTenant(name, slug, adminEmail, adminPassword, admi
 		return nil, nil, ErrTenantSlugExists
 	}
 
+	// New tenants start on the default plan
+	plan, err := s.planRepo.FindByCode(defaultPlanCode())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load default plan: %w", err)
+	}
+
 	// Start transaction
 	tx := s.db.Begin()
 	defer func() {
@@ -68,17 +97,13 @@ func (s *TenantService) CreateTenant(name, slug, adminEmail, adminPassword, admi
 		Slug: slug,
 		Settings: domain.JSON{
 			"onboarding_completed": false,
-			"features": map[string]bool{
-				"chat_enabled":     true,
-				"crm_enabled":      false,
-				"calendar_enabled": false,
-			},
 		},
-		SubscriptionStatus: "trial",
+		SubscriptionStatus: domain.SubscriptionTrial,
+		PlanID:             &plan.ID,
 	}
 
 	// Set trial end date (14 days)
-	trialEnd := time.Now().Add(14 * 24 * time.Hour)
+	trialEnd := time.Now().Add(domain.TrialPeriod)
 	tenant.SubscriptionEndsAt = &trialEnd
 
 	if err := tx.Create(tenant).Error; err != nil {
@@ -86,12 +111,12 @@ func (s *TenantService) CreateTenant(name, slug, adminEmail, adminPassword, admi
 		return nil, nil, fmt.Errorf("failed to create tenant: %w", err)
 	}
 
-	// Create admin user
+	// Create the owner, the only user allowed to transfer or delete the tenant
 	user := &domain.User{
 		TenantID: tenant.ID,
 		Email:    adminEmail,
 		Name:     adminName,
-		Role:     "admin",
+		Role:     domain.RoleOwner,
 		IsActive: true,
 	}
 
@@ -105,6 +130,14 @@ func (s *TenantService) CreateTenant(name, slug, adminEmail, adminPassword, admi
 		return nil, nil, fmt.Errorf("failed to create admin user: %w", err)
 	}
 
+	// Create the Chatwoot account of the tenant in the background
+	if ChatwootProvisioningEnabled() {
+		if err := tx.Create(domain.NewChatwootProvisioning(tenant.ID, user.ID, time.Now())).Error; err != nil {
+			tx.Rollback()
+			return nil, nil, fmt.Errorf("failed to schedule Chatwoot provisioning: %w", err)
+		}
+	}
+
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
@@ -137,6 +170,103 @@ func (s *TenantService) GetTenantBySlug(slug string) (*domain.Tenant, error) {
 	return tenant, nil
 }
 
+// ResolveSlug finds a tenant by its current slug or by a former slug whose
+// alias is still active. redirect is true when the slug is a former one and
+// the caller should move to tenant.Slug.
+func (s *TenantService) ResolveSlug(slug string) (tenant *domain.Tenant, redirect bool, err error) {
+	tenant, err = s.tenantRepo.FindBySlug(slug)
+	if err == nil {
+		return tenant, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	alias, err := s.aliasRepo.FindActive(slug, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrTenantNotFound
+		}
+		return nil, false, err
+	}
+
+	tenant, err = s.GetTenant(alias.TenantID)
+	if err != nil {
+		return nil, false, err
+	}
+	return tenant, true, nil
+}
+
+// ChangeSlug renames the tenant. The former slug keeps resolving to the
+// tenant through an alias for the configured period.
+func (s *TenantService) ChangeSlug(tenantID uuid.UUID, slug string, actorID *uuid.UUID, meta AuditContext) (*domain.Tenant, *domain.TenantSlugAlias, error) {
+	tenant, err := s.GetTenant(tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !isValidSlug(slug) {
+		return nil, nil, ErrInvalidSlug
+	}
+	if domain.IsReservedSlug(slug) {
+		return nil, nil, ErrReservedSlug
+	}
+	if slug == tenant.Slug {
+		return tenant, nil, nil
+	}
+
+	// A tenant may take back one of its own former slugs
+	if existing, err := s.tenantRepo.FindBySlug(slug); err == nil && existing.ID != tenantID {
+		return nil, nil, ErrTenantSlugExists
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+	if alias, err := s.aliasRepo.FindActive(slug, time.Now()); err == nil && alias.TenantID != tenantID {
+		return nil, nil, ErrTenantSlugExists
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	oldSlug := tenant.Slug
+	alias := &domain.TenantSlugAlias{
+		TenantID:  tenantID,
+		Slug:      oldSlug,
+		ExpiresAt: time.Now().Add(s.aliasTTL),
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Drop the alias of the new slug and any expired alias of the old one
+		if err := tx.Where("slug IN ?", []string{slug, oldSlug}).Delete(&domain.TenantSlugAlias{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domain.Tenant{}).Where("id = ?", tenantID).Update("slug", slug).Error; err != nil {
+			return fmt.Errorf("failed to update slug: %w", err)
+		}
+
+		if err := tx.Create(alias).Error; err != nil {
+			return fmt.Errorf("failed to create slug alias: %w", err)
+		}
+
+		return tx.Create(newAuditLog(tenantID, actorID, domain.AuditTenantSlugChanged, meta, domain.JSON{
+			"from":             oldSlug,
+			"to":               slug,
+			"alias_expires_at": alias.ExpiresAt.UTC().Format(time.RFC3339),
+		})).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tenant.Slug = slug
+	return tenant, alias, nil
+}
+
+// ListSlugAliases returns the former slugs of a tenant
+func (s *TenantService) ListSlugAliases(tenantID uuid.UUID) ([]*domain.TenantSlugAlias, error) {
+	return s.aliasRepo.FindByTenant(tenantID)
+}
+
 // UpdateTenant updates tenant information
 func (s *TenantService) UpdateTenant(id uuid.UUID, updates map[string]interface{}) (*domain.Tenant, error) {
 	tenant, err := s.tenantRepo.FindByID(id)
@@ -171,19 +301,29 @@ func (s *TenantService) UpdateTenant(id uuid.UUID, updates map[string]interface{
 		tenant.Name = name
 	}
 
-	if settings, ok := updates["settings"].(domain.JSON); ok {
+	// JSON request bodies decode to a plain map
+	settings, ok := updates["settings"].(map[string]interface{})
+	if !ok {
+		settings, _ = updates["settings"].(domain.JSON)
+	}
+	businessHoursUpdated := false
+	if settings != nil {
+		if tenant.Settings == nil {
+			tenant.Settings = domain.JSON{}
+		}
 		// Merge settings instead of replacing
 		for key, value := range settings {
+			if isManagedSetting(key) {
+				continue
+			}
 			tenant.Settings[key] = value
 		}
+		_, businessHoursUpdated = settings[domain.BusinessHoursSettingsKey]
 	}
 
-	if subscriptionStatus, ok := updates["subscription_status"].(string); ok {
-		tenant.SubscriptionStatus = subscriptionStatus
-	}
-
-	if subscriptionEndsAt, ok := updates["subscription_ends_at"].(*time.Time); ok {
-		tenant.SubscriptionEndsAt = subscriptionEndsAt
+	// A new domain has to be verified again
+	if verification := tenant.DomainVerification(); verification != nil && (tenant.Domain == nil || verification.Domain != *tenant.Domain) {
+		delete(tenant.Settings, domain.DomainVerificationSettingsKey)
 	}
 
 	// Save changes
@@ -191,6 +331,13 @@ func (s *TenantService) UpdateTenant(id uuid.UUID, updates map[string]interface{
 		return nil, err
 	}
 
+	if businessHoursUpdated {
+		events.Publish(context.Background(), events.Event{
+			Name:     domain.EventBusinessHoursUpdated,
+			TenantID: tenant.ID,
+		})
+	}
+
 	return tenant, nil
 }
 
@@ -244,10 +391,17 @@ func (s *TenantService) GetTenantStats(tenantID uuid.UUID) (map[string]interface
 		return nil, err
 	}
 
+	plan, usage, err := s.quotaService.GetAllUsage(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
 	stats := map[string]interface{}{
 		"user_count":          userCount,
 		"subscription_status": tenant.SubscriptionStatus,
 		"created_at":          tenant.CreatedAt,
+		"plan":                plan,
+		"usage":               usage,
 	}
 
 	if tenant.SubscriptionEndsAt != nil {
@@ -260,6 +414,16 @@ func (s *TenantService) GetTenantStats(tenantID uuid.UUID) (map[string]interface
 
 // Helper functions
 
+// isManagedSetting reports whether a settings key is maintained by the
+// platform and cannot be written through UpdateTenant
+func isManagedSetting(key string) bool {
+	switch key {
+	case domain.OnboardingCompletedSettingsKey, domain.DomainVerificationSettingsKey, domain.BrandingSettingsKey:
+		return true
+	}
+	return false
+}
+
 func isValidSlug(slug string) bool {
 	// Slug must be lowercase, alphanumeric with hyphens, 3-63 characters
 	if len(slug) < 3 || len(slug) > 63 {
@@ -0,0 +1,138 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/events"
+	"github.com/widia/widia-connect/internal/infrastructure/metering"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidUsageMetric = errors.New("invalid usage metric")
+	ErrInvalidGranularity = errors.New("invalid usage granularity")
+	ErrInvalidUsageRange  = errors.New("invalid usage date range")
+	ErrUsageRangeTooLarge = errors.New("usage date range too large")
+)
+
+// Retention of rollups and the largest range a single query may cover
+const (
+	HourlyUsageRetention = 90 * 24 * time.Hour
+	maxHourlyUsageRange  = 31 * 24 * time.Hour
+	maxDailyUsageRange   = 366 * 24 * time.Hour
+)
+
+// UsageReport is the metered usage of a tenant over a date range
+type UsageReport struct {
+	From        time.Time             `json:"from"`
+	To          time.Time             `json:"to"`
+	Granularity string                `json:"granularity"`
+	Totals      map[string]int64      `json:"totals"`
+	Series      []*domain.UsageRollup `json:"series"`
+}
+
+type UsageService struct {
+	db        *gorm.DB
+	usageRepo domain.UsageRepository
+}
+
+func NewUsageService(db *gorm.DB, usageRepo domain.UsageRepository) *UsageService {
+	return &UsageService{
+		db:        db,
+		usageRepo: usageRepo,
+	}
+}
+
+// Subscribe meters the conversations and messages of the Chatwoot accounts
+// of tenants from their webhook events. The bot runs in Chatwoot as an agent
+// bot: each of its replies counts as a bot run.
+func (s *UsageService) Subscribe(bus *events.Bus) {
+	bus.Subscribe(domain.EventChatwootConversationCreated, func(ctx context.Context, event events.Event) error {
+		metering.Record(event.TenantID, domain.MetricConversationsStarted, 1)
+		return nil
+	})
+	bus.Subscribe(domain.EventChatwootMessageCreated, func(ctx context.Context, event events.Event) error {
+		webhook := ChatwootEvent(event)
+		if webhook == nil || webhook.Message == nil || !webhook.Message.Outgoing() {
+			return nil
+		}
+		metering.Record(event.TenantID, domain.MetricMessagesSent, 1)
+		if webhook.Message.SentByBot() {
+			metering.Record(event.TenantID, domain.MetricBotRuns, 1)
+		}
+		return nil
+	})
+}
+
+// GetUsage returns the rollups of a tenant between from (inclusive) and to (exclusive)
+func (s *UsageService) GetUsage(tenantID uuid.UUID, from, to time.Time, granularity string, metrics []string) (*UsageReport, error) {
+	if !to.After(from) {
+		return nil, ErrInvalidUsageRange
+	}
+
+	switch granularity {
+	case domain.GranularityHour:
+		if to.Sub(from) > maxHourlyUsageRange {
+			return nil, ErrUsageRangeTooLarge
+		}
+	case domain.GranularityDay:
+		if to.Sub(from) > maxDailyUsageRange {
+			return nil, ErrUsageRangeTooLarge
+		}
+	default:
+		return nil, ErrInvalidGranularity
+	}
+
+	for _, metric := range metrics {
+		if !domain.IsValidUsageMetric(metric) {
+			return nil, ErrInvalidUsageMetric
+		}
+	}
+
+	series, err := s.usageRepo.Query(tenantID, granularity, metrics, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int64)
+	reported := metrics
+	if len(reported) == 0 {
+		reported = domain.UsageMetrics
+	}
+	for _, metric := range reported {
+		totals[metric] = 0
+	}
+	for _, rollup := range series {
+		totals[rollup.Metric] += rollup.Count
+	}
+
+	return &UsageReport{
+		From:        from,
+		To:          to,
+		Granularity: granularity,
+		Totals:      totals,
+		Series:      series,
+	}, nil
+}
+
+// PruneHourlyRollups deletes hourly rollups past their retention; daily rollups are kept
+func (s *UsageService) PruneHourlyRollups(now time.Time) (int64, error) {
+	return s.usageRepo.DeleteBefore(domain.GranularityHour, now.Add(-HourlyUsageRetention))
+}
+
+// RunRetention is the scheduler entry point for the usage retention job
+func (s *UsageService) RunRetention(ctx context.Context) error {
+	deleted, err := s.PruneHourlyRollups(time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to prune usage rollups: %w", err)
+	}
+
+	log.Printf("Usage retention: %d hourly rollup(s) deleted", deleted)
+	return nil
+}
@@ -0,0 +1,561 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/events"
+	"github.com/widia/widia-connect/internal/infrastructure/metering"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidUserCSV   = errors.New("invalid users CSV")
+	ErrUserImportFailed = errors.New("user import has invalid rows")
+)
+
+// maxUserImportRows bounds the size of a single CSV import
+const maxUserImportRows = 1000
+
+// invitationExpiration is how long an invitation link stays valid
+const invitationExpiration = 7 * 24 * time.Hour
+
+// Statuses of the rows of a user import
+const (
+	UserImportValid     = "valid"
+	UserImportInvalid   = "invalid"
+	UserImportDuplicate = "duplicate"
+	UserImportCreated   = "created"
+	UserImportFailed    = "failed"
+	UserImportSkipped   = "skipped"
+)
+
+// userCSVHeader lists the columns of the users CSV, in export order
+var userCSVHeader = []string{"name", "email", "role", "team", "is_active", "last_login_at", "created_at"}
+
+// UserImportOptions control how a users CSV is applied
+type UserImportOptions struct {
+	// DryRun validates every row without creating anything
+	DryRun bool
+	// Atomic creates every user or none; otherwise valid rows are created and the others reported
+	Atomic bool
+	// SendInvitations emails each created user a link to set their password
+	SendInvitations bool
+	ActorID         uuid.UUID
+	ActorRole       string
+}
+
+// UserImportRow is the outcome of one row of the CSV
+type UserImportRow struct {
+	Line    int        `json:"line"`
+	Name    string     `json:"name"`
+	Email   string     `json:"email"`
+	Role    string     `json:"role"`
+	Teams   []string   `json:"teams"`
+	Status  string     `json:"status"`
+	Errors  []string   `json:"errors,omitempty"`
+	UserID  *uuid.UUID `json:"user_id,omitempty"`
+	Invited bool       `json:"invited,omitempty"`
+
+	teamIDs []uuid.UUID
+}
+
+// UserImportReport describes what an import did, or would do in dry-run mode
+type UserImportReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Atomic  bool             `json:"atomic"`
+	Total   int              `json:"total"`
+	Valid   int              `json:"valid"`
+	Created int              `json:"created"`
+	Failed  int              `json:"failed"`
+	Invited int              `json:"invited"`
+	Rows    []*UserImportRow `json:"rows"`
+}
+
+type UserImportService struct {
+	db           *gorm.DB
+	userRepo     domain.UserRepository
+	tenantRepo   domain.TenantRepository
+	teamRepo     domain.TeamRepository
+	userService  *UserService
+	roleService  *RoleService
+	quotaService *QuotaService
+	emailService *email.EmailService
+}
+
+func NewUserImportService(
+	db *gorm.DB,
+	userRepo domain.UserRepository,
+	tenantRepo domain.TenantRepository,
+	teamRepo domain.TeamRepository,
+	userService *UserService,
+	roleService *RoleService,
+	quotaService *QuotaService,
+) *UserImportService {
+	return &UserImportService{
+		db:           db,
+		userRepo:     userRepo,
+		tenantRepo:   tenantRepo,
+		teamRepo:     teamRepo,
+		userService:  userService,
+		roleService:  roleService,
+		quotaService: quotaService,
+		emailService: email.NewEmailService(),
+	}
+}
+
+// Import creates users from a CSV with the columns name, email, role and team.
+// Role defaults to agent and team may list several team names separated by ";".
+// Every row is validated first; in atomic mode any invalid row aborts the
+// import, otherwise only the valid rows are created.
+func (s *UserImportService) Import(ctx context.Context, tenantID uuid.UUID, r io.Reader, opts UserImportOptions) (*UserImportReport, error) {
+	rows, err := parseUserCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UserImportReport{
+		DryRun: opts.DryRun,
+		Atomic: opts.Atomic,
+		Total:  len(rows),
+		Rows:   rows,
+	}
+
+	if err := s.validateRows(tenantID, opts.ActorRole, rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row.Status == UserImportValid {
+			report.Valid++
+		}
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	if opts.Atomic {
+		if report.Valid != report.Total {
+			markSkipped(rows)
+			report.Failed = report.Total - report.Valid
+			return report, ErrUserImportFailed
+		}
+		if err := s.quotaService.Check(tenantID, domain.QuotaSeats, int64(report.Valid)); err != nil {
+			return nil, err
+		}
+		if err := s.createAtomic(ctx, tenantID, rows); err != nil {
+			return nil, err
+		}
+	} else {
+		s.createPartial(ctx, tenantID, rows)
+	}
+
+	created := []*domain.User{}
+	for _, row := range rows {
+		switch row.Status {
+		case UserImportCreated:
+			report.Created++
+			created = append(created, &domain.User{ID: *row.UserID, Name: row.Name, Email: row.Email, Role: row.Role})
+		default:
+			report.Failed++
+		}
+	}
+
+	for _, user := range created {
+		events.Publish(ctx, events.Event{
+			Name:     domain.EventUserCreated,
+			TenantID: tenantID,
+			ActorID:  &opts.ActorID,
+			Payload:  map[string]interface{}{"user_id": user.ID, "role": user.Role, "source": "csv_import"},
+		})
+	}
+
+	if opts.SendInvitations && len(created) > 0 {
+		invited := s.sendInvitations(tenantID, opts.ActorID, created)
+		for _, row := range rows {
+			if row.UserID != nil && invited[*row.UserID] {
+				row.Invited = true
+				report.Invited++
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// Export writes the users of the tenant as CSV, in the format accepted by Import
+func (s *UserImportService) Export(tenantID uuid.UUID, w io.Writer) error {
+	users, err := s.userRepo.FindByTenant(tenantID)
+	if err != nil {
+		return err
+	}
+
+	teams, err := s.teamRepo.FindByTenant(tenantID)
+	if err != nil {
+		return err
+	}
+	userTeams := make(map[uuid.UUID][]string)
+	for _, team := range teams {
+		for _, member := range team.Members {
+			userTeams[member.UserID] = append(userTeams[member.UserID], team.Name)
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(userCSVHeader); err != nil {
+		return err
+	}
+	for _, user := range users {
+		lastLogin := ""
+		if user.LastLoginAt != nil {
+			lastLogin = user.LastLoginAt.UTC().Format(time.RFC3339)
+		}
+		record := []string{
+			escapeCSVFormula(user.Name),
+			escapeCSVFormula(user.Email),
+			escapeCSVFormula(user.Role),
+			escapeCSVFormula(strings.Join(userTeams[user.ID], ";")),
+			fmt.Sprintf("%t", user.IsActive),
+			lastLogin,
+			user.CreatedAt.UTC().Format(time.RFC3339),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// validateRows checks each row and marks it valid, invalid or duplicate
+func (s *UserImportService) validateRows(tenantID uuid.UUID, actorRole string, rows []*UserImportRow) error {
+	teams, err := s.teamRepo.FindByTenant(tenantID)
+	if err != nil {
+		return err
+	}
+	teamsByName := make(map[string]uuid.UUID, len(teams))
+	for _, team := range teams {
+		teamsByName[strings.ToLower(team.Name)] = team.ID
+	}
+
+	assignable := make(map[string]error)
+	seen := make(map[string]int)
+
+	for _, row := range rows {
+		if row.Name == "" {
+			row.Errors = append(row.Errors, "name is required")
+		}
+
+		if !isValidEmail(row.Email) {
+			row.Errors = append(row.Errors, ErrInvalidEmail.Error())
+		} else if line, ok := seen[row.Email]; ok {
+			row.Status = UserImportDuplicate
+			row.Errors = append(row.Errors, fmt.Sprintf("email repeated from line %d", line))
+		} else {
+			seen[row.Email] = row.Line
+			existing, _ := s.userRepo.FindByEmailAndTenant(row.Email, tenantID)
+			if existing != nil {
+				row.Status = UserImportDuplicate
+				row.Errors = append(row.Errors, ErrUserEmailExists.Error())
+			}
+		}
+
+		if err := s.userService.validateRole(tenantID, row.Role); err != nil {
+			if !errors.Is(err, ErrInvalidRole) && !errors.Is(err, ErrOwnerAssignment) {
+				return err
+			}
+			row.Errors = append(row.Errors, fmt.Sprintf("%s: %s", err.Error(), row.Role))
+		} else {
+			// The imported users cannot get permissions the actor does not have
+			checked, ok := assignable[row.Role]
+			if !ok {
+				checked = s.roleService.CheckAssign(tenantID, actorRole, row.Role)
+				if checked != nil && !errors.Is(checked, ErrPermissionEscalation) {
+					return checked
+				}
+				assignable[row.Role] = checked
+			}
+			if checked != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("%s: %s", checked.Error(), row.Role))
+			}
+		}
+
+		for _, name := range row.Teams {
+			teamID, ok := teamsByName[strings.ToLower(name)]
+			if !ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("team not found: %s", name))
+				continue
+			}
+			row.teamIDs = append(row.teamIDs, teamID)
+		}
+
+		switch {
+		case row.Status == UserImportDuplicate:
+		case len(row.Errors) > 0:
+			row.Status = UserImportInvalid
+		default:
+			row.Status = UserImportValid
+		}
+	}
+
+	return nil
+}
+
+// createAtomic creates every row in a single transaction
+func (s *UserImportService) createAtomic(ctx context.Context, tenantID uuid.UUID, rows []*UserImportRow) error {
+	users := make([]*domain.User, len(rows))
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, row := range rows {
+			user, err := createImportedUser(tx, tenantID, row)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", row.Line, err)
+			}
+			users[i] = user
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, row := range rows {
+		row.Status = UserImportCreated
+		row.UserID = &users[i].ID
+	}
+	return nil
+}
+
+// createPartial creates the valid rows one by one, reporting the ones that fail
+func (s *UserImportService) createPartial(ctx context.Context, tenantID uuid.UUID, rows []*UserImportRow) {
+	for _, row := range rows {
+		if row.Status != UserImportValid {
+			continue
+		}
+
+		if err := s.quotaService.Check(tenantID, domain.QuotaSeats, 1); err != nil {
+			row.Status = UserImportFailed
+			row.Errors = append(row.Errors, ErrUserLimitReached.Error())
+			continue
+		}
+
+		var user *domain.User
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var err error
+			user, err = createImportedUser(tx, tenantID, row)
+			return err
+		})
+		if err != nil {
+			row.Status = UserImportFailed
+			row.Errors = append(row.Errors, err.Error())
+			continue
+		}
+
+		row.Status = UserImportCreated
+		row.UserID = &user.ID
+	}
+}
+
+// createImportedUser creates the user of a row with its team memberships.
+// Imported users have no usable password until they accept an invitation
+// or reset it.
+func createImportedUser(tx *gorm.DB, tenantID uuid.UUID, row *UserImportRow) (*domain.User, error) {
+	user := &domain.User{
+		TenantID:     tenantID,
+		Email:        row.Email,
+		Name:         row.Name,
+		Role:         row.Role,
+		PasswordHash: unusablePasswordHash,
+		IsActive:     true,
+	}
+	if err := tx.Create(user).Error; err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	for _, teamID := range row.teamIDs {
+		member := &domain.TeamMember{
+			TenantID: tenantID,
+			TeamID:   teamID,
+			UserID:   user.ID,
+		}
+		if err := tx.Create(member).Error; err != nil {
+			return nil, fmt.Errorf("failed to add team member: %w", err)
+		}
+	}
+
+	return user, nil
+}
+
+// sendInvitations emails the created users a link to set their password and
+// returns the users that were invited
+func (s *UserImportService) sendInvitations(tenantID, actorID uuid.UUID, users []*domain.User) map[uuid.UUID]bool {
+	invited := make(map[uuid.UUID]bool, len(users))
+
+	tenant, err := s.tenantRepo.FindByID(tenantID)
+	if err != nil {
+		log.Printf("Failed to load tenant %s for invitations: %v", tenantID, err)
+		return invited
+	}
+
+	inviterName := tenant.Name
+	if actor, err := s.userRepo.FindByID(actorID); err == nil {
+		inviterName = actor.Name
+	}
+
+	for _, user := range users {
+		// The token and its email are stored together, the outbox worker delivers the email
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			token, expiresAt, err := createInvitationToken(tx, user.ID)
+			if err != nil {
+				return err
+			}
+			return s.emailService.WithQueue(txOutbox{tx: tx}).SendInvitationEmail(emailBranding(tenant).WithLocale(user.Locale), user.Email, user.Name, tenant.Name, inviterName, token, expiresAt)
+		})
+		if err != nil {
+			log.Printf("Failed to invite user %s: %v", user.ID, err)
+			continue
+		}
+		metering.Record(tenant.ID, domain.MetricEmailsSent, 1)
+		invited[user.ID] = true
+	}
+
+	return invited
+}
+
+// createInvitationToken creates a password reset token valid for the invitation period
+func createInvitationToken(tx *gorm.DB, userID uuid.UUID) (string, time.Time, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", time.Time{}, err
+	}
+
+	token := &domain.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Token:     hex.EncodeToString(tokenBytes),
+		ExpiresAt: time.Now().Add(invitationExpiration),
+	}
+	if err := tx.Create(token).Error; err != nil {
+		return "", time.Time{}, err
+	}
+	return token.Token, token.ExpiresAt, nil
+}
+
+// parseUserCSV reads the rows of a users CSV. Columns are matched by header
+// name, so their order does not matter and export-only columns are ignored.
+func parseUserCSV(r io.Reader) ([]*UserImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: file is empty", ErrInvalidUserCSV)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUserCSV, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, required := range []string{"name", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidUserCSV, required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(unescapeCSVFormula(record[i]))
+	}
+
+	rows := []*UserImportRow{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidUserCSV, err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(rows) == maxUserImportRows {
+			return nil, fmt.Errorf("%w: at most %d users per import", ErrInvalidUserCSV, maxUserImportRows)
+		}
+
+		row := &UserImportRow{
+			Line:  line,
+			Name:  field(record, "name"),
+			Email: strings.ToLower(field(record, "email")),
+			Role:  field(record, "role"),
+			Teams: []string{},
+		}
+		if row.Role == "" {
+			row.Role = domain.RoleAgent
+		}
+		for _, team := range strings.Split(field(record, "team"), ";") {
+			if team = strings.TrimSpace(team); team != "" {
+				row.Teams = append(row.Teams, team)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no users found", ErrInvalidUserCSV)
+	}
+	return rows, nil
+}
+
+// escapeCSVFormula prefixes the values that spreadsheets would run as a
+// formula with a quote, which they display as text
+func escapeCSVFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeCSVFormula reverts escapeCSVFormula, so exported files import as is
+func unescapeCSVFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && escapeCSVFormula(value[1:]) != value[1:] {
+		return value[1:]
+	}
+	return value
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// markSkipped flags the valid rows of an aborted atomic import
+func markSkipped(rows []*UserImportRow) {
+	for _, row := range rows {
+		if row.Status == UserImportValid {
+			row.Status = UserImportSkipped
+		}
+	}
+}
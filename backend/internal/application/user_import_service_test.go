@@ -0,0 +1,43 @@
+package application
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestExportedCellsAreNotFormulas(t *testing.T) {
+	formulas := []string{"=HYPERLINK(\"http://evil.test\")", "+1", "-1", "@SUM(A1)", "\tcmd", "\rcmd"}
+	plain := []string{"Ana", "O'Brien", "'quoted"}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(userCSVHeader)
+	for _, name := range append(formulas, plain...) {
+		writer.Write([]string{escapeCSVFormula(name), "user@acme.test", "agent", "", "true", "", ""})
+	}
+	writer.Flush()
+
+	for _, name := range formulas {
+		if escaped := escapeCSVFormula(name); escaped != "'"+name {
+			t.Errorf("expected %q to be escaped, got %q", name, escaped)
+		}
+	}
+	for _, name := range plain {
+		if escaped := escapeCSVFormula(name); escaped != name {
+			t.Errorf("expected %q to be kept, got %q", name, escaped)
+		}
+	}
+
+	// The export imports back with the original values
+	rows, err := parseUserCSV(&buf)
+	if err != nil {
+		t.Fatalf("failed to parse export: %v", err)
+	}
+	for i, name := range append(formulas, plain...) {
+		if want := strings.TrimSpace(name); rows[i].Name != want {
+			t.Errorf("expected imported name %q, got %q", want, rows[i].Name)
+		}
+	}
+}
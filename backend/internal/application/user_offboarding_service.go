@@ -0,0 +1,390 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/pkg/chatwoot"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidReassignment = errors.New("invalid reassignment target")
+)
+
+// UserOffboardingInput selects where the open work of the offboarded user goes
+type UserOffboardingInput struct {
+	// ReassignTo is domain.ReassignToUser, ReassignToTeam or ReassignToQueue
+	ReassignTo string     `json:"reassign_to"`
+	UserID     *uuid.UUID `json:"user_id"`
+	TeamID     *uuid.UUID `json:"team_id"`
+	DryRun     bool       `json:"dry_run"`
+}
+
+// ReassignedItem is a row moved away from the offboarded user. AssignedTo is
+// nil when the row went back to the routing queue.
+type ReassignedItem struct {
+	ID         string     `json:"id"`
+	AssignedTo *uuid.UUID `json:"assigned_to"`
+}
+
+// ReassignedTable lists the rows of a table moved away from the offboarded user
+type ReassignedTable struct {
+	Table string           `json:"table"`
+	Count int              `json:"count"`
+	Items []ReassignedItem `json:"items"`
+}
+
+// UserOffboardingSummary reports everything an offboarding changed, or would
+// change for a dry run
+type UserOffboardingSummary struct {
+	UserID              uuid.UUID         `json:"user_id"`
+	DryRun              bool              `json:"dry_run"`
+	ReassignTo          string            `json:"reassign_to"`
+	TargetUserID        *uuid.UUID        `json:"target_user_id,omitempty"`
+	TargetTeamID        *uuid.UUID        `json:"target_team_id,omitempty"`
+	Deactivated         bool              `json:"deactivated"`
+	SessionsRevoked     int64             `json:"sessions_revoked"`
+	APIKeysRevoked      int64             `json:"api_keys_revoked"`
+	Reassigned          []ReassignedTable `json:"reassigned"`
+	ChatwootUpdated     int               `json:"chatwoot_updated"`
+	ChatwootFailed      []int             `json:"chatwoot_failed"`
+	SkippedTables       []string          `json:"skipped_tables"`
+	chatwootAssignments []chatwootAssignment
+}
+
+// chatwootAssignment is a Chatwoot conversation to hand to an agent, or to
+// unassign when AgentID is nil
+type chatwootAssignment struct {
+	ConversationID int
+	AgentID        *int
+}
+
+type UserOffboardingService struct {
+	db          *gorm.DB
+	userRepo    domain.UserRepository
+	teamRepo    domain.TeamRepository
+	userService *UserService
+	teamService *TeamService
+	chatwoot    ChatwootClients
+}
+
+// NewUserOffboardingService creates the user offboarding service. chatwootClients
+// may be nil to leave Chatwoot untouched.
+func NewUserOffboardingService(
+	db *gorm.DB,
+	userRepo domain.UserRepository,
+	teamRepo domain.TeamRepository,
+	userService *UserService,
+	teamService *TeamService,
+	chatwootClients ChatwootClients,
+) *UserOffboardingService {
+	return &UserOffboardingService{
+		db:          db,
+		userRepo:    userRepo,
+		teamRepo:    teamRepo,
+		userService: userService,
+		teamService: teamService,
+		chatwoot:    chatwootClients,
+	}
+}
+
+// Offboard deactivates a user, revokes their sessions and API keys and moves
+// their open conversations, leads, deals and activities to another user, to
+// the members of a team or back to the routing queue. Chatwoot conversations
+// are reassigned on a best effort basis once the changes are committed. The
+// actor must be allowed to manage the assignments of the user and of every
+// target.
+func (s *UserOffboardingService) Offboard(tenantID, actorID uuid.UUID, actorRole string, userID uuid.UUID, input UserOffboardingInput, meta AuditContext) (*UserOffboardingSummary, error) {
+	if userID == actorID {
+		return nil, ErrCannotDeleteSelf
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, ErrUserNotFound
+	}
+	if user.Role == domain.RoleOwner {
+		return nil, ErrOwnerProtected
+	}
+	if user.IsActive && domain.IsAdminRole(user.Role) {
+		if err := s.userService.checkLastAdmin(tenantID, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	assignees, err := s.resolveTargets(tenantID, user.ID, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAssignments(tenantID, actorID, actorRole, user, assignees); err != nil {
+		return nil, err
+	}
+
+	summary := &UserOffboardingSummary{
+		UserID:         user.ID,
+		DryRun:         input.DryRun,
+		ReassignTo:     input.ReassignTo,
+		TargetUserID:   input.UserID,
+		TargetTeamID:   input.TeamID,
+		Reassigned:     []ReassignedTable{},
+		ChatwootFailed: []int{},
+		SkippedTables:  []string{},
+	}
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"is_active": false,
+			"presence":  domain.PresenceOffline,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to deactivate user: %w", err)
+		}
+		summary.Deactivated = user.IsActive
+
+		result := tx.Model(&domain.RefreshToken{}).
+			Where("user_id = ? AND revoked = false", user.ID).
+			Updates(map[string]interface{}{
+				"revoked":    true,
+				"revoked_at": now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", result.Error)
+		}
+		summary.SessionsRevoked = result.RowsAffected
+
+		for _, table := range domain.UserCredentialTables {
+			revoked, ok, err := revokeCredentials(tx, table, tenantID, user.ID, now)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				summary.SkippedTables = append(summary.SkippedTables, table.Name)
+				continue
+			}
+			summary.APIKeysRevoked += revoked
+		}
+
+		for _, table := range domain.AssignableTables {
+			moved, ok, err := s.reassignTable(tx, table, tenantID, user.ID, assignees, summary)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				summary.SkippedTables = append(summary.SkippedTables, table.Name)
+				continue
+			}
+			summary.Reassigned = append(summary.Reassigned, *moved)
+		}
+
+		if err := tx.Create(newAuditLog(tenantID, &actorID, domain.AuditUserOffboarded, meta, domain.JSON{
+			"user_id":          user.ID.String(),
+			"reassign_to":      input.ReassignTo,
+			"sessions_revoked": summary.SessionsRevoked,
+			"api_keys_revoked": summary.APIKeysRevoked,
+			"reassigned":       reassignedCounts(summary.Reassigned),
+		})).Error; err != nil {
+			return err
+		}
+
+		if input.DryRun {
+			return errDryRunRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRunRollback) {
+		return nil, err
+	}
+
+	if !input.DryRun {
+		s.syncChatwoot(user, summary)
+	}
+
+	return summary, nil
+}
+
+// resolveTargets returns the users the work is spread across, in order. An
+// empty list sends the work back to the routing queue.
+func (s *UserOffboardingService) resolveTargets(tenantID, offboardedID uuid.UUID, input UserOffboardingInput) ([]*domain.User, error) {
+	switch input.ReassignTo {
+	case domain.ReassignToQueue:
+		return nil, nil
+
+	case domain.ReassignToUser:
+		if input.UserID == nil || *input.UserID == offboardedID {
+			return nil, fmt.Errorf("%w: pick another user", ErrInvalidReassignment)
+		}
+		target, err := s.userRepo.FindByID(*input.UserID)
+		if err != nil || target.TenantID != tenantID || !target.IsActive {
+			return nil, fmt.Errorf("%w: user must be an active user of the tenant", ErrInvalidReassignment)
+		}
+		return []*domain.User{target}, nil
+
+	case domain.ReassignToTeam:
+		if input.TeamID == nil {
+			return nil, fmt.Errorf("%w: pick a team", ErrInvalidReassignment)
+		}
+		team, err := s.teamRepo.FindByID(*input.TeamID)
+		if err != nil || team.TenantID != tenantID {
+			return nil, ErrTeamNotFound
+		}
+
+		var members []*domain.User
+		for _, member := range team.Members {
+			if member.User != nil && member.User.IsActive && member.UserID != offboardedID {
+				members = append(members, member.User)
+			}
+		}
+		if len(members) == 0 {
+			return nil, fmt.Errorf("%w: team has no other active member", ErrInvalidReassignment)
+		}
+		return members, nil
+	}
+
+	return nil, fmt.Errorf("%w: reassign_to must be user, team or queue", ErrInvalidReassignment)
+}
+
+// checkAssignments verifies that the actor may take the work of the user
+// and give it to each assignee
+func (s *UserOffboardingService) checkAssignments(tenantID, actorID uuid.UUID, actorRole string, user *domain.User, assignees []*domain.User) error {
+	for _, target := range append([]*domain.User{user}, assignees...) {
+		if target.ID == actorID {
+			continue
+		}
+		allowed, err := s.teamService.CanManageAssignment(tenantID, actorID, actorRole, target.ID)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrAssignmentForbidden
+		}
+	}
+	return nil
+}
+
+// reassignTable moves the open rows of a table, spreading them across the
+// assignees in turn. ok is false when the table does not exist yet.
+func (s *UserOffboardingService) reassignTable(tx *gorm.DB, table domain.AssignableTable, tenantID, userID uuid.UUID, assignees []*domain.User, summary *UserOffboardingSummary) (*ReassignedTable, bool, error) {
+	columns, err := tableColumns(tx, table.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	if !containsString(columns, "tenant_id") || !containsString(columns, table.AssigneeColumn) {
+		return nil, false, nil
+	}
+	chatwootColumn := ""
+	if table.ChatwootColumn != "" && containsString(columns, table.ChatwootColumn) {
+		chatwootColumn = table.ChatwootColumn
+	}
+
+	selected := "id::text AS id"
+	if chatwootColumn != "" {
+		selected += ", " + quoteIdent(chatwootColumn) + " AS chatwoot_id"
+	}
+	var rows []struct {
+		ID         string
+		ChatwootID *int
+	}
+	if err := tx.Table(quoteIdent(table.Name)).
+		Select(selected).
+		Where("tenant_id = ? AND "+quoteIdent(table.AssigneeColumn)+" = ?", tenantID, userID).
+		Where(table.OpenCondition).
+		Order("id").
+		Scan(&rows).Error; err != nil {
+		return nil, false, fmt.Errorf("failed to list open %s: %w", table.Name, err)
+	}
+
+	moved := &ReassignedTable{Table: table.Name, Items: make([]ReassignedItem, 0, len(rows))}
+	for i, row := range rows {
+		var assignee *domain.User
+		if len(assignees) > 0 {
+			assignee = assignees[i%len(assignees)]
+		}
+
+		item := ReassignedItem{ID: row.ID}
+		var agentID *int
+		if assignee != nil {
+			item.AssignedTo = &assignee.ID
+			agentID = assignee.ChatwootAgentID
+		}
+
+		if err := tx.Table(quoteIdent(table.Name)).
+			Where("id = ?", row.ID).
+			Update(table.AssigneeColumn, item.AssignedTo).Error; err != nil {
+			return nil, false, fmt.Errorf("failed to reassign %s %s: %w", table.Name, row.ID, err)
+		}
+
+		moved.Items = append(moved.Items, item)
+		if row.ChatwootID != nil {
+			summary.chatwootAssignments = append(summary.chatwootAssignments, chatwootAssignment{
+				ConversationID: *row.ChatwootID,
+				AgentID:        agentID,
+			})
+		}
+	}
+	moved.Count = len(moved.Items)
+
+	return moved, true, nil
+}
+
+// syncChatwoot mirrors the reassigned conversations in Chatwoot and takes the
+// agent of the offboarded user offline. Failures are reported in the summary.
+func (s *UserOffboardingService) syncChatwoot(user *domain.User, summary *UserOffboardingSummary) {
+	client := chatwootFor(s.chatwoot, user.TenantID)
+	if client == nil {
+		return
+	}
+
+	for _, assignment := range summary.chatwootAssignments {
+		var err error
+		if assignment.AgentID != nil {
+			err = client.AssignAgent(assignment.ConversationID, *assignment.AgentID)
+		} else {
+			err = client.UnassignConversation(assignment.ConversationID)
+		}
+		if err != nil {
+			log.Printf("Failed to reassign Chatwoot conversation %d: %v", assignment.ConversationID, err)
+			summary.ChatwootFailed = append(summary.ChatwootFailed, assignment.ConversationID)
+			continue
+		}
+		summary.ChatwootUpdated++
+	}
+
+	if user.ChatwootAgentID != nil {
+		if err := client.UpdateAgentAvailability(*user.ChatwootAgentID, chatwoot.AvailabilityOffline); err != nil {
+			log.Printf("Failed to set Chatwoot agent %d offline: %v", *user.ChatwootAgentID, err)
+		}
+	}
+}
+
+// revokeCredentials revokes the credentials of a user in a table. ok is
+// false when the table does not exist yet.
+func revokeCredentials(tx *gorm.DB, table domain.CredentialTable, tenantID, userID uuid.UUID, now time.Time) (int64, bool, error) {
+	columns, err := tableColumns(tx, table.Name)
+	if err != nil {
+		return 0, false, err
+	}
+	if !containsString(columns, "tenant_id") || !containsString(columns, table.UserColumn) || !containsString(columns, table.RevokedColumn) {
+		return 0, false, nil
+	}
+
+	result := tx.Table(quoteIdent(table.Name)).
+		Where("tenant_id = ? AND "+quoteIdent(table.UserColumn)+" = ? AND "+quoteIdent(table.RevokedColumn)+" IS NULL", tenantID, userID).
+		Update(table.RevokedColumn, now)
+	if result.Error != nil {
+		return 0, false, fmt.Errorf("failed to revoke %s: %w", table.Name, result.Error)
+	}
+	return result.RowsAffected, true, nil
+}
+
+func reassignedCounts(tables []ReassignedTable) map[string]int {
+	counts := make(map[string]int, len(tables))
+	for _, table := range tables {
+		counts[table.Table] = table.Count
+	}
+	return counts
+}
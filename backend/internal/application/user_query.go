@@ -0,0 +1,137 @@
+package application
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+)
+
+var (
+	ErrInvalidUserQuery = errors.New("invalid user query")
+	ErrInvalidCursor    = errors.New("invalid or expired cursor")
+)
+
+// UserListParams are the filters, sorting and pagination of the user list
+type UserListParams struct {
+	Search          string
+	Roles           []string
+	IsActive        *bool
+	LastLoginAfter  *time.Time
+	LastLoginBefore *time.Time
+	NeverLoggedIn   bool
+	EmailBounced    bool
+	// Sort is a sortable field, prefixed with "-" for descending order
+	Sort   string
+	Limit  int
+	Cursor string
+}
+
+// UserList is a page of the user list
+type UserList struct {
+	Data       []*domain.User `json:"data"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Total      int64          `json:"total"`
+	Limit      int            `json:"limit"`
+}
+
+// userListCursor is the decoded form of the opaque cursor handed to clients.
+// It records the sort it was created for, so it cannot be reused with another one.
+type userListCursor struct {
+	Sort string `json:"s"`
+	domain.UserCursor
+}
+
+// QueryUsers returns a page of the tenant users. The page size defaults to
+// domain.DefaultUserPageSize and is capped at domain.MaxUserPageSize.
+func (s *UserService) QueryUsers(tenantID uuid.UUID, params UserListParams) (*UserList, error) {
+	sort := params.Sort
+	if sort == "" {
+		sort = domain.UserSortCreatedAt
+	}
+	field := strings.TrimPrefix(sort, "-")
+	if !domain.IsValidUserSort(field) {
+		return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidUserQuery, field)
+	}
+
+	if params.LastLoginAfter != nil && params.LastLoginBefore != nil && !params.LastLoginAfter.Before(*params.LastLoginBefore) {
+		return nil, fmt.Errorf("%w: last login range is empty", ErrInvalidUserQuery)
+	}
+	if params.NeverLoggedIn && (params.LastLoginAfter != nil || params.LastLoginBefore != nil) {
+		return nil, fmt.Errorf("%w: never logged in cannot be combined with a last login range", ErrInvalidUserQuery)
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = domain.DefaultUserPageSize
+	}
+	if limit > domain.MaxUserPageSize {
+		limit = domain.MaxUserPageSize
+	}
+
+	query := domain.UserQuery{
+		TenantID:        tenantID,
+		Search:          strings.TrimSpace(params.Search),
+		Roles:           params.Roles,
+		IsActive:        params.IsActive,
+		LastLoginAfter:  params.LastLoginAfter,
+		LastLoginBefore: params.LastLoginBefore,
+		NeverLoggedIn:   params.NeverLoggedIn,
+		EmailBounced:    params.EmailBounced,
+		SortBy:          field,
+		SortDesc:        strings.HasPrefix(sort, "-"),
+		Limit:           limit,
+	}
+
+	if params.Cursor != "" {
+		cursor, err := decodeUserCursor(params.Cursor)
+		if err != nil || cursor.Sort != sort {
+			return nil, ErrInvalidCursor
+		}
+		query.After = &cursor.UserCursor
+	}
+
+	page, err := s.userRepo.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UserList{
+		Data:  page.Users,
+		Total: page.Total,
+		Limit: limit,
+	}
+	if result.Data == nil {
+		result.Data = []*domain.User{}
+	}
+	if page.Next != nil {
+		result.NextCursor = encodeUserCursor(userListCursor{Sort: sort, UserCursor: *page.Next})
+	}
+	return result, nil
+}
+
+func encodeUserCursor(cursor userListCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeUserCursor(value string) (*userListCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor userListCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
@@ -1,6 +1,7 @@
 package application
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -8,6 +9,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/events"
 	"gorm.io/gorm"
 )
 
@@ -21,20 +24,24 @@ var (
 	ErrCannotDeleteSelf   = errors.New("cannot delete your own account")
 	ErrLastAdmin          = errors.New("cannot delete or deactivate the last admin")
 	ErrWrongPassword      = errors.New("incorrect password")
+	ErrOwnerAssignment    = errors.New("owner role can only be given through an ownership transfer")
+	ErrOwnerProtected     = errors.New("the tenant owner cannot be demoted, deactivated or deleted")
+	ErrInvalidLocale      = errors.New("unsupported locale")
 )
 
-// Valid roles
-var ValidRoles = []string{"owner", "admin", "agent", "viewer"}
-
 type UserService struct {
-	db       *gorm.DB
-	userRepo domain.UserRepository
+	db           *gorm.DB
+	userRepo     domain.UserRepository
+	roleService  *RoleService
+	quotaService *QuotaService
 }
 
-func NewUserService(db *gorm.DB, userRepo domain.UserRepository) *UserService {
+func NewUserService(db *gorm.DB, userRepo domain.UserRepository, roleService *RoleService, quotaService *QuotaService) *UserService {
 	return &UserService{
-		db:       db,
-		userRepo: userRepo,
+		db:           db,
+		userRepo:     userRepo,
+		roleService:  roleService,
+		quotaService: quotaService,
 	}
 }
 
@@ -45,9 +52,9 @@ func (s *UserService) CreateUser(tenantID uuid.UUID, email, password, name, role
 		return nil, ErrInvalidEmail
 	}
 
-	// Validate role
-	if !isValidRole(role) {
-		return nil, ErrInvalidRole
+	// Validate role, either a system role or a custom role of the tenant
+	if err := s.validateRole(tenantID, role); err != nil {
+		return nil, err
 	}
 
 	// Validate password
@@ -61,18 +68,11 @@ func (s *UserService) CreateUser(tenantID uuid.UUID, email, password, name, role
 		return nil, ErrUserEmailExists
 	}
 
-	// Check user limit based on plan (TODO: implement plan limits)
-	currentCount, err := s.userRepo.CountByTenant(tenantID)
-	if err != nil {
+	// Check seat limit of the tenant plan
+	if err := s.quotaService.Check(tenantID, domain.QuotaSeats, 1); err != nil {
 		return nil, err
 	}
 
-	// Example limit check (should be based on actual plan)
-	maxUsers := s.getMaxUsersForTenant(tenantID)
-	if currentCount >= maxUsers {
-		return nil, ErrUserLimitReached
-	}
-
 	// Create user
 	user := &domain.User{
 		TenantID: tenantID,
@@ -90,6 +90,12 @@ func (s *UserService) CreateUser(tenantID uuid.UUID, email, password, name, role
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	events.Publish(context.Background(), events.Event{
+		Name:     domain.EventUserCreated,
+		TenantID: tenantID,
+		Payload:  map[string]interface{}{"user_id": user.ID, "role": user.Role},
+	})
+
 	return user, nil
 }
 
@@ -153,17 +159,23 @@ func (s *UserService) UpdateUser(id uuid.UUID, updates map[string]interface{}) (
 			if existingUser != nil && existingUser.ID != user.ID {
 				return nil, ErrUserEmailExists
 			}
+			// A new address has not bounced yet
+			user.EmailBouncedAt = nil
 		}
 		user.Email = email
 	}
 
-	if role, ok := updates["role"].(string); ok {
-		if !isValidRole(role) {
-			return nil, ErrInvalidRole
+	if role, ok := updates["role"].(string); ok && role != user.Role {
+		// The owner keeps their role until they transfer the ownership
+		if user.Role == domain.RoleOwner {
+			return nil, ErrOwnerProtected
+		}
+		if err := s.validateRole(user.TenantID, role); err != nil {
+			return nil, err
 		}
 
 		// Check if this is the last admin
-		if user.Role == "admin" && role != "admin" {
+		if domain.IsAdminRole(user.Role) && !domain.IsAdminRole(role) {
 			if err := s.checkLastAdmin(user.TenantID, user.ID); err != nil {
 				return nil, err
 			}
@@ -171,21 +183,60 @@ func (s *UserService) UpdateUser(id uuid.UUID, updates map[string]interface{}) (
 		user.Role = role
 	}
 
+	deactivated := false
 	if isActive, ok := updates["is_active"].(bool); ok {
+		if user.Role == domain.RoleOwner && !isActive {
+			return nil, ErrOwnerProtected
+		}
+
 		// Check if deactivating the last admin
-		if user.IsActive && !isActive && user.Role == "admin" {
+		if user.IsActive && !isActive && domain.IsAdminRole(user.Role) {
 			if err := s.checkLastAdmin(user.TenantID, user.ID); err != nil {
 				return nil, err
 			}
 		}
+		deactivated = user.IsActive && !isActive
 		user.IsActive = isActive
 	}
 
+	if limit, ok := updates["max_concurrent_conversations"].(float64); ok && limit >= 0 {
+		user.MaxConcurrentConversations = int(limit)
+	}
+
+	// An empty locale makes the user receive emails in the tenant locale
+	if locale, ok := updates["locale"].(string); ok {
+		if locale != "" {
+			locale = email.NormalizeLocale(locale)
+			if locale == "" {
+				return nil, ErrInvalidLocale
+			}
+		}
+		user.Locale = locale
+	}
+
+	// Link or unlink the Chatwoot agent of the user
+	if agentID, ok := updates["chatwoot_agent_id"]; ok {
+		switch value := agentID.(type) {
+		case nil:
+			user.ChatwootAgentID = nil
+		case float64:
+			id := int(value)
+			user.ChatwootAgentID = &id
+		}
+	}
+
 	// Save changes
 	if err := s.userRepo.Update(user); err != nil {
 		return nil, err
 	}
 
+	// A deactivated user cannot renew their session
+	if deactivated {
+		if err := s.revokeSessions(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
 	return user, nil
 }
 
@@ -204,13 +255,21 @@ func (s *UserService) DeleteUser(id uuid.UUID, currentUserID uuid.UUID) error {
 		return err
 	}
 
+	if user.Role == domain.RoleOwner {
+		return ErrOwnerProtected
+	}
+
 	// Check if this is the last admin
-	if user.Role == "admin" {
+	if domain.IsAdminRole(user.Role) {
 		if err := s.checkLastAdmin(user.TenantID, user.ID); err != nil {
 			return err
 		}
 	}
 
+	if err := s.revokeSessions(id); err != nil {
+		return err
+	}
+
 	return s.userRepo.Delete(id)
 }
 
@@ -296,13 +355,19 @@ func (s *UserService) GetUserStats(tenantID uuid.UUID) (map[string]interface{},
 		roleCount[user.Role]++
 	}
 
+	seats, err := s.quotaService.GetUsage(tenantID, domain.QuotaSeats)
+	if err != nil {
+		return nil, err
+	}
+
 	return map[string]interface{}{
-		"total":       len(users),
-		"active":      activeCount,
-		"inactive":    len(users) - activeCount,
-		"by_role":     roleCount,
-		"limit":       s.getMaxUsersForTenant(tenantID),
-		"remaining":   s.getMaxUsersForTenant(tenantID) - int64(len(users)),
+		"total":     len(users),
+		"active":    activeCount,
+		"inactive":  len(users) - activeCount,
+		"by_role":   roleCount,
+		"limit":     seats.Limit,
+		"remaining": seats.Remaining,
+		"unlimited": seats.Unlimited,
 	}, nil
 }
 
@@ -327,13 +392,30 @@ func isValidEmail(email string) bool {
 	return true
 }
 
-func isValidRole(role string) bool {
-	for _, validRole := range ValidRoles {
-		if role == validRole {
-			return true
-		}
+func (s *UserService) validateRole(tenantID uuid.UUID, role string) error {
+	// Each tenant has exactly one owner, set at registration or by a transfer
+	if role == domain.RoleOwner {
+		return ErrOwnerAssignment
+	}
+
+	exists, err := s.roleService.RoleExists(tenantID, role)
+	if err != nil {
+		return err
 	}
-	return false
+	if !exists {
+		return ErrInvalidRole
+	}
+	return nil
+}
+
+// revokeSessions revokes the refresh tokens of a user
+func (s *UserService) revokeSessions(userID uuid.UUID) error {
+	return s.db.Model(&domain.RefreshToken{}).
+		Where("user_id = ? AND revoked = false", userID).
+		Updates(map[string]interface{}{
+			"revoked":    true,
+			"revoked_at": time.Now(),
+		}).Error
 }
 
 func (s *UserService) checkLastAdmin(tenantID uuid.UUID, excludeUserID uuid.UUID) error {
@@ -344,7 +426,8 @@ func (s *UserService) checkLastAdmin(tenantID uuid.UUID, excludeUserID uuid.UUID
 
 	adminCount := 0
 	for _, user := range users {
-		if user.ID != excludeUserID && user.Role == "admin" && user.IsActive {
+		// Custom roles do not count, so the tenant always keeps a built-in administrator
+		if user.ID != excludeUserID && domain.IsAdminRole(user.Role) && user.IsActive {
 			adminCount++
 		}
 	}
@@ -355,9 +438,3 @@ func (s *UserService) checkLastAdmin(tenantID uuid.UUID, excludeUserID uuid.UUID
 
 	return nil
 }
-
-func (s *UserService) getMaxUsersForTenant(tenantID uuid.UUID) int64 {
-	// TODO: Implement actual plan limits based on subscription
-	// For now, return default limits
-	return 100 // Default limit
-}
\ No newline at end of file
@@ -0,0 +1,52 @@
+package domain
+
+// Targets of the work of an offboarded user
+const (
+	ReassignToUser  = "user"
+	ReassignToTeam  = "team"
+	ReassignToQueue = "queue"
+)
+
+// AssignableTable describes a tenant scoped table whose rows are assigned to
+// a user. Tables or columns that do not exist in the database yet are skipped.
+type AssignableTable struct {
+	Name string
+	// AssigneeColumn references the user the row is assigned to
+	AssigneeColumn string
+	// OpenCondition selects the rows still being worked on
+	OpenCondition string
+	// ChatwootColumn holds the Chatwoot conversation of the row, if any
+	ChatwootColumn string
+}
+
+// AssignableTables lists the work assigned to users, reassigned when a user
+// is offboarded
+var AssignableTables = []AssignableTable{
+	{Name: "conversations", AssigneeColumn: "assigned_to", OpenCondition: "status <> 'resolved'", ChatwootColumn: "chatwoot_conversation_id"},
+	{Name: "leads", AssigneeColumn: "assigned_to", OpenCondition: "stage NOT IN ('won', 'lost')"},
+	{Name: "deals", AssigneeColumn: "owner_id", OpenCondition: "won_at IS NULL AND lost_at IS NULL"},
+	{Name: "activities", AssigneeColumn: "assigned_to", OpenCondition: "completed_at IS NULL"},
+}
+
+// CredentialTable describes a table of long lived credentials of users, such
+// as API keys, revoked when a user is offboarded
+type CredentialTable struct {
+	Name          string
+	UserColumn    string
+	RevokedColumn string
+}
+
+// UserCredentialTables lists the credentials revoked when a user is offboarded
+var UserCredentialTables = []CredentialTable{
+	{Name: "api_keys", UserColumn: "user_id", RevokedColumn: "revoked_at"},
+}
+
+// FindAssignableTable returns the assignable table with the given name
+func FindAssignableTable(name string) (AssignableTable, bool) {
+	for _, table := range AssignableTables {
+		if table.Name == name {
+			return table, true
+		}
+	}
+	return AssignableTable{}, false
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit log actions
+const (
+	AuditTenantDeletionRequested  = "tenant.deletion_requested"
+	AuditTenantRestored           = "tenant.restored"
+	AuditTenantPurged             = "tenant.purged"
+	AuditTenantSlugChanged        = "tenant.slug_changed"
+	AuditOwnershipTransferStarted = "tenant.ownership_transfer_requested"
+	AuditOwnershipTransferred     = "tenant.ownership_transferred"
+	AuditUserOffboarded           = "user.offboarded"
+)
+
+// AuditLog is an entry of the tenant audit trail
+type AuditLog struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID   uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	UserID     *uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	Action     string     `json:"action" gorm:"type:varchar(100);not null"`
+	EntityType string     `json:"entity_type" gorm:"type:varchar(50)"`
+	EntityID   *uuid.UUID `json:"entity_id" gorm:"type:uuid"`
+	Changes    JSON       `json:"changes" gorm:"type:jsonb"`
+	IPAddress  *string    `json:"ip_address" gorm:"type:inet"`
+	UserAgent  string     `json:"user_agent" gorm:"type:text"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for the AuditLog model
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+type AuditLogRepository interface {
+	Create(entry *AuditLog) error
+	FindByTenant(tenantID uuid.UUID, limit int) ([]*AuditLog, error)
+	FindLatestByAction(tenantID uuid.UUID, action string) (*AuditLog, error)
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BillingAccount links a tenant to its customer and subscription at the billing provider
+type BillingAccount struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID          uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Provider          string     `json:"provider" gorm:"type:varchar(50);not null"`
+	CustomerID        string     `json:"customer_id" gorm:"type:varchar(255);not null;index"`
+	SubscriptionID    *string    `json:"subscription_id" gorm:"type:varchar(255);index"`
+	PriceID           string     `json:"price_id" gorm:"type:varchar(255)"`
+	Status            string     `json:"status" gorm:"type:varchar(50)"`
+	CurrentPeriodEnd  *time.Time `json:"current_period_end"`
+	CancelAtPeriodEnd bool       `json:"cancel_at_period_end" gorm:"default:false"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the BillingAccount model
+func (BillingAccount) TableName() string {
+	return "billing_accounts"
+}
+
+// Invoice is a local copy of a provider invoice
+type Invoice struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID          uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	ProviderInvoiceID string     `json:"provider_invoice_id" gorm:"type:varchar(255);not null;unique"`
+	Number            string     `json:"number" gorm:"type:varchar(100)"`
+	Status            string     `json:"status" gorm:"type:varchar(50)"`
+	AmountDue         int64      `json:"amount_due"`
+	AmountPaid        int64      `json:"amount_paid"`
+	Currency          string     `json:"currency" gorm:"type:varchar(3)"`
+	HostedURL         string     `json:"hosted_url" gorm:"type:text"`
+	PDFURL            string     `json:"pdf_url" gorm:"type:text"`
+	PeriodStart       *time.Time `json:"period_start"`
+	PeriodEnd         *time.Time `json:"period_end"`
+	IssuedAt          time.Time  `json:"issued_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the Invoice model
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// Billing webhook processing states
+const (
+	WebhookEventProcessing = "processing"
+	WebhookEventProcessed  = "processed"
+	WebhookEventFailed     = "failed"
+)
+
+// BillingWebhookEvent records every webhook received so retries are processed once
+type BillingWebhookEvent struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Provider    string     `json:"provider" gorm:"type:varchar(50);not null;uniqueIndex:idx_billing_webhook_events_provider_event"`
+	EventID     string     `json:"event_id" gorm:"type:varchar(255);not null;uniqueIndex:idx_billing_webhook_events_provider_event"`
+	Type        string     `json:"type" gorm:"type:varchar(100);not null"`
+	Status      string     `json:"status" gorm:"type:varchar(20);not null"`
+	Error       string     `json:"error" gorm:"type:text"`
+	Payload     string     `json:"-" gorm:"type:jsonb"`
+	ProcessedAt *time.Time `json:"processed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the BillingWebhookEvent model
+func (BillingWebhookEvent) TableName() string {
+	return "billing_webhook_events"
+}
+
+type BillingAccountRepository interface {
+	FindByTenant(tenantID uuid.UUID) (*BillingAccount, error)
+	FindByCustomer(provider, customerID string) (*BillingAccount, error)
+	Save(account *BillingAccount) error
+}
+
+type InvoiceRepository interface {
+	FindByTenant(tenantID uuid.UUID, limit int) ([]*Invoice, error)
+	Upsert(invoice *Invoice) error
+}
+
+type BillingWebhookEventRepository interface {
+	// Create inserts the event unless it was already received and reports
+	// whether it was inserted
+	Create(event *BillingWebhookEvent) (bool, error)
+	// Claim marks as processing an event that failed, or whose processing
+	// started before staleBefore, and returns it; nil when the event is
+	// processed or being processed
+	Claim(provider, eventID string, staleBefore time.Time) (*BillingWebhookEvent, error)
+	Save(event *BillingWebhookEvent) error
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "encoding/json"
+
+// BrandingSettingsKey is the tenant settings entry holding the branding
+const BrandingSettingsKey = "branding"
+
+// TenantBranding customizes how the platform presents itself on behalf of a
+// tenant: public pages and transactional emails
+type TenantBranding struct {
+	DisplayName     string `json:"display_name,omitempty"`
+	LogoKey         string `json:"logo_key,omitempty"`
+	LogoContentType string `json:"logo_content_type,omitempty"`
+	PrimaryColor    string `json:"primary_color,omitempty"`
+	SecondaryColor  string `json:"secondary_color,omitempty"`
+	SupportEmail    string `json:"support_email,omitempty"`
+	Footer          string `json:"footer,omitempty"`
+}
+
+// Branding returns the branding stored in the tenant settings
+func (t *Tenant) Branding() TenantBranding {
+	var branding TenantBranding
+
+	raw, ok := t.Settings[BrandingSettingsKey]
+	if !ok {
+		return branding
+	}
+
+	// Settings are loosely typed; round trip through JSON to decode the entry
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return branding
+	}
+	_ = json.Unmarshal(data, &branding)
+	return branding
+}
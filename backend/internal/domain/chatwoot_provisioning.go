@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Chatwoot provisioning states. A failing step is retried with backoff until
+// ChatwootProvisioningMaxAttempts; the resources already created are then
+// removed and the provisioning fails until it is retried by hand.
+const (
+	ProvisioningPending      = "pending"
+	ProvisioningCompleted    = "completed"
+	ProvisioningCompensating = "compensating"
+	ProvisioningFailed       = "failed"
+)
+
+// Chatwoot provisioning steps, in order
+const (
+	ProvisioningStepCreateAccount = "create_account"
+	ProvisioningStepCreateAgent   = "create_agent"
+	ProvisioningStepCreateInbox   = "create_inbox"
+	ProvisioningStepConnect       = "connect"
+)
+
+// ChatwootProvisioningSteps are the steps run for a new tenant
+var ChatwootProvisioningSteps = []string{
+	ProvisioningStepCreateAccount,
+	ProvisioningStepCreateAgent,
+	ProvisioningStepCreateInbox,
+	ProvisioningStepConnect,
+}
+
+const (
+	// ChatwootProvisioningMaxAttempts is the number of tries of a step before
+	// the provisioning is compensated
+	ChatwootProvisioningMaxAttempts = 6
+	// ChatwootProvisioningRetryBaseDelay is the wait after the first failure; it doubles with every attempt
+	ChatwootProvisioningRetryBaseDelay = 30 * time.Second
+	// ChatwootProvisioningRetryMaxDelay caps the wait between two tries
+	ChatwootProvisioningRetryMaxDelay = time.Hour
+	// ChatwootProvisioningLockTimeout releases provisionings claimed by a worker that stopped
+	ChatwootProvisioningLockTimeout = 10 * time.Minute
+)
+
+// ChatwootProvisioningRetryDelay returns the wait before the next try after
+// the given number of failed attempts
+func ChatwootProvisioningRetryDelay(attempts int) time.Duration {
+	delay := ChatwootProvisioningRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= ChatwootProvisioningRetryMaxDelay {
+			return ChatwootProvisioningRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// ChatwootProvisioning is the saga creating the Chatwoot account of a new
+// tenant. It is written in the transaction creating the tenant and records
+// the ID of every resource as soon as it exists, so a step is never repeated
+// and the resources can be removed if a later step fails.
+type ChatwootProvisioning struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex"`
+	// UserID is the tenant admin who becomes an agent of the account
+	UserID uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
+
+	Status string `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	// Step is the next step to run, or the step that failed
+	Step string `json:"step" gorm:"type:varchar(30);not null"`
+
+	AccountID *int `json:"account_id"`
+	AgentID   *int `json:"agent_id"`
+	InboxID   *int `json:"inbox_id"`
+
+	Attempts      int       `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time `json:"next_attempt_at" gorm:"not null"`
+	LastError     string    `json:"last_error,omitempty" gorm:"type:text"`
+	// LockedUntil is set while a worker runs the provisioning
+	LockedUntil *time.Time `json:"-"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the ChatwootProvisioning model
+func (ChatwootProvisioning) TableName() string {
+	return "chatwoot_provisionings"
+}
+
+// NewChatwootProvisioning returns the provisioning of a new tenant, due now
+func NewChatwootProvisioning(tenantID, userID uuid.UUID, now time.Time) *ChatwootProvisioning {
+	return &ChatwootProvisioning{
+		TenantID:      tenantID,
+		UserID:        userID,
+		Status:        ProvisioningPending,
+		Step:          ProvisioningStepCreateAccount,
+		NextAttemptAt: now,
+	}
+}
+
+type ChatwootProvisioningRepository interface {
+	Create(provisioning *ChatwootProvisioning) error
+	FindByTenant(tenantID uuid.UUID) (*ChatwootProvisioning, error)
+	// ClaimDue locks up to limit pending or compensating provisionings due
+	// for a try and returns them. Provisionings locked by another worker are
+	// skipped.
+	ClaimDue(now time.Time, limit int) ([]*ChatwootProvisioning, error)
+	Update(provisioning *ChatwootProvisioning) error
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Chatwoot webhook delivery states. A pending delivery is stored but its
+// event was not published yet, or the process stopped before it was.
+const (
+	ChatwootWebhookPending   = "pending"
+	ChatwootWebhookProcessed = "processed"
+	ChatwootWebhookFailed    = "failed"
+)
+
+const (
+	// ChatwootWebhookRedispatchAfter is the wait before a pending delivery is
+	// published again by the sweep
+	ChatwootWebhookRedispatchAfter = 5 * time.Minute
+	// ChatwootWebhookRetention is how long deliveries are kept to detect
+	// duplicates once published
+	ChatwootWebhookRetention = 7 * 24 * time.Hour
+)
+
+// ChatwootWebhookDelivery records a webhook received from the Chatwoot account
+// of a tenant, so a delivery retried by Chatwoot is published once
+type ChatwootWebhookDelivery struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_chatwoot_webhook_deliveries_delivery,priority:1"`
+	// DeliveryID is the delivery header sent by Chatwoot, or the hash of the body
+	DeliveryID     string     `json:"delivery_id" gorm:"type:varchar(255);not null;uniqueIndex:idx_chatwoot_webhook_deliveries_delivery,priority:2"`
+	Event          string     `json:"event" gorm:"type:varchar(50);not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	Payload        string     `json:"-" gorm:"type:jsonb;not null"`
+	FailedHandlers int        `json:"failed_handlers" gorm:"not null;default:0"`
+	ProcessedAt    *time.Time `json:"processed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the ChatwootWebhookDelivery model
+func (ChatwootWebhookDelivery) TableName() string {
+	return "chatwoot_webhook_deliveries"
+}
+
+type ChatwootWebhookRepository interface {
+	// Create stores a delivery and reports false when it was already received
+	Create(delivery *ChatwootWebhookDelivery) (bool, error)
+	// MarkPublished records the outcome of the handlers of a delivery
+	MarkPublished(id uuid.UUID, failedHandlers int, now time.Time) error
+	// ClaimPending returns up to limit deliveries left pending since before,
+	// touching them so other workers skip them
+	ClaimPending(before, now time.Time, limit int) ([]*ChatwootWebhookDelivery, error)
+	// DeletePublishedBefore deletes the published deliveries received before a time
+	DeletePublishedBefore(before time.Time) (int64, error)
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of email delivery events
+const (
+	DeliveryEventBounce    = "bounce"
+	DeliveryEventComplaint = "complaint"
+	// DeliveryEventSuppressed is an email that was not sent because the
+	// recipient is on the suppression list
+	DeliveryEventSuppressed = "suppressed"
+)
+
+// Bounce types. A hard bounce is a permanent failure, such as an unknown
+// mailbox; a soft bounce is temporary, such as a full mailbox.
+const (
+	BounceHard = "hard"
+	BounceSoft = "soft"
+)
+
+// Sources of delivery events
+const (
+	DeliverySourceWebhook = "webhook"
+	DeliverySourceDSN     = "dsn"
+	DeliverySourceSender  = "sender"
+)
+
+// Reasons a recipient is on the suppression list
+const (
+	SuppressionHardBounce = "hard_bounce"
+	SuppressionComplaint  = "complaint"
+	SuppressionManual     = "manual"
+)
+
+// EmailDeliveryEvent is something that happened to an email after it left
+// the outbox: a bounce or a complaint reported by the receiving side, or a
+// send skipped because the recipient is suppressed
+type EmailDeliveryEvent struct {
+	ID uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	// TenantID is nil when the email could not be attributed to a tenant
+	TenantID   *uuid.UUID `json:"tenant_id" gorm:"type:uuid;index:idx_email_delivery_events_recipient,priority:1"`
+	Recipient  string     `json:"recipient" gorm:"type:varchar(320);not null;index:idx_email_delivery_events_recipient,priority:2"`
+	Type       string     `json:"type" gorm:"type:varchar(20);not null"`
+	BounceType string     `json:"bounce_type,omitempty" gorm:"type:varchar(20)"`
+	Source     string     `json:"source" gorm:"type:varchar(20);not null"`
+	// Diagnostic is the explanation of the receiving server, such as an SMTP reply
+	Diagnostic string `json:"diagnostic,omitempty" gorm:"type:text"`
+	// EmailID is the outbox email the event is about, when known
+	EmailID *uuid.UUID `json:"email_id" gorm:"type:uuid"`
+	// ExternalID identifies the event at the provider, so redelivered
+	// webhooks are recorded once
+	ExternalID string    `json:"-" gorm:"type:varchar(255);uniqueIndex:idx_email_delivery_events_external,where:external_id <> ''"`
+	OccurredAt time.Time `json:"occurred_at" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for the EmailDeliveryEvent model
+func (EmailDeliveryEvent) TableName() string {
+	return "email_delivery_events"
+}
+
+// Suppresses reports whether the event stops further emails to the recipient
+func (e *EmailDeliveryEvent) Suppresses() bool {
+	return e.Type == DeliveryEventComplaint || (e.Type == DeliveryEventBounce && e.BounceType == BounceHard)
+}
+
+// EmailSuppression is an address the emails of a tenant are no longer sent to
+type EmailSuppression struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_email_suppressions_tenant_email,priority:1"`
+	// Email is stored lowercased
+	Email     string    `json:"email" gorm:"type:varchar(320);not null;uniqueIndex:idx_email_suppressions_tenant_email,priority:2"`
+	Reason    string    `json:"reason" gorm:"type:varchar(20);not null"`
+	Detail    string    `json:"detail,omitempty" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for the EmailSuppression model
+func (EmailSuppression) TableName() string {
+	return "email_suppressions"
+}
+
+// EmailDeliveryFilter selects delivery events; zero fields match everything
+type EmailDeliveryFilter struct {
+	TenantID  *uuid.UUID
+	Recipient string
+	Type      string
+	Limit     int
+}
+
+type EmailDeliveryRepository interface {
+	// CreateEvent stores an event and reports whether it was new; an event
+	// with the ExternalID of a stored one is ignored
+	CreateEvent(event *EmailDeliveryEvent) (bool, error)
+	// ListEvents returns the matching events, newest first
+	ListEvents(filter EmailDeliveryFilter) ([]*EmailDeliveryEvent, error)
+	// Suppress adds an address to the suppression list of a tenant, keeping
+	// the existing entry if the address is already suppressed
+	Suppress(suppression *EmailSuppression) error
+	FindSuppression(tenantID uuid.UUID, email string) (*EmailSuppression, error)
+	IsSuppressed(tenantID uuid.UUID, email string) (bool, error)
+	ListSuppressions(tenantID uuid.UUID) ([]*EmailSuppression, error)
+	DeleteSuppression(tenantID uuid.UUID, email string) error
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Email outbox states. A failed email is retried until EmailMaxAttempts,
+// after which it is dead and only retried by hand.
+const (
+	EmailPending = "pending"
+	EmailSending = "sending"
+	EmailSent    = "sent"
+	EmailFailed  = "failed"
+	EmailDead    = "dead"
+)
+
+const (
+	// EmailMaxAttempts is the number of deliveries tried before an email is dead
+	EmailMaxAttempts = 8
+	// EmailRetryBaseDelay is the wait after the first failed delivery; it doubles with every attempt
+	EmailRetryBaseDelay = time.Minute
+	// EmailRetryMaxDelay caps the wait between two deliveries
+	EmailRetryMaxDelay = 6 * time.Hour
+	// EmailSendingTimeout releases emails left sending by a worker that stopped
+	EmailSendingTimeout = 10 * time.Minute
+)
+
+// EmailRetryDelay returns the wait before the next delivery after the given
+// number of failed attempts
+func EmailRetryDelay(attempts int) time.Duration {
+	delay := EmailRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= EmailRetryMaxDelay {
+			return EmailRetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// EmailOutboxMessage is an email waiting for delivery, written in the same
+// transaction as the change that triggered it
+type EmailOutboxMessage struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID      *uuid.UUID `json:"tenant_id" gorm:"type:uuid;index"`
+	FromAddress   string     `json:"from_address" gorm:"type:varchar(320);not null"`
+	ToAddress     string     `json:"to_address" gorm:"type:varchar(320);not null"`
+	ReplyTo       string     `json:"reply_to" gorm:"type:varchar(320)"`
+	Subject       string     `json:"subject" gorm:"type:varchar(998);not null"`
+	TextBody      string     `json:"text_body" gorm:"type:text"`
+	HTMLBody      string     `json:"html_body" gorm:"type:text"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null"`
+	LastError     string     `json:"last_error,omitempty" gorm:"type:text"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the EmailOutboxMessage model
+func (EmailOutboxMessage) TableName() string {
+	return "email_outbox"
+}
+
+// EmailOutboxFilter selects outbox messages; zero fields match everything
+type EmailOutboxFilter struct {
+	Status   string
+	TenantID *uuid.UUID
+	// To matches the recipient address, case insensitively
+	To    string
+	Limit int
+}
+
+type EmailOutboxRepository interface {
+	Create(msg *EmailOutboxMessage) error
+	FindByID(id uuid.UUID) (*EmailOutboxMessage, error)
+	// List returns the matching messages, newest first
+	List(filter EmailOutboxFilter) ([]*EmailOutboxMessage, error)
+	// CountByStatus returns the number of messages in each state
+	CountByStatus() (map[string]int64, error)
+	// ClaimDue marks up to limit messages due for delivery as sending and
+	// returns them. Messages claimed by another worker are skipped.
+	ClaimDue(now time.Time, limit int) ([]*EmailOutboxMessage, error)
+	Update(msg *EmailOutboxMessage) error
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEmailRetryDelay(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		4:  8 * time.Minute,
+		20: EmailRetryMaxDelay,
+	}
+
+	for attempts, want := range cases {
+		if got := EmailRetryDelay(attempts); got != want {
+			t.Errorf("EmailRetryDelay(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
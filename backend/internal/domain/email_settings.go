@@ -0,0 +1,38 @@
+package domain
+
+import "encoding/json"
+
+// EmailSettingsKey is the tenant settings entry holding the email settings
+const EmailSettingsKey = "email"
+
+// EmailTemplateOverride replaces the default subject or intro of an email
+// template; empty fields keep the default
+type EmailTemplateOverride struct {
+	Subject string `json:"subject,omitempty"`
+	Intro   string `json:"intro,omitempty"`
+}
+
+// TenantEmailSettings customizes the transactional emails sent on behalf of a tenant
+type TenantEmailSettings struct {
+	// Locale of the emails of users who did not choose one
+	Locale    string                           `json:"locale,omitempty"`
+	Templates map[string]EmailTemplateOverride `json:"templates,omitempty"`
+}
+
+// EmailSettings returns the email settings stored in the tenant settings
+func (t *Tenant) EmailSettings() TenantEmailSettings {
+	var settings TenantEmailSettings
+
+	raw, ok := t.Settings[EmailSettingsKey]
+	if !ok {
+		return settings
+	}
+
+	// Settings are loosely typed; round trip through JSON to decode the entry
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return settings
+	}
+	_ = json.Unmarshal(data, &settings)
+	return settings
+}
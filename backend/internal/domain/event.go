@@ -0,0 +1,22 @@
+package domain
+
+// Domain events published on the event bus
+const (
+	EventUserCreated          = "user.created"
+	EventChannelConnected     = "channel.connected"
+	EventBotFlowConfigured    = "bot_flow.configured"
+	EventBusinessHoursUpdated = "business_hours.updated"
+	EventDomainVerified       = "domain.verified"
+)
+
+// Chatwoot webhook events, published asynchronously with the decoded
+// *chatwoot.WebhookEvent under ChatwootEventPayloadKey
+const (
+	EventChatwootMessageCreated            = "chatwoot.message_created"
+	EventChatwootConversationCreated       = "chatwoot.conversation_created"
+	EventChatwootConversationStatusChanged = "chatwoot.conversation_status_changed"
+	EventChatwootConversationUpdated       = "chatwoot.conversation_updated"
+	EventChatwootContactCreated            = "chatwoot.contact_created"
+
+	ChatwootEventPayloadKey = "chatwoot"
+)
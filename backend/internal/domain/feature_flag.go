@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Feature keys known by the platform
+const (
+	FeatureChat     = "chat"
+	FeatureCRM      = "crm"
+	FeatureCalendar = "calendar"
+	// FeatureCustomMail lets a tenant send emails from its own domain
+	FeatureCustomMail = "custom_mail"
+)
+
+// FeatureFlag describes an entry of the feature catalog
+type FeatureFlag struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	// RolloutPercentage enables the flag for a stable share of the remaining tenants
+	RolloutPercentage int `json:"rollout_percentage"`
+}
+
+// FeatureCatalog is the list of flags that can be resolved for a tenant.
+// Plan defaults come from Plan.Features.
+var FeatureCatalog = []FeatureFlag{
+	{
+		Key:         FeatureChat,
+		Description: "Omnichannel chat inbox",
+	},
+	{
+		Key:         FeatureCRM,
+		Description: "Leads and deals pipeline",
+	},
+	{
+		Key:         FeatureCalendar,
+		Description: "Meeting scheduling",
+	},
+	{
+		Key:         FeatureCustomMail,
+		Description: "Emails sent from the tenant domain and SMTP relay",
+	},
+}
+
+// FindFeatureFlag looks up a flag in the catalog
+func FindFeatureFlag(key string) (FeatureFlag, bool) {
+	for _, flag := range FeatureCatalog {
+		if flag.Key == key {
+			return flag, true
+		}
+	}
+	return FeatureFlag{}, false
+}
+
+// TenantFeatureOverride forces a flag on or off for a single tenant
+type TenantFeatureOverride struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID  uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_tenant_feature"`
+	Feature   string     `json:"feature" gorm:"type:varchar(100);not null;uniqueIndex:idx_tenant_feature"`
+	Enabled   bool       `json:"enabled" gorm:"not null"`
+	Reason    string     `json:"reason" gorm:"type:text"`
+	UpdatedBy *uuid.UUID `json:"updated_by" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the TenantFeatureOverride model
+func (TenantFeatureOverride) TableName() string {
+	return "tenant_feature_overrides"
+}
+
+type FeatureOverrideRepository interface {
+	FindByTenant(tenantID uuid.UUID) ([]*TenantFeatureOverride, error)
+	FindByTenantAndFeature(tenantID uuid.UUID, feature string) (*TenantFeatureOverride, error)
+	Upsert(override *TenantFeatureOverride) error
+	Delete(tenantID uuid.UUID, feature string) error
+}
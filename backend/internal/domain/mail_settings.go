@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Mail delivery modes of a tenant
+const (
+	// MailModePlatform sends through the platform transport
+	MailModePlatform = "platform"
+	// MailModeSMTP sends through the SMTP relay of the tenant
+	MailModeSMTP = "smtp"
+)
+
+// Health of the SMTP relay of a tenant. Only healthy relays are used; the
+// others fall back to the platform transport.
+const (
+	MailHealthUnknown = "unknown"
+	MailHealthHealthy = "healthy"
+	MailHealthFailing = "failing"
+)
+
+const (
+	// MailHealthFailureThreshold is the number of consecutive failures after
+	// which a healthy relay is considered failing
+	MailHealthFailureThreshold = 3
+	// SenderVerificationExpiry is how long a sender verification link is valid
+	SenderVerificationExpiry = 48 * time.Hour
+)
+
+// TenantMailSettings configures how the emails of a tenant are delivered and
+// the address they are sent from
+type TenantMailSettings struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Mode     string    `json:"mode" gorm:"type:varchar(20);not null;default:'platform';index:idx_tenant_mail_settings_mode"`
+
+	SMTPHost string `json:"smtp_host" gorm:"type:varchar(255)"`
+	SMTPPort int    `json:"smtp_port"`
+	// SMTPTLSMode is empty (STARTTLS when offered), none, starttls or tls
+	SMTPTLSMode  string `json:"smtp_tls_mode" gorm:"type:varchar(20)"`
+	SMTPUsername string `json:"smtp_username" gorm:"type:varchar(255)"`
+	// SMTPPasswordEncrypted is sealed with the tenant ID as associated data
+	SMTPPasswordEncrypted string `json:"-" gorm:"type:text"`
+
+	FromEmail string `json:"from_email" gorm:"type:varchar(320)"`
+	FromName  string `json:"from_name" gorm:"type:varchar(100)"`
+	// SenderVerifiedAt is set once the owner of FromEmail confirmed it
+	SenderVerifiedAt            *time.Time `json:"sender_verified_at"`
+	SenderVerificationTokenHash string     `json:"-" gorm:"type:varchar(64);index:idx_tenant_mail_settings_token"`
+	SenderVerificationExpiresAt *time.Time `json:"-"`
+
+	HealthStatus        string     `json:"health_status" gorm:"type:varchar(20);not null;default:'unknown'"`
+	ConsecutiveFailures int        `json:"consecutive_failures" gorm:"not null;default:0"`
+	LastError           string     `json:"last_error,omitempty" gorm:"type:text"`
+	LastCheckedAt       *time.Time `json:"last_checked_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for the TenantMailSettings model
+func (TenantMailSettings) TableName() string {
+	return "tenant_mail_settings"
+}
+
+// UsesRelay reports whether emails are currently sent through the tenant relay
+func (s *TenantMailSettings) UsesRelay() bool {
+	return s.Mode == MailModeSMTP && s.HealthStatus == MailHealthHealthy
+}
+
+// SenderVerified reports whether FromEmail was confirmed by its owner
+func (s *TenantMailSettings) SenderVerified() bool {
+	return s.FromEmail != "" && s.SenderVerifiedAt != nil
+}
+
+type TenantMailSettingsRepository interface {
+	FindByTenant(tenantID uuid.UUID) (*TenantMailSettings, error)
+	FindByVerificationTokenHash(hash string) (*TenantMailSettings, error)
+	// ListRelays returns the settings of the tenants sending through their own relay
+	ListRelays() ([]*TenantMailSettings, error)
+	Save(settings *TenantMailSettings) error
+	Delete(tenantID uuid.UUID) error
+	// RecordHealth stores the outcome of a relay check or delivery. A success
+	// makes the relay healthy; failures make it failing once they reach
+	// MailHealthFailureThreshold, or right away if it was not healthy.
+	RecordHealth(tenantID uuid.UUID, checkErr error, now time.Time) error
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Onboarding steps
+const (
+	OnboardingInviteTeammate   = "invite_teammate"
+	OnboardingConnectChannel   = "connect_channel"
+	OnboardingConfigureBotFlow = "configure_bot_flow"
+	OnboardingSetBusinessHours = "set_business_hours"
+	OnboardingVerifyDomain     = "verify_domain"
+)
+
+// Tenant settings keys managed by the platform
+const (
+	OnboardingCompletedSettingsKey = "onboarding_completed"
+	DomainVerificationSettingsKey  = "domain_verification"
+	BusinessHoursSettingsKey       = "business_hours"
+)
+
+// OnboardingStepDefinition describes a checklist step and the event that completes it
+type OnboardingStepDefinition struct {
+	Key   string `json:"key"`
+	Title string `json:"title"`
+	Event string `json:"-"`
+}
+
+// OnboardingSteps is the checklist shown to new tenants, in display order
+var OnboardingSteps = []OnboardingStepDefinition{
+	{Key: OnboardingInviteTeammate, Title: "Convide um colega de equipe", Event: EventUserCreated},
+	{Key: OnboardingConnectChannel, Title: "Conecte um canal de atendimento", Event: EventChannelConnected},
+	{Key: OnboardingConfigureBotFlow, Title: "Configure o fluxo do bot", Event: EventBotFlowConfigured},
+	{Key: OnboardingSetBusinessHours, Title: "Defina o horário de atendimento", Event: EventBusinessHoursUpdated},
+	{Key: OnboardingVerifyDomain, Title: "Verifique o seu domínio", Event: EventDomainVerified},
+}
+
+// OnboardingStep records when a tenant completed a checklist step
+type OnboardingStep struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID    uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_tenant_onboarding_step"`
+	Step        string    `json:"step" gorm:"type:varchar(50);not null;uniqueIndex:idx_tenant_onboarding_step"`
+	CompletedAt time.Time `json:"completed_at" gorm:"not null"`
+}
+
+// TableName returns the table name for the OnboardingStep model
+func (OnboardingStep) TableName() string {
+	return "tenant_onboarding_steps"
+}
+
+// OnboardingNudge records a reminder email sent to a tenant that stalled during onboarding
+type OnboardingNudge struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_onboarding_nudge"`
+	Day      int       `json:"day" gorm:"not null;uniqueIndex:idx_onboarding_nudge"`
+	SentAt   time.Time `json:"sent_at" gorm:"not null"`
+}
+
+// TableName returns the table name for the OnboardingNudge model
+func (OnboardingNudge) TableName() string {
+	return "onboarding_nudges"
+}
+
+// DomainVerification is the DNS challenge proving that a tenant controls its domain
+type DomainVerification struct {
+	Domain     string     `json:"domain"`
+	Token      string     `json:"token"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// DomainVerification returns the challenge stored in the tenant settings, if any
+func (t *Tenant) DomainVerification() *DomainVerification {
+	raw, ok := t.Settings[DomainVerificationSettingsKey]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var verification DomainVerification
+	if err := json.Unmarshal(data, &verification); err != nil || verification.Token == "" {
+		return nil
+	}
+	return &verification
+}
+
+type OnboardingRepository interface {
+	FindSteps(tenantID uuid.UUID) ([]*OnboardingStep, error)
+	// CompleteStep records a step once and reports whether it was newly completed
+	CompleteStep(tenantID uuid.UUID, step string, at time.Time) (bool, error)
+	// LatestNudgeDay returns the highest nudge day sent to a tenant, or 0
+	LatestNudgeDay(tenantID uuid.UUID) (int, error)
+	CreateNudge(nudge *OnboardingNudge) error
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OwnershipTransferExpiry is how long the target of a transfer has to accept it
+const OwnershipTransferExpiry = 72 * time.Hour
+
+// Ownership transfer states
+const (
+	OwnershipTransferPending   = "pending"
+	OwnershipTransferAccepted  = "accepted"
+	OwnershipTransferCancelled = "cancelled"
+)
+
+// OwnershipTransfer hands the owner role of a tenant to another user. The
+// current owner initiates it and the target confirms with the emailed token.
+type OwnershipTransfer struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID   uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	FromUserID uuid.UUID `json:"from_user_id" gorm:"type:uuid;not null"`
+	ToUserID   uuid.UUID `json:"to_user_id" gorm:"type:uuid;not null"`
+	// TokenHash is the SHA-256 of the confirmation token sent to the target
+	TokenHash   string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	Status      string     `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	CancelledAt *time.Time `json:"cancelled_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the OwnershipTransfer model
+func (OwnershipTransfer) TableName() string {
+	return "ownership_transfers"
+}
+
+// IsOpen reports whether the transfer can still be accepted
+func (t *OwnershipTransfer) IsOpen(now time.Time) bool {
+	return t.Status == OwnershipTransferPending && now.Before(t.ExpiresAt)
+}
+
+type OwnershipTransferRepository interface {
+	Create(transfer *OwnershipTransfer) error
+	// FindPending returns the open transfer of a tenant, if any
+	FindPending(tenantID uuid.UUID, now time.Time) (*OwnershipTransfer, error)
+	FindByTokenHash(tokenHash string) (*OwnershipTransfer, error)
+	// CancelPending cancels every pending transfer of a tenant
+	CancelPending(tenantID uuid.UUID, now time.Time) error
+}
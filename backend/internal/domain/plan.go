@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UnlimitedQuota marks a plan limit without a ceiling
+const UnlimitedQuota = -1
+
+// QuotaResource identifies a resource that is limited by the tenant plan
+type QuotaResource string
+
+const (
+	QuotaSeats                QuotaResource = "seats"
+	QuotaInboxes              QuotaResource = "inboxes"
+	QuotaMonthlyConversations QuotaResource = "monthly_conversations"
+	QuotaBotFlows             QuotaResource = "bot_flows"
+	QuotaAPIKeys              QuotaResource = "api_keys"
+)
+
+// QuotaResources lists every resource limited by plans
+var QuotaResources = []QuotaResource{
+	QuotaSeats,
+	QuotaInboxes,
+	QuotaMonthlyConversations,
+	QuotaBotFlows,
+	QuotaAPIKeys,
+}
+
+// Plan represents a subscription plan with its usage limits and entitlements
+type Plan struct {
+	ID                      uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Code                    string     `json:"code" gorm:"type:varchar(50);unique;not null"`
+	Name                    string     `json:"name" gorm:"type:varchar(255);not null"`
+	Description             string     `json:"description" gorm:"type:text"`
+	PriceCents              int64      `json:"price_cents" gorm:"default:0"`
+	Currency                string     `json:"currency" gorm:"type:varchar(3);default:'BRL'"`
+	ProviderPriceID         *string    `json:"provider_price_id" gorm:"type:varchar(255);unique"`
+	MaxSeats                int        `json:"max_seats" gorm:"not null;default:-1"`
+	MaxInboxes              int        `json:"max_inboxes" gorm:"not null;default:-1"`
+	MaxMonthlyConversations int        `json:"max_monthly_conversations" gorm:"not null;default:-1"`
+	MaxBotFlows             int        `json:"max_bot_flows" gorm:"not null;default:-1"`
+	MaxAPIKeys              int        `json:"max_api_keys" gorm:"not null;default:-1"`
+	Features                StringList `json:"features" gorm:"type:jsonb;default:'[]'"`
+	IsPublic                bool       `json:"is_public" gorm:"default:true"`
+	CreatedAt               time.Time  `json:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the Plan model
+func (Plan) TableName() string {
+	return "plans"
+}
+
+// Limit returns the plan limit for a resource
+func (p *Plan) Limit(resource QuotaResource) int {
+	switch resource {
+	case QuotaSeats:
+		return p.MaxSeats
+	case QuotaInboxes:
+		return p.MaxInboxes
+	case QuotaMonthlyConversations:
+		return p.MaxMonthlyConversations
+	case QuotaBotFlows:
+		return p.MaxBotFlows
+	case QuotaAPIKeys:
+		return p.MaxAPIKeys
+	default:
+		return 0
+	}
+}
+
+// HasFeature checks if the plan entitles the tenant to a feature
+func (p *Plan) HasFeature(feature string) bool {
+	return p.Features.Contains(feature)
+}
+
+// DefaultPlans are the plans seeded, and updated, at startup
+var DefaultPlans = []Plan{
+	{
+		Code:                    "trial",
+		Name:                    "Trial",
+		Description:             "14-day evaluation",
+		MaxSeats:                5,
+		MaxInboxes:              2,
+		MaxMonthlyConversations: 500,
+		MaxBotFlows:             2,
+		MaxAPIKeys:              1,
+		Features:                StringList{FeatureChat},
+		IsPublic:                false,
+	},
+	{
+		Code:                    "starter",
+		Name:                    "Starter",
+		PriceCents:              19900,
+		MaxSeats:                10,
+		MaxInboxes:              3,
+		MaxMonthlyConversations: 2000,
+		MaxBotFlows:             5,
+		MaxAPIKeys:              2,
+		Features:                StringList{FeatureChat},
+		IsPublic:                true,
+	},
+	{
+		Code:                    "pro",
+		Name:                    "Pro",
+		PriceCents:              59900,
+		MaxSeats:                50,
+		MaxInboxes:              10,
+		MaxMonthlyConversations: 10000,
+		MaxBotFlows:             20,
+		MaxAPIKeys:              10,
+		Features:                StringList{FeatureChat, FeatureCRM, FeatureCalendar},
+		IsPublic:                true,
+	},
+	{
+		Code:                    "enterprise",
+		Name:                    "Enterprise",
+		MaxSeats:                UnlimitedQuota,
+		MaxInboxes:              UnlimitedQuota,
+		MaxMonthlyConversations: UnlimitedQuota,
+		MaxBotFlows:             UnlimitedQuota,
+		MaxAPIKeys:              UnlimitedQuota,
+		Features:                StringList{FeatureChat, FeatureCRM, FeatureCalendar, FeatureCustomMail},
+		IsPublic:                true,
+	},
+}
+
+type PlanRepository interface {
+	FindByID(id uuid.UUID) (*Plan, error)
+	FindByCode(code string) (*Plan, error)
+	FindByProviderPriceID(priceID string) (*Plan, error)
+	List(publicOnly bool) ([]*Plan, error)
+}
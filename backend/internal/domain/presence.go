@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Presence states of a user
+const (
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceBusy    = "busy"
+	PresenceOffline = "offline"
+)
+
+// PresenceTimeout is how long a presence lasts without a heartbeat before
+// the user is considered offline
+const PresenceTimeout = 2 * time.Minute
+
+// IsValidPresence reports whether a presence state is known
+func IsValidPresence(presence string) bool {
+	switch presence {
+	case PresenceOnline, PresenceAway, PresenceBusy, PresenceOffline:
+		return true
+	}
+	return false
+}
+
+// EffectivePresence returns the presence of a user, falling back to offline
+// when the last heartbeat is older than PresenceTimeout
+func (u *User) EffectivePresence(now time.Time) string {
+	if u.Presence == "" || u.LastSeenAt == nil || now.Sub(*u.LastSeenAt) > PresenceTimeout {
+		return PresenceOffline
+	}
+	return u.Presence
+}
+
+// TimeRange is a period of a day, as "HH:MM" in the schedule time zone. The
+// end is exclusive and "24:00" ends at midnight.
+type TimeRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Minutes returns the start and end of the range in minutes since midnight
+func (r TimeRange) Minutes() (int, int, error) {
+	start, err := parseClock(r.Start)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(r.End)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end <= start {
+		return 0, 0, fmt.Errorf("range %s-%s ends before it starts", r.Start, r.End)
+	}
+	return start, end, nil
+}
+
+// WeeklyHours maps lowercase English weekday names to the working periods of that day
+type WeeklyHours map[string][]TimeRange
+
+// Scan implements the sql.Scanner interface
+func (w *WeeklyHours) Scan(value interface{}) error {
+	if value == nil {
+		*w = WeeklyHours{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into WeeklyHours", value)
+	}
+
+	return json.Unmarshal(bytes, w)
+}
+
+// Value implements the driver.Valuer interface
+func (w WeeklyHours) Value() (driver.Value, error) {
+	if w == nil {
+		return "{}", nil
+	}
+	return json.Marshal(w)
+}
+
+// Validate checks the weekday names and time ranges
+func (w WeeklyHours) Validate() error {
+	for day, ranges := range w {
+		if !weekdays[day] {
+			return fmt.Errorf("unknown weekday %q", day)
+		}
+		for _, r := range ranges {
+			if _, _, err := r.Minutes(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// UserSchedule holds the working hours of a user
+type UserSchedule struct {
+	ID       uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID uuid.UUID   `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	UserID   uuid.UUID   `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	TimeZone string      `json:"time_zone" gorm:"type:varchar(64);not null;default:'America/Sao_Paulo'"`
+	Weekly   WeeklyHours `json:"weekly" gorm:"type:jsonb;default:'{}'"`
+	// Holidays are days off, as YYYY-MM-DD in the schedule time zone
+	Holidays  StringList `json:"holidays" gorm:"type:jsonb;default:'[]'"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the UserSchedule model
+func (UserSchedule) TableName() string {
+	return "user_schedules"
+}
+
+// IsWorking reports whether the schedule covers the given instant
+func (s *UserSchedule) IsWorking(at time.Time) bool {
+	location, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := at.In(location)
+
+	if s.Holidays.Contains(local.Format("2006-01-02")) {
+		return false
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	for _, r := range s.Weekly[strings.ToLower(local.Weekday().String())] {
+		start, end, err := r.Minutes()
+		if err != nil {
+			continue
+		}
+		if minute >= start && minute < end {
+			return true
+		}
+	}
+	return false
+}
+
+type UserScheduleRepository interface {
+	FindByUser(userID uuid.UUID) (*UserSchedule, error)
+	// FindByUsers returns the schedules of the given users, keyed by user ID
+	FindByUsers(userIDs []uuid.UUID) (map[uuid.UUID]*UserSchedule, error)
+	Save(schedule *UserSchedule) error
+}
+
+var weekdays = map[string]bool{
+	"sunday":    true,
+	"monday":    true,
+	"tuesday":   true,
+	"wednesday": true,
+	"thursday":  true,
+	"friday":    true,
+	"saturday":  true,
+}
+
+func parseClock(value string) (int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hour, &minute); err != nil || len(value) != 5 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	if hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return hour*60 + minute, nil
+}
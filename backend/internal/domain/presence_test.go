@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserScheduleIsWorking(t *testing.T) {
+	schedule := &UserSchedule{
+		TimeZone: "America/Sao_Paulo",
+		Weekly: WeeklyHours{
+			"monday": {{Start: "09:00", End: "12:00"}, {Start: "13:00", End: "18:00"}},
+		},
+		Holidays: StringList{"2026-04-20"},
+	}
+
+	cases := []struct {
+		name string
+		at   string
+		want bool
+	}{
+		{"morning", "2026-04-13T12:30:00Z", true}, // 09:30 in São Paulo
+		{"lunch", "2026-04-13T15:30:00Z", false},  // 12:30
+		{"end is exclusive", "2026-04-13T21:00:00Z", false},
+		{"no hours on tuesday", "2026-04-14T12:30:00Z", false},
+		{"holiday", "2026-04-20T12:30:00Z", false},
+	}
+	for _, tc := range cases {
+		at, _ := time.Parse(time.RFC3339, tc.at)
+		if got := schedule.IsWorking(at); got != tc.want {
+			t.Errorf("%s: IsWorking(%s) = %v, want %v", tc.name, tc.at, got, tc.want)
+		}
+	}
+}
+
+func TestWeeklyHoursValidate(t *testing.T) {
+	if err := (WeeklyHours{"funday": {{Start: "09:00", End: "10:00"}}}).Validate(); err == nil {
+		t.Error("unknown weekday should be rejected")
+	}
+	if err := (WeeklyHours{"friday": {{Start: "18:00", End: "09:00"}}}).Validate(); err == nil {
+		t.Error("range ending before it starts should be rejected")
+	}
+	if err := (WeeklyHours{"friday": {{Start: "22:00", End: "24:00"}}}).Validate(); err != nil {
+		t.Errorf("range ending at midnight should be accepted: %v", err)
+	}
+}
+
+func TestEffectivePresence(t *testing.T) {
+	now := time.Now()
+	seen := now.Add(-PresenceTimeout - time.Second)
+	user := &User{Presence: PresenceBusy, LastSeenAt: &seen}
+	if got := user.EffectivePresence(now); got != PresenceOffline {
+		t.Errorf("stale presence = %s, want offline", got)
+	}
+
+	seen = now.Add(-time.Second)
+	if got := user.EffectivePresence(now); got != PresenceBusy {
+		t.Errorf("fresh presence = %s, want busy", got)
+	}
+}
@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Permissions granted to roles
+const (
+	PermUsersRead            = "users.read"
+	PermUsersInvite          = "users.invite"
+	PermUsersUpdate          = "users.update"
+	PermUsersDelete          = "users.delete"
+	PermRolesManage          = "roles.manage"
+	PermTeamsManage          = "teams.manage"
+	PermTenantSettingsRead   = "tenant.settings.read"
+	PermTenantSettingsWrite  = "tenant.settings.write"
+	PermTenantBillingManage  = "tenant.billing.manage"
+	PermTenantDataExport     = "tenant.data.export"
+	PermConversationsRead    = "conversations.read"
+	PermConversationsReply   = "conversations.reply"
+	PermConversationsAssign  = "conversations.assign"
+	PermConversationsReadAll = "conversations.read_all"
+	PermCRMContactsRead      = "crm.contacts.read"
+	PermCRMContactsWrite     = "crm.contacts.write"
+	PermCRMDealsRead         = "crm.deals.read"
+	PermCRMDealsWrite        = "crm.deals.write"
+	PermCRMDealsDelete       = "crm.deals.delete"
+	PermReportsRead          = "reports.read"
+)
+
+// PermissionDefinition describes a permission of the catalog
+type PermissionDefinition struct {
+	Key         string `json:"key"`
+	Description string `json:"description"`
+}
+
+// PermissionCatalog lists every permission that can be granted to a role
+var PermissionCatalog = []PermissionDefinition{
+	{Key: PermUsersRead, Description: "Ver os usuários da conta"},
+	{Key: PermUsersInvite, Description: "Convidar e criar usuários"},
+	{Key: PermUsersUpdate, Description: "Editar usuários e redefinir senhas"},
+	{Key: PermUsersDelete, Description: "Remover usuários"},
+	{Key: PermRolesManage, Description: "Gerenciar papéis e permissões"},
+	{Key: PermTeamsManage, Description: "Gerenciar equipes e seus membros"},
+	{Key: PermTenantSettingsRead, Description: "Ver as configurações da conta"},
+	{Key: PermTenantSettingsWrite, Description: "Alterar as configurações da conta"},
+	{Key: PermTenantBillingManage, Description: "Gerenciar assinatura e faturamento"},
+	{Key: PermTenantDataExport, Description: "Exportar os dados da conta"},
+	{Key: PermConversationsRead, Description: "Ver conversas"},
+	{Key: PermConversationsReply, Description: "Responder conversas"},
+	{Key: PermConversationsAssign, Description: "Atribuir conversas a agentes"},
+	{Key: PermConversationsReadAll, Description: "Ver conversas e leads de todas as equipes"},
+	{Key: PermCRMContactsRead, Description: "Ver contatos"},
+	{Key: PermCRMContactsWrite, Description: "Criar e editar contatos"},
+	{Key: PermCRMDealsRead, Description: "Ver negócios"},
+	{Key: PermCRMDealsWrite, Description: "Criar e editar negócios"},
+	{Key: PermCRMDealsDelete, Description: "Excluir negócios"},
+	{Key: PermReportsRead, Description: "Ver relatórios"},
+}
+
+// IsKnownPermission reports whether a permission is part of the catalog
+func IsKnownPermission(permission string) bool {
+	for _, definition := range PermissionCatalog {
+		if definition.Key == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// System roles available to every tenant
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleAgent  = "agent"
+	RoleViewer = "viewer"
+)
+
+// SystemRoles maps the built-in roles to their permissions, in display order
+var SystemRoles = []SystemRole{
+	{Name: RoleOwner, Description: "Proprietário da conta, com acesso total", Permissions: allPermissions()},
+	{Name: RoleAdmin, Description: "Administra usuários, configurações e faturamento", Permissions: allPermissions()},
+	{Name: RoleAgent, Description: "Atende conversas e trabalha o CRM", Permissions: []string{
+		PermUsersRead,
+		PermConversationsRead,
+		PermConversationsReply,
+		PermCRMContactsRead,
+		PermCRMContactsWrite,
+		PermCRMDealsRead,
+		PermCRMDealsWrite,
+	}},
+	{Name: RoleViewer, Description: "Acesso somente leitura", Permissions: []string{
+		PermUsersRead,
+		PermConversationsRead,
+		PermConversationsReadAll,
+		PermCRMContactsRead,
+		PermCRMDealsRead,
+		PermReportsRead,
+	}},
+}
+
+// SystemRole is a built-in role that cannot be changed by tenants
+type SystemRole struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// FindSystemRole returns the built-in role with the given name
+func FindSystemRole(name string) (SystemRole, bool) {
+	for _, role := range SystemRoles {
+		if role.Name == name {
+			return role, true
+		}
+	}
+	return SystemRole{}, false
+}
+
+// IsAdminRole reports whether a role is one of the built-in administrator roles
+func IsAdminRole(name string) bool {
+	return name == RoleOwner || name == RoleAdmin
+}
+
+func allPermissions() []string {
+	permissions := make([]string, 0, len(PermissionCatalog))
+	for _, definition := range PermissionCatalog {
+		permissions = append(permissions, definition.Key)
+	}
+	return permissions
+}
+
+// Role is a tenant defined role granting a set of permissions. Users refer
+// to it by name, like they do for system roles.
+type Role struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID    uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:roles_tenant_id_name_key"`
+	Name        string     `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:roles_tenant_id_name_key"`
+	Description string     `json:"description" gorm:"type:varchar(255)"`
+	Permissions StringList `json:"permissions" gorm:"type:jsonb;default:'[]'"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the Role model
+func (Role) TableName() string {
+	return "roles"
+}
+
+type RoleRepository interface {
+	Create(role *Role) error
+	FindByID(id uuid.UUID) (*Role, error)
+	FindByName(tenantID uuid.UUID, name string) (*Role, error)
+	FindByTenant(tenantID uuid.UUID) ([]*Role, error)
+	Update(role *Role) error
+	Delete(id uuid.UUID) error
+}
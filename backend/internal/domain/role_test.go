@@ -0,0 +1,21 @@
+package domain
+
+import "testing"
+
+func TestSystemRolesUseCatalogPermissions(t *testing.T) {
+	for _, role := range SystemRoles {
+		for _, permission := range role.Permissions {
+			if !IsKnownPermission(permission) {
+				t.Errorf("role %s grants unknown permission %s", role.Name, permission)
+			}
+		}
+	}
+
+	owner, ok := FindSystemRole(RoleOwner)
+	if !ok || len(owner.Permissions) != len(PermissionCatalog) {
+		t.Errorf("owner should have every permission, got %v", owner.Permissions)
+	}
+	if _, ok := FindSystemRole("super_admin"); ok {
+		t.Error("super_admin is not a tenant role")
+	}
+}
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription lifecycle states stored in Tenant.SubscriptionStatus
+const (
+	SubscriptionTrial     = "trial"
+	SubscriptionActive    = "active"
+	SubscriptionPastDue   = "past_due"
+	SubscriptionCanceled  = "canceled"
+	SubscriptionSuspended = "suspended"
+	SubscriptionExpired   = "expired"
+)
+
+// TrialPeriod is the length of the trial given to new tenants
+const TrialPeriod = 14 * 24 * time.Hour
+
+// Access levels granted to a tenant by its subscription state
+const (
+	AccessFull        = "full"
+	AccessReadOnly    = "read_only"
+	AccessBillingOnly = "billing_only"
+)
+
+// subscriptionTransitions lists the states reachable from each state
+var subscriptionTransitions = map[string][]string{
+	SubscriptionTrial:     {SubscriptionActive, SubscriptionPastDue, SubscriptionCanceled, SubscriptionExpired, SubscriptionSuspended},
+	SubscriptionActive:    {SubscriptionPastDue, SubscriptionCanceled, SubscriptionSuspended},
+	SubscriptionPastDue:   {SubscriptionActive, SubscriptionCanceled, SubscriptionSuspended},
+	SubscriptionCanceled:  {SubscriptionActive, SubscriptionExpired},
+	SubscriptionSuspended: {SubscriptionActive, SubscriptionCanceled},
+	SubscriptionExpired:   {SubscriptionActive},
+}
+
+// IsValidSubscriptionStatus checks if a status is part of the lifecycle
+func IsValidSubscriptionStatus(status string) bool {
+	_, ok := subscriptionTransitions[status]
+	return ok
+}
+
+// CanTransitionSubscription checks if the lifecycle allows moving between two states
+func CanTransitionSubscription(from, to string) bool {
+	for _, allowed := range subscriptionTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedSubscriptionTransitions returns the states reachable from a state
+func AllowedSubscriptionTransitions(from string) []string {
+	return subscriptionTransitions[from]
+}
+
+// SubscriptionAccessLevel returns what a tenant may do in a given state
+func SubscriptionAccessLevel(status string) string {
+	switch status {
+	case SubscriptionExpired:
+		return AccessReadOnly
+	case SubscriptionSuspended:
+		return AccessBillingOnly
+	default:
+		return AccessFull
+	}
+}
+
+// Subscription event types
+const (
+	SubscriptionEventTransition = "transition"
+	SubscriptionEventReminder   = "reminder"
+)
+
+// SubscriptionEvent records lifecycle history of a tenant subscription
+type SubscriptionEvent struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID   uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	Type       string     `json:"type" gorm:"type:varchar(50);not null"`
+	FromStatus string     `json:"from_status" gorm:"type:varchar(50)"`
+	ToStatus   string     `json:"to_status" gorm:"type:varchar(50)"`
+	Reason     string     `json:"reason" gorm:"type:text"`
+	Metadata   JSON       `json:"metadata" gorm:"type:jsonb;default:'{}'"`
+	ActorID    *uuid.UUID `json:"actor_id" gorm:"type:uuid"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName returns the table name for the SubscriptionEvent model
+func (SubscriptionEvent) TableName() string {
+	return "subscription_events"
+}
+
+type SubscriptionEventRepository interface {
+	Create(event *SubscriptionEvent) error
+	FindByTenant(tenantID uuid.UUID, limit int) ([]*SubscriptionEvent, error)
+	ReminderSent(tenantID uuid.UUID, daysBefore int, periodEnd time.Time) (bool, error)
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "testing"
+
+func TestCanTransitionSubscription(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{SubscriptionTrial, SubscriptionActive, true},
+		{SubscriptionTrial, SubscriptionExpired, true},
+		{SubscriptionActive, SubscriptionPastDue, true},
+		{SubscriptionPastDue, SubscriptionSuspended, true},
+		{SubscriptionExpired, SubscriptionActive, true},
+		{SubscriptionActive, SubscriptionTrial, false},
+		{SubscriptionExpired, SubscriptionTrial, false},
+		{SubscriptionSuspended, SubscriptionExpired, false},
+		{"unknown", SubscriptionActive, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransitionSubscription(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionSubscription(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestSubscriptionAccessLevel(t *testing.T) {
+	tests := map[string]string{
+		SubscriptionTrial:     AccessFull,
+		SubscriptionActive:    AccessFull,
+		SubscriptionPastDue:   AccessFull,
+		SubscriptionCanceled:  AccessFull,
+		SubscriptionExpired:   AccessReadOnly,
+		SubscriptionSuspended: AccessBillingOnly,
+	}
+
+	for status, want := range tests {
+		if got := SubscriptionAccessLevel(status); got != want {
+			t.Errorf("SubscriptionAccessLevel(%q) = %q, want %q", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Team groups agents of a tenant, such as inbound, outbound or key accounts
+type Team struct {
+	ID             uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID       uuid.UUID     `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_teams_tenant_name"`
+	Name           string        `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_teams_tenant_name"`
+	Description    string        `json:"description" gorm:"type:varchar(255)"`
+	ChatwootTeamID *int          `json:"chatwoot_team_id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	Members        []*TeamMember `json:"members,omitempty" gorm:"foreignKey:TeamID"`
+}
+
+// TableName returns the table name for the Team model
+func (Team) TableName() string {
+	return "teams"
+}
+
+// TeamMember is the membership of a user in a team. Leads manage the
+// assignments of the other members.
+type TeamMember struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID  uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	TeamID    uuid.UUID `json:"team_id" gorm:"type:uuid;not null;uniqueIndex:idx_team_members_team_user"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_team_members_team_user;index"`
+	IsLead    bool      `json:"is_lead" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	User      *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName returns the table name for the TeamMember model
+func (TeamMember) TableName() string {
+	return "team_members"
+}
+
+type TeamRepository interface {
+	Create(team *Team) error
+	// FindByID returns a team with its members
+	FindByID(id uuid.UUID) (*Team, error)
+	// FindByTenant returns the teams of a tenant with their members
+	FindByTenant(tenantID uuid.UUID) ([]*Team, error)
+	Update(team *Team) error
+	Delete(id uuid.UUID) error
+	FindMember(teamID, userID uuid.UUID) (*TeamMember, error)
+	// SaveMember adds a member or updates the lead flag of an existing one
+	SaveMember(member *TeamMember) error
+	RemoveMember(teamID, userID uuid.UUID) error
+	// FindMemberships returns the team memberships of a user
+	FindMemberships(userID uuid.UUID) ([]*TeamMember, error)
+	// FindTeammateIDs returns the users sharing a team with the user, the user included.
+	// When leadOnly is set only teams the user leads are considered.
+	FindTeammateIDs(userID uuid.UUID, leadOnly bool) ([]uuid.UUID, error)
+}
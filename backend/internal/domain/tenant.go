@@ -11,16 +11,39 @@ import (
 )
 
 type Tenant struct {
-	ID                 uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Slug               string         `json:"slug" gorm:"type:varchar(63);unique;not null"`
-	Name               string         `json:"name" gorm:"type:varchar(255);not null"`
-	Domain             *string        `json:"domain" gorm:"type:varchar(255)"`
-	Settings           JSON           `json:"settings" gorm:"type:jsonb;default:'{}'"`
-	SubscriptionStatus string         `json:"subscription_status" gorm:"type:varchar(50);default:'trial'"`
-	SubscriptionEndsAt *time.Time     `json:"subscription_ends_at"`
-	CreatedAt          time.Time      `json:"created_at"`
-	UpdatedAt          time.Time      `json:"updated_at"`
-	DeletedAt          gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                  uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Slug                string         `json:"slug" gorm:"type:varchar(63);unique;not null"`
+	Name                string         `json:"name" gorm:"type:varchar(255);not null"`
+	Domain              *string        `json:"domain" gorm:"type:varchar(255)"`
+	Settings            JSON           `json:"settings" gorm:"type:jsonb;default:'{}'"`
+	SubscriptionStatus  string         `json:"subscription_status" gorm:"type:varchar(50);default:'trial'"`
+	SubscriptionEndsAt  *time.Time     `json:"subscription_ends_at"`
+	PlanID              *uuid.UUID     `json:"plan_id" gorm:"type:uuid"`
+	Status              string         `json:"status" gorm:"type:varchar(30);not null;default:'active';index"`
+	DeletionRequestedAt *time.Time     `json:"deletion_requested_at"`
+	DeletionScheduledAt *time.Time     `json:"deletion_scheduled_at"`
+	DeletionRequestedBy *uuid.UUID     `json:"deletion_requested_by" gorm:"type:uuid"`
+	ChatwootAccountID   *int           `json:"chatwoot_account_id"`
+	ChatwootAgentID     *int           `json:"chatwoot_agent_id"`
+	ChatwootInboxID     *int           `json:"chatwoot_inbox_id"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations
+	Plan *Plan `json:"plan,omitempty" gorm:"foreignKey:PlanID"`
+}
+
+// Tenant lifecycle states stored in Tenant.Status
+const (
+	TenantStatusActive          = "active"
+	TenantStatusPendingDeletion = "pending_deletion"
+	TenantStatusPurged          = "purged"
+)
+
+// IsPendingDeletion reports whether the tenant is waiting for its scheduled purge
+func (t *Tenant) IsPendingDeletion() bool {
+	return t.Status == TenantStatusPendingDeletion
 }
 
 type TenantRepository interface {
@@ -32,8 +55,11 @@ type TenantRepository interface {
 	Delete(id uuid.UUID) error
 	List(limit, offset int) ([]*Tenant, error)
 	Count() (int64, error)
+	// ExistsBySlug reports whether a tenant or an active slug alias uses the slug
 	ExistsBySlug(slug string) (bool, error)
 	ExistsByDomain(domain string) (bool, error)
+	FindBySubscriptionStatus(statuses []string, endsBefore time.Time) ([]*Tenant, error)
+	FindDueForPurge(now time.Time) ([]*Tenant, error)
 }
 
 type TenantService interface {
@@ -68,4 +94,40 @@ func (j JSON) Value() (driver.Value, error) {
 		return "{}", nil
 	}
 	return json.Marshal(j)
-}
\ No newline at end of file
+}
+
+// StringList type for JSONB array fields
+type StringList []string
+
+// Scan implements the sql.Scanner interface
+func (l *StringList) Scan(value interface{}) error {
+	if value == nil {
+		*l = StringList{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into StringList", value)
+	}
+
+	return json.Unmarshal(bytes, l)
+}
+
+// Value implements the driver.Valuer interface
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return json.Marshal(l)
+}
+
+// Contains reports whether the list holds the given value
+func (l StringList) Contains(value string) bool {
+	for _, item := range l {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
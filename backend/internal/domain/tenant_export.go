@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportFormatVersion is the version of the tenant archive layout
+const ExportFormatVersion = 1
+
+// Tenant export states
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+	ExportExpired   = "expired"
+)
+
+// TenantExport is an asynchronous export of all tenant data into an archive
+type TenantExport struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID      uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	RequestedBy   *uuid.UUID `json:"requested_by" gorm:"type:uuid"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	FormatVersion int        `json:"format_version" gorm:"not null"`
+	StorageKey    string     `json:"-" gorm:"type:varchar(512)"`
+	SizeBytes     int64      `json:"size_bytes"`
+	Checksum      string     `json:"checksum" gorm:"type:varchar(64)"`
+	Error         string     `json:"error,omitempty" gorm:"type:text"`
+	StartedAt     *time.Time `json:"started_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for the TenantExport model
+func (TenantExport) TableName() string {
+	return "tenant_exports"
+}
+
+// TenantTable describes a tenant scoped table included in exports and imports
+type TenantTable struct {
+	Name string
+	// Omit lists columns never written to an archive (secrets)
+	Omit []string
+	// ExportOnly tables are not imported: they hold provider records that must
+	// not be duplicated, or rows that are unusable without their omitted secrets
+	ExportOnly bool
+	// Reset sets columns of the imported rows, e.g. to turn off settings that
+	// need the omitted secrets
+	Reset map[string]interface{}
+	// OnePerTenant tables hold at most one row per tenant; merging such rows
+	// into a tenant that has one is a conflict
+	OnePerTenant bool
+	// Unique lists the columns that are unique within a tenant; merging rows
+	// whose values already exist in the target tenant is a conflict
+	Unique []string
+}
+
+// TenantDataTables lists the tenant scoped tables in dependency order:
+// a table only references tables listed before it. Tables that do not exist
+// in the database yet are skipped.
+var TenantDataTables = []TenantTable{
+	{
+		Name: "users",
+		Omit: []string{"password_hash"},
+		// Archives are supplied by customers: they never grant platform
+		// access, and Chatwoot agents stay with the archived tenant
+		Reset: map[string]interface{}{
+			"is_platform_admin": false,
+			"chatwoot_agent_id": nil,
+		},
+		Unique: []string{"email"},
+	},
+	{Name: "roles", Unique: []string{"name"}},
+	{Name: "teams", Unique: []string{"name"}},
+	{Name: "team_members"},
+	{Name: "user_schedules"},
+	{Name: "tenant_feature_overrides", Unique: []string{"feature"}},
+	{
+		Name: "tenant_mail_settings",
+		Omit: []string{"smtp_password_encrypted", "sender_verification_token_hash"},
+		// The SMTP relay cannot be used without its password
+		Reset: map[string]interface{}{
+			"mode":                 MailModePlatform,
+			"health_status":        MailHealthUnknown,
+			"consecutive_failures": 0,
+			"last_error":           nil,
+			"last_checked_at":      nil,
+		},
+		OnePerTenant: true,
+	},
+	{Name: "email_suppressions", Unique: []string{"email"}},
+	{Name: "tenant_integrations", Omit: []string{"credentials_encrypted", "webhook_secret_encrypted"}, ExportOnly: true, Unique: []string{"provider"}},
+	{Name: "chatwoot_provisionings", ExportOnly: true, OnePerTenant: true},
+	{Name: "inboxes"},
+	{Name: "leads"},
+	{Name: "conversations"},
+	{Name: "messages"},
+	{Name: "qualification_flows"},
+	{Name: "companies"},
+	{Name: "contacts"},
+	{Name: "pipelines"},
+	{Name: "deals"},
+	{Name: "activities"},
+	{Name: "meetings"},
+	{Name: "custom_fields"},
+	{Name: "custom_field_values"},
+	{Name: "subscription_events"},
+	{Name: "invoices", ExportOnly: true},
+	{Name: "usage_rollups", Unique: []string{"metric", "granularity", "period_start"}},
+	{Name: "audit_logs"},
+}
+
+// ExportManifest describes the content of a tenant archive
+type ExportManifest struct {
+	FormatVersion int                  `json:"format_version"`
+	TenantID      uuid.UUID            `json:"tenant_id"`
+	TenantSlug    string               `json:"tenant_slug"`
+	CreatedAt     time.Time            `json:"created_at"`
+	Files         []ExportManifestFile `json:"files"`
+}
+
+// ExportManifestFile is one NDJSON file of a tenant archive
+type ExportManifestFile struct {
+	Table  string `json:"table"`
+	Path   string `json:"path"`
+	Rows   int64  `json:"rows"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+type TenantExportRepository interface {
+	Create(export *TenantExport) error
+	FindByID(id uuid.UUID) (*TenantExport, error)
+	FindByTenant(tenantID uuid.UUID, limit int) ([]*TenantExport, error)
+	FindByStatus(status string, updatedBefore time.Time) ([]*TenantExport, error)
+	FindExpired(now time.Time) ([]*TenantExport, error)
+	Update(export *TenantExport) error
+	// Claim atomically moves an export from one status to another
+	Claim(id uuid.UUID, from, to string) (bool, error)
+}
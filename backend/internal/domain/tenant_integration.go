@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Providers a tenant can connect
+const (
+	IntegrationChatwoot = "chatwoot"
+)
+
+// Status of a tenant integration, from the last connection check
+const (
+	IntegrationConnected = "connected"
+	IntegrationFailing   = "failing"
+)
+
+// TenantIntegration holds the connection of a tenant to an external service.
+// The credentials are encrypted, bound to the tenant and the provider.
+type TenantIntegration struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_tenant_integrations_provider,priority:1"`
+	Provider string    `json:"provider" gorm:"type:varchar(50);not null;uniqueIndex:idx_tenant_integrations_provider,priority:2"`
+
+	BaseURL string `json:"base_url" gorm:"type:varchar(500);not null"`
+	// AccountID is the account of the tenant at the provider
+	AccountID            int    `json:"account_id" gorm:"not null"`
+	CredentialsEncrypted string `json:"-" gorm:"type:text;not null"`
+	// WebhookSecretEncrypted is the secret signing the webhooks of the
+	// provider, when the tenant set one
+	WebhookSecretEncrypted string `json:"-" gorm:"type:text"`
+
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;default:'connected'"`
+	LastError     string     `json:"last_error,omitempty" gorm:"type:text"`
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	ConnectedBy   *uuid.UUID `json:"connected_by" gorm:"type:uuid"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for the TenantIntegration model
+func (TenantIntegration) TableName() string {
+	return "tenant_integrations"
+}
+
+// CredentialsContext is the associated data binding the encrypted
+// credentials to the tenant and the provider
+func (i *TenantIntegration) CredentialsContext() string {
+	return i.TenantID.String() + ":" + i.Provider
+}
+
+// WebhookSecretContext is the associated data binding the encrypted webhook
+// secret to the tenant and the provider
+func (i *TenantIntegration) WebhookSecretContext() string {
+	return i.CredentialsContext() + ":webhook"
+}
+
+type TenantIntegrationRepository interface {
+	FindByTenant(tenantID uuid.UUID, provider string) (*TenantIntegration, error)
+	Save(integration *TenantIntegration) error
+	Delete(tenantID uuid.UUID, provider string) error
+	// RecordCheck stores the outcome of a connection check
+	RecordCheck(tenantID uuid.UUID, provider string, checkErr error, now time.Time) error
+}
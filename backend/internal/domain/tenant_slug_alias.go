@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReservedSlugs are subdomains used by the platform that tenants cannot claim
+var ReservedSlugs = []string{
+	"www",
+	"app",
+	"api",
+	"admin",
+	"auth",
+	"login",
+	"dashboard",
+	"static",
+	"assets",
+	"cdn",
+	"mail",
+	"smtp",
+	"status",
+	"support",
+	"help",
+	"docs",
+	"blog",
+	"billing",
+	"webhooks",
+	"files",
+}
+
+// IsReservedSlug reports whether a slug is reserved by the platform
+func IsReservedSlug(slug string) bool {
+	for _, reserved := range ReservedSlugs {
+		if reserved == slug {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantSlugAlias keeps a former slug of a tenant resolvable after a rename
+type TenantSlugAlias struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID  uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	Slug      string    `json:"slug" gorm:"type:varchar(63);not null;uniqueIndex"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for the TenantSlugAlias model
+func (TenantSlugAlias) TableName() string {
+	return "tenant_slug_aliases"
+}
+
+type TenantSlugAliasRepository interface {
+	// FindActive returns the alias of a slug if it has not expired
+	FindActive(slug string, now time.Time) (*TenantSlugAlias, error)
+	FindByTenant(tenantID uuid.UUID) ([]*TenantSlugAlias, error)
+}
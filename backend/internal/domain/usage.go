@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Metered usage events
+const (
+	MetricMessagesSent         = "messages_sent"
+	MetricConversationsStarted = "conversations_started"
+	MetricBotRuns              = "bot_runs"
+	MetricAPICalls             = "api_calls"
+	MetricEmailsSent           = "emails_sent"
+)
+
+// UsageMetrics lists every metered event
+var UsageMetrics = []string{
+	MetricMessagesSent,
+	MetricConversationsStarted,
+	MetricBotRuns,
+	MetricAPICalls,
+	MetricEmailsSent,
+}
+
+// Rollup granularities
+const (
+	GranularityHour = "hour"
+	GranularityDay  = "day"
+)
+
+// IsValidUsageMetric checks if a metric is metered
+func IsValidUsageMetric(metric string) bool {
+	for _, m := range UsageMetrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// UsageRollup is the aggregated count of a metric for a tenant in one period
+type UsageRollup struct {
+	ID          uuid.UUID `json:"-" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID    uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;uniqueIndex:idx_usage_rollups_period"`
+	Metric      string    `json:"metric" gorm:"type:varchar(50);not null;uniqueIndex:idx_usage_rollups_period"`
+	Granularity string    `json:"granularity" gorm:"type:varchar(10);not null;uniqueIndex:idx_usage_rollups_period"`
+	PeriodStart time.Time `json:"period_start" gorm:"not null;uniqueIndex:idx_usage_rollups_period"`
+	Count       int64     `json:"count" gorm:"not null;default:0"`
+	CreatedAt   time.Time `json:"-"`
+	UpdatedAt   time.Time `json:"-"`
+}
+
+// TableName returns the table name for the UsageRollup model
+func (UsageRollup) TableName() string {
+	return "usage_rollups"
+}
+
+type UsageRepository interface {
+	// Increment adds the counts of the given rollups to the stored ones
+	Increment(rollups []UsageRollup) error
+	Query(tenantID uuid.UUID, granularity string, metrics []string, from, to time.Time) ([]*UsageRollup, error)
+	Sum(tenantID uuid.UUID, metric string, from, to time.Time) (int64, error)
+	DeleteBefore(granularity string, before time.Time) (int64, error)
+}
@@ -17,6 +17,19 @@ type User struct {
 	Role        string         `json:"role" gorm:"type:varchar(50);not null;default:'agent'"`
 	IsActive    bool           `json:"is_active" gorm:"default:true"`
 	LastLoginAt *time.Time     `json:"last_login_at"`
+	ChatwootAgentID *int       `json:"chatwoot_agent_id"`
+	Presence    string         `json:"presence" gorm:"type:varchar(20);not null;default:'offline'"`
+	LastSeenAt  *time.Time     `json:"last_seen_at"`
+	// MaxConcurrentConversations caps the conversations routed to the user, 0 means no limit
+	MaxConcurrentConversations int `json:"max_concurrent_conversations" gorm:"not null;default:0"`
+	// Locale of the emails sent to the user, the tenant locale when empty
+	Locale      string         `json:"locale" gorm:"type:varchar(10)"`
+	// EmailBouncedAt is set when emails to the user hard bounce or are
+	// reported as spam; the address is on the tenant suppression list
+	EmailBouncedAt *time.Time    `json:"email_bounced_at"`
+	// IsPlatformAdmin grants the /admin routes across tenants. It is set in
+	// the database by the operators and never through the API.
+	IsPlatformAdmin bool         `json:"is_platform_admin" gorm:"not null;default:false"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -45,6 +58,8 @@ type UserRepository interface {
 	FindByEmail(email string) (*User, error)
 	FindByEmailAndTenant(email string, tenantID uuid.UUID) (*User, error)
 	FindByTenant(tenantID uuid.UUID) ([]*User, error)
+	// Query returns a page of the tenant users matching the query, with the total count
+	Query(query UserQuery) (*UserPage, error)
 	Update(user *User) error
 	Delete(id uuid.UUID) error
 	CountByTenant(tenantID uuid.UUID) (int64, error)
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Fields the user list can be sorted by
+const (
+	UserSortName        = "name"
+	UserSortEmail       = "email"
+	UserSortCreatedAt   = "created_at"
+	UserSortLastLoginAt = "last_login_at"
+)
+
+// Page sizes of the user list
+const (
+	DefaultUserPageSize = 25
+	MaxUserPageSize     = 100
+)
+
+// UserQuery filters, sorts and paginates the users of a tenant
+type UserQuery struct {
+	TenantID uuid.UUID
+	// Search matches part of the name or email, case insensitively
+	Search          string
+	Roles           []string
+	IsActive        *bool
+	LastLoginAfter  *time.Time
+	LastLoginBefore *time.Time
+	NeverLoggedIn   bool
+	EmailBounced    bool
+	SortBy          string
+	SortDesc        bool
+	Limit           int
+	// After continues the listing after the last user of the previous page
+	After *UserCursor
+}
+
+// UserCursor is the position of a user in a sorted user list: the value of
+// the sort field and the user ID, which breaks ties
+type UserCursor struct {
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// UserPage is a page of a user query
+type UserPage struct {
+	Users []*User
+	// Next is the position to continue from, nil on the last page
+	Next  *UserCursor
+	Total int64
+}
+
+// IsValidUserSort reports whether the user list can be sorted by a field
+func IsValidUserSort(field string) bool {
+	switch field {
+	case UserSortName, UserSortEmail, UserSortCreatedAt, UserSortLastLoginAt:
+		return true
+	}
+	return false
+}
+
+// UserSortValue returns the value of the sort field of a user, as stored in
+// cursors. Text fields compare case insensitively and users that never logged
+// in sort as if they last logged in at the Unix epoch.
+func UserSortValue(user *User, field string) string {
+	switch field {
+	case UserSortName:
+		return strings.ToLower(user.Name)
+	case UserSortEmail:
+		return strings.ToLower(user.Email)
+	case UserSortLastLoginAt:
+		if user.LastLoginAt == nil {
+			return time.Unix(0, 0).UTC().Format(time.RFC3339Nano)
+		}
+		return user.LastLoginAt.UTC().Format(time.RFC3339Nano)
+	default:
+		return user.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserSortValue(t *testing.T) {
+	login := time.Date(2026, 3, 1, 12, 30, 0, 0, time.FixedZone("BRT", -3*3600))
+	user := &User{Name: "Ana Souza", Email: "Ana@Example.com", LastLoginAt: &login}
+
+	if got := UserSortValue(user, UserSortName); got != "ana souza" {
+		t.Errorf("name sort value = %q", got)
+	}
+	if got := UserSortValue(user, UserSortEmail); got != "ana@example.com" {
+		t.Errorf("email sort value = %q", got)
+	}
+	if got := UserSortValue(user, UserSortLastLoginAt); got != "2026-03-01T15:30:00Z" {
+		t.Errorf("last login sort value = %q, want UTC", got)
+	}
+
+	user.LastLoginAt = nil
+	if got := UserSortValue(user, UserSortLastLoginAt); got != "1970-01-01T00:00:00Z" {
+		t.Errorf("users that never logged in should sort at the epoch, got %q", got)
+	}
+
+	if IsValidUserSort("password_hash") {
+		t.Error("password_hash must not be sortable")
+	}
+}
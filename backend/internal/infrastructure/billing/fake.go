@@ -0,0 +1,135 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FakeProvider is an in-memory provider for local development and tests.
+// It accepts webhooks in the Stripe format signed with its own secret.
+type FakeProvider struct {
+	WebhookSecret string
+
+	mu            sync.Mutex
+	customers     map[string]*Customer
+	subscriptions map[string]*Subscription
+	invoices      map[string][]Invoice
+}
+
+// NewFakeProvider creates a fake provider
+func NewFakeProvider(webhookSecret string) *FakeProvider {
+	return &FakeProvider{
+		WebhookSecret: webhookSecret,
+		customers:     make(map[string]*Customer),
+		subscriptions: make(map[string]*Subscription),
+		invoices:      make(map[string][]Invoice),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) SignatureHeader() string {
+	return defaultSignatureHeaderName
+}
+
+func (p *FakeProvider) CreateCustomer(ctx context.Context, params CustomerParams) (*Customer, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	customer := &Customer{
+		ID:    "cus_fake_" + shortID(),
+		Email: params.Email,
+		Name:  params.Name,
+	}
+	p.customers[customer.ID] = customer
+	return customer, nil
+}
+
+func (p *FakeProvider) CreateSubscription(ctx context.Context, customerID, priceID string) (*Subscription, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.customers[customerID]; !ok {
+		return nil, ErrNotFound
+	}
+
+	now := time.Now()
+	subscription := &Subscription{
+		ID:               "sub_fake_" + shortID(),
+		CustomerID:       customerID,
+		Status:           "active",
+		PriceID:          priceID,
+		CurrentPeriodEnd: now.AddDate(0, 1, 0),
+	}
+	p.subscriptions[subscription.ID] = subscription
+
+	p.invoices[customerID] = append(p.invoices[customerID], Invoice{
+		ID:             "in_fake_" + shortID(),
+		CustomerID:     customerID,
+		SubscriptionID: subscription.ID,
+		Number:         fmt.Sprintf("FAKE-%04d", len(p.invoices[customerID])+1),
+		Status:         "paid",
+		Currency:       "brl",
+		PeriodStart:    now,
+		PeriodEnd:      subscription.CurrentPeriodEnd,
+		CreatedAt:      now,
+	})
+
+	return subscription, nil
+}
+
+func (p *FakeProvider) CancelSubscription(ctx context.Context, subscriptionID string, atPeriodEnd bool) (*Subscription, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	subscription, ok := p.subscriptions[subscriptionID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if atPeriodEnd {
+		subscription.CancelAtPeriodEnd = true
+	} else {
+		subscription.Status = "canceled"
+		subscription.CurrentPeriodEnd = time.Now()
+	}
+	return subscription, nil
+}
+
+func (p *FakeProvider) CreateCheckoutSession(ctx context.Context, params CheckoutParams) (*CheckoutSession, error) {
+	id := "cs_fake_" + shortID()
+	return &CheckoutSession{
+		ID:                id,
+		URL:               params.SuccessURL,
+		CustomerID:        params.CustomerID,
+		ClientReferenceID: params.ClientReferenceID,
+	}, nil
+}
+
+func (p *FakeProvider) ListInvoices(ctx context.Context, customerID string, limit int) ([]Invoice, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	invoices := p.invoices[customerID]
+	if len(invoices) > limit {
+		invoices = invoices[len(invoices)-limit:]
+	}
+	return append([]Invoice(nil), invoices...), nil
+}
+
+func (p *FakeProvider) ParseWebhook(payload []byte, signature string) (*Event, error) {
+	if err := VerifySignature(payload, signature, p.WebhookSecret, DefaultSignatureTolerance, time.Now()); err != nil {
+		return nil, err
+	}
+	return decodeStripeEvent(payload)
+}
+
+func shortID() string {
+	return uuid.New().String()[:8]
+}
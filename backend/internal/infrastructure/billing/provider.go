@@ -0,0 +1,199 @@
+package billing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrNotFound         = errors.New("billing object not found")
+	ErrNotConfigured    = errors.New("billing provider not configured")
+)
+
+// Webhook event types (Stripe naming)
+const (
+	EventCheckoutCompleted     = "checkout.session.completed"
+	EventSubscriptionCreated   = "customer.subscription.created"
+	EventSubscriptionUpdated   = "customer.subscription.updated"
+	EventSubscriptionDeleted   = "customer.subscription.deleted"
+	EventInvoiceFinalized      = "invoice.finalized"
+	EventInvoicePaid           = "invoice.paid"
+	EventInvoicePaymentFailed  = "invoice.payment_failed"
+	EventInvoiceUpdated        = "invoice.updated"
+	DefaultSignatureTolerance  = 5 * time.Minute
+	defaultSignatureHeaderName = "Stripe-Signature"
+)
+
+// Customer is a billing customer linked to a tenant
+type Customer struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// Subscription is a provider subscription
+type Subscription struct {
+	ID                string    `json:"id"`
+	CustomerID        string    `json:"customer_id"`
+	Status            string    `json:"status"`
+	PriceID           string    `json:"price_id"`
+	CurrentPeriodEnd  time.Time `json:"current_period_end"`
+	CancelAtPeriodEnd bool      `json:"cancel_at_period_end"`
+}
+
+// CheckoutSession is a hosted payment page
+type CheckoutSession struct {
+	ID                string `json:"id"`
+	URL               string `json:"url"`
+	CustomerID        string `json:"customer_id"`
+	SubscriptionID    string `json:"subscription_id"`
+	ClientReferenceID string `json:"client_reference_id"`
+}
+
+// Invoice is a provider invoice
+type Invoice struct {
+	ID             string    `json:"id"`
+	CustomerID     string    `json:"customer_id"`
+	SubscriptionID string    `json:"subscription_id"`
+	Number         string    `json:"number"`
+	Status         string    `json:"status"`
+	AmountDue      int64     `json:"amount_due"`
+	AmountPaid     int64     `json:"amount_paid"`
+	Currency       string    `json:"currency"`
+	HostedURL      string    `json:"hosted_url"`
+	PDFURL         string    `json:"pdf_url"`
+	PeriodStart    time.Time `json:"period_start"`
+	PeriodEnd      time.Time `json:"period_end"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Event is a verified webhook event decoded into provider-neutral objects
+type Event struct {
+	ID           string
+	Type         string
+	CreatedAt    time.Time
+	Checkout     *CheckoutSession
+	Subscription *Subscription
+	Invoice      *Invoice
+}
+
+// CustomerParams are used to create a customer
+type CustomerParams struct {
+	Email    string
+	Name     string
+	Metadata map[string]string
+}
+
+// CheckoutParams are used to create a checkout session for a subscription
+type CheckoutParams struct {
+	CustomerID        string
+	PriceID           string
+	SuccessURL        string
+	CancelURL         string
+	ClientReferenceID string
+}
+
+// Provider is implemented by every billing backend
+type Provider interface {
+	Name() string
+	SignatureHeader() string
+	CreateCustomer(ctx context.Context, params CustomerParams) (*Customer, error)
+	CreateSubscription(ctx context.Context, customerID, priceID string) (*Subscription, error)
+	CancelSubscription(ctx context.Context, subscriptionID string, atPeriodEnd bool) (*Subscription, error)
+	CreateCheckoutSession(ctx context.Context, params CheckoutParams) (*CheckoutSession, error)
+	ListInvoices(ctx context.Context, customerID string, limit int) ([]Invoice, error)
+	ParseWebhook(payload []byte, signature string) (*Event, error)
+}
+
+// NewProviderFromConfig builds the provider selected by BILLING_PROVIDER
+// (stripe or fake). A webhook secret is required, so unsigned webhooks are
+// never accepted, and the fake provider is refused in production.
+func NewProviderFromConfig() (Provider, error) {
+	switch name := viper.GetString("BILLING_PROVIDER"); name {
+	case "stripe":
+		secretKey := viper.GetString("STRIPE_SECRET_KEY")
+		webhookSecret := viper.GetString("STRIPE_WEBHOOK_SECRET")
+		if secretKey == "" || webhookSecret == "" {
+			return nil, fmt.Errorf("%w: STRIPE_SECRET_KEY and STRIPE_WEBHOOK_SECRET are required", ErrNotConfigured)
+		}
+		return NewStripeProvider(secretKey, webhookSecret), nil
+	case "", "fake":
+		if viper.GetString("ENV") == "production" {
+			return nil, fmt.Errorf("%w: the fake provider is not allowed in production", ErrNotConfigured)
+		}
+		secret := viper.GetString("BILLING_WEBHOOK_SECRET")
+		if secret == "" {
+			return nil, fmt.Errorf("%w: BILLING_WEBHOOK_SECRET is required", ErrNotConfigured)
+		}
+		return NewFakeProvider(secret), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown provider %q", ErrNotConfigured, name)
+	}
+}
+
+// SignPayload builds a Stripe-style signature header ("t=<ts>,v1=<hmac>")
+func SignPayload(payload []byte, secret string, timestamp time.Time) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", ts, computeSignature(ts, payload, secret))
+}
+
+// VerifySignature checks a Stripe-style signature header against the payload
+func VerifySignature(payload []byte, header, secret string, tolerance time.Duration, now time.Time) error {
+	if secret == "" || header == "" {
+		return ErrInvalidSignature
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 && now.Sub(time.Unix(ts, 0)).Abs() > tolerance {
+		return ErrInvalidSignature
+	}
+
+	expected := computeSignature(timestamp, payload, secret)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+func computeSignature(timestamp string, payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,57 @@
+package billing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	payload := []byte(`{"id":"evt_1","type":"invoice.paid"}`)
+	secret := "whsec_test"
+	now := time.Unix(1700000000, 0)
+
+	header := SignPayload(payload, secret, now)
+
+	if err := VerifySignature(payload, header, secret, DefaultSignatureTolerance, now); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+
+	if err := VerifySignature([]byte(`{"id":"evt_2"}`), header, secret, DefaultSignatureTolerance, now); err != ErrInvalidSignature {
+		t.Errorf("expected tampered payload to fail, got %v", err)
+	}
+
+	if err := VerifySignature(payload, header, "other", DefaultSignatureTolerance, now); err != ErrInvalidSignature {
+		t.Errorf("expected wrong secret to fail, got %v", err)
+	}
+
+	if err := VerifySignature(payload, header, secret, DefaultSignatureTolerance, now.Add(10*time.Minute)); err != ErrInvalidSignature {
+		t.Errorf("expected stale signature to fail, got %v", err)
+	}
+}
+
+func TestDecodeStripeEvent(t *testing.T) {
+	payload := []byte(`{
+		"id": "evt_1",
+		"type": "customer.subscription.updated",
+		"created": 1700000000,
+		"data": {"object": {
+			"id": "sub_1",
+			"customer": "cus_1",
+			"status": "past_due",
+			"current_period_end": 1702592000,
+			"items": {"data": [{"price": {"id": "price_pro"}}]}
+		}}
+	}`)
+
+	event, err := decodeStripeEvent(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if event.Subscription == nil {
+		t.Fatal("expected subscription to be decoded")
+	}
+	if event.Subscription.CustomerID != "cus_1" || event.Subscription.PriceID != "price_pro" || event.Subscription.Status != "past_due" {
+		t.Errorf("unexpected subscription: %+v", event.Subscription)
+	}
+}
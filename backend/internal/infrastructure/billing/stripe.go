@@ -0,0 +1,319 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const stripeAPIURL = "https://api.stripe.com/v1"
+
+// StripeProvider talks to the Stripe API (or any API compatible with it)
+type StripeProvider struct {
+	BaseURL       string
+	SecretKey     string
+	WebhookSecret string
+	HTTPClient    *http.Client
+}
+
+// NewStripeProvider creates a Stripe provider
+func NewStripeProvider(secretKey, webhookSecret string) *StripeProvider {
+	return &StripeProvider{
+		BaseURL:       stripeAPIURL,
+		SecretKey:     secretKey,
+		WebhookSecret: webhookSecret,
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (p *StripeProvider) Name() string {
+	return "stripe"
+}
+
+func (p *StripeProvider) SignatureHeader() string {
+	return defaultSignatureHeaderName
+}
+
+// CreateCustomer creates a Stripe customer
+func (p *StripeProvider) CreateCustomer(ctx context.Context, params CustomerParams) (*Customer, error) {
+	form := url.Values{}
+	form.Set("email", params.Email)
+	form.Set("name", params.Name)
+	for key, value := range params.Metadata {
+		form.Set(fmt.Sprintf("metadata[%s]", key), value)
+	}
+
+	var customer stripeCustomer
+	if err := p.do(ctx, http.MethodPost, "/customers", form, &customer); err != nil {
+		return nil, err
+	}
+
+	return &Customer{ID: customer.ID, Email: customer.Email, Name: customer.Name}, nil
+}
+
+// CreateSubscription subscribes a customer to a price using the default payment method
+func (p *StripeProvider) CreateSubscription(ctx context.Context, customerID, priceID string) (*Subscription, error) {
+	form := url.Values{}
+	form.Set("customer", customerID)
+	form.Set("items[0][price]", priceID)
+
+	var subscription stripeSubscription
+	if err := p.do(ctx, http.MethodPost, "/subscriptions", form, &subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription.toSubscription(), nil
+}
+
+// CancelSubscription cancels a subscription now or at the end of the period
+func (p *StripeProvider) CancelSubscription(ctx context.Context, subscriptionID string, atPeriodEnd bool) (*Subscription, error) {
+	var subscription stripeSubscription
+
+	if atPeriodEnd {
+		form := url.Values{}
+		form.Set("cancel_at_period_end", "true")
+		if err := p.do(ctx, http.MethodPost, "/subscriptions/"+subscriptionID, form, &subscription); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := p.do(ctx, http.MethodDelete, "/subscriptions/"+subscriptionID, nil, &subscription); err != nil {
+			return nil, err
+		}
+	}
+
+	return subscription.toSubscription(), nil
+}
+
+// CreateCheckoutSession creates a hosted checkout page for a subscription
+func (p *StripeProvider) CreateCheckoutSession(ctx context.Context, params CheckoutParams) (*CheckoutSession, error) {
+	form := url.Values{}
+	form.Set("mode", "subscription")
+	form.Set("customer", params.CustomerID)
+	form.Set("line_items[0][price]", params.PriceID)
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("success_url", params.SuccessURL)
+	form.Set("cancel_url", params.CancelURL)
+	form.Set("client_reference_id", params.ClientReferenceID)
+
+	var session stripeCheckoutSession
+	if err := p.do(ctx, http.MethodPost, "/checkout/sessions", form, &session); err != nil {
+		return nil, err
+	}
+
+	return session.toCheckoutSession(), nil
+}
+
+// ListInvoices returns the latest invoices of a customer
+func (p *StripeProvider) ListInvoices(ctx context.Context, customerID string, limit int) ([]Invoice, error) {
+	query := url.Values{}
+	query.Set("customer", customerID)
+	query.Set("limit", strconv.Itoa(limit))
+
+	var list struct {
+		Data []stripeInvoice `json:"data"`
+	}
+	if err := p.do(ctx, http.MethodGet, "/invoices?"+query.Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+
+	invoices := make([]Invoice, 0, len(list.Data))
+	for _, invoice := range list.Data {
+		invoices = append(invoices, *invoice.toInvoice())
+	}
+	return invoices, nil
+}
+
+// ParseWebhook verifies the Stripe-Signature header and decodes the event
+func (p *StripeProvider) ParseWebhook(payload []byte, signature string) (*Event, error) {
+	if err := VerifySignature(payload, signature, p.WebhookSecret, DefaultSignatureTolerance, time.Now()); err != nil {
+		return nil, err
+	}
+	return decodeStripeEvent(payload)
+}
+
+func (p *StripeProvider) do(ctx context.Context, method, path string, form url.Values, out interface{}) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.BaseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.SecretKey)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// Stripe wire objects
+
+type stripeCustomer struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+type stripeSubscription struct {
+	ID                string `json:"id"`
+	Customer          string `json:"customer"`
+	Status            string `json:"status"`
+	CurrentPeriodEnd  int64  `json:"current_period_end"`
+	CancelAtPeriodEnd bool   `json:"cancel_at_period_end"`
+	Items             struct {
+		Data []struct {
+			Price struct {
+				ID string `json:"id"`
+			} `json:"price"`
+		} `json:"data"`
+	} `json:"items"`
+}
+
+func (s *stripeSubscription) toSubscription() *Subscription {
+	subscription := &Subscription{
+		ID:                s.ID,
+		CustomerID:        s.Customer,
+		Status:            s.Status,
+		CancelAtPeriodEnd: s.CancelAtPeriodEnd,
+	}
+	if s.CurrentPeriodEnd > 0 {
+		subscription.CurrentPeriodEnd = time.Unix(s.CurrentPeriodEnd, 0)
+	}
+	if len(s.Items.Data) > 0 {
+		subscription.PriceID = s.Items.Data[0].Price.ID
+	}
+	return subscription
+}
+
+type stripeCheckoutSession struct {
+	ID                string `json:"id"`
+	URL               string `json:"url"`
+	Customer          string `json:"customer"`
+	Subscription      string `json:"subscription"`
+	ClientReferenceID string `json:"client_reference_id"`
+}
+
+func (s *stripeCheckoutSession) toCheckoutSession() *CheckoutSession {
+	return &CheckoutSession{
+		ID:                s.ID,
+		URL:               s.URL,
+		CustomerID:        s.Customer,
+		SubscriptionID:    s.Subscription,
+		ClientReferenceID: s.ClientReferenceID,
+	}
+}
+
+type stripeInvoice struct {
+	ID               string `json:"id"`
+	Customer         string `json:"customer"`
+	Subscription     string `json:"subscription"`
+	Number           string `json:"number"`
+	Status           string `json:"status"`
+	AmountDue        int64  `json:"amount_due"`
+	AmountPaid       int64  `json:"amount_paid"`
+	Currency         string `json:"currency"`
+	HostedInvoiceURL string `json:"hosted_invoice_url"`
+	InvoicePDF       string `json:"invoice_pdf"`
+	PeriodStart      int64  `json:"period_start"`
+	PeriodEnd        int64  `json:"period_end"`
+	Created          int64  `json:"created"`
+}
+
+func (i *stripeInvoice) toInvoice() *Invoice {
+	return &Invoice{
+		ID:             i.ID,
+		CustomerID:     i.Customer,
+		SubscriptionID: i.Subscription,
+		Number:         i.Number,
+		Status:         i.Status,
+		AmountDue:      i.AmountDue,
+		AmountPaid:     i.AmountPaid,
+		Currency:       i.Currency,
+		HostedURL:      i.HostedInvoiceURL,
+		PDFURL:         i.InvoicePDF,
+		PeriodStart:    time.Unix(i.PeriodStart, 0),
+		PeriodEnd:      time.Unix(i.PeriodEnd, 0),
+		CreatedAt:      time.Unix(i.Created, 0),
+	}
+}
+
+type stripeEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// decodeStripeEvent turns a Stripe event payload into an Event
+func decodeStripeEvent(payload []byte) (*Event, error) {
+	var raw stripeEvent
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode event: %w", err)
+	}
+
+	if raw.ID == "" || raw.Type == "" {
+		return nil, fmt.Errorf("event id and type are required")
+	}
+
+	event := &Event{
+		ID:        raw.ID,
+		Type:      raw.Type,
+		CreatedAt: time.Unix(raw.Created, 0),
+	}
+
+	switch {
+	case raw.Type == EventCheckoutCompleted:
+		var session stripeCheckoutSession
+		if err := json.Unmarshal(raw.Data.Object, &session); err != nil {
+			return nil, fmt.Errorf("failed to decode checkout session: %w", err)
+		}
+		event.Checkout = session.toCheckoutSession()
+	case strings.HasPrefix(raw.Type, "customer.subscription."):
+		var subscription stripeSubscription
+		if err := json.Unmarshal(raw.Data.Object, &subscription); err != nil {
+			return nil, fmt.Errorf("failed to decode subscription: %w", err)
+		}
+		event.Subscription = subscription.toSubscription()
+	case strings.HasPrefix(raw.Type, "invoice."):
+		var invoice stripeInvoice
+		if err := json.Unmarshal(raw.Data.Object, &invoice); err != nil {
+			return nil, fmt.Errorf("failed to decode invoice: %w", err)
+		}
+		event.Invoice = invoice.toInvoice()
+	}
+
+	return event, nil
+}
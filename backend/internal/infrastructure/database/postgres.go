@@ -8,6 +8,7 @@ import (
 	"github.com/widia/widia-connect/internal/domain"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -41,10 +42,39 @@ func Initialize() (*gorm.DB, error) {
 }
 
 func Migrate(db *gorm.DB) error {
+	// The user_role enum must be gone before the models are migrated
+	if err := convertUserRoles(db); err != nil {
+		return fmt.Errorf("failed to convert user roles: %w", err)
+	}
+	
 	// Auto migrate domain models
 	if err := db.AutoMigrate(
+		&domain.Plan{},
 		&domain.Tenant{},
 		&domain.User{},
+		&domain.TenantFeatureOverride{},
+		&domain.SubscriptionEvent{},
+		&domain.BillingAccount{},
+		&domain.Invoice{},
+		&domain.BillingWebhookEvent{},
+		&domain.UsageRollup{},
+		&domain.TenantExport{},
+		&domain.AuditLog{},
+		&domain.TenantSlugAlias{},
+		&domain.OnboardingStep{},
+		&domain.OnboardingNudge{},
+		&domain.Role{},
+		&domain.Team{},
+		&domain.TeamMember{},
+		&domain.UserSchedule{},
+		&domain.OwnershipTransfer{},
+		&domain.EmailOutboxMessage{},
+		&domain.TenantMailSettings{},
+		&domain.EmailDeliveryEvent{},
+		&domain.EmailSuppression{},
+		&domain.TenantIntegration{},
+		&domain.ChatwootProvisioning{},
+		&domain.ChatwootWebhookDelivery{},
 	); err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
@@ -58,14 +88,130 @@ func Migrate(db *gorm.DB) error {
 		return fmt.Errorf("failed to create unique index: %w", err)
 	}
 	
+	// Move the data of existing tenants to the current model
+	if err := backfillData(db); err != nil {
+		return fmt.Errorf("failed to backfill data: %w", err)
+	}
+	
+	// A tenant has exactly one owner
+	if err := db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_owner
+		ON users(tenant_id)
+		WHERE role = 'owner' AND deleted_at IS NULL
+	`).Error; err != nil {
+		return fmt.Errorf("failed to create owner index: %w", err)
+	}
+	
 	// Enable RLS on users table
 	if err := enableRLS(db); err != nil {
 		return fmt.Errorf("failed to enable RLS: %w", err)
 	}
 	
+	// Seed default subscription plans
+	if err := seedPlans(db); err != nil {
+		return fmt.Errorf("failed to seed plans: %w", err)
+	}
+	
 	return nil
 }
 
+// seedPlans creates the default plans and updates the existing ones, so
+// changes to their limits and features apply on the next start. The provider
+// price is set by the operators and kept.
+func seedPlans(db *gorm.DB) error {
+	for _, plan := range domain.DefaultPlans {
+		plan := plan
+		if err := db.Omit("ProviderPriceID").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "code"}},
+			UpdateAll: true,
+		}).Create(&plan).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertUserRoles turns users.role from the former user_role enum, which
+// knows neither owner nor custom roles, into a plain string
+func convertUserRoles(db *gorm.DB) error {
+	var enum int64
+	if err := db.Raw(`
+		SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_name = 'users' AND column_name = 'role' AND data_type = 'USER-DEFINED'
+	`).Scan(&enum).Error; err != nil || enum == 0 {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range []string{
+			`ALTER TABLE users ALTER COLUMN role DROP DEFAULT`,
+			`ALTER TABLE users ALTER COLUMN role TYPE VARCHAR(50) USING role::text`,
+			`UPDATE users SET role = 'agent' WHERE role IS NULL`,
+			`ALTER TABLE users ALTER COLUMN role SET DEFAULT 'agent'`,
+			`ALTER TABLE users ALTER COLUMN role SET NOT NULL`,
+			`DROP TYPE IF EXISTS user_role`,
+		} {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// backfillData moves the data written by former versions to the current
+// model. Each statement only matches rows left to migrate, so it runs on
+// every start like seedPlans.
+func backfillData(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range []string{
+			// Flags set in the tenant settings ("chat_enabled": true, ...)
+			// become feature overrides, so tenants keep the features they had
+			`INSERT INTO tenant_feature_overrides (tenant_id, feature, enabled, reason, created_at, updated_at)
+			SELECT t.id, regexp_replace(f.key, '_enabled$', ''), (f.value)::text::boolean, 'Migrated from tenant settings', NOW(), NOW()
+			FROM tenants t, jsonb_each(t.settings->'features') AS f
+			WHERE jsonb_typeof(t.settings->'features') = 'object'
+			  AND jsonb_typeof(f.value) = 'boolean'
+			ON CONFLICT (tenant_id, feature) DO NOTHING`,
+			`UPDATE tenants SET settings = settings - 'features' WHERE settings ? 'features'`,
+
+			// Users created with the former super_admin role become platform
+			// admins and tenant admins
+			`UPDATE users SET is_platform_admin = true, role = 'admin' WHERE role = 'super_admin'`,
+
+			// Keep a single owner per tenant: the earliest one stays owner, the
+			// others become admins
+			`UPDATE users SET role = 'admin'
+			WHERE role = 'owner' AND deleted_at IS NULL
+			  AND id NOT IN (
+			    SELECT DISTINCT ON (tenant_id) id
+			    FROM users
+			    WHERE role = 'owner' AND deleted_at IS NULL
+			    ORDER BY tenant_id, created_at, id
+			  )`,
+
+			// Tenants registered before ownership existed: promote the
+			// earliest admin, active ones first
+			`UPDATE users SET role = 'owner'
+			WHERE id IN (
+			    SELECT DISTINCT ON (u.tenant_id) u.id
+			    FROM users u
+			    WHERE u.role = 'admin' AND u.deleted_at IS NULL
+			      AND NOT EXISTS (
+			        SELECT 1 FROM users o
+			        WHERE o.tenant_id = u.tenant_id AND o.role = 'owner' AND o.deleted_at IS NULL
+			      )
+			    ORDER BY u.tenant_id, u.is_active DESC, u.created_at, u.id
+			)`,
+		} {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func enableRLS(db *gorm.DB) error {
 	// Enable RLS on users table
 	if err := db.Exec("ALTER TABLE users ENABLE ROW LEVEL SECURITY").Error; err != nil {
@@ -0,0 +1,123 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Default platform branding used when a tenant has not customized it
+const (
+	defaultPrimaryColor   = "#667eea"
+	defaultSecondaryColor = "#764ba2"
+)
+
+// Branding is the look of the transactional emails sent on behalf of a tenant
+type Branding struct {
+	// TenantID is the tenant the emails are sent for, uuid.Nil for the platform
+	TenantID       uuid.UUID
+	Name           string
+	LogoURL        string
+	PrimaryColor   string
+	SecondaryColor string
+	SupportEmail   string
+	Footer         string
+	// Locale of the recipient, the default locale when empty
+	Locale string
+	// Overrides customize the subject and intro of the templates by name
+	Overrides map[string]TemplateOverride
+}
+
+// DefaultBranding returns the platform branding
+func DefaultBranding() Branding {
+	return Branding{
+		Name:           getEnv("SMTP_FROM_NAME", "Widia Sales AI"),
+		PrimaryColor:   defaultPrimaryColor,
+		SecondaryColor: defaultSecondaryColor,
+	}
+}
+
+// WithDefaults fills the fields a tenant left empty with the platform branding
+func (b Branding) WithDefaults() Branding {
+	defaults := DefaultBranding()
+	if b.Name == "" {
+		b.Name = defaults.Name
+	}
+	if b.PrimaryColor == "" {
+		b.PrimaryColor = defaults.PrimaryColor
+	}
+	if b.SecondaryColor == "" {
+		b.SecondaryColor = defaults.SecondaryColor
+	}
+	return b
+}
+
+func (b Branding) copyright() string {
+	if b.Footer != "" {
+		return b.Footer
+	}
+	return fmt.Sprintf("© %d %s. %s", time.Now().Year(), b.Name, engine.translate(b.locale(), "footer.rights", TemplateData{}))
+}
+
+// WithLocale returns the branding for a recipient who chose a locale; an
+// empty or unsupported locale keeps the tenant locale
+func (b Branding) WithLocale(locale string) Branding {
+	if locale = NormalizeLocale(locale); locale != "" {
+		b.Locale = locale
+	}
+	return b
+}
+
+func (b Branding) locale() string {
+	if locale := NormalizeLocale(b.Locale); locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// formatDate formats a date the way the locale writes it
+func (b Branding) formatDate(t time.Time) string {
+	return t.Format(engine.translate(b.locale(), "date_format", TemplateData{}))
+}
+
+// formatDateTime formats a date and time the way the locale writes it
+func (b Branding) formatDateTime(t time.Time) string {
+	return t.Format(engine.translate(b.locale(), "datetime_format", TemplateData{}))
+}
+
+// renderHTML wraps the content of an email in the branded layout
+func (b Branding) renderHTML(heading, content string) string {
+	view := templateView{
+		Locale:  b.locale(),
+		Brand:   b,
+		Heading: heading,
+		Content: htmltemplate.HTML(content),
+	}
+
+	var buf bytes.Buffer
+	if err := engine.html.ExecuteTemplate(&buf, "layout", view); err != nil {
+		log.Printf("Failed to render email layout: %v", err)
+		return content
+	}
+	return buf.String()
+}
+
+// renderText appends the branded footer to the plain text version of an email
+func (b Branding) renderText(content string) string {
+	view := templateView{
+		Locale:  b.locale(),
+		Brand:   b,
+		Content: content,
+	}
+
+	var buf bytes.Buffer
+	if err := engine.text.ExecuteTemplate(&buf, "layout.txt", view); err != nil {
+		log.Printf("Failed to render email text layout: %v", err)
+		return content
+	}
+	return buf.String()
+}
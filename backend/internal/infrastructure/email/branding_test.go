@@ -0,0 +1,38 @@
+package email
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBrandingWithDefaults(t *testing.T) {
+	brand := Branding{PrimaryColor: "#000000"}.WithDefaults()
+
+	if brand.Name == "" || brand.SecondaryColor != defaultSecondaryColor {
+		t.Errorf("expected empty fields to use the platform defaults, got %+v", brand)
+	}
+	if brand.PrimaryColor != "#000000" {
+		t.Errorf("expected tenant color to be kept, got %s", brand.PrimaryColor)
+	}
+}
+
+func TestBrandingRenderHTML(t *testing.T) {
+	brand := Branding{
+		Name:         "Acme <Corp>",
+		LogoURL:      "https://cdn.example.com/logo.png",
+		PrimaryColor: "#112233",
+		SupportEmail: "help@acme.test",
+		Footer:       "Acme Corp, Rua A 123",
+	}.WithDefaults()
+
+	body := brand.renderHTML("Title", "<p>content</p>")
+
+	for _, want := range []string{"#112233", "https://cdn.example.com/logo.png", "help@acme.test", "Acme Corp, Rua A 123", "Acme &lt;Corp&gt;"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected rendered email to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "Widia Sales AI") {
+		t.Error("expected white-label email not to mention the platform")
+	}
+}
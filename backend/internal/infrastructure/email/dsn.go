@@ -0,0 +1,199 @@
+package email
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// ErrNotDeliveryReport is returned for returned mail that is neither a
+// delivery status notification nor an abuse feedback report
+var ErrNotDeliveryReport = errors.New("message is not a delivery report")
+
+// DeliveryReport is a bounce (RFC 3464 delivery status notification) or a
+// complaint (RFC 5965 abuse feedback report) about an email
+type DeliveryReport struct {
+	// Complaint is set for feedback reports, otherwise the report is a bounce
+	Complaint bool
+	// MessageID is the Message-ID header of the original email, when quoted
+	MessageID  string
+	Recipients []ReportRecipient
+}
+
+// ReportRecipient is the outcome of the original email for one recipient
+type ReportRecipient struct {
+	Address string
+	// Action is failed, delayed, delivered, relayed or expanded for bounces
+	Action string
+	// Status is the enhanced status code, such as 5.1.1
+	Status     string
+	Diagnostic string
+}
+
+// Failed reports whether the recipient did not get the email
+func (r ReportRecipient) Failed() bool {
+	return r.Action == "failed" || r.Action == "delayed"
+}
+
+// Permanent reports whether the failure will not go away by retrying
+func (r ReportRecipient) Permanent() bool {
+	return r.Action == "failed" && !strings.HasPrefix(r.Status, "4")
+}
+
+// ParseReport reads a delivery status notification or an abuse feedback
+// report from a returned email
+func ParseReport(r io.Reader) (*DeliveryReport, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/report" {
+		return nil, ErrNotDeliveryReport
+	}
+
+	report := &DeliveryReport{}
+	switch strings.ToLower(params["report-type"]) {
+	case "delivery-status":
+	case "feedback-report":
+		report.Complaint = true
+	default:
+		return nil, ErrNotDeliveryReport
+	}
+
+	var originalTo string
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read report: %w", err)
+		}
+
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		body := decodePart(part)
+		switch strings.ToLower(partType) {
+		case "message/delivery-status", "message/global-delivery-status":
+			recipients, err := parseDeliveryStatus(body)
+			if err != nil {
+				return nil, err
+			}
+			report.Recipients = append(report.Recipients, recipients...)
+		case "message/feedback-report":
+			fields, err := readFields(bufio.NewReader(body))
+			if err != nil && err != io.EOF {
+				return nil, fmt.Errorf("failed to read feedback report: %w", err)
+			}
+			for _, address := range fields.Values("Original-Rcpt-To") {
+				report.Recipients = append(report.Recipients, ReportRecipient{
+					Address:    strings.Trim(strings.TrimSpace(address), "<>"),
+					Diagnostic: fields.Get("Feedback-Type"),
+				})
+			}
+		case "message/rfc822", "text/rfc822-headers", "message/rfc822-headers", "message/global", "message/global-headers":
+			headers, err := readFields(bufio.NewReader(body))
+			if err != nil && len(headers) == 0 {
+				continue
+			}
+			report.MessageID = MessageIDLocalPart(headers.Get("Message-Id"))
+			originalTo = headers.Get("To")
+		}
+	}
+
+	// Feedback reports may leave out the recipient, found in the original email
+	if report.Complaint && len(report.Recipients) == 0 && originalTo != "" {
+		if address, err := mail.ParseAddress(originalTo); err == nil {
+			report.Recipients = append(report.Recipients, ReportRecipient{Address: address.Address})
+		}
+	}
+	if len(report.Recipients) == 0 {
+		return nil, ErrNotDeliveryReport
+	}
+	return report, nil
+}
+
+// parseDeliveryStatus reads the per-message fields followed by a block of
+// fields for each recipient
+func parseDeliveryStatus(body io.Reader) ([]ReportRecipient, error) {
+	reader := bufio.NewReader(body)
+	if _, err := readFields(reader); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read delivery status: %w", err)
+	}
+
+	var recipients []ReportRecipient
+	for {
+		fields, err := readFields(reader)
+		if len(fields) > 0 {
+			address := typedValue(fields.Get("Final-Recipient"))
+			if address == "" {
+				address = typedValue(fields.Get("Original-Recipient"))
+			}
+			if address != "" {
+				recipients = append(recipients, ReportRecipient{
+					Address:    address,
+					Action:     strings.ToLower(strings.TrimSpace(fields.Get("Action"))),
+					Status:     strings.TrimSpace(fields.Get("Status")),
+					Diagnostic: typedValue(fields.Get("Diagnostic-Code")),
+				})
+			}
+		}
+		if err == io.EOF {
+			return recipients, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read delivery status: %w", err)
+		}
+	}
+}
+
+// readFields reads a block of header fields up to a blank line
+func readFields(reader *bufio.Reader) (textproto.MIMEHeader, error) {
+	// Skip the blank lines between blocks
+	for {
+		next, err := reader.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if next[0] != '\r' && next[0] != '\n' {
+			break
+		}
+		if _, err := reader.ReadByte(); err != nil {
+			return nil, err
+		}
+	}
+	fields, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return fields, err
+}
+
+// typedValue strips the type of a field such as "rfc822; ana@acme.test"
+func typedValue(value string) string {
+	if i := strings.Index(value, ";"); i >= 0 {
+		value = value[i+1:]
+	}
+	return strings.TrimSpace(value)
+}
+
+// decodePart undoes the base64 transfer encoding of a part; quoted-printable
+// parts are already decoded by the multipart reader
+func decodePart(part *multipart.Part) io.Reader {
+	if strings.EqualFold(part.Header.Get("Content-Transfer-Encoding"), "base64") {
+		return base64.NewDecoder(base64.StdEncoding, part)
+	}
+	return part
+}
@@ -0,0 +1,128 @@
+package email
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleDSN = "From: MAILER-DAEMON@mx.acme.test\r\n" +
+	"To: noreply@widia.ai\r\n" +
+	"Subject: Undelivered Mail Returned to Sender\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/report; report-type=delivery-status; boundary=\"BOUNDARY\"\r\n" +
+	"\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"Your message could not be delivered.\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Type: message/delivery-status\r\n" +
+	"\r\n" +
+	"Reporting-MTA: dns; mx.acme.test\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; ana@acme.test\r\n" +
+	"Action: failed\r\n" +
+	"Status: 5.1.1\r\n" +
+	"Diagnostic-Code: smtp; 550 5.1.1 <ana@acme.test>: Recipient address rejected:\r\n" +
+	" User unknown\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; bia@acme.test\r\n" +
+	"Action: delayed\r\n" +
+	"Status: 4.2.2\r\n" +
+	"\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Type: text/rfc822-headers\r\n" +
+	"\r\n" +
+	"From: Acme <noreply@widia.ai>\r\n" +
+	"To: ana@acme.test\r\n" +
+	"Message-ID: <7d3f1c2e-8a4b-4c5d-9e6f-0a1b2c3d4e5f@widia.ai>\r\n" +
+	"Subject: Welcome\r\n" +
+	"\r\n" +
+	"--BOUNDARY--\r\n"
+
+const sampleARF = "From: abuse@isp.test\r\n" +
+	"To: noreply@widia.ai\r\n" +
+	"Subject: Abuse report\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/report; report-type=feedback-report; boundary=\"ARF\"\r\n" +
+	"\r\n" +
+	"--ARF\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"This is an email abuse report.\r\n" +
+	"--ARF\r\n" +
+	"Content-Type: message/feedback-report\r\n" +
+	"\r\n" +
+	"Feedback-Type: abuse\r\n" +
+	"User-Agent: ISP-FBL/1.0\r\n" +
+	"Version: 1\r\n" +
+	"--ARF\r\n" +
+	"Content-Type: message/rfc822\r\n" +
+	"\r\n" +
+	"From: Acme <noreply@widia.ai>\r\n" +
+	"To: Carla <carla@isp.test>\r\n" +
+	"Message-ID: <abc@widia.ai>\r\n" +
+	"Subject: Welcome\r\n" +
+	"\r\n" +
+	"Hello\r\n" +
+	"--ARF--\r\n"
+
+func TestParseReportBounce(t *testing.T) {
+	report, err := ParseReport(strings.NewReader(sampleDSN))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Complaint {
+		t.Error("expected a bounce")
+	}
+	if report.MessageID != "7d3f1c2e-8a4b-4c5d-9e6f-0a1b2c3d4e5f" {
+		t.Errorf("unexpected message ID %q", report.MessageID)
+	}
+	if len(report.Recipients) != 2 {
+		t.Fatalf("expected 2 recipients, got %+v", report.Recipients)
+	}
+
+	hard := report.Recipients[0]
+	if hard.Address != "ana@acme.test" || !hard.Failed() || !hard.Permanent() {
+		t.Errorf("expected a hard bounce, got %+v", hard)
+	}
+	if !strings.Contains(hard.Diagnostic, "User unknown") || strings.HasPrefix(hard.Diagnostic, "smtp") {
+		t.Errorf("unexpected diagnostic %q", hard.Diagnostic)
+	}
+
+	soft := report.Recipients[1]
+	if soft.Address != "bia@acme.test" || !soft.Failed() || soft.Permanent() {
+		t.Errorf("expected a soft bounce, got %+v", soft)
+	}
+}
+
+func TestParseReportComplaint(t *testing.T) {
+	report, err := ParseReport(strings.NewReader(sampleARF))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.Complaint || report.MessageID != "abc" {
+		t.Errorf("unexpected report %+v", report)
+	}
+	// The recipient comes from the original email
+	if len(report.Recipients) != 1 || report.Recipients[0].Address != "carla@isp.test" {
+		t.Errorf("unexpected recipients %+v", report.Recipients)
+	}
+}
+
+func TestParseReportRejectsOtherMail(t *testing.T) {
+	msg := "From: ana@acme.test\r\nSubject: Out of office\r\nContent-Type: text/plain\r\n\r\nBack Monday\r\n"
+	if _, err := ParseReport(strings.NewReader(msg)); err != ErrNotDeliveryReport {
+		t.Errorf("expected ErrNotDeliveryReport, got %v", err)
+	}
+}
+
+func TestMessageIDRoundTrip(t *testing.T) {
+	msg := &Message{ID: "42", From: "Acme <noreply@acme.test>", To: "ana@acme.test"}
+	if !strings.Contains(string(msg.Bytes()), "Message-ID: <42@acme.test>\r\n") {
+		t.Errorf("missing Message-ID header:\n%s", msg.Bytes())
+	}
+	if id := MessageIDLocalPart("<42@acme.test>"); id != "42" {
+		t.Errorf("unexpected local part %q", id)
+	}
+}
@@ -1,33 +1,55 @@
 package email
 
 import (
+	"context"
 	"fmt"
-	"net/smtp"
+	"log"
 	"os"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type EmailService struct {
-	smtpHost     string
-	smtpPort     string
-	smtpUser     string
-	smtpPassword string
-	fromEmail    string
-	fromName     string
-	appURL       string
+	fromEmail string
+	appURL    string
+	mailer    Mailer
+	// queue overrides the default queue, to write messages in a transaction
+	queue Queue
+	// direct sends messages right away, bypassing the queues
+	direct bool
 }
 
 func NewEmailService() *EmailService {
+	return NewEmailServiceWithMailer(NewMailerFromEnv())
+}
+
+// NewEmailServiceWithMailer creates an email service delivering with the given transport
+func NewEmailServiceWithMailer(mailer Mailer) *EmailService {
 	return &EmailService{
-		smtpHost:     getEnv("SMTP_HOST", "localhost"),
-		smtpPort:     getEnv("SMTP_PORT", "1025"), // Mailhog default port
-		smtpUser:     getEnv("SMTP_USER", ""),
-		smtpPassword: getEnv("SMTP_PASSWORD", ""),
-		fromEmail:    getEnv("SMTP_FROM_EMAIL", "noreply@widia.ai"),
-		fromName:     getEnv("SMTP_FROM_NAME", "Widia Sales AI"),
-		appURL:       getEnv("APP_URL", "http://localhost:3003"),
+		fromEmail: getEnv("SMTP_FROM_EMAIL", "noreply@widia.ai"),
+		appURL:    getEnv("APP_URL", "http://localhost:3003"),
+		mailer:    mailer,
 	}
 }
 
+// WithQueue returns a copy of the service that stores its messages in the
+// queue, such as an outbox bound to the transaction of a business change
+func (s *EmailService) WithQueue(queue Queue) *EmailService {
+	clone := *s
+	clone.queue = queue
+	return &clone
+}
+
+// WithMailer returns a copy of the service that sends right away through the
+// mailer, bypassing the queues, to report delivery errors to the caller
+func (s *EmailService) WithMailer(mailer Mailer) *EmailService {
+	clone := *s
+	clone.mailer = mailer
+	clone.direct = true
+	return &clone
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -36,217 +58,176 @@ func getEnv(key, defaultValue string) string {
 }
 
 // SendPasswordResetEmail sends a password reset email to the user
-func (s *EmailService) SendPasswordResetEmail(toEmail, userName, resetToken string) error {
-	resetLink := fmt.Sprintf("%s/auth/reset-password?token=%s", s.appURL, resetToken)
-	
-	subject := "Redefinição de Senha - Widia Sales AI"
-	
-	htmlBody := fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%); color: white; padding: 30px; text-align: center; border-radius: 10px 10px 0 0; }
-        .content { background: #f8f9fa; padding: 30px; border-radius: 0 0 10px 10px; }
-        .button { display: inline-block; padding: 12px 30px; background: #667eea; color: white; text-decoration: none; border-radius: 5px; margin: 20px 0; }
-        .footer { text-align: center; margin-top: 30px; color: #666; font-size: 14px; }
-        .warning { background: #fff3cd; border: 1px solid #ffc107; padding: 10px; border-radius: 5px; margin: 20px 0; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>🔐 Redefinição de Senha</h1>
-        </div>
-        <div class="content">
-            <p>Olá <strong>%s</strong>,</p>
-            
-            <p>Recebemos uma solicitação para redefinir a senha da sua conta no Widia Sales AI.</p>
-            
-            <p>Para criar uma nova senha, clique no botão abaixo:</p>
-            
-            <center>
-                <a href="%s" class="button">Redefinir Minha Senha</a>
-            </center>
-            
-            <div class="warning">
-                <strong>⚠️ Importante:</strong>
-                <ul>
-                    <li>Este link expira em 1 hora</li>
-                    <li>Se você não solicitou esta redefinição, ignore este email</li>
-                    <li>Por segurança, nunca compartilhe este link com outras pessoas</li>
-                </ul>
-            </div>
-            
-            <p>Se o botão não funcionar, copie e cole este link no seu navegador:</p>
-            <p style="word-break: break-all; background: #fff; padding: 10px; border-radius: 5px;">%s</p>
-            
-            <div class="footer">
-                <p>Este é um email automático, por favor não responda.</p>
-                <p>© 2024 Widia Sales AI. Todos os direitos reservados.</p>
-            </div>
-        </div>
-    </div>
-</body>
-</html>
-	`, userName, resetLink, resetLink)
-	
-	plainBody := fmt.Sprintf(`
-Olá %s,
-
-Recebemos uma solicitação para redefinir a senha da sua conta no Widia Sales AI.
-
-Para criar uma nova senha, acesse o link abaixo:
-%s
-
-Importante:
-- Este link expira em 1 hora
-- Se você não solicitou esta redefinição, ignore este email
-- Por segurança, nunca compartilhe este link com outras pessoas
-
-Este é um email automático, por favor não responda.
-
-© 2024 Widia Sales AI. Todos os direitos reservados.
-	`, userName, resetLink)
-	
-	return s.sendEmail(toEmail, subject, plainBody, htmlBody)
-}
-
-// sendEmail sends an email with both plain text and HTML versions
-func (s *EmailService) sendEmail(to, subject, plainBody, htmlBody string) error {
-	from := fmt.Sprintf("%s <%s>", s.fromName, s.fromEmail)
-	
-	// Create message with both plain text and HTML
-	boundary := "WIDIA_BOUNDARY_12345"
-	
-	headers := make(map[string]string)
-	headers["From"] = from
-	headers["To"] = to
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = fmt.Sprintf("multipart/alternative; boundary=%s", boundary)
-	
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
+func (s *EmailService) SendPasswordResetEmail(brand Branding, toEmail, userName, resetToken string) error {
+	return s.send(TemplatePasswordReset, brand, toEmail, TemplateData{
+		UserName: userName,
+		Link:     fmt.Sprintf("%s/auth/reset-password?token=%s", s.appURL, resetToken),
+	})
+}
+
+// SendWelcomeEmail sends a welcome email to new users
+func (s *EmailService) SendWelcomeEmail(brand Branding, toEmail, userName, tenantName string) error {
+	return s.send(TemplateWelcome, brand, toEmail, TemplateData{
+		UserName:   userName,
+		TenantName: tenantName,
+		Link:       fmt.Sprintf("%s/dashboard", s.appURL),
+	})
+}
+
+// SendSubscriptionExpiryReminder warns tenant admins that the trial or subscription is about to end
+func (s *EmailService) SendSubscriptionExpiryReminder(brand Branding, toEmail, userName, tenantName string, daysLeft int, endsAt time.Time) error {
+	return s.send(TemplateSubscriptionExpiry, brand, toEmail, TemplateData{
+		UserName:   userName,
+		TenantName: tenantName,
+		Link:       fmt.Sprintf("%s/dashboard/settings?tab=billing", s.appURL),
+		Date:       brand.formatDate(endsAt),
+		Days:       daysLeft,
+	})
+}
+
+// SendTenantExportReady tells the requester that the tenant data export can be downloaded
+func (s *EmailService) SendTenantExportReady(brand Branding, toEmail, userName, tenantName string, expiresAt time.Time) error {
+	return s.send(TemplateExportReady, brand, toEmail, TemplateData{
+		UserName:   userName,
+		TenantName: tenantName,
+		Link:       fmt.Sprintf("%s/dashboard/settings?tab=data", s.appURL),
+		Date:       brand.formatDate(expiresAt),
+	})
+}
+
+// SendTenantDeletionScheduled tells the tenant admins that the account will be deleted after the grace period
+func (s *EmailService) SendTenantDeletionScheduled(brand Branding, toEmail, userName, tenantName string, scheduledAt time.Time) error {
+	return s.send(TemplateDeletionScheduled, brand, toEmail, TemplateData{
+		UserName:   userName,
+		TenantName: tenantName,
+		Date:       brand.formatDate(scheduledAt),
+	})
+}
+
+// SendOnboardingNudge reminds the tenant admins of the onboarding steps still pending
+func (s *EmailService) SendOnboardingNudge(brand Branding, toEmail, userName, tenantName string, pending []string, completed, total int) error {
+	return s.send(TemplateOnboardingNudge, brand, toEmail, TemplateData{
+		UserName:   userName,
+		TenantName: tenantName,
+		Link:       fmt.Sprintf("%s/dashboard/onboarding", s.appURL),
+		Completed:  completed,
+		Total:      total,
+		Steps:      pending,
+	})
+}
+
+// SendInvitationEmail invites a user added by an admin to set their password
+func (s *EmailService) SendInvitationEmail(brand Branding, toEmail, userName, tenantName, inviterName, token string, expiresAt time.Time) error {
+	return s.send(TemplateInvitation, brand, toEmail, TemplateData{
+		UserName:   userName,
+		TenantName: tenantName,
+		ActorName:  inviterName,
+		Link:       fmt.Sprintf("%s/auth/reset-password?token=%s", s.appURL, token),
+		Date:       brand.formatDate(expiresAt),
+	})
+}
+
+// SendOwnershipTransferRequest asks a user to confirm they accept the ownership of the tenant
+func (s *EmailService) SendOwnershipTransferRequest(brand Branding, toEmail, userName, tenantName, ownerName, token string, expiresAt time.Time) error {
+	return s.send(TemplateOwnershipTransferRequest, brand, toEmail, TemplateData{
+		UserName:   userName,
+		TenantName: tenantName,
+		ActorName:  ownerName,
+		Link:       fmt.Sprintf("%s/auth/ownership/accept?token=%s", s.appURL, token),
+		Date:       brand.formatDateTime(expiresAt),
+	})
+}
+
+// SendOwnershipTransferCompleted tells the former owner that the ownership was transferred
+func (s *EmailService) SendOwnershipTransferCompleted(brand Branding, toEmail, userName, tenantName, newOwnerName string) error {
+	return s.send(TemplateOwnershipTransferCompleted, brand, toEmail, TemplateData{
+		UserName:   userName,
+		TenantName: tenantName,
+		ActorName:  newOwnerName,
+	})
+}
+
+// SendSenderVerification asks the owner of a mailbox to confirm that a tenant may send emails from it
+func (s *EmailService) SendSenderVerification(brand Branding, toEmail, actorName, tenantName, token string, expiresAt time.Time) error {
+	return s.send(TemplateSenderVerification, brand, toEmail, TemplateData{
+		TenantName: tenantName,
+		ActorName:  actorName,
+		Link:       fmt.Sprintf("%s/auth/verify-sender?token=%s", s.appURL, token),
+		Date:       brand.formatDateTime(expiresAt),
+	})
+}
+
+// SendTestEmail checks the email delivery settings of a tenant
+func (s *EmailService) SendTestEmail(brand Branding, toEmail, userName, tenantName string) error {
+	return s.send(TemplateTestEmail, brand, toEmail, TemplateData{
+		UserName:   userName,
+		TenantName: tenantName,
+	})
+}
+
+// Preview renders a template with sample data, as the tenant of the brand
+// would receive it
+func (s *EmailService) Preview(name string, brand Branding) (*Rendered, error) {
+	brand = brand.WithDefaults()
+	return renderTemplate(name, brand, sampleData(s.appURL, brand))
+}
+
+// send renders a template in the locale of the brand and sends it
+func (s *EmailService) send(name string, brand Branding, toEmail string, data TemplateData) error {
+	brand = brand.WithDefaults()
+	rendered, err := renderTemplate(name, brand, data)
+	if err != nil {
+		return err
+	}
+	return s.sendEmail(brand, toEmail, rendered.Subject, rendered.Text, rendered.HTML)
+}
+
+// sendEmail queues an email with both plain text and HTML versions, or sends
+// it right away when no queue is configured. Emails to suppressed recipients
+// are dropped; only direct sends report it, as ErrRecipientSuppressed.
+func (s *EmailService) sendEmail(brand Branding, to, subject, plainBody, htmlBody string) error {
+	if s.suppressed(brand.TenantID, to) {
+		if s.direct {
+			return ErrRecipientSuppressed
+		}
+		log.Printf("Skipped email %q to suppressed recipient %s", subject, to)
+		return nil
 	}
-	message += "\r\n"
-	
-	// Plain text part
-	message += fmt.Sprintf("--%s\r\n", boundary)
-	message += "Content-Type: text/plain; charset=\"UTF-8\"\r\n"
-	message += "\r\n"
-	message += plainBody
-	message += "\r\n"
-	
-	// HTML part
-	message += fmt.Sprintf("--%s\r\n", boundary)
-	message += "Content-Type: text/html; charset=\"UTF-8\"\r\n"
-	message += "\r\n"
-	message += htmlBody
-	message += "\r\n"
-	
-	message += fmt.Sprintf("--%s--", boundary)
-	
-	// Connect to SMTP server
-	addr := fmt.Sprintf("%s:%s", s.smtpHost, s.smtpPort)
-	
-	// For Mailhog and local testing, we don't need authentication
-	var auth smtp.Auth
-	if s.smtpUser != "" && s.smtpPassword != "" {
-		auth = smtp.PlainAuth("", s.smtpUser, s.smtpPassword, s.smtpHost)
+
+	msg := &Message{
+		TenantID: brand.TenantID,
+		From:     fmt.Sprintf("%s <%s>", brand.Name, s.fromEmail),
+		To:       to,
+		ReplyTo:  brand.SupportEmail,
+		Subject:  subject,
+		Text:     plainBody,
+		HTML:     htmlBody,
 	}
-	
-	// Send the email
-	err := smtp.SendMail(addr, auth, s.fromEmail, []string{to}, []byte(message))
-	if err != nil {
-		return fmt.Errorf("failed to send email: %v", err)
+
+	if !s.direct {
+		queue := s.queue
+		if queue == nil {
+			queue = loadDefaultQueue()
+		}
+		if queue != nil {
+			return queue.Enqueue(msg)
+		}
 	}
-	
-	return nil
+
+	return s.mailer.Send(context.Background(), msg)
 }
 
-// SendWelcomeEmail sends a welcome email to new users
-func (s *EmailService) SendWelcomeEmail(toEmail, userName, tenantName string) error {
-	subject := "Bem-vindo ao Widia Sales AI!"
-	
-	htmlBody := fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; }
-        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
-        .header { background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%); color: white; padding: 30px; text-align: center; border-radius: 10px 10px 0 0; }
-        .content { background: #f8f9fa; padding: 30px; border-radius: 0 0 10px 10px; }
-        .button { display: inline-block; padding: 12px 30px; background: #667eea; color: white; text-decoration: none; border-radius: 5px; margin: 20px 0; }
-        .feature { background: white; padding: 15px; margin: 10px 0; border-radius: 5px; }
-        .footer { text-align: center; margin-top: 30px; color: #666; font-size: 14px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>🎉 Bem-vindo ao Widia Sales AI!</h1>
-        </div>
-        <div class="content">
-            <p>Olá <strong>%s</strong>,</p>
-            
-            <p>Seja muito bem-vindo(a) à <strong>%s</strong> no Widia Sales AI!</p>
-            
-            <p>Sua conta foi criada com sucesso e você já pode começar a usar nossa plataforma de vendas com inteligência artificial.</p>
-            
-            <div class="feature">
-                <h3>✨ O que você pode fazer:</h3>
-                <ul>
-                    <li>Qualificar leads automaticamente com IA</li>
-                    <li>Integrar múltiplos canais de comunicação</li>
-                    <li>Agendar reuniões automaticamente</li>
-                    <li>Acompanhar métricas em tempo real</li>
-                </ul>
-            </div>
-            
-            <center>
-                <a href="%s/dashboard" class="button">Acessar o Dashboard</a>
-            </center>
-            
-            <p>Se tiver qualquer dúvida, nossa equipe está sempre pronta para ajudar!</p>
-            
-            <div class="footer">
-                <p>Este é um email automático, por favor não responda.</p>
-                <p>© 2024 Widia Sales AI. Todos os direitos reservados.</p>
-            </div>
-        </div>
-    </div>
-</body>
-</html>
-	`, userName, tenantName, s.appURL)
-	
-	plainBody := fmt.Sprintf(`
-Olá %s,
-
-Seja muito bem-vindo(a) à %s no Widia Sales AI!
-
-Sua conta foi criada com sucesso e você já pode começar a usar nossa plataforma de vendas com inteligência artificial.
-
-O que você pode fazer:
-- Qualificar leads automaticamente com IA
-- Integrar múltiplos canais de comunicação
-- Agendar reuniões automaticamente
-- Acompanhar métricas em tempo real
-
-Acesse o dashboard: %s/dashboard
-
-Se tiver qualquer dúvida, nossa equipe está sempre pronta para ajudar!
-
-Este é um email automático, por favor não responda.
-
-© 2024 Widia Sales AI. Todos os direitos reservados.
-	`, userName, tenantName, s.appURL)
-	
-	return s.sendEmail(toEmail, subject, plainBody, htmlBody)
-}
\ No newline at end of file
+// suppressed reports whether the tenant emails must not be sent to the
+// address. Emails are sent when the list cannot be checked.
+func (s *EmailService) suppressed(tenantID uuid.UUID, to string) bool {
+	list := loadSuppressionList()
+	if list == nil || tenantID == uuid.Nil {
+		return false
+	}
+	suppressed, err := list.Suppressed(tenantID, to)
+	if err != nil {
+		log.Printf("Failed to check the suppression list for %s: %v", to, err)
+		return false
+	}
+	return suppressed
+}
@@ -0,0 +1,53 @@
+package email
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Mailbox reads the messages delivered to a maildir, such as the mailbox
+// collecting the mail returned to the platform
+type Mailbox struct {
+	Dir string
+}
+
+func NewMailbox(dir string) *Mailbox {
+	return &Mailbox{Dir: dir}
+}
+
+// Unread returns the names of the messages not processed yet, oldest first
+func (m *Mailbox) Unread() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(m.Dir, "new"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read mailbox: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	// Maildir names start with the delivery time
+	sort.Strings(names)
+	return names, nil
+}
+
+// Open opens an unread message
+func (m *Mailbox) Open(name string) (*os.File, error) {
+	return os.Open(filepath.Join(m.Dir, "new", filepath.Base(name)))
+}
+
+// MarkRead moves a message to cur with the seen flag, so it is not read again
+func (m *Mailbox) MarkRead(name string) error {
+	name = filepath.Base(name)
+	if err := os.MkdirAll(filepath.Join(m.Dir, "cur"), 0o755); err != nil {
+		return fmt.Errorf("failed to create mailbox: %w", err)
+	}
+	return os.Rename(filepath.Join(m.Dir, "new", name), filepath.Join(m.Dir, "cur", name+":2,S"))
+}
@@ -0,0 +1,315 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Mail transports selected with MAIL_TRANSPORT
+const (
+	TransportSMTP    = "smtp"
+	TransportMaildir = "maildir"
+	TransportMemory  = "memory"
+)
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewMailerFromEnv returns the transport selected with MAIL_TRANSPORT:
+// smtp (default), maildir, writing to MAILDIR_PATH, or memory
+func NewMailerFromEnv() Mailer {
+	switch getEnv("MAIL_TRANSPORT", TransportSMTP) {
+	case TransportMaildir:
+		return NewMaildirMailer(getEnv("MAILDIR_PATH", "./tmp/maildir"))
+	case TransportMemory:
+		return NewMemoryMailer()
+	default:
+		return NewSMTPMailer(
+			getEnv("SMTP_HOST", "localhost"),
+			getEnv("SMTP_PORT", "1025"), // Mailhog default port
+			getEnv("SMTP_USER", ""),
+			getEnv("SMTP_PASSWORD", ""),
+		)
+	}
+}
+
+// TLS modes of an SMTP connection
+const (
+	// TLSModeAuto upgrades the connection with STARTTLS when the server offers it
+	TLSModeAuto = ""
+	// TLSModeNone never encrypts the connection
+	TLSModeNone = "none"
+	// TLSModeStartTLS requires the STARTTLS upgrade
+	TLSModeStartTLS = "starttls"
+	// TLSModeImplicit connects over TLS, usually on port 465
+	TLSModeImplicit = "tls"
+)
+
+// smtpTimeout bounds a whole SMTP session when the context has no deadline
+const smtpTimeout = 30 * time.Second
+
+// IsTLSMode reports whether mode is a known TLS mode
+func IsTLSMode(mode string) bool {
+	switch mode {
+	case TLSModeAuto, TLSModeNone, TLSModeStartTLS, TLSModeImplicit:
+		return true
+	}
+	return false
+}
+
+// ErrNonPublicAddress is returned when a host resolves to an address that is
+// not reachable from the internet
+var ErrNonPublicAddress = errors.New("address is not public")
+
+// nonPublicNetworks are the special purpose ranges not covered by the net.IP
+// predicates: shared address space, benchmarking and reserved ranges
+var nonPublicNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("192.0.0.0/24"),
+	mustParseCIDR("198.18.0.0/15"),
+	mustParseCIDR("240.0.0.0/4"),
+	mustParseCIDR("64:ff9b::/96"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// IsPublicIP reports whether ip is a public unicast address. Loopback,
+// private, link-local (such as the 169.254.169.254 metadata endpoint of cloud
+// providers), multicast and reserved addresses are not.
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckPublicHost resolves a host and fails unless all its addresses are public
+func CheckPublicHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("%w: %s does not resolve", ErrNonPublicAddress, host)
+	}
+	for _, addr := range addrs {
+		if !IsPublicIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrNonPublicAddress, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// SMTPMailer sends messages through an SMTP server
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	TLSMode  string
+	// PublicOnly refuses to connect to addresses that are not public, for
+	// servers configured by tenants. The address is checked when dialing, so a
+	// host that resolves to another address later is refused too.
+	PublicOnly bool
+}
+
+func NewSMTPMailer(host, port, user, password string) *SMTPMailer {
+	return &SMTPMailer{Host: host, Port: port, User: user, Password: password}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	client, err := m.connect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Mail(msg.envelopeFrom()); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := w.Write(msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return client.Quit()
+}
+
+// Verify connects and authenticates to the server without sending a message
+func (m *SMTPMailer) Verify(ctx context.Context) error {
+	client, err := m.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Noop(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// connect opens a session with the TLS mode of the mailer, authenticated
+// when credentials are set
+func (m *SMTPMailer) connect(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.Host, m.Port)
+	tlsConfig := &tls.Config{ServerName: m.Host}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+
+	dialer := &net.Dialer{Deadline: deadline}
+	if m.PublicOnly {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublicIP(net.ParseIP(host)) {
+				return fmt.Errorf("%w: %s", ErrNonPublicAddress, host)
+			}
+			return nil
+		}
+	}
+	var conn net.Conn
+	var err error
+	if m.TLSMode == TLSModeImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if m.TLSMode == TLSModeStartTLS || m.TLSMode == TLSModeAuto {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, err
+			}
+		} else if m.TLSMode == TLSModeStartTLS {
+			client.Close()
+			return nil, errors.New("server does not support STARTTLS")
+		}
+	}
+
+	// For Mailhog and local testing, we don't need authentication
+	if m.User != "" && m.Password != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.User, m.Password, m.Host)); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// MaildirMailer writes each message to a file of a maildir, for development
+type MaildirMailer struct {
+	Dir string
+}
+
+func NewMaildirMailer(dir string) *MaildirMailer {
+	return &MaildirMailer{Dir: dir}
+}
+
+func (m *MaildirMailer) Send(ctx context.Context, msg *Message) error {
+	// Write to tmp and move to new so readers never see a partial message
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(m.Dir, sub), 0o755); err != nil {
+			return fmt.Errorf("failed to create maildir: %w", err)
+		}
+	}
+
+	name := fmt.Sprintf("%d.%s.widia.eml", time.Now().UnixNano(), uuid.NewString())
+	tmp := filepath.Join(m.Dir, "tmp", name)
+	if err := os.WriteFile(tmp, msg.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(m.Dir, "new", name)); err != nil {
+		return fmt.Errorf("failed to deliver email: %w", err)
+	}
+	return nil
+}
+
+// MemoryMailer keeps the messages in memory, for tests
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+	// Err is returned by Send when set, to simulate a failing transport
+	Err error
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Err != nil {
+		return m.Err
+	}
+	m.messages = append(m.messages, *msg)
+	return nil
+}
+
+// Messages returns a copy of the messages sent so far
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// Reset forgets the messages sent so far
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
@@ -0,0 +1,158 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type recordingQueue struct {
+	messages []*Message
+}
+
+func (q *recordingQueue) Enqueue(msg *Message) error {
+	q.messages = append(q.messages, msg)
+	return nil
+}
+
+func TestEmailServiceSendsWithMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+	service := NewEmailServiceWithMailer(mailer)
+	tenantID := uuid.New()
+
+	brand := Branding{TenantID: tenantID, Name: "Acme", SupportEmail: "help@acme.test"}
+	if err := service.SendWelcomeEmail(brand, "ana@acme.test", "Ana", "Acme"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	msg := messages[0]
+	if msg.To != "ana@acme.test" || msg.ReplyTo != "help@acme.test" || msg.TenantID != tenantID {
+		t.Errorf("unexpected message %+v", msg)
+	}
+	if msg.envelopeFrom() != service.fromEmail {
+		t.Errorf("expected envelope sender %s, got %s", service.fromEmail, msg.envelopeFrom())
+	}
+}
+
+func TestEmailServiceWithQueue(t *testing.T) {
+	mailer := NewMemoryMailer()
+	queue := &recordingQueue{}
+	service := NewEmailServiceWithMailer(mailer).WithQueue(queue)
+
+	if err := service.SendWelcomeEmail(Branding{}, "ana@acme.test", "Ana", "Acme"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(queue.messages) != 1 || len(mailer.Messages()) != 0 {
+		t.Errorf("expected the message to be queued, got %d queued and %d sent", len(queue.messages), len(mailer.Messages()))
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	msg := &Message{
+		From:    "Acme <noreply@acme.test>",
+		To:      "ana@acme.test",
+		Subject: "Redefinição de Senha",
+		Text:    "plain",
+		HTML:    "<p>html</p>",
+	}
+
+	raw := string(msg.Bytes())
+	for _, want := range []string{"To: ana@acme.test\r\n", "Subject: =?UTF-8?q?", "plain", "<p>html</p>"} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("expected message to contain %q", want)
+		}
+	}
+	if strings.Contains(raw, "Reply-To") {
+		t.Error("expected no Reply-To header without a reply address")
+	}
+}
+
+func TestMaildirMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer := NewMaildirMailer(dir)
+
+	if err := mailer.Send(context.Background(), &Message{To: "ana@acme.test", Subject: "Hi"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files, err := os.ReadDir(filepath.Join(dir, "new"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected 1 delivered file, got %d (%v)", len(files), err)
+	}
+}
+
+type fixedSuppressions map[string]bool
+
+func (s fixedSuppressions) Suppressed(tenantID uuid.UUID, address string) (bool, error) {
+	return s[address], nil
+}
+
+func TestEmailServiceSkipsSuppressedRecipients(t *testing.T) {
+	SetSuppressionList(fixedSuppressions{"ana@acme.test": true})
+	defer SetSuppressionList(nil)
+
+	mailer := NewMemoryMailer()
+	queue := &recordingQueue{}
+	brand := Branding{TenantID: uuid.New(), Name: "Acme"}
+
+	// Queued emails are dropped without failing the change that sent them
+	if err := NewEmailServiceWithMailer(mailer).WithQueue(queue).SendWelcomeEmail(brand, "ana@acme.test", "Ana", "Acme"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(queue.messages) != 0 {
+		t.Errorf("expected the email to be dropped, got %d", len(queue.messages))
+	}
+
+	// Direct sends report the suppression
+	direct := NewEmailServiceWithMailer(nil).WithMailer(mailer)
+	if err := direct.SendWelcomeEmail(brand, "ana@acme.test", "Ana", "Acme"); err != ErrRecipientSuppressed {
+		t.Errorf("expected ErrRecipientSuppressed, got %v", err)
+	}
+	if err := direct.SendWelcomeEmail(brand, "bia@acme.test", "Bia", "Acme"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mailer.Messages()) != 1 {
+		t.Errorf("expected 1 message, got %d", len(mailer.Messages()))
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for address, public := range map[string]bool{
+		"8.8.8.8":         true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		if got := IsPublicIP(net.ParseIP(address)); got != public {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", address, got, public)
+		}
+	}
+}
+
+func TestSMTPMailerPublicOnlyRefusesPrivateAddresses(t *testing.T) {
+	mailer := NewSMTPMailer("127.0.0.1", "2525", "", "")
+	mailer.PublicOnly = true
+	if err := mailer.Verify(context.Background()); !errors.Is(err, ErrNonPublicAddress) {
+		t.Errorf("expected ErrNonPublicAddress, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package email
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// messageBoundary separates the plain text and HTML parts of a message
+const messageBoundary = "WIDIA_BOUNDARY_12345"
+
+// Message is an email ready to be handed to a Mailer
+type Message struct {
+	// ID is sent as the Message-ID header, so bounces and complaints quoting
+	// the message can be traced back to it
+	ID string
+	// TenantID is the tenant the email is sent for, uuid.Nil for platform emails
+	TenantID uuid.UUID
+	From     string
+	To       string
+	ReplyTo  string
+	Subject  string
+	Text     string
+	HTML     string
+}
+
+// Bytes renders the message as a multipart/alternative MIME document
+func (m *Message) Bytes() []byte {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("From: %s\r\n", m.From))
+	if m.ID != "" {
+		b.WriteString(fmt.Sprintf("Message-ID: %s\r\n", m.messageID()))
+	}
+	b.WriteString(fmt.Sprintf("To: %s\r\n", m.To))
+	if m.ReplyTo != "" {
+		b.WriteString(fmt.Sprintf("Reply-To: %s\r\n", m.ReplyTo))
+	}
+	b.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", m.Subject)))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString(fmt.Sprintf("Content-Type: multipart/alternative; boundary=%s\r\n", messageBoundary))
+	b.WriteString("\r\n")
+
+	// Plain text part
+	b.WriteString(fmt.Sprintf("--%s\r\n", messageBoundary))
+	b.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(m.Text)
+	b.WriteString("\r\n")
+
+	// HTML part
+	b.WriteString(fmt.Sprintf("--%s\r\n", messageBoundary))
+	b.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(m.HTML)
+	b.WriteString("\r\n")
+
+	b.WriteString(fmt.Sprintf("--%s--", messageBoundary))
+
+	return []byte(b.String())
+}
+
+// envelopeFrom returns the bare address of the From header
+func (m *Message) envelopeFrom() string {
+	if start := strings.LastIndex(m.From, "<"); start >= 0 {
+		if end := strings.LastIndex(m.From, ">"); end > start {
+			return m.From[start+1 : end]
+		}
+	}
+	return m.From
+}
+
+// messageID returns the Message-ID header, scoped to the domain of the sender
+func (m *Message) messageID() string {
+	domain := "localhost"
+	if at := strings.LastIndex(m.envelopeFrom(), "@"); at >= 0 {
+		domain = m.envelopeFrom()[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", m.ID, domain)
+}
+
+// MessageIDLocalPart returns the part of a Message-ID header before the
+// domain, the ID of the message when it was sent by the platform
+func MessageIDLocalPart(header string) string {
+	id := strings.Trim(strings.TrimSpace(header), "<>")
+	if at := strings.LastIndex(id, "@"); at >= 0 {
+		id = id[:at]
+	}
+	return id
+}
@@ -0,0 +1,48 @@
+package email
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// The link fallback repeats the button link, which the text version already shows
+	linkFallbackPattern = regexp.MustCompile(`(?s)<div class="link-fallback">.*?</div>`)
+	whitespacePattern   = regexp.MustCompile(`\s+`)
+	anchorPattern       = regexp.MustCompile(`<a [^>]*href="([^"]*)"[^>]*>(.*?)</a>`)
+	listItemPattern     = regexp.MustCompile(`(?i)<li[^>]*>`)
+	lineBreakPattern    = regexp.MustCompile(`(?i)<br\s*/?>|</h\d>`)
+	blockEndPattern     = regexp.MustCompile(`(?i)</(p|div|ul|center)>`)
+	tagPattern          = regexp.MustCompile(`<[^>]+>`)
+	blankLinesPattern   = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText derives the plain text alternative of the HTML content of an
+// email: links are written out, list items become dashes and blocks are
+// separated by blank lines
+func htmlToText(content string) string {
+	text := linkFallbackPattern.ReplaceAllString(content, "")
+	// Source whitespace is not meaningful, the markup decides the line breaks
+	text = whitespacePattern.ReplaceAllString(text, " ")
+	text = anchorPattern.ReplaceAllStringFunc(text, func(anchor string) string {
+		match := anchorPattern.FindStringSubmatch(anchor)
+		href, label := match[1], strings.TrimSpace(tagPattern.ReplaceAllString(match[2], ""))
+		if label == "" || label == href {
+			return href
+		}
+		return label + ": " + href
+	})
+	text = listItemPattern.ReplaceAllString(text, "\n- ")
+	text = lineBreakPattern.ReplaceAllString(text, "\n")
+	text = blockEndPattern.ReplaceAllString(text, "\n\n")
+	text = tagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	text = blankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text) + "\n"
+}
@@ -0,0 +1,27 @@
+package email
+
+import "sync/atomic"
+
+// Queue stores messages for later delivery, such as the email outbox
+type Queue interface {
+	Enqueue(msg *Message) error
+}
+
+type queueHolder struct {
+	queue Queue
+}
+
+var defaultQueue atomic.Pointer[queueHolder]
+
+// SetDefaultQueue sets the queue used by the email services that were not
+// given one. Until it is called messages are sent right away.
+func SetDefaultQueue(q Queue) {
+	defaultQueue.Store(&queueHolder{queue: q})
+}
+
+func loadDefaultQueue() Queue {
+	if holder := defaultQueue.Load(); holder != nil {
+		return holder.queue
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package email
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// ErrRecipientSuppressed is returned when an email is sent right away to an
+// address that stopped accepting the emails of the tenant
+var ErrRecipientSuppressed = errors.New("recipient is on the suppression list")
+
+// SuppressionList tells which addresses the emails of a tenant must not be
+// sent to, such as addresses that hard bounced or complained
+type SuppressionList interface {
+	Suppressed(tenantID uuid.UUID, address string) (bool, error)
+}
+
+type suppressionHolder struct {
+	list SuppressionList
+}
+
+var defaultSuppressions atomic.Pointer[suppressionHolder]
+
+// SetSuppressionList sets the list checked by the email services before
+// every send. Until it is called every address is accepted.
+func SetSuppressionList(list SuppressionList) {
+	defaultSuppressions.Store(&suppressionHolder{list: list})
+}
+
+func loadSuppressionList() SuppressionList {
+	if holder := defaultSuppressions.Load(); holder != nil {
+		return holder.list
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"log"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Locales supported by the email templates
+const (
+	LocalePortuguese = "pt-BR"
+	LocaleEnglish    = "en"
+	LocaleSpanish    = "es"
+
+	// DefaultLocale is used when neither the user nor the tenant chose a locale
+	DefaultLocale = LocalePortuguese
+)
+
+// Locales lists the supported locales
+var Locales = []string{LocalePortuguese, LocaleEnglish, LocaleSpanish}
+
+// Email templates
+const (
+	TemplatePasswordReset              = "password_reset"
+	TemplateWelcome                    = "welcome"
+	TemplateSubscriptionExpiry         = "subscription_expiry"
+	TemplateExportReady                = "export_ready"
+	TemplateDeletionScheduled          = "deletion_scheduled"
+	TemplateOnboardingNudge            = "onboarding_nudge"
+	TemplateInvitation                 = "invitation"
+	TemplateOwnershipTransferRequest   = "ownership_transfer_request"
+	TemplateOwnershipTransferCompleted = "ownership_transfer_completed"
+	TemplateSenderVerification         = "sender_verification"
+	TemplateTestEmail                  = "test_email"
+)
+
+// Templates lists the email templates
+var Templates = []string{
+	TemplatePasswordReset,
+	TemplateWelcome,
+	TemplateSubscriptionExpiry,
+	TemplateExportReady,
+	TemplateDeletionScheduled,
+	TemplateOnboardingNudge,
+	TemplateInvitation,
+	TemplateOwnershipTransferRequest,
+	TemplateOwnershipTransferCompleted,
+	TemplateSenderVerification,
+	TemplateTestEmail,
+}
+
+// Placeholders lists the fields of TemplateData usable in overrides
+var Placeholders = []string{"{{.BrandName}}", "{{.UserName}}", "{{.TenantName}}", "{{.ActorName}}", "{{.Date}}", "{{.Days}}", "{{.Completed}}", "{{.Total}}"}
+
+var (
+	ErrUnknownTemplate = errors.New("unknown email template")
+	ErrInvalidOverride = errors.New("invalid template override")
+)
+
+// Override limits
+const (
+	maxOverrideSubjectLength = 200
+	maxOverrideIntroLength   = 1000
+)
+
+//go:embed templates/*.html templates/*.txt locales/*.json
+var templateFiles embed.FS
+
+// TemplateData holds the values rendered in a template. Subjects, intros and
+// the other localized texts refer to them as {{.UserName}} and so on.
+type TemplateData struct {
+	BrandName  string
+	UserName   string
+	TenantName string
+	// ActorName is the person behind the email: the inviter or the current or new owner
+	ActorName string
+	Link      string
+	Date      string
+	Days      int
+	Completed int
+	Total     int
+	Steps     []string
+}
+
+// TemplateOverride replaces the default subject or intro of a template; empty
+// fields keep the default
+type TemplateOverride struct {
+	Subject string
+	Intro   string
+}
+
+// Rendered is an email ready to be sent
+type Rendered struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// messages holds the parsed texts of a locale by key
+type messages map[string]*texttemplate.Template
+
+type templateEngine struct {
+	html    *htmltemplate.Template
+	text    *texttemplate.Template
+	locales map[string]messages
+	// sources keeps the unparsed texts, shown to admins as the defaults
+	sources map[string]map[string]string
+}
+
+var engine = mustLoadTemplates()
+
+func mustLoadTemplates() *templateEngine {
+	e, err := loadTemplates(templateFiles)
+	if err != nil {
+		panic(fmt.Sprintf("email: failed to load templates: %v", err))
+	}
+	return e
+}
+
+func loadTemplates(files fs.FS) (*templateEngine, error) {
+	html, err := htmltemplate.ParseFS(files, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.ParseFS(files, "templates/layout.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	e := &templateEngine{
+		html:    html,
+		text:    text,
+		locales: make(map[string]messages),
+		sources: make(map[string]map[string]string),
+	}
+	for _, locale := range Locales {
+		data, err := fs.ReadFile(files, path.Join("locales", locale+".json"))
+		if err != nil {
+			return nil, err
+		}
+		var raw map[string]string
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("locale %s: %w", locale, err)
+		}
+
+		bundle := make(messages, len(raw))
+		for key, value := range raw {
+			tmpl, err := texttemplate.New(key).Parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("locale %s, key %s: %w", locale, key, err)
+			}
+			bundle[key] = tmpl
+		}
+		e.locales[locale] = bundle
+		e.sources[locale] = raw
+	}
+	return e, nil
+}
+
+// NormalizeLocale returns the supported locale matching a locale or language
+// tag such as "en-US" or "pt", or an empty string if none matches
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(strings.ReplaceAll(locale, "_", "-"))
+	if locale == "" {
+		return ""
+	}
+	for _, supported := range Locales {
+		if strings.EqualFold(locale, supported) {
+			return supported
+		}
+	}
+
+	language, _, _ := strings.Cut(locale, "-")
+	for _, supported := range Locales {
+		base, _, _ := strings.Cut(supported, "-")
+		if strings.EqualFold(language, base) {
+			return supported
+		}
+	}
+	return ""
+}
+
+// IsTemplate reports whether name is a known email template
+func IsTemplate(name string) bool {
+	for _, template := range Templates {
+		if template == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultText returns the subject and intro of a template in a locale, with
+// their placeholders
+func DefaultText(name, locale string) (string, string) {
+	return engine.source(locale, name+".subject"), engine.source(locale, name+".intro")
+}
+
+// ValidateOverride checks that an override parses and renders with the
+// template placeholders
+func ValidateOverride(override TemplateOverride) error {
+	if len(override.Subject) > maxOverrideSubjectLength || len(override.Intro) > maxOverrideIntroLength {
+		return ErrInvalidOverride
+	}
+	// The subject is sent as a header
+	if strings.ContainsAny(override.Subject, "\r\n") {
+		return ErrInvalidOverride
+	}
+	for _, text := range []string{override.Subject, override.Intro} {
+		if _, err := formatText(text, sampleData("", Branding{}.WithDefaults())); err != nil {
+			return ErrInvalidOverride
+		}
+	}
+	return nil
+}
+
+// lookup returns the text of a key in a locale, falling back to the default locale
+func (e *templateEngine) lookup(locale, key string) *texttemplate.Template {
+	if tmpl, ok := e.locales[locale][key]; ok {
+		return tmpl
+	}
+	return e.locales[DefaultLocale][key]
+}
+
+func (e *templateEngine) source(locale, key string) string {
+	if text, ok := e.sources[locale][key]; ok {
+		return text
+	}
+	return e.sources[DefaultLocale][key]
+}
+
+// translate renders the text of a key, or returns the key if it is missing
+func (e *templateEngine) translate(locale, key string, data TemplateData) string {
+	tmpl := e.lookup(locale, key)
+	if tmpl == nil {
+		log.Printf("Missing email text %q for locale %s", key, locale)
+		return key
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Printf("Failed to render email text %q for locale %s: %v", key, locale, err)
+		return key
+	}
+	return buf.String()
+}
+
+// formatText renders a text written by a tenant admin
+func formatText(text string, data TemplateData) (string, error) {
+	tmpl, err := texttemplate.New("override").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// templateView is the value the HTML and text templates are executed with
+type templateView struct {
+	Name    string
+	Locale  string
+	Brand   Branding
+	Data    TemplateData
+	Heading string
+	Intro   string
+	Content any
+}
+
+// T returns the localized text of a key
+func (v templateView) T(key string) string {
+	return engine.translate(v.Locale, key, v.Data)
+}
+
+// Copyright returns the footer line of the brand
+func (v templateView) Copyright() string {
+	return v.Brand.copyright()
+}
+
+// renderTemplate renders a template for the brand locale, applying the
+// tenant overrides of the subject and intro
+func renderTemplate(name string, brand Branding, data TemplateData) (*Rendered, error) {
+	if !IsTemplate(name) {
+		return nil, ErrUnknownTemplate
+	}
+
+	locale := brand.locale()
+	data.BrandName = brand.Name
+	view := templateView{Name: name, Locale: locale, Brand: brand, Data: data}
+
+	override := brand.Overrides[name]
+	subject := view.overridden(override.Subject, name+".subject")
+	view.Heading = view.T(name + ".heading")
+	view.Intro = view.overridden(override.Intro, name+".intro")
+
+	var content bytes.Buffer
+	if err := engine.html.ExecuteTemplate(&content, name, view); err != nil {
+		return nil, fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+
+	return &Rendered{
+		Subject: subject,
+		HTML:    brand.renderHTML(view.Heading, content.String()),
+		Text:    brand.renderText(htmlToText(content.String())),
+	}, nil
+}
+
+// overridden renders the tenant override of a text, or the default text when
+// there is no override or it no longer renders
+func (v templateView) overridden(override, key string) string {
+	if strings.TrimSpace(override) != "" {
+		text, err := formatText(override, v.Data)
+		if err == nil {
+			return text
+		}
+		log.Printf("Ignoring invalid override of %s for tenant %s: %v", key, v.Brand.TenantID, err)
+	}
+	return v.T(key)
+}
+
+// sampleData returns the data used to preview a template
+func sampleData(appURL string, brand Branding) TemplateData {
+	return TemplateData{
+		BrandName:  brand.Name,
+		UserName:   "Maria Silva",
+		TenantName: "Acme",
+		ActorName:  "João Souza",
+		Link:       appURL + "/dashboard",
+		Date:       brand.formatDate(time.Now().AddDate(0, 0, 7)),
+		Days:       3,
+		Completed:  2,
+		Total:      5,
+		Steps:      []string{"Conectar um canal", "Convidar a equipe", "Configurar o agente de IA"},
+	}
+}
@@ -0,0 +1,119 @@
+package email
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplatesRenderInEveryLocale(t *testing.T) {
+	for _, locale := range Locales {
+		brand := Branding{Name: "Acme", Locale: locale}.WithDefaults()
+		data := sampleData("https://app.example.com", brand)
+
+		for _, name := range Templates {
+			rendered, err := renderTemplate(name, brand, data)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", locale, name, err)
+			}
+
+			for _, part := range []string{rendered.Subject, rendered.HTML, rendered.Text} {
+				if strings.Contains(part, "<no value>") || strings.Contains(part, name+".") {
+					t.Errorf("%s/%s: expected every text to be translated, got %q", locale, name, part)
+				}
+			}
+			if rendered.Subject == "" {
+				t.Errorf("%s/%s: expected a subject", locale, name)
+			}
+			if strings.Contains(rendered.Text, "<") {
+				t.Errorf("%s/%s: expected plain text without markup, got %q", locale, name, rendered.Text)
+			}
+		}
+	}
+}
+
+func TestRenderTemplateLocalizes(t *testing.T) {
+	brand := Branding{Name: "Acme"}.WithDefaults()
+	data := TemplateData{UserName: "Ana", Link: "https://app.example.com/auth/reset-password?token=abc&x=1"}
+
+	english, err := renderTemplate(TemplatePasswordReset, brand.WithLocale("en-US"), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if english.Subject != "Password Reset - Acme" {
+		t.Errorf("expected English subject, got %q", english.Subject)
+	}
+	if !strings.Contains(english.Text, "Hi Ana,") || !strings.Contains(english.Text, "Reset My Password: https://app.example.com/auth/reset-password?token=abc&x=1") {
+		t.Errorf("expected the text version to include the greeting and link, got %q", english.Text)
+	}
+
+	portuguese, err := renderTemplate(TemplatePasswordReset, brand.WithLocale("xx"), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(portuguese.HTML, "Olá Ana,") {
+		t.Error("expected unsupported locales to fall back to the default locale")
+	}
+}
+
+func TestRenderTemplateOverrides(t *testing.T) {
+	brand := Branding{
+		Name: "Acme",
+		Overrides: map[string]TemplateOverride{
+			TemplateWelcome:       {Subject: "Oi {{.UserName}}, <bem-vindo>", Intro: "Que bom ter a {{.TenantName}} aqui"},
+			TemplatePasswordReset: {Subject: "{{.Missing}}"},
+		},
+	}.WithDefaults()
+	data := TemplateData{UserName: "Ana", TenantName: "Loja"}
+
+	welcome, err := renderTemplate(TemplateWelcome, brand, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if welcome.Subject != "Oi Ana, <bem-vindo>" {
+		t.Errorf("expected overridden subject, got %q", welcome.Subject)
+	}
+	if !strings.Contains(welcome.HTML, "Que bom ter a Loja aqui") || !strings.Contains(welcome.Text, "Que bom ter a Loja aqui") {
+		t.Error("expected overridden intro in both versions")
+	}
+
+	reset, err := renderTemplate(TemplatePasswordReset, brand, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reset.Subject != "Redefinição de Senha - Acme" {
+		t.Errorf("expected a broken override to fall back to the default, got %q", reset.Subject)
+	}
+}
+
+func TestValidateOverride(t *testing.T) {
+	if err := ValidateOverride(TemplateOverride{Subject: "Oi {{.UserName}}", Intro: "Bem-vindo à {{.TenantName}}"}); err != nil {
+		t.Errorf("expected valid override, got %v", err)
+	}
+	for _, override := range []TemplateOverride{
+		{Subject: "{{.Missing}}"},
+		{Intro: "{{if}}"},
+		{Subject: "line\nbreak"},
+		{Subject: strings.Repeat("a", maxOverrideSubjectLength+1)},
+	} {
+		if err := ValidateOverride(override); err != ErrInvalidOverride {
+			t.Errorf("expected %+v to be rejected, got %v", override, err)
+		}
+	}
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	cases := map[string]string{
+		"pt-BR": LocalePortuguese,
+		"pt_br": LocalePortuguese,
+		"pt":    LocalePortuguese,
+		"en-US": LocaleEnglish,
+		"ES":    LocaleSpanish,
+		"fr":    "",
+		"":      "",
+	}
+	for input, want := range cases {
+		if got := NormalizeLocale(input); got != want {
+			t.Errorf("NormalizeLocale(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
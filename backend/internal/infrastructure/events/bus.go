@@ -0,0 +1,73 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is a domain event raised by the application, such as a user being
+// created or a domain being verified
+type Event struct {
+	Name       string
+	TenantID   uuid.UUID
+	ActorID    *uuid.UUID
+	Payload    map[string]interface{}
+	OccurredAt time.Time
+}
+
+// Handler reacts to an event
+type Handler func(ctx context.Context, event Event) error
+
+// Bus dispatches events to the handlers subscribed to their name. Handlers run
+// synchronously in the order they subscribed; a failing handler is logged and
+// does not stop the others.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers a handler for an event name
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Publish runs the handlers of the event and returns how many failed
+func (b *Bus) Publish(ctx context.Context, event Event) int {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers[event.Name]
+	b.mu.RUnlock()
+
+	failed := 0
+	for _, handler := range handlers {
+		if err := run(ctx, handler, event); err != nil {
+			log.Printf("Event %s handler failed for tenant %s: %v", event.Name, event.TenantID, err)
+			failed++
+		}
+	}
+	return failed
+}
+
+// run calls a handler, turning a panic into an error
+func run(ctx context.Context, handler Handler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, event)
+}
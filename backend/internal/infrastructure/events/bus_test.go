@@ -0,0 +1,69 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBusPublish(t *testing.T) {
+	bus := NewBus()
+	tenantID := uuid.New()
+
+	var received []string
+	bus.Subscribe("user.created", func(ctx context.Context, event Event) error {
+		received = append(received, "first")
+		return errors.New("boom")
+	})
+	bus.Subscribe("user.created", func(ctx context.Context, event Event) error {
+		panic("handler bug")
+	})
+	bus.Subscribe("user.created", func(ctx context.Context, event Event) error {
+		if event.TenantID != tenantID || event.OccurredAt.IsZero() {
+			t.Errorf("unexpected event %+v", event)
+		}
+		received = append(received, "third")
+		return nil
+	})
+	bus.Subscribe("domain.verified", func(ctx context.Context, event Event) error {
+		t.Error("handler of another event should not run")
+		return nil
+	})
+
+	failed := bus.Publish(context.Background(), Event{Name: "user.created", TenantID: tenantID})
+
+	if failed != 2 {
+		t.Errorf("expected 2 failed handlers, got %d", failed)
+	}
+	if len(received) != 2 || received[1] != "third" {
+		t.Errorf("expected every handler to run, got %v", received)
+	}
+}
+
+func TestQueuePublishesInBackground(t *testing.T) {
+	bus := NewBus()
+	bus.Subscribe("chatwoot.message_created", func(ctx context.Context, event Event) error {
+		return errors.New("boom")
+	})
+
+	queue := NewQueue(bus, 1)
+	failures := make(chan int, 1)
+	if !queue.Enqueue(Event{Name: "chatwoot.message_created"}, func(failed int) { failures <- failed }) {
+		t.Fatal("expected the event to be accepted")
+	}
+	if queue.Enqueue(Event{Name: "chatwoot.message_created"}, nil) {
+		t.Error("expected a full queue to refuse the event")
+	}
+
+	queue.Start(1)
+	queue.Close()
+
+	if failed := <-failures; failed != 1 {
+		t.Errorf("expected 1 failed handler, got %d", failed)
+	}
+	if queue.Enqueue(Event{Name: "chatwoot.message_created"}, nil) {
+		t.Error("expected a closed queue to refuse the event")
+	}
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+var defaultBus atomic.Pointer[Bus]
+
+// SetDefault sets the bus used by the package level Publish
+func SetDefault(b *Bus) {
+	defaultBus.Store(b)
+}
+
+// Publish dispatches an event on the default bus. It is a no-op until SetDefault is called.
+func Publish(ctx context.Context, event Event) {
+	if b := defaultBus.Load(); b != nil {
+		b.Publish(ctx, event)
+	}
+}
+
+var defaultQueue atomic.Pointer[Queue]
+
+// SetDefaultQueue sets the queue used by the package level Enqueue
+func SetDefaultQueue(q *Queue) {
+	defaultQueue.Store(q)
+}
+
+// Enqueue publishes an event on the default queue in the background and
+// reports whether it was accepted. It refuses every event until
+// SetDefaultQueue is called.
+func Enqueue(event Event, done func(failed int)) bool {
+	if q := defaultQueue.Load(); q != nil {
+		return q.Enqueue(event, done)
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Queue publishes events on a bus from background workers, so callers such
+// as webhook receivers return without waiting for the handlers. Queued events
+// live in memory only: callers that must not lose them store them first and
+// enqueue them again when they were not published.
+type Queue struct {
+	bus    *Bus
+	events chan queuedEvent
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+type queuedEvent struct {
+	event Event
+	done  func(failed int)
+}
+
+// NewQueue creates a queue holding up to size events waiting for a worker
+func NewQueue(bus *Bus, size int) *Queue {
+	return &Queue{
+		bus:    bus,
+		events: make(chan queuedEvent, size),
+	}
+}
+
+// Start runs the workers publishing the queued events
+func (q *Queue) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+}
+
+// Enqueue adds an event without blocking and reports whether it was
+// accepted; it is refused when the queue is full or closed. done, when set,
+// is called with the number of failed handlers once the event is published.
+func (q *Queue) Enqueue(event Event, done func(failed int)) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return false
+	}
+
+	select {
+	case q.events <- queuedEvent{event: event, done: done}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops accepting events and waits for the queued ones to be published
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.events)
+	}
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for queued := range q.events {
+		failed := q.bus.Publish(context.Background(), queued.event)
+		if queued.done != nil {
+			queued.done(failed)
+		}
+	}
+}
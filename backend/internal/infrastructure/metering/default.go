@@ -0,0 +1,21 @@
+package metering
+
+import (
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+var defaultMeter atomic.Pointer[Meter]
+
+// SetDefault sets the meter used by the package level Record
+func SetDefault(m *Meter) {
+	defaultMeter.Store(m)
+}
+
+// Record counts usage on the default meter. It is a no-op until SetDefault is called.
+func Record(tenantID uuid.UUID, metric string, n int64) {
+	if m := defaultMeter.Load(); m != nil {
+		m.Record(tenantID, metric, n)
+	}
+}
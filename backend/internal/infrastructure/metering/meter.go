@@ -0,0 +1,186 @@
+package metering
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+)
+
+const (
+	// DefaultFlushInterval is how often buffered events are written
+	DefaultFlushInterval = 10 * time.Second
+	// DefaultMaxBuffered is the number of buffered counters that triggers an early flush
+	DefaultMaxBuffered = 5000
+	// maxRetained caps the counters kept in memory while the store is failing
+	maxRetained = 100000
+)
+
+type bucketKey struct {
+	tenantID uuid.UUID
+	metric   string
+	hour     time.Time
+}
+
+// Meter counts usage events in memory and flushes them to hourly and daily
+// rollups in batches, so recording never waits on the database
+type Meter struct {
+	store         domain.UsageRepository
+	flushInterval time.Duration
+	maxBuffered   int
+
+	mu      sync.Mutex
+	buckets map[bucketKey]int64
+
+	flushCh chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+	now     func() time.Time
+}
+
+// New creates a meter writing to the given store
+func New(store domain.UsageRepository) *Meter {
+	return &Meter{
+		store:         store,
+		flushInterval: DefaultFlushInterval,
+		maxBuffered:   DefaultMaxBuffered,
+		buckets:       make(map[bucketKey]int64),
+		flushCh:       make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		now:           time.Now,
+	}
+}
+
+// Record counts n occurrences of a metric for a tenant
+func (m *Meter) Record(tenantID uuid.UUID, metric string, n int64) {
+	if tenantID == uuid.Nil || n <= 0 {
+		return
+	}
+
+	key := bucketKey{
+		tenantID: tenantID,
+		metric:   metric,
+		hour:     m.now().UTC().Truncate(time.Hour),
+	}
+
+	m.mu.Lock()
+	m.buckets[key] += n
+	full := len(m.buckets) >= m.maxBuffered
+	m.mu.Unlock()
+
+	if full {
+		select {
+		case m.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Start runs the background flush loop
+func (m *Meter) Start() {
+	m.wg.Add(1)
+	go m.loop()
+}
+
+// Close stops the flush loop and writes every buffered event
+func (m *Meter) Close() {
+	m.once.Do(func() {
+		close(m.done)
+		m.wg.Wait()
+		if err := m.Flush(); err != nil {
+			log.Printf("Failed to flush usage on shutdown: %v", err)
+		}
+	})
+}
+
+// Flush writes the buffered counters. On failure they are put back into
+// the buffer and retried on the next flush.
+func (m *Meter) Flush() error {
+	m.mu.Lock()
+	if len(m.buckets) == 0 {
+		m.mu.Unlock()
+		return nil
+	}
+	pending := m.buckets
+	m.buckets = make(map[bucketKey]int64)
+	m.mu.Unlock()
+
+	if err := m.store.Increment(toRollups(pending, m.now())); err != nil {
+		m.restore(pending)
+		return err
+	}
+
+	return nil
+}
+
+func (m *Meter) loop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		case <-m.flushCh:
+		}
+
+		if err := m.Flush(); err != nil {
+			log.Printf("Failed to flush usage: %v", err)
+		}
+	}
+}
+
+func (m *Meter) restore(pending map[bucketKey]int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, count := range pending {
+		if _, ok := m.buckets[key]; !ok && len(m.buckets) >= maxRetained {
+			log.Printf("Usage buffer full, dropping %d %s event(s) of tenant %s", count, key.metric, key.tenantID)
+			continue
+		}
+		m.buckets[key] += count
+	}
+}
+
+// toRollups expands hourly buckets into hourly and daily rollups
+func toRollups(buckets map[bucketKey]int64, now time.Time) []domain.UsageRollup {
+	days := make(map[bucketKey]int64)
+	rollups := make([]domain.UsageRollup, 0, len(buckets)*2)
+
+	for key, count := range buckets {
+		rollups = append(rollups, domain.UsageRollup{
+			TenantID:    key.tenantID,
+			Metric:      key.metric,
+			Granularity: domain.GranularityHour,
+			PeriodStart: key.hour,
+			Count:       count,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+
+		day := key
+		day.hour = time.Date(key.hour.Year(), key.hour.Month(), key.hour.Day(), 0, 0, 0, 0, time.UTC)
+		days[day] += count
+	}
+
+	for key, count := range days {
+		rollups = append(rollups, domain.UsageRollup{
+			TenantID:    key.tenantID,
+			Metric:      key.metric,
+			Granularity: domain.GranularityDay,
+			PeriodStart: key.hour,
+			Count:       count,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}
+
+	return rollups
+}
@@ -0,0 +1,105 @@
+package metering
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	fail    bool
+	rollups []domain.UsageRollup
+}
+
+func (s *memoryStore) Increment(rollups []domain.UsageRollup) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("database unavailable")
+	}
+	s.rollups = append(s.rollups, rollups...)
+	return nil
+}
+
+func (s *memoryStore) Query(uuid.UUID, string, []string, time.Time, time.Time) ([]*domain.UsageRollup, error) {
+	return nil, nil
+}
+
+func (s *memoryStore) Sum(uuid.UUID, string, time.Time, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (s *memoryStore) DeleteBefore(string, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (s *memoryStore) total(granularity string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total int64
+	for _, r := range s.rollups {
+		if r.Granularity == granularity {
+			total += r.Count
+		}
+	}
+	return total
+}
+
+func TestMeterAggregatesHourlyAndDaily(t *testing.T) {
+	store := &memoryStore{}
+	meter := New(store)
+
+	clock := time.Date(2024, 3, 10, 22, 15, 0, 0, time.UTC)
+	meter.now = func() time.Time { return clock }
+
+	tenantID := uuid.New()
+	meter.Record(tenantID, domain.MetricAPICalls, 2)
+	clock = clock.Add(time.Hour)
+	meter.Record(tenantID, domain.MetricAPICalls, 3)
+
+	if err := meter.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hourly, daily := 0, 0
+	for _, r := range store.rollups {
+		switch r.Granularity {
+		case domain.GranularityHour:
+			hourly++
+		case domain.GranularityDay:
+			daily++
+			if r.Count != 5 {
+				t.Errorf("expected daily count 5, got %d", r.Count)
+			}
+		}
+	}
+	if hourly != 2 || daily != 1 {
+		t.Errorf("expected 2 hourly and 1 daily rollups, got %d and %d", hourly, daily)
+	}
+}
+
+func TestMeterKeepsEventsWhenFlushFails(t *testing.T) {
+	store := &memoryStore{fail: true}
+	meter := New(store)
+	meter.Start()
+
+	meter.Record(uuid.New(), domain.MetricMessagesSent, 4)
+	if err := meter.Flush(); err == nil {
+		t.Fatal("expected flush error")
+	}
+
+	store.mu.Lock()
+	store.fail = false
+	store.mu.Unlock()
+
+	meter.Close()
+
+	if total := store.total(domain.GranularityHour); total != 4 {
+		t.Errorf("expected 4 events flushed on close, got %d", total)
+	}
+}
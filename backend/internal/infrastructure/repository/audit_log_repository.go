@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) domain.AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+func (r *AuditLogRepository) Create(entry *domain.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *AuditLogRepository) FindByTenant(tenantID uuid.UUID, limit int) ([]*domain.AuditLog, error) {
+	var entries []*domain.AuditLog
+	err := r.db.Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *AuditLogRepository) FindLatestByAction(tenantID uuid.UUID, action string) (*domain.AuditLog, error) {
+	var entry domain.AuditLog
+	err := r.db.Where("tenant_id = ? AND action = ?", tenantID, action).
+		Order("created_at DESC").
+		First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
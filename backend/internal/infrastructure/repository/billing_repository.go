@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BillingAccountRepository struct {
+	db *gorm.DB
+}
+
+func NewBillingAccountRepository(db *gorm.DB) domain.BillingAccountRepository {
+	return &BillingAccountRepository{db: db}
+}
+
+func (r *BillingAccountRepository) FindByTenant(tenantID uuid.UUID) (*domain.BillingAccount, error) {
+	var account domain.BillingAccount
+	err := r.db.Where("tenant_id = ?", tenantID).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *BillingAccountRepository) FindByCustomer(provider, customerID string) (*domain.BillingAccount, error) {
+	var account domain.BillingAccount
+	err := r.db.Where("provider = ? AND customer_id = ?", provider, customerID).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *BillingAccountRepository) Save(account *domain.BillingAccount) error {
+	return r.db.Save(account).Error
+}
+
+type InvoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) domain.InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+func (r *InvoiceRepository) FindByTenant(tenantID uuid.UUID, limit int) ([]*domain.Invoice, error) {
+	var invoices []*domain.Invoice
+	err := r.db.Where("tenant_id = ?", tenantID).
+		Order("issued_at DESC").
+		Limit(limit).
+		Find(&invoices).Error
+	return invoices, err
+}
+
+func (r *InvoiceRepository) Upsert(invoice *domain.Invoice) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "provider_invoice_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"number", "status", "amount_due", "amount_paid", "currency",
+			"hosted_url", "pdf_url", "period_start", "period_end", "updated_at",
+		}),
+	}).Create(invoice).Error
+}
+
+type BillingWebhookEventRepository struct {
+	db *gorm.DB
+}
+
+func NewBillingWebhookEventRepository(db *gorm.DB) domain.BillingWebhookEventRepository {
+	return &BillingWebhookEventRepository{db: db}
+}
+
+func (r *BillingWebhookEventRepository) Create(event *domain.BillingWebhookEvent) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}, {Name: "event_id"}},
+		DoNothing: true,
+	}).Create(event)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *BillingWebhookEventRepository) Claim(provider, eventID string, staleBefore time.Time) (*domain.BillingWebhookEvent, error) {
+	var events []*domain.BillingWebhookEvent
+	err := r.db.Raw(`
+		UPDATE billing_webhook_events SET status = ?, updated_at = NOW()
+		WHERE provider = ? AND event_id = ?
+		AND (status = ? OR (status = ? AND updated_at < ?))
+		RETURNING *`,
+		domain.WebhookEventProcessing,
+		provider, eventID,
+		domain.WebhookEventFailed, domain.WebhookEventProcessing, staleBefore,
+	).Scan(&events).Error
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return events[0], nil
+}
+
+func (r *BillingWebhookEventRepository) Save(event *domain.BillingWebhookEvent) error {
+	return r.db.Save(event).Error
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+type ChatwootProvisioningRepository struct {
+	db *gorm.DB
+}
+
+func NewChatwootProvisioningRepository(db *gorm.DB) domain.ChatwootProvisioningRepository {
+	return &ChatwootProvisioningRepository{db: db}
+}
+
+func (r *ChatwootProvisioningRepository) Create(provisioning *domain.ChatwootProvisioning) error {
+	return r.db.Create(provisioning).Error
+}
+
+func (r *ChatwootProvisioningRepository) FindByTenant(tenantID uuid.UUID) (*domain.ChatwootProvisioning, error) {
+	var provisioning domain.ChatwootProvisioning
+	err := r.db.Where("tenant_id = ?", tenantID).First(&provisioning).Error
+	if err != nil {
+		return nil, err
+	}
+	return &provisioning, nil
+}
+
+func (r *ChatwootProvisioningRepository) ClaimDue(now time.Time, limit int) ([]*domain.ChatwootProvisioning, error) {
+	var provisionings []*domain.ChatwootProvisioning
+	err := r.db.Raw(`
+		UPDATE chatwoot_provisionings SET locked_until = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM chatwoot_provisionings
+			WHERE status IN (?, ?) AND next_attempt_at <= ?
+			  AND (locked_until IS NULL OR locked_until < ?)
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(domain.ChatwootProvisioningLockTimeout), now,
+		domain.ProvisioningPending, domain.ProvisioningCompensating, now,
+		now,
+		limit,
+	).Scan(&provisionings).Error
+	return provisionings, err
+}
+
+func (r *ChatwootProvisioningRepository) Update(provisioning *domain.ChatwootProvisioning) error {
+	return r.db.Save(provisioning).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ChatwootWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewChatwootWebhookRepository(db *gorm.DB) domain.ChatwootWebhookRepository {
+	return &ChatwootWebhookRepository{db: db}
+}
+
+func (r *ChatwootWebhookRepository) Create(delivery *domain.ChatwootWebhookDelivery) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "delivery_id"}},
+		DoNothing: true,
+	}).Create(delivery)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *ChatwootWebhookRepository) MarkPublished(id uuid.UUID, failedHandlers int, now time.Time) error {
+	status := domain.ChatwootWebhookProcessed
+	if failedHandlers > 0 {
+		status = domain.ChatwootWebhookFailed
+	}
+	return r.db.Model(&domain.ChatwootWebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"failed_handlers": failedHandlers,
+			"processed_at":    now,
+			"updated_at":      now,
+		}).Error
+}
+
+func (r *ChatwootWebhookRepository) ClaimPending(before, now time.Time, limit int) ([]*domain.ChatwootWebhookDelivery, error) {
+	var deliveries []*domain.ChatwootWebhookDelivery
+	err := r.db.Raw(`
+		UPDATE chatwoot_webhook_deliveries SET updated_at = ?
+		WHERE id IN (
+			SELECT id FROM chatwoot_webhook_deliveries
+			WHERE status = ? AND updated_at < ?
+			ORDER BY created_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now,
+		domain.ChatwootWebhookPending, before,
+		limit,
+	).Scan(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *ChatwootWebhookRepository) DeletePublishedBefore(before time.Time) (int64, error) {
+	result := r.db.
+		Where("status <> ? AND created_at < ?", domain.ChatwootWebhookPending, before).
+		Delete(&domain.ChatwootWebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmailDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailDeliveryRepository(db *gorm.DB) domain.EmailDeliveryRepository {
+	return &EmailDeliveryRepository{db: db}
+}
+
+func (r *EmailDeliveryRepository) CreateEvent(event *domain.EmailDeliveryEvent) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *EmailDeliveryRepository) ListEvents(filter domain.EmailDeliveryFilter) ([]*domain.EmailDeliveryEvent, error) {
+	query := r.db.Model(&domain.EmailDeliveryEvent{})
+	if filter.TenantID != nil {
+		query = query.Where("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.Recipient != "" {
+		query = query.Where("recipient = ?", strings.ToLower(filter.Recipient))
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var events []*domain.EmailDeliveryEvent
+	err := query.Order("occurred_at DESC").Find(&events).Error
+	return events, err
+}
+
+func (r *EmailDeliveryRepository) Suppress(suppression *domain.EmailSuppression) error {
+	suppression.Email = strings.ToLower(suppression.Email)
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "email"}},
+		DoNothing: true,
+	}).Create(suppression).Error
+}
+
+func (r *EmailDeliveryRepository) FindSuppression(tenantID uuid.UUID, email string) (*domain.EmailSuppression, error) {
+	var suppression domain.EmailSuppression
+	err := r.db.Where("tenant_id = ? AND email = ?", tenantID, strings.ToLower(email)).First(&suppression).Error
+	if err != nil {
+		return nil, err
+	}
+	return &suppression, nil
+}
+
+func (r *EmailDeliveryRepository) IsSuppressed(tenantID uuid.UUID, email string) (bool, error) {
+	var count int64
+	err := r.db.Model(&domain.EmailSuppression{}).
+		Where("tenant_id = ? AND email = ?", tenantID, strings.ToLower(email)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *EmailDeliveryRepository) ListSuppressions(tenantID uuid.UUID) ([]*domain.EmailSuppression, error) {
+	var suppressions []*domain.EmailSuppression
+	err := r.db.Where("tenant_id = ?", tenantID).Order("created_at DESC").Find(&suppressions).Error
+	return suppressions, err
+}
+
+func (r *EmailDeliveryRepository) DeleteSuppression(tenantID uuid.UUID, email string) error {
+	result := r.db.Where("tenant_id = ? AND email = ?", tenantID, strings.ToLower(email)).Delete(&domain.EmailSuppression{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+type EmailOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailOutboxRepository(db *gorm.DB) domain.EmailOutboxRepository {
+	return &EmailOutboxRepository{db: db}
+}
+
+func (r *EmailOutboxRepository) Create(msg *domain.EmailOutboxMessage) error {
+	return r.db.Create(msg).Error
+}
+
+func (r *EmailOutboxRepository) FindByID(id uuid.UUID) (*domain.EmailOutboxMessage, error) {
+	var msg domain.EmailOutboxMessage
+	err := r.db.Where("id = ?", id).First(&msg).Error
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func (r *EmailOutboxRepository) List(filter domain.EmailOutboxFilter) ([]*domain.EmailOutboxMessage, error) {
+	query := r.db.Model(&domain.EmailOutboxMessage{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.TenantID != nil {
+		query = query.Where("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.To != "" {
+		query = query.Where("LOWER(to_address) = ?", strings.ToLower(filter.To))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var messages []*domain.EmailOutboxMessage
+	err := query.Order("created_at DESC").Find(&messages).Error
+	return messages, err
+}
+
+func (r *EmailOutboxRepository) CountByStatus() (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Model(&domain.EmailOutboxMessage{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *EmailOutboxRepository) ClaimDue(now time.Time, limit int) ([]*domain.EmailOutboxMessage, error) {
+	var messages []*domain.EmailOutboxMessage
+	err := r.db.Raw(`
+		UPDATE email_outbox SET status = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE (status IN (?, ?) AND next_attempt_at <= ?)
+			   OR (status = ? AND updated_at < ?)
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		domain.EmailSending, now,
+		domain.EmailPending, domain.EmailFailed, now,
+		domain.EmailSending, now.Add(-domain.EmailSendingTimeout),
+		limit,
+	).Scan(&messages).Error
+	return messages, err
+}
+
+func (r *EmailOutboxRepository) Update(msg *domain.EmailOutboxMessage) error {
+	return r.db.Save(msg).Error
+}
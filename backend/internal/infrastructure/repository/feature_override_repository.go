@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FeatureOverrideRepository struct {
+	db *gorm.DB
+}
+
+func NewFeatureOverrideRepository(db *gorm.DB) domain.FeatureOverrideRepository {
+	return &FeatureOverrideRepository{db: db}
+}
+
+func (r *FeatureOverrideRepository) FindByTenant(tenantID uuid.UUID) ([]*domain.TenantFeatureOverride, error) {
+	var overrides []*domain.TenantFeatureOverride
+	err := r.db.Where("tenant_id = ?", tenantID).Order("feature").Find(&overrides).Error
+	return overrides, err
+}
+
+func (r *FeatureOverrideRepository) FindByTenantAndFeature(tenantID uuid.UUID, feature string) (*domain.TenantFeatureOverride, error) {
+	var override domain.TenantFeatureOverride
+	err := r.db.Where("tenant_id = ? AND feature = ?", tenantID, feature).First(&override).Error
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+func (r *FeatureOverrideRepository) Upsert(override *domain.TenantFeatureOverride) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "feature"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "reason", "updated_by", "updated_at"}),
+	}).Create(override).Error
+}
+
+func (r *FeatureOverrideRepository) Delete(tenantID uuid.UUID, feature string) error {
+	return r.db.Where("tenant_id = ? AND feature = ?", tenantID, feature).
+		Delete(&domain.TenantFeatureOverride{}).Error
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OnboardingRepository struct {
+	db *gorm.DB
+}
+
+func NewOnboardingRepository(db *gorm.DB) domain.OnboardingRepository {
+	return &OnboardingRepository{db: db}
+}
+
+func (r *OnboardingRepository) FindSteps(tenantID uuid.UUID) ([]*domain.OnboardingStep, error) {
+	var steps []*domain.OnboardingStep
+	err := r.db.Where("tenant_id = ?", tenantID).
+		Order("completed_at ASC").
+		Find(&steps).Error
+	return steps, err
+}
+
+func (r *OnboardingRepository) CompleteStep(tenantID uuid.UUID, step string, at time.Time) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.OnboardingStep{
+		TenantID:    tenantID,
+		Step:        step,
+		CompletedAt: at,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *OnboardingRepository) LatestNudgeDay(tenantID uuid.UUID) (int, error) {
+	var day int
+	err := r.db.Model(&domain.OnboardingNudge{}).
+		Where("tenant_id = ?", tenantID).
+		Select("COALESCE(MAX(day), 0)").
+		Scan(&day).Error
+	return day, err
+}
+
+func (r *OnboardingRepository) CreateNudge(nudge *domain.OnboardingNudge) error {
+	return r.db.Create(nudge).Error
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+type OwnershipTransferRepository struct {
+	db *gorm.DB
+}
+
+func NewOwnershipTransferRepository(db *gorm.DB) domain.OwnershipTransferRepository {
+	return &OwnershipTransferRepository{db: db}
+}
+
+func (r *OwnershipTransferRepository) Create(transfer *domain.OwnershipTransfer) error {
+	return r.db.Create(transfer).Error
+}
+
+func (r *OwnershipTransferRepository) FindPending(tenantID uuid.UUID, now time.Time) (*domain.OwnershipTransfer, error) {
+	var transfer domain.OwnershipTransfer
+	err := r.db.Where("tenant_id = ? AND status = ? AND expires_at > ?", tenantID, domain.OwnershipTransferPending, now).
+		Order("created_at DESC").
+		First(&transfer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *OwnershipTransferRepository) FindByTokenHash(tokenHash string) (*domain.OwnershipTransfer, error) {
+	var transfer domain.OwnershipTransfer
+	err := r.db.Where("token_hash = ?", tokenHash).First(&transfer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *OwnershipTransferRepository) CancelPending(tenantID uuid.UUID, now time.Time) error {
+	return r.db.Model(&domain.OwnershipTransfer{}).
+		Where("tenant_id = ? AND status = ?", tenantID, domain.OwnershipTransferPending).
+		Updates(map[string]interface{}{
+			"status":       domain.OwnershipTransferCancelled,
+			"cancelled_at": now,
+			"updated_at":   now,
+		}).Error
+}
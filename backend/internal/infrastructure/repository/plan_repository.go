@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+type PlanRepository struct {
+	db *gorm.DB
+}
+
+func NewPlanRepository(db *gorm.DB) domain.PlanRepository {
+	return &PlanRepository{db: db}
+}
+
+func (r *PlanRepository) FindByID(id uuid.UUID) (*domain.Plan, error) {
+	var plan domain.Plan
+	err := r.db.Where("id = ?", id).First(&plan).Error
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *PlanRepository) FindByCode(code string) (*domain.Plan, error) {
+	var plan domain.Plan
+	err := r.db.Where("code = ?", code).First(&plan).Error
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *PlanRepository) FindByProviderPriceID(priceID string) (*domain.Plan, error) {
+	var plan domain.Plan
+	err := r.db.Where("provider_price_id = ?", priceID).First(&plan).Error
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *PlanRepository) List(publicOnly bool) ([]*domain.Plan, error) {
+	var plans []*domain.Plan
+	query := r.db.Order("price_cents, code")
+	if publicOnly {
+		query = query.Where("is_public = ?", true)
+	}
+	err := query.Find(&plans).Error
+	return plans, err
+}
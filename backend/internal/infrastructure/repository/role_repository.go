@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) domain.RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+func (r *RoleRepository) Create(role *domain.Role) error {
+	return r.db.Create(role).Error
+}
+
+func (r *RoleRepository) FindByID(id uuid.UUID) (*domain.Role, error) {
+	var role domain.Role
+	err := r.db.Where("id = ?", id).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) FindByName(tenantID uuid.UUID, name string) (*domain.Role, error) {
+	var role domain.Role
+	err := r.db.Where("tenant_id = ? AND name = ?", tenantID, name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepository) FindByTenant(tenantID uuid.UUID) ([]*domain.Role, error) {
+	var roles []*domain.Role
+	err := r.db.Where("tenant_id = ?", tenantID).
+		Order("name ASC").
+		Find(&roles).Error
+	return roles, err
+}
+
+func (r *RoleRepository) Update(role *domain.Role) error {
+	return r.db.Save(role).Error
+}
+
+func (r *RoleRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&domain.Role{}, "id = ?", id).Error
+}
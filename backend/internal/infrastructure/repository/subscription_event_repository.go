@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+type SubscriptionEventRepository struct {
+	db *gorm.DB
+}
+
+func NewSubscriptionEventRepository(db *gorm.DB) domain.SubscriptionEventRepository {
+	return &SubscriptionEventRepository{db: db}
+}
+
+func (r *SubscriptionEventRepository) Create(event *domain.SubscriptionEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *SubscriptionEventRepository) FindByTenant(tenantID uuid.UUID, limit int) ([]*domain.SubscriptionEvent, error) {
+	var events []*domain.SubscriptionEvent
+	err := r.db.Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (r *SubscriptionEventRepository) ReminderSent(tenantID uuid.UUID, daysBefore int, periodEnd time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&domain.SubscriptionEvent{}).
+		Where("tenant_id = ? AND type = ?", tenantID, domain.SubscriptionEventReminder).
+		Where("metadata->>'days_before' = ? AND metadata->>'period_end' = ?",
+			strconv.Itoa(daysBefore), periodEnd.UTC().Format(time.RFC3339)).
+		Count(&count).Error
+	return count > 0, err
+}
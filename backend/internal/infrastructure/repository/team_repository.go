@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TeamRepository struct {
+	db *gorm.DB
+}
+
+func NewTeamRepository(db *gorm.DB) domain.TeamRepository {
+	return &TeamRepository{db: db}
+}
+
+func (r *TeamRepository) Create(team *domain.Team) error {
+	return r.db.Omit("Members").Create(team).Error
+}
+
+func (r *TeamRepository) FindByID(id uuid.UUID) (*domain.Team, error) {
+	var team domain.Team
+	err := r.db.Preload("Members.User").Where("id = ?", id).First(&team).Error
+	if err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+func (r *TeamRepository) FindByTenant(tenantID uuid.UUID) ([]*domain.Team, error) {
+	var teams []*domain.Team
+	err := r.db.Preload("Members.User").
+		Where("tenant_id = ?", tenantID).
+		Order("name ASC").
+		Find(&teams).Error
+	return teams, err
+}
+
+func (r *TeamRepository) Update(team *domain.Team) error {
+	return r.db.Omit("Members").Save(team).Error
+}
+
+func (r *TeamRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", id).Delete(&domain.TeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Team{}, "id = ?", id).Error
+	})
+}
+
+func (r *TeamRepository) FindMember(teamID, userID uuid.UUID) (*domain.TeamMember, error) {
+	var member domain.TeamMember
+	err := r.db.Where("team_id = ? AND user_id = ?", teamID, userID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *TeamRepository) SaveMember(member *domain.TeamMember) error {
+	return r.db.Omit("User").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "team_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"is_lead"}),
+	}).Create(member).Error
+}
+
+func (r *TeamRepository) RemoveMember(teamID, userID uuid.UUID) error {
+	return r.db.Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&domain.TeamMember{}).Error
+}
+
+func (r *TeamRepository) FindMemberships(userID uuid.UUID) ([]*domain.TeamMember, error) {
+	var members []*domain.TeamMember
+	err := r.db.Where("user_id = ?", userID).Find(&members).Error
+	return members, err
+}
+
+func (r *TeamRepository) FindTeammateIDs(userID uuid.UUID, leadOnly bool) ([]uuid.UUID, error) {
+	teams := r.db.Model(&domain.TeamMember{}).Select("team_id").Where("user_id = ?", userID)
+	if leadOnly {
+		teams = teams.Where("is_lead = ?", true)
+	}
+
+	var ids []uuid.UUID
+	err := r.db.Model(&domain.TeamMember{}).
+		Distinct("user_id").
+		Where("team_id IN (?)", teams).
+		Pluck("user_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if id == userID {
+			return ids, nil
+		}
+	}
+	return append(ids, userID), nil
+}
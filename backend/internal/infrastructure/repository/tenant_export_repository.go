@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+type TenantExportRepository struct {
+	db *gorm.DB
+}
+
+func NewTenantExportRepository(db *gorm.DB) domain.TenantExportRepository {
+	return &TenantExportRepository{db: db}
+}
+
+func (r *TenantExportRepository) Create(export *domain.TenantExport) error {
+	return r.db.Create(export).Error
+}
+
+func (r *TenantExportRepository) FindByID(id uuid.UUID) (*domain.TenantExport, error) {
+	var export domain.TenantExport
+	err := r.db.Where("id = ?", id).First(&export).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *TenantExportRepository) FindByTenant(tenantID uuid.UUID, limit int) ([]*domain.TenantExport, error) {
+	var exports []*domain.TenantExport
+	err := r.db.Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *TenantExportRepository) FindByStatus(status string, updatedBefore time.Time) ([]*domain.TenantExport, error) {
+	var exports []*domain.TenantExport
+	err := r.db.Where("status = ? AND updated_at < ?", status, updatedBefore).
+		Order("created_at").
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *TenantExportRepository) FindExpired(now time.Time) ([]*domain.TenantExport, error) {
+	var exports []*domain.TenantExport
+	err := r.db.Where("status = ? AND expires_at < ?", domain.ExportCompleted, now).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *TenantExportRepository) Update(export *domain.TenantExport) error {
+	return r.db.Save(export).Error
+}
+
+func (r *TenantExportRepository) Claim(id uuid.UUID, from, to string) (bool, error) {
+	result := r.db.Model(&domain.TenantExport{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]interface{}{"status": to, "updated_at": time.Now()})
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+type TenantIntegrationRepository struct {
+	db *gorm.DB
+}
+
+func NewTenantIntegrationRepository(db *gorm.DB) domain.TenantIntegrationRepository {
+	return &TenantIntegrationRepository{db: db}
+}
+
+func (r *TenantIntegrationRepository) FindByTenant(tenantID uuid.UUID, provider string) (*domain.TenantIntegration, error) {
+	var integration domain.TenantIntegration
+	err := r.db.Where("tenant_id = ? AND provider = ?", tenantID, provider).First(&integration).Error
+	if err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+func (r *TenantIntegrationRepository) Save(integration *domain.TenantIntegration) error {
+	return r.db.Save(integration).Error
+}
+
+func (r *TenantIntegrationRepository) Delete(tenantID uuid.UUID, provider string) error {
+	return r.db.Where("tenant_id = ? AND provider = ?", tenantID, provider).Delete(&domain.TenantIntegration{}).Error
+}
+
+func (r *TenantIntegrationRepository) RecordCheck(tenantID uuid.UUID, provider string, checkErr error, now time.Time) error {
+	updates := map[string]interface{}{
+		"status":          domain.IntegrationConnected,
+		"last_error":      "",
+		"last_checked_at": now,
+	}
+	if checkErr != nil {
+		updates["status"] = domain.IntegrationFailing
+		updates["last_error"] = checkErr.Error()
+	}
+	// UpdateColumns keeps updated_at, which identifies the stored credentials
+	return r.db.Model(&domain.TenantIntegration{}).
+		Where("tenant_id = ? AND provider = ?", tenantID, provider).
+		UpdateColumns(updates).Error
+}
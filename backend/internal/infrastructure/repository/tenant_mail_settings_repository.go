@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+type TenantMailSettingsRepository struct {
+	db *gorm.DB
+}
+
+func NewTenantMailSettingsRepository(db *gorm.DB) domain.TenantMailSettingsRepository {
+	return &TenantMailSettingsRepository{db: db}
+}
+
+func (r *TenantMailSettingsRepository) FindByTenant(tenantID uuid.UUID) (*domain.TenantMailSettings, error) {
+	var settings domain.TenantMailSettings
+	err := r.db.Where("tenant_id = ?", tenantID).First(&settings).Error
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *TenantMailSettingsRepository) FindByVerificationTokenHash(hash string) (*domain.TenantMailSettings, error) {
+	var settings domain.TenantMailSettings
+	err := r.db.Where("sender_verification_token_hash = ?", hash).First(&settings).Error
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *TenantMailSettingsRepository) ListRelays() ([]*domain.TenantMailSettings, error) {
+	var settings []*domain.TenantMailSettings
+	err := r.db.Where("mode = ?", domain.MailModeSMTP).Find(&settings).Error
+	return settings, err
+}
+
+func (r *TenantMailSettingsRepository) Save(settings *domain.TenantMailSettings) error {
+	return r.db.Save(settings).Error
+}
+
+func (r *TenantMailSettingsRepository) Delete(tenantID uuid.UUID) error {
+	return r.db.Where("tenant_id = ?", tenantID).Delete(&domain.TenantMailSettings{}).Error
+}
+
+func (r *TenantMailSettingsRepository) RecordHealth(tenantID uuid.UUID, checkErr error, now time.Time) error {
+	query := r.db.Model(&domain.TenantMailSettings{}).Where("tenant_id = ?", tenantID)
+	if checkErr == nil {
+		return query.Updates(map[string]interface{}{
+			"health_status":        domain.MailHealthHealthy,
+			"consecutive_failures": 0,
+			"last_error":           "",
+			"last_checked_at":      now,
+		}).Error
+	}
+
+	return query.Updates(map[string]interface{}{
+		"health_status": gorm.Expr("CASE WHEN health_status <> ? OR consecutive_failures + 1 >= ? THEN ? ELSE health_status END",
+			domain.MailHealthHealthy, domain.MailHealthFailureThreshold, domain.MailHealthFailing),
+		"consecutive_failures": gorm.Expr("consecutive_failures + 1"),
+		"last_error":           checkErr.Error(),
+		"last_checked_at":      now,
+	}).Error
+}
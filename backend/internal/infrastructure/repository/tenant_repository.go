@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/widia/widia-connect/internal/domain"
 	"gorm.io/gorm"
@@ -69,6 +71,12 @@ func (r *TenantRepository) Count() (int64, error) {
 func (r *TenantRepository) ExistsBySlug(slug string) (bool, error) {
 	var count int64
 	err := r.db.Model(&domain.Tenant{}).Where("slug = ?", slug).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	// Former slugs stay taken while their alias is active
+	err = r.db.Model(&domain.TenantSlugAlias{}).Where("slug = ? AND expires_at > ?", slug, time.Now()).Count(&count).Error
 	return count > 0, err
 }
 
@@ -76,4 +84,17 @@ func (r *TenantRepository) ExistsByDomain(domainName string) (bool, error) {
 	var count int64
 	err := r.db.Model(&domain.Tenant{}).Where("domain = ?", domainName).Count(&count).Error
 	return count > 0, err
-}
\ No newline at end of file
+}
+
+func (r *TenantRepository) FindBySubscriptionStatus(statuses []string, endsBefore time.Time) ([]*domain.Tenant, error) {
+	var tenants []*domain.Tenant
+	err := r.db.Where("subscription_status IN ? AND subscription_ends_at IS NOT NULL AND subscription_ends_at < ?", statuses, endsBefore).
+		Find(&tenants).Error
+	return tenants, err
+}
+func (r *TenantRepository) FindDueForPurge(now time.Time) ([]*domain.Tenant, error) {
+	var tenants []*domain.Tenant
+	err := r.db.Where("status = ? AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", domain.TenantStatusPendingDeletion, now).
+		Find(&tenants).Error
+	return tenants, err
+}
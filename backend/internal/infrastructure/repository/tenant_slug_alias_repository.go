@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+type TenantSlugAliasRepository struct {
+	db *gorm.DB
+}
+
+func NewTenantSlugAliasRepository(db *gorm.DB) domain.TenantSlugAliasRepository {
+	return &TenantSlugAliasRepository{db: db}
+}
+
+func (r *TenantSlugAliasRepository) FindActive(slug string, now time.Time) (*domain.TenantSlugAlias, error) {
+	var alias domain.TenantSlugAlias
+	err := r.db.Where("slug = ? AND expires_at > ?", slug, now).First(&alias).Error
+	if err != nil {
+		return nil, err
+	}
+	return &alias, nil
+}
+
+func (r *TenantSlugAliasRepository) FindByTenant(tenantID uuid.UUID) ([]*domain.TenantSlugAlias, error) {
+	var aliases []*domain.TenantSlugAlias
+	err := r.db.Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&aliases).Error
+	return aliases, err
+}
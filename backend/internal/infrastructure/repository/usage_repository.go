@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UsageRepository struct {
+	db *gorm.DB
+}
+
+func NewUsageRepository(db *gorm.DB) domain.UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+func (r *UsageRepository) Increment(rollups []domain.UsageRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "metric"}, {Name: "granularity"}, {Name: "period_start"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":      gorm.Expr("usage_rollups.count + excluded.count"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).CreateInBatches(rollups, 500).Error
+}
+
+func (r *UsageRepository) Query(tenantID uuid.UUID, granularity string, metrics []string, from, to time.Time) ([]*domain.UsageRollup, error) {
+	var rollups []*domain.UsageRollup
+	query := r.db.Where("tenant_id = ? AND granularity = ?", tenantID, granularity).
+		Where("period_start >= ? AND period_start < ?", from, to)
+	if len(metrics) > 0 {
+		query = query.Where("metric IN ?", metrics)
+	}
+	err := query.Order("period_start, metric").Find(&rollups).Error
+	return rollups, err
+}
+
+func (r *UsageRepository) Sum(tenantID uuid.UUID, metric string, from, to time.Time) (int64, error) {
+	var total int64
+	err := r.db.Model(&domain.UsageRollup{}).
+		Where("tenant_id = ? AND metric = ? AND granularity = ?", tenantID, metric, domain.GranularityHour).
+		Where("period_start >= ? AND period_start < ?", from, to).
+		Select("COALESCE(SUM(count), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *UsageRepository) DeleteBefore(granularity string, before time.Time) (int64, error) {
+	result := r.db.Where("granularity = ? AND period_start < ?", granularity, before).
+		Delete(&domain.UsageRollup{})
+	return result.RowsAffected, result.Error
+}
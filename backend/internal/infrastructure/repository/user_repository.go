@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/google/uuid"
 	"github.com/widia/widia-connect/internal/domain"
 	"gorm.io/gorm"
@@ -51,6 +54,84 @@ func (r *UserRepository) FindByTenant(tenantID uuid.UUID) ([]*domain.User, error
 	return users, err
 }
 
+// userSortExpressions map the sortable fields to SQL expressions matching
+// domain.UserSortValue, with the type their cursor value is cast to
+var userSortExpressions = map[string][2]string{
+	domain.UserSortName:        {"LOWER(COALESCE(name, ''))", "text"},
+	domain.UserSortEmail:       {"LOWER(email)", "text"},
+	domain.UserSortCreatedAt:   {"created_at", "timestamptz"},
+	domain.UserSortLastLoginAt: {"COALESCE(last_login_at, 'epoch'::timestamptz)", "timestamptz"},
+}
+
+func (r *UserRepository) Query(query domain.UserQuery) (*domain.UserPage, error) {
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("tenant_id = ?", query.TenantID)
+		if query.Search != "" {
+			pattern := "%" + escapeLike(strings.ToLower(query.Search)) + "%"
+			db = db.Where("(LOWER(name) LIKE ? OR LOWER(email) LIKE ?)", pattern, pattern)
+		}
+		if len(query.Roles) > 0 {
+			db = db.Where("role IN ?", query.Roles)
+		}
+		if query.IsActive != nil {
+			db = db.Where("is_active = ?", *query.IsActive)
+		}
+		if query.NeverLoggedIn {
+			db = db.Where("last_login_at IS NULL")
+		}
+		if query.EmailBounced {
+			db = db.Where("email_bounced_at IS NOT NULL")
+		}
+		if query.LastLoginAfter != nil {
+			db = db.Where("last_login_at >= ?", *query.LastLoginAfter)
+		}
+		if query.LastLoginBefore != nil {
+			db = db.Where("last_login_at < ?", *query.LastLoginBefore)
+		}
+		return db
+	}
+
+	page := &domain.UserPage{}
+	if err := r.db.Model(&domain.User{}).Scopes(filter).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	sort, ok := userSortExpressions[query.SortBy]
+	if !ok {
+		query.SortBy = domain.UserSortCreatedAt
+		sort = userSortExpressions[query.SortBy]
+	}
+	direction, comparison := "ASC", ">"
+	if query.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	db := r.db.Scopes(filter)
+	if query.After != nil {
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?::%s, ?)", sort[0], comparison, sort[1]), query.After.Value, query.After.ID)
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	var users []*domain.User
+	err := db.Order(fmt.Sprintf("%s %s, id %s", sort[0], direction, direction)).
+		Limit(query.Limit + 1).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(users) > query.Limit {
+		users = users[:query.Limit]
+		last := users[len(users)-1]
+		page.Next = &domain.UserCursor{
+			Value: domain.UserSortValue(last, query.SortBy),
+			ID:    last.ID,
+		}
+	}
+	page.Users = users
+	return page, nil
+}
+
 func (r *UserRepository) Update(user *domain.User) error {
 	return r.db.Save(user).Error
 }
@@ -63,4 +144,9 @@ func (r *UserRepository) CountByTenant(tenantID uuid.UUID) (int64, error) {
 	var count int64
 	err := r.db.Model(&domain.User{}).Where("tenant_id = ?", tenantID).Count(&count).Error
 	return count, err
-}
\ No newline at end of file
+}
+
+// escapeLike escapes the LIKE wildcards of a search term
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
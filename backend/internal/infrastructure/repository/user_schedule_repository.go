@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserScheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewUserScheduleRepository(db *gorm.DB) domain.UserScheduleRepository {
+	return &UserScheduleRepository{db: db}
+}
+
+func (r *UserScheduleRepository) FindByUser(userID uuid.UUID) (*domain.UserSchedule, error) {
+	var schedule domain.UserSchedule
+	err := r.db.Where("user_id = ?", userID).First(&schedule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *UserScheduleRepository) FindByUsers(userIDs []uuid.UUID) (map[uuid.UUID]*domain.UserSchedule, error) {
+	schedules := make(map[uuid.UUID]*domain.UserSchedule, len(userIDs))
+	if len(userIDs) == 0 {
+		return schedules, nil
+	}
+
+	var rows []*domain.UserSchedule
+	if err := r.db.Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, schedule := range rows {
+		schedules[schedule.UserID] = schedule
+	}
+	return schedules, nil
+}
+
+func (r *UserScheduleRepository) Save(schedule *domain.UserSchedule) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"time_zone", "weekly", "holidays", "updated_at"}),
+	}).Create(schedule).Error
+}
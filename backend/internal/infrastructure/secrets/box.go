@@ -0,0 +1,81 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ciphertextPrefix versions the format of the encrypted values
+const ciphertextPrefix = "v1."
+
+var ErrInvalidCiphertext = errors.New("invalid or tampered ciphertext")
+
+// Box encrypts the secrets stored in the database, such as SMTP passwords
+// and API tokens, with AES-256-GCM
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox creates a box whose key is derived from the secret
+func NewBox(secret string) *Box {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		// A 32 byte key is always valid
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return &Box{aead: aead}
+}
+
+// NewBoxFromConfig creates the box keyed with ENCRYPTION_KEY (defaults to JWT_SECRET)
+func NewBoxFromConfig() *Box {
+	secret := viper.GetString("ENCRYPTION_KEY")
+	if secret == "" {
+		secret = viper.GetString("JWT_SECRET")
+	}
+	return NewBox(secret)
+}
+
+// Encrypt seals the plaintext. The associated value, such as the tenant ID,
+// is authenticated but not stored: decrypting requires the same value, so a
+// ciphertext copied to another record cannot be opened.
+func (b *Box) Encrypt(plaintext, associated string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), []byte(associated))
+	return ciphertextPrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt with the same associated value
+func (b *Box) Decrypt(ciphertext, associated string) (string, error) {
+	encoded, ok := strings.CutPrefix(ciphertext, ciphertextPrefix)
+	if !ok {
+		return "", ErrInvalidCiphertext
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+
+	nonce, sealed := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, sealed, []byte(associated))
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,50 @@
+package secrets
+
+import "testing"
+
+func TestBoxRoundTrip(t *testing.T) {
+	box := NewBox("test-secret")
+
+	ciphertext, err := box.Encrypt("s3cr3t", "tenant-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ciphertext == "s3cr3t" {
+		t.Fatal("expected the value to be encrypted")
+	}
+
+	plaintext, err := box.Decrypt(ciphertext, "tenant-1")
+	if err != nil || plaintext != "s3cr3t" {
+		t.Fatalf("expected s3cr3t, got %q (%v)", plaintext, err)
+	}
+
+	again, _ := box.Encrypt("s3cr3t", "tenant-1")
+	if again == ciphertext {
+		t.Error("expected a fresh nonce for every encryption")
+	}
+}
+
+func TestBoxRejectsTampering(t *testing.T) {
+	box := NewBox("test-secret")
+	ciphertext, _ := box.Encrypt("s3cr3t", "tenant-1")
+
+	if _, err := box.Decrypt(ciphertext, "tenant-2"); err != ErrInvalidCiphertext {
+		t.Errorf("expected another associated value to fail, got %v", err)
+	}
+	if _, err := NewBox("other-secret").Decrypt(ciphertext, "tenant-1"); err != ErrInvalidCiphertext {
+		t.Errorf("expected another key to fail, got %v", err)
+	}
+	tampered := []byte(ciphertext)
+	middle := len(tampered) / 2
+	if tampered[middle] == 'A' {
+		tampered[middle] = 'B'
+	} else {
+		tampered[middle] = 'A'
+	}
+	if _, err := box.Decrypt(string(tampered), "tenant-1"); err != ErrInvalidCiphertext {
+		t.Errorf("expected a tampered value to fail, got %v", err)
+	}
+	if _, err := box.Decrypt("plain", "tenant-1"); err != ErrInvalidCiphertext {
+		t.Errorf("expected an unversioned value to fail, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalStorage keeps objects on the local filesystem. Signed URLs point to
+// the API download route, which verifies them with the same signer.
+type LocalStorage struct {
+	root      string
+	publicURL string
+	signer    *Signer
+}
+
+// NewLocalStorage creates a filesystem storage rooted at dir
+func NewLocalStorage(dir, publicURL, secret string) *LocalStorage {
+	return &LocalStorage{
+		root:      dir,
+		publicURL: strings.TrimRight(publicURL, "/"),
+		signer:    NewSigner(secret),
+	}
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return 0, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return 0, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	return written, nil
+}
+
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(target)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) SignedURL(key string, expiresIn time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s?%s", s.publicURL, key, s.signer.Sign(key, time.Now().Add(expiresIn))), nil
+}
+
+// Verify checks the expires and signature query parameters of a signed URL
+func (s *LocalStorage) Verify(key, expires, signature string) error {
+	return s.signer.Verify(key, expires, signature, time.Now())
+}
+
+// path maps a key to a file below the root, rejecting keys that escape it
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+
+	clean := path.Clean(key)
+	if clean != key || clean == "." || strings.HasPrefix(clean, "../") || clean == ".." {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
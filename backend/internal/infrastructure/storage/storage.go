@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrNotFound         = errors.New("object not found")
+	ErrInvalidKey       = errors.New("invalid object key")
+	ErrInvalidSignature = errors.New("invalid or expired signed URL")
+)
+
+// Storage stores binary objects (archives, logos...) under slash separated keys
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that grants read access to the object until it expires
+	SignedURL(key string, expiresIn time.Duration) (string, error)
+}
+
+// NewFromConfig builds the storage configured by STORAGE_DIR, STORAGE_PUBLIC_URL
+// and STORAGE_SIGNING_SECRET (defaults to JWT_SECRET)
+func NewFromConfig() Storage {
+	root := viper.GetString("STORAGE_DIR")
+	if root == "" {
+		root = "./storage"
+	}
+
+	publicURL := viper.GetString("STORAGE_PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = fmt.Sprintf("http://localhost:%s/api/files", viper.GetString("PORT"))
+	}
+
+	secret := viper.GetString("STORAGE_SIGNING_SECRET")
+	if secret == "" {
+		secret = viper.GetString("JWT_SECRET")
+	}
+
+	return NewLocalStorage(root, publicURL, secret)
+}
+
+// Signer creates and verifies expiring signatures for object keys
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner creates a signer with the given secret
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// Sign returns the query string authorizing access to key until expiresAt
+func (s *Signer) Sign(key string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.signature(key, expires))
+	return query.Encode()
+}
+
+// Verify checks a signature produced by Sign
+func (s *Signer) Verify(key, expires, signature string, now time.Time) error {
+	ts, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > ts {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.signature(key, expires))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (s *Signer) signature(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignedURLVerifier is implemented by storages whose signed URLs are served by the API
+type SignedURLVerifier interface {
+	Verify(key, expires, signature string) error
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignerVerify(t *testing.T) {
+	signer := NewSigner("secret")
+	now := time.Unix(1700000000, 0)
+	expires := "1700000600"
+	signature := signer.signature("exports/a.zip", expires)
+
+	if err := signer.Verify("exports/a.zip", expires, signature, now); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	if err := signer.Verify("exports/b.zip", expires, signature, now); err != ErrInvalidSignature {
+		t.Errorf("expected other key to fail, got %v", err)
+	}
+	if err := signer.Verify("exports/a.zip", expires, signature, now.Add(time.Hour)); err != ErrInvalidSignature {
+		t.Errorf("expected expired link to fail, got %v", err)
+	}
+}
+
+func TestLocalStorageRejectsEscapingKeys(t *testing.T) {
+	store := NewLocalStorage(t.TempDir(), "http://localhost/api/files", "secret")
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b"} {
+		if _, err := store.path(key); err != ErrInvalidKey {
+			t.Errorf("expected %q to be rejected, got %v", key, err)
+		}
+	}
+
+	if _, err := store.path("exports/tenant/export.zip"); err != nil {
+		t.Errorf("expected valid key, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// FeatureChecker resolves whether a feature flag is enabled for a tenant
+type FeatureChecker interface {
+	IsEnabled(tenantID uuid.UUID, feature string) (bool, error)
+}
+
+// RequireFeature creates a middleware that only lets requests through when the
+// tenant has the given feature enabled
+func RequireFeature(checker FeatureChecker, feature string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		tenantID, ok := c.Locals("tenant_id").(uuid.UUID)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Unauthorized: tenant not found",
+			})
+		}
+
+		enabled, err := checker.IsEnabled(tenantID, feature)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to resolve feature flag",
+			})
+		}
+
+		if !enabled {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   "Feature not available for this tenant",
+				"feature": feature,
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -29,6 +29,62 @@ func RequireRole(allowedRoles ...string) fiber.Handler {
 	}
 }
 
+// PermissionChecker resolves the current role of a user and whether a role
+// of a tenant grants permissions
+type PermissionChecker interface {
+	UserRole(tenantID, userID uuid.UUID) (string, error)
+	HasPermissions(tenantID uuid.UUID, role string, permissions ...string) (bool, error)
+}
+
+// RequirePermission creates a middleware that checks if the user role grants
+// every given permission. The role is read on each request rather than from
+// the token, so renaming a role or demoting a user applies at once; handlers
+// behind it get that role from GetUserRole.
+func RequirePermission(checker PermissionChecker, permissions ...string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		tenantID, tenantOK := c.Locals("tenant_id").(uuid.UUID)
+		userID, userOK := c.Locals("user_id").(uuid.UUID)
+		if !tenantOK || !userOK {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Unauthorized: role not found",
+			})
+		}
+
+		userRole, err := checker.UserRole(tenantID, userID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to resolve permissions",
+			})
+		}
+
+		allowed := false
+		if userRole != "" {
+			allowed, err = checker.HasPermissions(tenantID, userRole, permissions...)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to resolve permissions",
+				})
+			}
+		}
+
+		if !allowed {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":       "Forbidden: insufficient permissions",
+				"permissions": permissions,
+			})
+		}
+
+		c.Locals("role", userRole)
+		return c.Next()
+	}
+}
+
+// ResolveRole creates a middleware that replaces the role of the token with
+// the current role of the user, for routes open to every role
+func ResolveRole(checker PermissionChecker) fiber.Handler {
+	return RequirePermission(checker)
+}
+
 // RequireAdmin is a shorthand for RequireRole("admin", "owner")
 func RequireAdmin() fiber.Handler {
 	return RequireRole("admin", "owner")
@@ -39,6 +95,41 @@ func RequireOwner() fiber.Handler {
 	return RequireRole("owner")
 }
 
+// PlatformAdminChecker resolves whether a user administers the platform
+// across tenants
+type PlatformAdminChecker interface {
+	IsPlatformAdmin(userID uuid.UUID) (bool, error)
+}
+
+// RequireSuperAdmin creates a middleware that only lets platform admins
+// through. The flag is read on each request rather than from the token, so
+// revoking it applies at once.
+func RequireSuperAdmin(checker PlatformAdminChecker) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		userID, ok := c.Locals("user_id").(uuid.UUID)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Unauthorized: user not found",
+			})
+		}
+
+		allowed, err := checker.IsPlatformAdmin(userID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to resolve permissions",
+			})
+		}
+
+		if !allowed {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Forbidden: platform admins only",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
 // GetUserID extracts the user ID from context
 func GetUserID(c fiber.Ctx) (uuid.UUID, error) {
 	userID, ok := c.Locals("user_id").(uuid.UUID)
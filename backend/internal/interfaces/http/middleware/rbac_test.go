@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+type platformAdmins map[uuid.UUID]bool
+
+func (p platformAdmins) IsPlatformAdmin(userID uuid.UUID) (bool, error) {
+	return p[userID], nil
+}
+
+func TestRequireSuperAdmin(t *testing.T) {
+	viper.Set("JWT_SECRET", "test-secret")
+	defer viper.Set("JWT_SECRET", "")
+
+	admin, owner := uuid.New(), uuid.New()
+	app := fiber.New()
+	group := app.Group("/admin/emails", AuthMiddleware(nil), RequireSuperAdmin(platformAdmins{admin: true}))
+	group.Get("/", func(c fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	cases := []struct {
+		name   string
+		userID uuid.UUID
+		role   string
+		want   int
+	}{
+		{"platform admin", admin, "agent", fiber.StatusOK},
+		{"tenant owner", owner, "owner", fiber.StatusForbidden},
+		{"former super admin role", owner, "super_admin", fiber.StatusForbidden},
+	}
+	for _, tc := range cases {
+		token, err := GenerateToken(tc.userID, uuid.New(), "user@acme.test", tc.role)
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		req := httptest.NewRequest("GET", "/admin/emails", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tc.name, err)
+		}
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, resp.StatusCode)
+		}
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/emails", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", resp.StatusCode)
+	}
+}
+
+type tenantRoles struct {
+	users       map[uuid.UUID]string
+	permissions map[string][]string
+}
+
+func (r tenantRoles) UserRole(tenantID, userID uuid.UUID) (string, error) {
+	return r.users[userID], nil
+}
+
+func (r tenantRoles) HasPermissions(tenantID uuid.UUID, role string, permissions ...string) (bool, error) {
+	for _, permission := range permissions {
+		granted := false
+		for _, p := range r.permissions[role] {
+			granted = granted || p == permission
+		}
+		if !granted {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func TestRequirePermissionUsesCurrentRole(t *testing.T) {
+	viper.Set("JWT_SECRET", "test-secret")
+	defer viper.Set("JWT_SECRET", "")
+
+	demoted, promoted, removed := uuid.New(), uuid.New(), uuid.New()
+	roles := tenantRoles{
+		users:       map[uuid.UUID]string{demoted: "agent", promoted: "admin"},
+		permissions: map[string][]string{"admin": {"users.update"}},
+	}
+
+	app := fiber.New()
+	group := app.Group("/tenant/users", AuthMiddleware(nil), RequirePermission(roles, "users.update"))
+	group.Get("/", func(c fiber.Ctx) error {
+		role, _ := GetUserRole(c)
+		return c.SendString(role)
+	})
+
+	cases := []struct {
+		name   string
+		userID uuid.UUID
+		claim  string
+		want   int
+	}{
+		{"demoted since the token was issued", demoted, "admin", fiber.StatusForbidden},
+		{"promoted since the token was issued", promoted, "agent", fiber.StatusOK},
+		{"removed user", removed, "admin", fiber.StatusForbidden},
+	}
+	for _, tc := range cases {
+		token, err := GenerateToken(tc.userID, uuid.New(), "user@acme.test", tc.claim)
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		req := httptest.NewRequest("GET", "/tenant/users", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tc.name, err)
+		}
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, resp.StatusCode)
+		}
+		if resp.StatusCode == fiber.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != roles.users[tc.userID] {
+				t.Errorf("%s: handler should see the current role, got %q", tc.name, body)
+			}
+		}
+	}
+}
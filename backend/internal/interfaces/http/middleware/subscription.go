@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"gorm.io/gorm"
+)
+
+// billingPaths stay reachable for tenants whose subscription is restricted.
+// Data exports and the deletion request are included so restricted tenants can
+// still take their data out and close their account.
+var billingPaths = []string{
+	"/api/billing",
+	"/api/tenant/subscription",
+	"/api/plans",
+	"/api/tenant/exports",
+	"/api/tenant/deletion",
+}
+
+// SubscriptionGuard restricts tenants according to their subscription state:
+// expired tenants are read-only, suspended tenants can only reach billing routes.
+// Tenants scheduled for deletion are locked out entirely until restored.
+func SubscriptionGuard(db *gorm.DB) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		tenantID, ok := c.Locals("tenant_id").(uuid.UUID)
+		if !ok {
+			return c.Next()
+		}
+
+		var tenant struct {
+			SubscriptionStatus  string
+			Status              string
+			DeletionScheduledAt *time.Time
+		}
+		if err := db.Table("tenants").Where("id = ? AND deleted_at IS NULL", tenantID).
+			Select("subscription_status, status, deletion_scheduled_at").First(&tenant).Error; err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant not found",
+			})
+		}
+
+		if tenant.Status == domain.TenantStatusPendingDeletion {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":                 "Tenant is scheduled for deletion",
+				"deletion_scheduled_at": tenant.DeletionScheduledAt,
+			})
+		}
+
+		access := domain.SubscriptionAccessLevel(tenant.SubscriptionStatus)
+		c.Locals("subscription_status", tenant.SubscriptionStatus)
+		c.Locals("subscription_access", access)
+
+		if access == domain.AccessFull || isBillingPath(c.Path()) {
+			return c.Next()
+		}
+
+		if access == domain.AccessReadOnly && isReadOnlyMethod(c.Method()) {
+			return c.Next()
+		}
+
+		return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
+			"error":               "Subscription inactive: action not allowed",
+			"subscription_status": tenant.SubscriptionStatus,
+			"access":              access,
+		})
+	}
+}
+
+func isBillingPath(path string) bool {
+	for _, prefix := range billingPaths {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isReadOnlyMethod(method string) bool {
+	return method == fiber.MethodGet || method == fiber.MethodHead || method == fiber.MethodOptions
+}
@@ -6,11 +6,21 @@ import (
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
 	"gorm.io/gorm"
 )
 
+// HeaderTenantRedirect carries the current slug when a request used a former one
+const HeaderTenantRedirect = "X-Tenant-Slug-Redirect"
+
+// TenantResolver finds a tenant by its current slug or by a former slug that
+// still redirects to it
+type TenantResolver interface {
+	ResolveSlug(slug string) (tenant *domain.Tenant, redirect bool, err error)
+}
+
 // TenantMiddleware extracts tenant from subdomain or header and sets RLS
-func TenantMiddleware(db *gorm.DB) fiber.Handler {
+func TenantMiddleware(db *gorm.DB, resolver TenantResolver) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		var tenantID uuid.UUID
 		
@@ -32,12 +42,15 @@ func TenantMiddleware(db *gorm.DB) fiber.Handler {
 				// Try to extract from subdomain
 				host := c.Get("Host")
 				subdomain := extractSubdomain(host)
-				if subdomain != "" && subdomain != "www" && subdomain != "app" {
-					// Look up tenant by slug
-					var tenant struct {
-						ID uuid.UUID
+				if subdomain != "" && !domain.IsReservedSlug(subdomain) {
+					// Look up tenant by slug, then by a former slug still aliased
+					tenant, redirect, err := resolver.ResolveSlug(subdomain)
+					if err == nil && redirect {
+						// Tell the client where the tenant lives now
+						c.Set(HeaderTenantRedirect, tenant.Slug)
+						c.Locals("tenant_slug_redirect", tenant.Slug)
 					}
-					if err := db.Table("tenants").Where("slug = ?", subdomain).Select("id").First(&tenant).Error; err != nil {
+					if err != nil {
 						return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 							"error": "Tenant not found",
 						})
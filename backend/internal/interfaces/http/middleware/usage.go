@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/metering"
+)
+
+// MeterAPICalls counts authenticated API requests per tenant. It must run
+// before the auth middleware so it can read the tenant once the request is handled.
+func MeterAPICalls() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		err := c.Next()
+
+		if !strings.HasPrefix(c.Path(), "/api/") {
+			return err
+		}
+
+		if tenantID, ok := c.Locals("tenant_id").(uuid.UUID); ok {
+			metering.Record(tenantID, domain.MetricAPICalls, 1)
+		}
+
+		return err
+	}
+}
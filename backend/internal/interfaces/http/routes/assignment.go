@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+// assignedWorkPermissions is the permission needed to read the rows of each
+// table listed under /tenant
+var assignedWorkPermissions = map[string]string{
+	"conversations": domain.PermConversationsRead,
+	"leads":         domain.PermCRMContactsRead,
+}
+
+func SetupAssignmentRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	userRepo := repository.NewUserRepository(db)
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+	chatwootClients := application.NewChatwootClientFactory(repository.NewTenantIntegrationRepository(db))
+	teamService := application.NewTeamService(db, repository.NewTeamRepository(db), userRepo, roleService, chatwootClients)
+	assignmentService := application.NewAssignmentService(db, userRepo, teamService, chatwootClients)
+
+	for name, permission := range assignedWorkPermissions {
+		table, _ := domain.FindAssignableTable(name)
+		work := router.Group("/tenant/"+name, middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db), middleware.RequirePermission(roleService, permission))
+
+		// List the rows within the team scope of the current user. Query
+		// parameters: open, limit and offset.
+		work.Get("/", func(c fiber.Ctx) error {
+			tenantID, err := middleware.GetTenantID(c)
+			if err != nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Tenant ID not found",
+				})
+			}
+			userID, _ := middleware.GetUserID(c)
+			role, _ := middleware.GetUserRole(c)
+
+			var query application.AssignedWorkQuery
+			for param, target := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+				if value := c.Query(param); value != "" {
+					if *target, err = strconv.Atoi(value); err != nil {
+						return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+							"error": param + " must be a number",
+						})
+					}
+				}
+			}
+			if value := c.Query("open"); value != "" {
+				if query.OpenOnly, err = strconv.ParseBool(value); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"error": "open must be true or false",
+					})
+				}
+			}
+
+			rows, err := assignmentService.List(table, tenantID, userID, role, query)
+			if err != nil {
+				return assignmentError(c, err)
+			}
+
+			return c.JSON(rows)
+		})
+
+		// Assign a row to a user, or back to the routing queue with a null user_id
+		work.Put("/:id/assignee", func(c fiber.Ctx) error {
+			tenantID, err := middleware.GetTenantID(c)
+			if err != nil {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Tenant ID not found",
+				})
+			}
+			actorID, _ := middleware.GetUserID(c)
+			actorRole, _ := middleware.GetUserRole(c)
+
+			var req struct {
+				UserID *uuid.UUID `json:"user_id"`
+			}
+			if err := c.Bind().JSON(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid request body",
+				})
+			}
+
+			if err := assignmentService.Assign(table, tenantID, actorID, actorRole, c.Params("id"), req.UserID); err != nil {
+				return assignmentError(c, err)
+			}
+
+			return c.JSON(fiber.Map{
+				"id":          c.Params("id"),
+				"assigned_to": req.UserID,
+			})
+		})
+	}
+}
+
+func assignmentError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, application.ErrAssignedWorkNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Not found",
+		})
+	case errors.Is(err, application.ErrAssignmentForbidden):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Not allowed to manage the assignments of this user",
+		})
+	case errors.Is(err, application.ErrInvalidAssignee):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Assignee must be an active user of the tenant",
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process assignment",
+		})
+	}
+}
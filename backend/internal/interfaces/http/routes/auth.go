@@ -17,10 +17,15 @@ func SetupAuthRoutes(router fiber.Router, db *gorm.DB) {
 	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
 	resetTokenRepo := repository.NewPasswordResetTokenRepository(db)
 	tenantRepo := repository.NewTenantRepository(db)
+	planRepo := repository.NewPlanRepository(db)
 	
+	usageRepo := repository.NewUsageRepository(db)
+	slugAliasRepo := repository.NewTenantSlugAliasRepository(db)
+	quotaService := application.NewQuotaService(db, tenantRepo, planRepo, userRepo, usageRepo)
 	authService := application.NewAuthServiceWithResetToken(db, userRepo, refreshTokenRepo, resetTokenRepo)
-	tenantService := application.NewTenantService(db, tenantRepo, userRepo)
-	userService := application.NewUserService(db, userRepo)
+	tenantService := application.NewTenantService(db, tenantRepo, userRepo, planRepo, slugAliasRepo, quotaService)
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+	userService := application.NewUserService(db, userRepo, roleService, quotaService)
 	
 	// Register new tenant
 	auth.Post("/register", func(c fiber.Ctx) error {
@@ -65,6 +70,10 @@ func SetupAuthRoutes(router fiber.Router, db *gorm.DB) {
 				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 					"error": "Tenant slug already exists",
 				})
+			case application.ErrReservedSlug:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "This slug is reserved, please choose another one",
+				})
 			default:
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"error": err.Error(),
@@ -112,14 +121,22 @@ func SetupAuthRoutes(router fiber.Router, db *gorm.DB) {
 			})
 		}
 		
-		// Find tenant
-		var tenant domain.Tenant
-		if err := db.Where("slug = ?", req.TenantSlug).First(&tenant).Error; err != nil {
+		// Find tenant, following former slugs of renamed tenants
+		tenant, redirect, err := tenantService.ResolveSlug(req.TenantSlug)
+		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Invalid credentials",
 			})
 		}
 		
+		// Users of a tenant scheduled for deletion are deactivated; point them to the restore flow
+		if tenant.IsPendingDeletion() {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":                 "Tenant is scheduled for deletion",
+				"deletion_scheduled_at": tenant.DeletionScheduledAt,
+			})
+		}
+		
 		// Authenticate user
 		user, accessToken, refreshToken, err := authService.Login(req.Email, req.Password, tenant.ID)
 		if err != nil {
@@ -128,12 +145,17 @@ func SetupAuthRoutes(router fiber.Router, db *gorm.DB) {
 			})
 		}
 		
-		return c.JSON(fiber.Map{
+		response := fiber.Map{
 			"token":         accessToken,
 			"refresh_token": refreshToken,
 			"user":          user,
 			"tenant":        tenant,
-		})
+		}
+		if redirect {
+			response["redirect_slug"] = tenant.Slug
+		}
+		
+		return c.JSON(response)
 	})
 	
 	// Refresh token
@@ -216,9 +238,17 @@ func SetupAuthRoutes(router fiber.Router, db *gorm.DB) {
 			})
 		}
 		
+		// Former slugs of renamed tenants still work, with a hint to the current one
+		tenantSlug := req.TenantSlug
+		var redirectSlug string
+		if tenant, redirect, err := tenantService.ResolveSlug(req.TenantSlug); err == nil && redirect {
+			tenantSlug = tenant.Slug
+			redirectSlug = tenant.Slug
+		}
+		
 		// Request password reset
 		// Note: We don't return the token in production - it should be sent via email
-		token, err := authService.RequestPasswordReset(req.Email, req.TenantSlug)
+		token, err := authService.RequestPasswordReset(req.Email, tenantSlug)
 		if err != nil {
 			// Log the error but don't expose it to the user
 			// This prevents user enumeration attacks
@@ -228,6 +258,9 @@ func SetupAuthRoutes(router fiber.Router, db *gorm.DB) {
 		response := fiber.Map{
 			"message": "If the email exists in our system, you will receive a password reset link",
 		}
+		if redirectSlug != "" {
+			response["redirect_slug"] = redirectSlug
+		}
 		
 		// In development, include the token for testing
 		// TODO: Remove this in production and send via email instead
@@ -0,0 +1,221 @@
+package routes
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/spf13/viper"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/billing"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupBillingRoutes(router fiber.Router, db *gorm.DB) {
+	billingService := newBillingService(db)
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+
+	// Billing of the current tenant (roles allowed to manage billing)
+	billingGroup := router.Group("/billing", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db), middleware.RequirePermission(roleService, domain.PermTenantBillingManage))
+
+	billingGroup.Get("/account", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		account, err := billingService.GetAccount(tenantID)
+		if err != nil {
+			if err == application.ErrBillingAccountNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Billing account not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get billing account",
+			})
+		}
+
+		return c.JSON(account)
+	})
+
+	billingGroup.Post("/checkout", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		email, _ := middleware.GetUserEmail(c)
+
+		var req struct {
+			Plan       string `json:"plan"`
+			SuccessURL string `json:"success_url"`
+			CancelURL  string `json:"cancel_url"`
+		}
+
+		if err := c.Bind().JSON(&req); err != nil || req.Plan == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Plan code is required",
+			})
+		}
+
+		billingURL := fmt.Sprintf("%s/dashboard/settings?tab=billing", appURL())
+		if req.SuccessURL == "" {
+			req.SuccessURL = billingURL + "&checkout=success"
+		}
+		if req.CancelURL == "" {
+			req.CancelURL = billingURL + "&checkout=canceled"
+		}
+		// The provider redirects the customer to these URLs, which must stay
+		// within the application
+		if !isAppURL(req.SuccessURL) || !isAppURL(req.CancelURL) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "success_url and cancel_url must point to the application",
+			})
+		}
+
+		session, err := billingService.CreateCheckoutSession(c.Context(), tenantID, email, req.Plan, req.SuccessURL, req.CancelURL)
+		if err != nil {
+			return billingError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"id":  session.ID,
+			"url": session.URL,
+		})
+	})
+
+	billingGroup.Post("/subscription", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		email, _ := middleware.GetUserEmail(c)
+
+		var req struct {
+			Plan string `json:"plan"`
+		}
+
+		if err := c.Bind().JSON(&req); err != nil || req.Plan == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Plan code is required",
+			})
+		}
+
+		account, err := billingService.CreateSubscription(c.Context(), tenantID, email, req.Plan)
+		if err != nil {
+			return billingError(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(account)
+	})
+
+	billingGroup.Get("/invoices", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		limit, _ := strconv.Atoi(c.Query("limit", "24"))
+		if limit <= 0 || limit > 100 {
+			limit = 24
+		}
+
+		invoices, err := billingService.ListInvoices(tenantID, limit)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to list invoices",
+			})
+		}
+
+		return c.JSON(invoices)
+	})
+}
+
+// BillingProvider is shared by the billing and webhook routes and the
+// background jobs so the fake provider keeps a single in-memory state. The
+// API does not start without a configured provider.
+var BillingProvider = sync.OnceValue(func() billing.Provider {
+	provider, err := billing.NewProviderFromConfig()
+	if err != nil {
+		log.Fatalf("Failed to configure billing: %v", err)
+	}
+	return provider
+})
+
+func newBillingService(db *gorm.DB) *application.BillingService {
+	tenantRepo := repository.NewTenantRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	planRepo := repository.NewPlanRepository(db)
+	eventRepo := repository.NewSubscriptionEventRepository(db)
+	subscriptionService := application.NewSubscriptionService(db, tenantRepo, userRepo, eventRepo)
+
+	return application.NewBillingService(
+		db,
+		BillingProvider(),
+		tenantRepo,
+		planRepo,
+		repository.NewBillingAccountRepository(db),
+		repository.NewInvoiceRepository(db),
+		repository.NewBillingWebhookEventRepository(db),
+		subscriptionService,
+	)
+}
+
+func billingError(c fiber.Ctx, err error) error {
+	switch err {
+	case application.ErrTenantNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tenant not found",
+		})
+	case application.ErrPlanNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Plan not found",
+		})
+	case application.ErrPlanNotBillable:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Plan is not available for purchase",
+		})
+	case application.ErrSubscriptionExists:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Tenant already has a subscription",
+		})
+	default:
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+}
+
+func appURL() string {
+	if url := viper.GetString("APP_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3003"
+}
+
+// isAppURL reports whether value is an absolute URL on the origin of APP_URL
+func isAppURL(value string) bool {
+	target, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	app, err := url.Parse(appURL())
+	if err != nil {
+		return false
+	}
+	return target.Scheme == app.Scheme && target.Host == app.Host && target.User == nil
+}
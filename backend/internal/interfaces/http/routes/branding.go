@@ -0,0 +1,170 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupBrandingRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	planRepo := repository.NewPlanRepository(db)
+	quotaService := application.NewQuotaService(db, tenantRepo, planRepo, userRepo, repository.NewUsageRepository(db))
+	tenantService := application.NewTenantService(db, tenantRepo, userRepo, planRepo, repository.NewTenantSlugAliasRepository(db), quotaService)
+	brandingService := application.NewBrandingService(db, tenantRepo, tenantService, fileStorage())
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+
+	// Branding of the current tenant
+	branding := router.Group("/tenant/branding", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
+
+	branding.Get("/", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		result, err := brandingService.GetBranding(tenantID)
+		if err != nil {
+			return brandingError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	branding.Put("/", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		var req application.BrandingUpdate
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		result, err := brandingService.UpdateBranding(tenantID, req)
+		if err != nil {
+			return brandingError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Multipart form with a "logo" file
+	branding.Post("/logo", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		fileHeader, err := c.FormFile("logo")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Logo file is required",
+			})
+		}
+		if fileHeader.Size > application.MaxLogoSize {
+			return brandingError(c, application.ErrLogoTooLarge)
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to read logo",
+			})
+		}
+		defer file.Close()
+
+		result, err := brandingService.UploadLogo(c.Context(), tenantID, file)
+		if err != nil {
+			return brandingError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	branding.Delete("/logo", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		result, err := brandingService.RemoveLogo(c.Context(), tenantID)
+		if err != nil {
+			return brandingError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Public branding used by login pages and white-label frontends
+	public := router.Group("/public/tenants/:slug/branding")
+
+	public.Get("/", func(c fiber.Ctx) error {
+		result, err := brandingService.GetPublicBranding(c.Params("slug"))
+		if err != nil {
+			return brandingError(c, err)
+		}
+
+		c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+		return c.JSON(result)
+	})
+
+	public.Get("/logo", func(c fiber.Ctx) error {
+		reader, contentType, err := brandingService.OpenLogo(c.Context(), c.Params("slug"))
+		if err != nil {
+			return brandingError(c, err)
+		}
+
+		// Logo URLs carry a version parameter, so they can be cached for long
+		c.Set(fiber.HeaderContentType, contentType)
+		c.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		return c.SendStream(reader)
+	})
+}
+
+func brandingError(c fiber.Ctx, err error) error {
+	switch err {
+	case application.ErrTenantNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tenant not found",
+		})
+	case application.ErrLogoNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Logo not found",
+		})
+	case application.ErrInvalidColor, application.ErrBrandingTooLong, application.ErrInvalidBrandName,
+		application.ErrInvalidLogo:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case application.ErrInvalidEmail:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid support email",
+		})
+	case application.ErrLogoTooLarge:
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "Logo must be at most 1 MB",
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process branding",
+		})
+	}
+}
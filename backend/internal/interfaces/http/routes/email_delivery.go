@@ -0,0 +1,156 @@
+package routes
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupEmailDeliveryRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	deliveryService := newEmailDeliveryService(db)
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+
+	// Bounces, complaints and suppression list of the current tenant
+	deliveries := router.Group("/tenant/email-deliveries", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
+
+	// Latest delivery events. Query parameters: type (bounce, complaint or
+	// suppressed) and limit (max 200)
+	deliveries.Get("/events", middleware.RequirePermission(roleService, domain.PermTenantSettingsRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		limit := 0
+		if value := c.Query("limit"); value != "" {
+			if limit, err = strconv.Atoi(value); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid limit",
+				})
+			}
+		}
+
+		events, err := deliveryService.ListEvents(tenantID, c.Query("type"), limit)
+		if err != nil {
+			return emailDeliveryError(c, err)
+		}
+
+		return c.JSON(events)
+	})
+
+	// Delivery log of an address: the emails sent to it, their bounces and
+	// complaints, and whether it is suppressed
+	deliveries.Get("/recipients/:email", middleware.RequirePermission(roleService, domain.PermTenantSettingsRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		address, err := url.PathUnescape(c.Params("email"))
+		if err != nil {
+			return emailDeliveryError(c, application.ErrInvalidEmail)
+		}
+
+		result, err := deliveryService.RecipientLog(tenantID, address)
+		if err != nil {
+			return emailDeliveryError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	deliveries.Get("/suppressions", middleware.RequirePermission(roleService, domain.PermTenantSettingsRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		suppressions, err := deliveryService.ListSuppressions(tenantID)
+		if err != nil {
+			return emailDeliveryError(c, err)
+		}
+
+		return c.JSON(suppressions)
+	})
+
+	deliveries.Post("/suppressions", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		var req struct {
+			Email  string `json:"email"`
+			Detail string `json:"detail"`
+		}
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		suppression, err := deliveryService.AddSuppression(tenantID, req.Email, req.Detail)
+		if err != nil {
+			return emailDeliveryError(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(suppression)
+	})
+
+	// Let emails reach the address again and clear the bounce flag of its users
+	deliveries.Delete("/suppressions/:email", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		address, err := url.PathUnescape(c.Params("email"))
+		if err != nil {
+			return emailDeliveryError(c, application.ErrSuppressionNotFound)
+		}
+
+		if err := deliveryService.RemoveSuppression(tenantID, address); err != nil {
+			return emailDeliveryError(c, err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+}
+
+func newEmailDeliveryService(db *gorm.DB) *application.EmailDeliveryService {
+	return application.NewEmailDeliveryService(db, repository.NewEmailDeliveryRepository(db), repository.NewEmailOutboxRepository(db))
+}
+
+func emailDeliveryError(c fiber.Ctx, err error) error {
+	switch err {
+	case application.ErrInvalidEmail:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid email address",
+		})
+	case application.ErrSuppressionNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Address is not on the suppression list",
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process email deliveries",
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupEmailOutboxRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	outboxRepo := repository.NewEmailOutboxRepository(db)
+	mailer := application.NewTenantMailer(repository.NewTenantMailSettingsRepository(db), email.NewMailerFromEnv())
+	outboxService := application.NewEmailOutboxService(outboxRepo, mailer)
+
+	// Super admin inspection of the email outbox
+	admin := router.Group("/admin/emails", middleware.AuthMiddleware(db), middleware.RequireSuperAdmin(application.NewPlatformAdmins(db)))
+
+	// List emails. Query parameters: status, tenant_id and limit (max 200)
+	admin.Get("/", func(c fiber.Ctx) error {
+		filter := domain.EmailOutboxFilter{
+			Status: c.Query("status"),
+		}
+		if value := c.Query("tenant_id"); value != "" {
+			tenantID, err := uuid.Parse(value)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid tenant ID",
+				})
+			}
+			filter.TenantID = &tenantID
+		}
+		if value := c.Query("limit"); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid limit",
+				})
+			}
+			filter.Limit = limit
+		}
+
+		messages, err := outboxService.List(filter)
+		if err != nil {
+			return emailOutboxError(c, err)
+		}
+
+		return c.JSON(messages)
+	})
+
+	admin.Get("/stats", func(c fiber.Ctx) error {
+		stats, err := outboxService.Stats()
+		if err != nil {
+			return emailOutboxError(c, err)
+		}
+
+		return c.JSON(stats)
+	})
+
+	admin.Get("/:id", func(c fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid email ID",
+			})
+		}
+
+		msg, err := outboxService.Get(id)
+		if err != nil {
+			return emailOutboxError(c, err)
+		}
+
+		return c.JSON(msg)
+	})
+
+	// Schedule a failed or dead email for delivery again
+	admin.Post("/:id/retry", func(c fiber.Ctx) error {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid email ID",
+			})
+		}
+
+		msg, err := outboxService.Retry(id)
+		if err != nil {
+			return emailOutboxError(c, err)
+		}
+
+		return c.JSON(msg)
+	})
+}
+
+func emailOutboxError(c fiber.Ctx, err error) error {
+	switch err {
+	case application.ErrEmailNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Email not found",
+		})
+	case application.ErrEmailNotRetryable:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Only failed or dead emails can be retried",
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process email outbox request",
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupEmailTemplateRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	templateService := application.NewEmailTemplateService(db, tenantRepo)
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+
+	// Email locale and template customization of the current tenant
+	templates := router.Group("/tenant/email-templates", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
+
+	templates.Get("/", middleware.RequirePermission(roleService, domain.PermTenantSettingsRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		result, err := templateService.GetSettings(tenantID)
+		if err != nil {
+			return emailTemplateError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	templates.Put("/locale", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		var req struct {
+			Locale string `json:"locale"`
+		}
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		result, err := templateService.UpdateLocale(tenantID, req.Locale)
+		if err != nil {
+			return emailTemplateError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Override the subject and intro of a template; empty fields restore the defaults
+	templates.Put("/:name", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		var req domain.EmailTemplateOverride
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		result, err := templateService.UpdateOverride(tenantID, c.Params("name"), req)
+		if err != nil {
+			return emailTemplateError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	templates.Delete("/:name", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		result, err := templateService.UpdateOverride(tenantID, c.Params("name"), domain.EmailTemplateOverride{})
+		if err != nil {
+			return emailTemplateError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Render a template with sample data. Query parameters: locale, and
+	// format=html to get the HTML page instead of the JSON rendering
+	templates.Get("/:name/preview", middleware.RequirePermission(roleService, domain.PermTenantSettingsRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		rendered, err := templateService.Preview(tenantID, c.Params("name"), c.Query("locale"))
+		if err != nil {
+			return emailTemplateError(c, err)
+		}
+
+		if c.Query("format") == "html" {
+			c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+			return c.SendString(rendered.HTML)
+		}
+
+		return c.JSON(rendered)
+	})
+}
+
+func emailTemplateError(c fiber.Ctx, err error) error {
+	switch err {
+	case application.ErrTenantNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tenant not found",
+		})
+	case application.ErrEmailTemplateNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Email template not found",
+		})
+	case application.ErrInvalidLocale:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unsupported locale",
+		})
+	case application.ErrInvalidTemplateOverride:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process email templates",
+		})
+	}
+}
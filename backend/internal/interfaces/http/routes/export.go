@@ -0,0 +1,141 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupExportRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	exportRepo := repository.NewTenantExportRepository(db)
+	exportService := application.NewExportService(db, tenantRepo, userRepo, exportRepo, fileStorage())
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+
+	// Tenant data exports (LGPD/GDPR portability)
+	exports := router.Group("/tenant/exports", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db), middleware.RequirePermission(roleService, domain.PermTenantDataExport))
+
+	exports.Post("/", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		currentUserID, _ := middleware.GetUserID(c)
+
+		export, err := exportService.RequestExport(tenantID, currentUserID)
+		if err != nil {
+			switch err {
+			case application.ErrTenantNotFound:
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Tenant not found",
+				})
+			case application.ErrExportInProgress:
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "An export is already in progress",
+				})
+			default:
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to request export",
+				})
+			}
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(export)
+	})
+
+	exports.Get("/", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		list, err := exportService.ListExports(tenantID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to list exports",
+			})
+		}
+
+		return c.JSON(list)
+	})
+
+	exports.Get("/:id", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		exportID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid export ID",
+			})
+		}
+
+		export, err := exportService.GetExport(tenantID, exportID)
+		if err != nil {
+			if err == application.ErrExportNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Export not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get export",
+			})
+		}
+
+		return c.JSON(export)
+	})
+
+	// Returns a short lived signed URL to download the archive
+	exports.Get("/:id/download", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		exportID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid export ID",
+			})
+		}
+
+		url, expiresAt, err := exportService.DownloadURL(tenantID, exportID)
+		if err != nil {
+			switch err {
+			case application.ErrExportNotFound:
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Export not found",
+				})
+			case application.ErrExportNotReady:
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "Export is not available for download",
+				})
+			default:
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to create download link",
+				})
+			}
+		}
+
+		return c.JSON(fiber.Map{
+			"url":        url,
+			"expires_at": expiresAt,
+		})
+	})
+}
@@ -0,0 +1,154 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupFeatureRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	overrideRepo := repository.NewFeatureOverrideRepository(db)
+	planRepo := repository.NewPlanRepository(db)
+	featureService := application.NewFeatureService(db, tenantRepo, overrideRepo, planRepo)
+
+	// Effective flags for the current tenant (used by the frontend)
+	features := router.Group("/tenant/features", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
+
+	features.Get("/", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		flags, err := featureService.GetEffectiveFlags(tenantID)
+		if err != nil {
+			if err == application.ErrTenantNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Tenant not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to resolve feature flags",
+			})
+		}
+
+		enabled := make(map[string]bool, len(flags))
+		for _, flag := range flags {
+			enabled[flag.Key] = flag.Enabled
+		}
+
+		return c.JSON(fiber.Map{
+			"features": enabled,
+			"details":  flags,
+		})
+	})
+
+	// Super admin routes to manage per-tenant overrides
+	admin := router.Group("/admin/tenants/:id/features", middleware.AuthMiddleware(db), middleware.RequireSuperAdmin(application.NewPlatformAdmins(db)))
+
+	admin.Get("/", func(c fiber.Ctx) error {
+		tenantID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid tenant ID",
+			})
+		}
+
+		flags, err := featureService.GetEffectiveFlags(tenantID)
+		if err != nil {
+			if err == application.ErrTenantNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Tenant not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to resolve feature flags",
+			})
+		}
+
+		overrides, err := featureService.ListOverrides(tenantID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to list feature overrides",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"features":  flags,
+			"overrides": overrides,
+		})
+	})
+
+	admin.Put("/:feature", func(c fiber.Ctx) error {
+		tenantID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid tenant ID",
+			})
+		}
+
+		var req struct {
+			Enabled *bool  `json:"enabled"`
+			Reason  string `json:"reason"`
+		}
+
+		if err := c.Bind().JSON(&req); err != nil || req.Enabled == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Field 'enabled' is required",
+			})
+		}
+
+		currentUserID, _ := middleware.GetUserID(c)
+
+		override, err := featureService.SetOverride(tenantID, c.Params("feature"), *req.Enabled, req.Reason, currentUserID)
+		if err != nil {
+			switch err {
+			case application.ErrUnknownFeature:
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Unknown feature flag",
+				})
+			case application.ErrTenantNotFound:
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Tenant not found",
+				})
+			default:
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+		}
+
+		return c.JSON(override)
+	})
+
+	admin.Delete("/:feature", func(c fiber.Ctx) error {
+		tenantID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid tenant ID",
+			})
+		}
+
+		if err := featureService.DeleteOverride(tenantID, c.Params("feature")); err != nil {
+			if err == application.ErrUnknownFeature {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Unknown feature flag",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to delete feature override",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"message": "Feature override removed",
+		})
+	})
+}
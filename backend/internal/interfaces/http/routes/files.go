@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"sync"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/widia/widia-connect/internal/infrastructure/storage"
+	"gorm.io/gorm"
+)
+
+// fileStorage is the storage shared by every route that stores files
+var fileStorage = sync.OnceValue(storage.NewFromConfig)
+
+// SetupFileRoutes serves stored objects through expiring signed URLs
+func SetupFileRoutes(router fiber.Router, db *gorm.DB) {
+	files := router.Group("/files")
+
+	files.Get("/*", func(c fiber.Ctx) error {
+		store := fileStorage()
+		key := c.Params("*")
+
+		verifier, ok := store.(storage.SignedURLVerifier)
+		if !ok {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "File not found",
+			})
+		}
+
+		if err := verifier.Verify(key, c.Query("expires"), c.Query("signature")); err != nil {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Invalid or expired link",
+			})
+		}
+
+		reader, err := store.Open(c.Context(), key)
+		if err != nil {
+			if err == storage.ErrNotFound || err == storage.ErrInvalidKey {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "File not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to read file",
+			})
+		}
+
+		c.Attachment(key[lastSlash(key)+1:])
+		return c.SendStream(reader)
+	})
+}
+
+func lastSlash(key string) int {
+	for i := len(key) - 1; i >= 0; i-- {
+		if key[i] == '/' {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupImportRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	planRepo := repository.NewPlanRepository(db)
+	quotaService := application.NewQuotaService(db, tenantRepo, planRepo, repository.NewUserRepository(db), repository.NewUsageRepository(db))
+	importService := application.NewImportService(db, tenantRepo, planRepo, quotaService)
+
+	// Super admin route to import a tenant export archive
+	admin := router.Group("/admin/tenants/import", middleware.AuthMiddleware(db), middleware.RequireSuperAdmin(application.NewPlatformAdmins(db)))
+
+	// Multipart form: archive (file), tenant_id (merge into an existing tenant),
+	// slug and name (new tenant), dry_run
+	admin.Post("/", func(c fiber.Ctx) error {
+		fileHeader, err := c.FormFile("archive")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Archive file is required",
+			})
+		}
+
+		opts := application.ImportOptions{
+			Slug: c.FormValue("slug"),
+			Name: c.FormValue("name"),
+		}
+		opts.DryRun, _ = strconv.ParseBool(c.FormValue("dry_run"))
+
+		if value := c.FormValue("tenant_id"); value != "" {
+			tenantID, err := uuid.Parse(value)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid tenant ID",
+				})
+			}
+			opts.TargetTenantID = &tenantID
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to read archive",
+			})
+		}
+		defer file.Close()
+
+		report, err := importService.Import(c.Context(), file, fileHeader.Size, opts)
+		if err != nil {
+			switch {
+			case errors.Is(err, application.ErrInvalidArchive):
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			case errors.Is(err, application.ErrImportConflicts):
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error":  "Import has conflicts",
+					"report": report,
+				})
+			case errors.Is(err, application.ErrTenantNotFound):
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Tenant not found",
+				})
+			default:
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+		}
+
+		if report.DryRun {
+			return c.JSON(report)
+		}
+		return c.Status(fiber.StatusCreated).JSON(report)
+	})
+}
@@ -0,0 +1,121 @@
+package routes
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupIntegrationRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	chatwootService := application.NewChatwootIntegrationService(repository.NewTenantIntegrationRepository(db))
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+
+	// Chatwoot account of the current tenant
+	chatwoot := router.Group("/tenant/integrations/chatwoot", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
+
+	chatwoot.Get("/", middleware.RequirePermission(roleService, domain.PermTenantSettingsRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		result, err := chatwootService.Get(tenantID)
+		if err != nil {
+			return integrationError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Connect the account, or change it; the connection is checked before
+	// anything is stored
+	chatwoot.Put("/", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		userID, err := middleware.GetUserID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User ID not found",
+			})
+		}
+
+		var req application.ChatwootConnectRequest
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		result, err := chatwootService.Connect(tenantID, userID, req)
+		if err != nil {
+			return integrationError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Check the stored connection; failures are reported in the status
+	chatwoot.Post("/test", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		result, err := chatwootService.Test(tenantID)
+		if err != nil {
+			return integrationError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	chatwoot.Delete("/", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		if err := chatwootService.Disconnect(tenantID); err != nil {
+			return integrationError(c, err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+}
+
+func integrationError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, application.ErrChatwootNotConnected):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Chatwoot is not connected",
+		})
+	case errors.Is(err, application.ErrInvalidChatwootSettings):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, application.ErrChatwootConnectionFailed):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process integration",
+		})
+	}
+}
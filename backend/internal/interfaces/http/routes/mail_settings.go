@@ -0,0 +1,218 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/email"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupMailSettingsRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	mailSettingsRepo := repository.NewTenantMailSettingsRepository(db)
+	mailSettingsService := application.NewMailSettingsService(db, tenantRepo, userRepo, mailSettingsRepo, email.NewMailerFromEnv())
+	featureService := application.NewFeatureService(db, tenantRepo,
+		repository.NewFeatureOverrideRepository(db), repository.NewPlanRepository(db))
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+
+	// SMTP relay and sender address of the current tenant
+	settings := router.Group("/tenant/mail-settings",
+		middleware.AuthMiddleware(db),
+		middleware.SubscriptionGuard(db),
+		middleware.RequireFeature(featureService, domain.FeatureCustomMail),
+	)
+
+	settings.Get("/", middleware.RequirePermission(roleService, domain.PermTenantSettingsRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		result, err := mailSettingsService.GetSettings(tenantID)
+		if err != nil {
+			return mailSettingsError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Replace the settings. A new sender address receives a verification
+	// email and a changed relay is checked before responding.
+	settings.Put("/", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		userID, err := middleware.GetUserID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User ID not found",
+			})
+		}
+
+		var req application.MailSettingsUpdate
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		result, err := mailSettingsService.UpdateSettings(c.Context(), tenantID, userID, req)
+		if err != nil {
+			return mailSettingsError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Go back to the platform transport and sender
+	settings.Delete("/", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		if err := mailSettingsService.ResetSettings(tenantID); err != nil {
+			return mailSettingsError(c, err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	settings.Post("/verification", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		userID, err := middleware.GetUserID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User ID not found",
+			})
+		}
+
+		result, err := mailSettingsService.ResendVerification(tenantID, userID)
+		if err != nil {
+			return mailSettingsError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Check the relay now instead of waiting for the periodic health check
+	settings.Post("/check", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		result, err := mailSettingsService.CheckHealth(c.Context(), tenantID)
+		if err != nil {
+			return mailSettingsError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Send a test email to the given address, or to the current user
+	settings.Post("/test", middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		userID, err := middleware.GetUserID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User ID not found",
+			})
+		}
+
+		var req struct {
+			To string `json:"to"`
+		}
+		if len(c.Body()) > 0 {
+			if err := c.Bind().JSON(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid request body",
+				})
+			}
+		}
+
+		result, err := mailSettingsService.SendTest(c.Context(), tenantID, userID, req.To)
+		if err != nil {
+			return mailSettingsError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Confirmation of a sender address from the link of the verification
+	// email; the token proves access to the mailbox, no login is required
+	router.Post("/public/mail-sender/verify", func(c fiber.Ctx) error {
+		var req struct {
+			Token string `json:"token"`
+		}
+		if err := c.Bind().JSON(&req); err != nil || req.Token == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Token is required",
+			})
+		}
+
+		result, err := mailSettingsService.VerifySender(req.Token)
+		if err != nil {
+			return mailSettingsError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"from_email":  result.FromEmail,
+			"verified_at": result.SenderVerifiedAt,
+		})
+	})
+}
+
+func mailSettingsError(c fiber.Ctx, err error) error {
+	switch err {
+	case application.ErrTenantNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tenant not found",
+		})
+	case application.ErrUserNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case application.ErrInvalidMailMode, application.ErrInvalidSMTPSettings, application.ErrSMTPHostNotAllowed,
+		application.ErrInvalidSender, application.ErrSenderRequired, application.ErrInvalidTestRecipient:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case application.ErrInvalidSenderToken:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid or expired verification link",
+		})
+	case application.ErrRelayNotConfigured, application.ErrSenderNotPending:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process mail settings",
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupOffboardingRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
+	offboardingService := application.NewOffboardingService(db, tenantRepo, userRepo, auditRepo, fileStorage(), BillingProvider())
+
+	// Owner requests the deletion of the tenant; allowed whatever the subscription state
+	deletion := router.Group("/tenant/deletion", middleware.AuthMiddleware(db), middleware.RequireOwner())
+
+	deletion.Post("/", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		currentUserID, _ := middleware.GetUserID(c)
+
+		var req struct {
+			Password string `json:"password"`
+			Reason   string `json:"reason"`
+		}
+		if err := c.Bind().JSON(&req); err != nil || req.Password == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Password confirmation is required",
+			})
+		}
+
+		tenant, err := offboardingService.RequestDeletion(tenantID, currentUserID, req.Password, req.Reason, auditContext(c))
+		if err != nil {
+			return offboardingError(c, err)
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message":               "Tenant scheduled for deletion",
+			"status":                tenant.Status,
+			"deletion_scheduled_at": tenant.DeletionScheduledAt,
+		})
+	})
+
+	// Public restore: the tenant users are deactivated, so the owner confirms with credentials
+	router.Post("/auth/restore-tenant", func(c fiber.Ctx) error {
+		var req struct {
+			TenantSlug string `json:"tenant_slug"`
+			Email      string `json:"email"`
+			Password   string `json:"password"`
+		}
+		if err := c.Bind().JSON(&req); err != nil || req.TenantSlug == "" || req.Email == "" || req.Password == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Missing required fields",
+			})
+		}
+
+		tenant, err := offboardingService.RestoreWithCredentials(req.TenantSlug, req.Email, req.Password, auditContext(c))
+		if err != nil {
+			return offboardingError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"message": "Tenant restored",
+			"tenant":  tenant,
+		})
+	})
+
+	// Super admin restore on behalf of a tenant
+	admin := router.Group("/admin/tenants/:id/restore", middleware.AuthMiddleware(db), middleware.RequireSuperAdmin(application.NewPlatformAdmins(db)))
+
+	admin.Post("/", func(c fiber.Ctx) error {
+		tenantID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid tenant ID",
+			})
+		}
+		currentUserID, _ := middleware.GetUserID(c)
+
+		tenant, err := offboardingService.Restore(tenantID, &currentUserID, auditContext(c))
+		if err != nil {
+			return offboardingError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"message": "Tenant restored",
+			"tenant":  tenant,
+		})
+	})
+}
+
+func auditContext(c fiber.Ctx) application.AuditContext {
+	return application.AuditContext{
+		IPAddress: c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+}
+
+func offboardingError(c fiber.Ctx, err error) error {
+	switch err {
+	case application.ErrTenantNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tenant not found",
+		})
+	case application.ErrUserNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case application.ErrWrongPassword:
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Incorrect password",
+		})
+	case application.ErrInvalidCredentials:
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid credentials",
+		})
+	case application.ErrOwnerRequired:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the tenant owner can delete the tenant",
+		})
+	case application.ErrRestoreNotAllowed:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the tenant owner can restore the tenant",
+		})
+	case application.ErrTenantPendingDeletion:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Tenant is already scheduled for deletion",
+		})
+	case application.ErrTenantNotPendingDeletion:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Tenant is not scheduled for deletion",
+		})
+	case application.ErrRestoreWindowClosed:
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{
+			"error": "The restore window has closed",
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process tenant deletion",
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupOnboardingRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	onboardingRepo := repository.NewOnboardingRepository(db)
+	provisioningRepo := repository.NewChatwootProvisioningRepository(db)
+	onboardingService := application.NewOnboardingService(db, tenantRepo, userRepo, onboardingRepo, provisioningRepo, application.NewChatwootClientFactory(repository.NewTenantIntegrationRepository(db)))
+	provisioningService := application.NewChatwootProvisioningService(db, provisioningRepo, tenantRepo, userRepo)
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+
+	// Onboarding checklist of the current tenant
+	router.Get("/tenant/onboarding", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		progress, err := onboardingService.GetProgress(tenantID)
+		if err != nil {
+			if err == application.ErrTenantNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Tenant not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get onboarding progress",
+			})
+		}
+
+		return c.JSON(progress)
+	})
+
+	// Retry the Chatwoot provisioning of the current tenant after it failed
+	router.Post("/tenant/onboarding/chatwoot/retry", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db), middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		provisioning, err := provisioningService.Retry(tenantID)
+		if err != nil {
+			switch err {
+			case application.ErrProvisioningNotFound:
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			case application.ErrProvisioningNotRetryable:
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to retry Chatwoot provisioning",
+			})
+		}
+
+		return c.JSON(provisioning)
+	})
+}
@@ -0,0 +1,169 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupOwnershipRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	transferRepo := repository.NewOwnershipTransferRepository(db)
+	ownershipService := application.NewOwnershipService(db, tenantRepo, userRepo, transferRepo)
+
+	ownership := router.Group("/tenant/ownership", middleware.AuthMiddleware(db))
+
+	ownership.Get("/", middleware.RequireAdmin(), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		transfer, err := ownershipService.GetPendingTransfer(tenantID)
+		if err == application.ErrTransferNotFound {
+			return c.JSON(fiber.Map{
+				"transfer": nil,
+			})
+		}
+		if err != nil {
+			return ownershipError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"transfer": transfer,
+		})
+	})
+
+	// The owner starts a transfer, confirming with their password
+	ownership.Post("/transfer", middleware.RequireOwner(), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		currentUserID, _ := middleware.GetUserID(c)
+
+		var req struct {
+			UserID   string `json:"user_id"`
+			Password string `json:"password"`
+		}
+		if err := c.Bind().JSON(&req); err != nil || req.Password == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Target user and password confirmation are required",
+			})
+		}
+		targetID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid user ID",
+			})
+		}
+
+		transfer, err := ownershipService.InitiateTransfer(tenantID, currentUserID, targetID, req.Password, auditContext(c))
+		if err != nil {
+			return ownershipError(c, err)
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message":  "Ownership transfer requested; the new owner must confirm by email",
+			"transfer": transfer,
+		})
+	})
+
+	ownership.Delete("/transfer", middleware.RequireOwner(), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		currentUserID, _ := middleware.GetUserID(c)
+
+		if err := ownershipService.CancelTransfer(tenantID, currentUserID); err != nil {
+			return ownershipError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"message": "Ownership transfer cancelled",
+		})
+	})
+
+	// The target confirms with the token sent by email, whatever their current role
+	ownership.Post("/accept", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		currentUserID, _ := middleware.GetUserID(c)
+
+		var req struct {
+			Token string `json:"token"`
+		}
+		if err := c.Bind().JSON(&req); err != nil || req.Token == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Token is required",
+			})
+		}
+
+		transfer, err := ownershipService.AcceptTransfer(tenantID, currentUserID, req.Token, auditContext(c))
+		if err != nil {
+			return ownershipError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"message":  "You are now the owner of the tenant; sign in again to refresh your session",
+			"transfer": transfer,
+		})
+	})
+}
+
+func ownershipError(c fiber.Ctx, err error) error {
+	switch err {
+	case application.ErrTenantNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tenant not found",
+		})
+	case application.ErrUserNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case application.ErrWrongPassword:
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Incorrect password",
+		})
+	case application.ErrOwnerRequired:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only the tenant owner can transfer the ownership",
+		})
+	case application.ErrInvalidTransferTarget:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Ownership can only be transferred to another active user of the tenant",
+		})
+	case application.ErrTransferNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Ownership transfer not found or expired",
+		})
+	case application.ErrTransferTargetMismatch:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "This ownership transfer is addressed to another user",
+		})
+	case application.ErrTransferOwnerChanged:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The tenant owner changed since the transfer was requested",
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process ownership transfer",
+		})
+	}
+}
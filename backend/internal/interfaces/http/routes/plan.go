@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupPlanRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	planRepo := repository.NewPlanRepository(db)
+	planService := application.NewPlanService(db, planRepo, tenantRepo)
+
+	// List plans available for subscription
+	plans := router.Group("/plans", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
+
+	plans.Get("/", func(c fiber.Ctx) error {
+		plansList, err := planService.ListPlans(true)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to list plans",
+			})
+		}
+
+		return c.JSON(plansList)
+	})
+
+	// Super admin route to move a tenant to another plan
+	admin := router.Group("/admin/tenants/:id/plan", middleware.AuthMiddleware(db), middleware.RequireSuperAdmin(application.NewPlatformAdmins(db)))
+
+	admin.Put("/", func(c fiber.Ctx) error {
+		tenantID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid tenant ID",
+			})
+		}
+
+		var req struct {
+			Plan string `json:"plan"`
+		}
+
+		if err := c.Bind().JSON(&req); err != nil || req.Plan == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Plan code is required",
+			})
+		}
+
+		tenant, err := planService.ChangeTenantPlan(tenantID, req.Plan)
+		if err != nil {
+			switch err {
+			case application.ErrTenantNotFound:
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Tenant not found",
+				})
+			case application.ErrPlanNotFound:
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Plan not found",
+				})
+			default:
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+		}
+
+		return c.JSON(tenant)
+	})
+}
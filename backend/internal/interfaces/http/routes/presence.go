@@ -0,0 +1,187 @@
+package routes
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupPresenceRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	userRepo := repository.NewUserRepository(db)
+	scheduleRepo := repository.NewUserScheduleRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+	chatwootClients := application.NewChatwootClientFactory(repository.NewTenantIntegrationRepository(db))
+	presenceService := application.NewPresenceService(db, userRepo, scheduleRepo, teamRepo, roleService, application.NewChatwootLoadCounter(db, chatwootClients), chatwootClients)
+
+	// Presence and schedule of the current user
+	profile := router.Group("/profile", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
+
+	profile.Get("/presence", func(c fiber.Ctx) error {
+		userID, err := middleware.GetUserID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User ID not found",
+			})
+		}
+
+		result, err := presenceService.GetPresence(userID)
+		if err != nil {
+			return presenceError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	profile.Put("/presence", func(c fiber.Ctx) error {
+		userID, err := middleware.GetUserID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User ID not found",
+			})
+		}
+
+		var req struct {
+			Presence string `json:"presence"`
+		}
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		result, err := presenceService.SetPresence(userID, req.Presence)
+		if err != nil {
+			return presenceError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Sent by clients every minute while the app is open
+	profile.Post("/presence/heartbeat", func(c fiber.Ctx) error {
+		userID, err := middleware.GetUserID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User ID not found",
+			})
+		}
+
+		result, err := presenceService.Heartbeat(userID)
+		if err != nil {
+			return presenceError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	profile.Get("/schedule", func(c fiber.Ctx) error {
+		userID, err := middleware.GetUserID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User ID not found",
+			})
+		}
+
+		result, err := presenceService.GetSchedule(userID)
+		if err != nil {
+			return presenceError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	profile.Put("/schedule", func(c fiber.Ctx) error {
+		userID, err := middleware.GetUserID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User ID not found",
+			})
+		}
+
+		var req application.ScheduleInput
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		result, err := presenceService.UpdateSchedule(userID, req)
+		if err != nil {
+			return presenceError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Presence of the tenant agents, used for routing
+	agents := router.Group("/tenant/agents", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
+
+	agents.Get("/presence", middleware.RequirePermission(roleService, domain.PermUsersRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		result, err := presenceService.ListPresence(tenantID)
+		if err != nil {
+			return presenceError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Agents that can take a conversation right now, least loaded first
+	agents.Get("/available", middleware.RequirePermission(roleService, domain.PermConversationsAssign), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		var teamID *uuid.UUID
+		if value := c.Query("team_id"); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid team ID",
+				})
+			}
+			teamID = &id
+		}
+
+		result, err := presenceService.AvailableAgents(tenantID, teamID)
+		if err != nil {
+			return presenceError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+}
+
+func presenceError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, application.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case errors.Is(err, application.ErrInvalidPresence), errors.Is(err, application.ErrInvalidSchedule):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process presence",
+		})
+	}
+}
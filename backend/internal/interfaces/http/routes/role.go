@@ -0,0 +1,195 @@
+package routes
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupRoleRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	roleRepo := repository.NewRoleRepository(db)
+	roleService := application.NewRoleService(db, roleRepo)
+
+	roles := router.Group("/tenant/roles", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
+
+	// List system and custom roles
+	roles.Get("/", middleware.RequirePermission(roleService, domain.PermUsersRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		result, err := roleService.ListRoles(tenantID)
+		if err != nil {
+			return roleError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Catalog of permissions that can be granted to custom roles
+	roles.Get("/permissions", func(c fiber.Ctx) error {
+		return c.JSON(domain.PermissionCatalog)
+	})
+
+	// Permissions of the current user
+	roles.Get("/me", middleware.ResolveRole(roleService), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		role, _ := middleware.GetUserRole(c)
+
+		permissions, err := roleService.Permissions(tenantID, role)
+		if err != nil {
+			return roleError(c, err)
+		}
+		if permissions == nil {
+			permissions = []string{}
+		}
+
+		return c.JSON(fiber.Map{
+			"role":        role,
+			"permissions": permissions,
+		})
+	})
+
+	roles.Get("/:id", middleware.RequirePermission(roleService, domain.PermUsersRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		roleID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid role ID",
+			})
+		}
+
+		result, err := roleService.GetRole(tenantID, roleID)
+		if err != nil {
+			return roleError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	roles.Post("/", middleware.RequirePermission(roleService, domain.PermRolesManage), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		actorRole, _ := middleware.GetUserRole(c)
+
+		var req application.RoleInput
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		result, err := roleService.CreateRole(tenantID, actorRole, req)
+		if err != nil {
+			return roleError(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(result)
+	})
+
+	roles.Put("/:id", middleware.RequirePermission(roleService, domain.PermRolesManage), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		actorRole, _ := middleware.GetUserRole(c)
+		roleID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid role ID",
+			})
+		}
+
+		var req application.RoleInput
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		result, err := roleService.UpdateRole(tenantID, roleID, actorRole, req)
+		if err != nil {
+			return roleError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	roles.Delete("/:id", middleware.RequirePermission(roleService, domain.PermRolesManage), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		roleID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid role ID",
+			})
+		}
+
+		if err := roleService.DeleteRole(tenantID, roleID); err != nil {
+			return roleError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"message": "Role deleted successfully",
+		})
+	})
+}
+
+func roleError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, application.ErrRoleNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Role not found",
+		})
+	case errors.Is(err, application.ErrInvalidRoleName), errors.Is(err, application.ErrUnknownPermission):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, application.ErrRoleNameExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Role name already exists",
+		})
+	case errors.Is(err, application.ErrRoleInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Role is assigned to users, move them to another role first",
+		})
+	case errors.Is(err, application.ErrPermissionEscalation):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Cannot grant permissions you do not have",
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process role",
+		})
+	}
+}
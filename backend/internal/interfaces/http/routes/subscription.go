@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupSubscriptionRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	tenantRepo := repository.NewTenantRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	eventRepo := repository.NewSubscriptionEventRepository(db)
+	subscriptionService := application.NewSubscriptionService(db, tenantRepo, userRepo, eventRepo)
+	planRepo := repository.NewPlanRepository(db)
+	usageRepo := repository.NewUsageRepository(db)
+	slugAliasRepo := repository.NewTenantSlugAliasRepository(db)
+	quotaService := application.NewQuotaService(db, tenantRepo, planRepo, userRepo, usageRepo)
+	tenantService := application.NewTenantService(db, tenantRepo, userRepo, planRepo, slugAliasRepo, quotaService)
+
+	// Subscription state of the current tenant
+	subscription := router.Group("/tenant/subscription", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
+
+	subscription.Get("/", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		tenant, err := tenantService.GetTenant(tenantID)
+		if err != nil {
+			if err == application.ErrTenantNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Tenant not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get tenant",
+			})
+		}
+
+		history, err := subscriptionService.GetHistory(tenantID, 20)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get subscription history",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"status":              tenant.SubscriptionStatus,
+			"ends_at":             tenant.SubscriptionEndsAt,
+			"access":              domain.SubscriptionAccessLevel(tenant.SubscriptionStatus),
+			"allowed_transitions": domain.AllowedSubscriptionTransitions(tenant.SubscriptionStatus),
+			"history":             history,
+		})
+	})
+
+	// Super admin route to force a lifecycle transition
+	admin := router.Group("/admin/tenants/:id/subscription", middleware.AuthMiddleware(db), middleware.RequireSuperAdmin(application.NewPlatformAdmins(db)))
+
+	admin.Post("/transition", func(c fiber.Ctx) error {
+		tenantID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid tenant ID",
+			})
+		}
+
+		var req struct {
+			Status string     `json:"status"`
+			EndsAt *time.Time `json:"ends_at"`
+			Reason string     `json:"reason"`
+		}
+
+		if err := c.Bind().JSON(&req); err != nil || req.Status == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Status is required",
+			})
+		}
+
+		currentUserID, _ := middleware.GetUserID(c)
+
+		tenant, err := subscriptionService.Transition(tenantID, req.Status, req.EndsAt, req.Reason, &currentUserID)
+		if err != nil {
+			switch err {
+			case application.ErrTenantNotFound:
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Tenant not found",
+				})
+			case application.ErrInvalidSubscriptionStatus:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid subscription status",
+				})
+			case application.ErrInvalidSubscriptionTransition:
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "Subscription transition not allowed",
+				})
+			default:
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+		}
+
+		return c.JSON(tenant)
+	})
+}
@@ -0,0 +1,317 @@
+package routes
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupTeamRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	teamRepo := repository.NewTeamRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
+	teamService := application.NewTeamService(db, teamRepo, userRepo, roleService, application.NewChatwootClientFactory(repository.NewTenantIntegrationRepository(db)))
+
+	teams := router.Group("/tenant/teams", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
+
+	teams.Get("/", middleware.RequirePermission(roleService, domain.PermUsersRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		result, err := teamService.ListTeams(tenantID)
+		if err != nil {
+			return teamError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Users whose conversations and leads the current user can see
+	teams.Get("/scope", middleware.ResolveRole(roleService), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		userID, _ := middleware.GetUserID(c)
+		role, _ := middleware.GetUserRole(c)
+
+		scope, err := teamService.Scope(tenantID, userID, role)
+		if err != nil {
+			return teamError(c, err)
+		}
+
+		return c.JSON(scope)
+	})
+
+	// Teams of the current user
+	teams.Get("/mine", func(c fiber.Ctx) error {
+		userID, err := middleware.GetUserID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User ID not found",
+			})
+		}
+
+		memberships, err := teamService.ListUserTeams(userID)
+		if err != nil {
+			return teamError(c, err)
+		}
+
+		return c.JSON(memberships)
+	})
+
+	// Whether the current user can manage the assignments of another user
+	teams.Get("/assignments/:userId", middleware.ResolveRole(roleService), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		actorID, _ := middleware.GetUserID(c)
+		actorRole, _ := middleware.GetUserRole(c)
+		assigneeID, err := uuid.Parse(c.Params("userId"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid user ID",
+			})
+		}
+
+		allowed, err := teamService.CanManageAssignment(tenantID, actorID, actorRole, assigneeID)
+		if err != nil {
+			return teamError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"user_id": assigneeID,
+			"allowed": allowed,
+		})
+	})
+
+	teams.Get("/:id", middleware.RequirePermission(roleService, domain.PermUsersRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		teamID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid team ID",
+			})
+		}
+
+		result, err := teamService.GetTeam(tenantID, teamID)
+		if err != nil {
+			return teamError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	teams.Post("/", middleware.RequirePermission(roleService, domain.PermTeamsManage), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		var req application.TeamInput
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		result, err := teamService.CreateTeam(tenantID, req)
+		if err != nil {
+			return teamError(c, err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(result)
+	})
+
+	teams.Put("/:id", middleware.RequirePermission(roleService, domain.PermTeamsManage), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		teamID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid team ID",
+			})
+		}
+
+		var req application.TeamInput
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		result, err := teamService.UpdateTeam(tenantID, teamID, req)
+		if err != nil {
+			return teamError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	teams.Delete("/:id", middleware.RequirePermission(roleService, domain.PermTeamsManage), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		teamID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid team ID",
+			})
+		}
+
+		if err := teamService.DeleteTeam(tenantID, teamID); err != nil {
+			return teamError(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"message": "Team deleted successfully",
+		})
+	})
+
+	// Add a member or change whether they lead the team
+	teams.Post("/:id/members", middleware.RequirePermission(roleService, domain.PermTeamsManage), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		teamID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid team ID",
+			})
+		}
+
+		var req struct {
+			UserID uuid.UUID `json:"user_id"`
+			IsLead bool      `json:"is_lead"`
+		}
+		if err := c.Bind().JSON(&req); err != nil || req.UserID == uuid.Nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+
+		result, err := teamService.AddMember(tenantID, teamID, req.UserID, req.IsLead)
+		if err != nil {
+			return teamError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	teams.Delete("/:id/members/:userId", middleware.RequirePermission(roleService, domain.PermTeamsManage), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		teamID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid team ID",
+			})
+		}
+		userID, err := uuid.Parse(c.Params("userId"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid user ID",
+			})
+		}
+
+		result, err := teamService.RemoveMember(tenantID, teamID, userID)
+		if err != nil {
+			return teamError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+
+	// Push the team and its members to Chatwoot again
+	teams.Post("/:id/sync", middleware.RequirePermission(roleService, domain.PermTeamsManage), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		teamID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid team ID",
+			})
+		}
+
+		result, err := teamService.SyncChatwoot(tenantID, teamID)
+		if err != nil {
+			return teamError(c, err)
+		}
+
+		return c.JSON(result)
+	})
+}
+
+func teamError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, application.ErrTeamNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Team not found",
+		})
+	case errors.Is(err, application.ErrUserNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case errors.Is(err, application.ErrTeamMemberMissing):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User is not a member of the team",
+		})
+	case errors.Is(err, application.ErrInvalidTeamName):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, application.ErrTeamNameExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Team name already exists",
+		})
+	case errors.Is(err, application.ErrChatwootDisabled):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Chatwoot integration is not configured",
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process team",
+		})
+	}
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/gofiber/fiber/v3"
 	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
 	"github.com/widia/widia-connect/internal/infrastructure/repository"
 	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
 	"gorm.io/gorm"
@@ -12,10 +13,15 @@ func SetupTenantRoutes(router fiber.Router, db *gorm.DB) {
 	// Initialize repositories and services
 	tenantRepo := repository.NewTenantRepository(db)
 	userRepo := repository.NewUserRepository(db)
-	tenantService := application.NewTenantService(db, tenantRepo, userRepo)
+	planRepo := repository.NewPlanRepository(db)
+	usageRepo := repository.NewUsageRepository(db)
+	slugAliasRepo := repository.NewTenantSlugAliasRepository(db)
+	quotaService := application.NewQuotaService(db, tenantRepo, planRepo, userRepo, usageRepo)
+	tenantService := application.NewTenantService(db, tenantRepo, userRepo, planRepo, slugAliasRepo, quotaService)
+	roleService := application.NewRoleService(db, repository.NewRoleRepository(db))
 	
 	// All tenant routes require authentication
-	tenant := router.Group("/tenant", middleware.AuthMiddleware(db))
+	tenant := router.Group("/tenant", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
 	
 	// Get current tenant information
 	tenant.Get("/", func(c fiber.Ctx) error {
@@ -43,7 +49,7 @@ func SetupTenantRoutes(router fiber.Router, db *gorm.DB) {
 	
 	// Admin-only routes group
 	adminTenant := tenant.Group("/")
-	adminTenant.Use(middleware.RequireAdmin())
+	adminTenant.Use(middleware.RequirePermission(roleService, domain.PermTenantSettingsWrite))
 	
 	// Update tenant (admin only)
 	adminTenant.Patch("/", func(c fiber.Ctx) error {
@@ -61,12 +67,15 @@ func SetupTenantRoutes(router fiber.Router, db *gorm.DB) {
 			})
 		}
 		
-		// Remove protected fields
+		// Remove protected fields; the slug is renamed through PUT /tenant/slug
 		delete(updates, "id")
 		delete(updates, "slug")
 		delete(updates, "created_at")
 		delete(updates, "updated_at")
 		delete(updates, "deleted_at")
+		delete(updates, "subscription_status")
+		delete(updates, "subscription_ends_at")
+		delete(updates, "plan_id")
 		
 		updatedTenant, err := tenantService.UpdateTenant(tenantID, updates)
 		if err != nil {
@@ -93,6 +102,111 @@ func SetupTenantRoutes(router fiber.Router, db *gorm.DB) {
 		return c.JSON(updatedTenant)
 	})
 	
+	// Rename the tenant slug (admin only); the former slug keeps working as an alias
+	adminTenant.Put("/slug", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		currentUserID, _ := middleware.GetUserID(c)
+		
+		var req struct {
+			Slug string `json:"slug"`
+		}
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+		
+		updatedTenant, alias, err := tenantService.ChangeSlug(tenantID, req.Slug, &currentUserID, auditContext(c))
+		if err != nil {
+			switch err {
+			case application.ErrTenantNotFound:
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Tenant not found",
+				})
+			case application.ErrInvalidSlug:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid slug format. Must be lowercase, alphanumeric with hyphens, 3-63 characters",
+				})
+			case application.ErrReservedSlug:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "This slug is reserved, please choose another one",
+				})
+			case application.ErrTenantSlugExists:
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "Tenant slug already exists",
+				})
+			default:
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to change slug",
+				})
+			}
+		}
+		
+		return c.JSON(fiber.Map{
+			"tenant": updatedTenant,
+			"alias":  alias,
+		})
+	})
+	
+	// List former slugs still redirecting to the tenant (admin only)
+	adminTenant.Get("/slug/aliases", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		
+		aliases, err := tenantService.ListSlugAliases(tenantID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to list slug aliases",
+			})
+		}
+		
+		return c.JSON(aliases)
+	})
+	
+	// Get the DNS record proving ownership of the custom domain (admin only)
+	adminTenant.Post("/domain/verification", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		
+		challenge, err := tenantService.RequestDomainVerification(tenantID)
+		if err != nil {
+			return domainVerificationError(c, err)
+		}
+		
+		return c.JSON(challenge)
+	})
+	
+	// Check the DNS record of the custom domain (admin only)
+	adminTenant.Post("/domain/verify", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		currentUserID, _ := middleware.GetUserID(c)
+		
+		challenge, err := tenantService.VerifyDomain(c.Context(), tenantID, &currentUserID)
+		if err != nil {
+			return domainVerificationError(c, err)
+		}
+		
+		return c.JSON(challenge)
+	})
+	
 	// Get tenant statistics (admin only)
 	adminTenant.Get("/stats", func(c fiber.Ctx) error {
 		tenantID, err := middleware.GetTenantID(c)
@@ -111,4 +225,29 @@ func SetupTenantRoutes(router fiber.Router, db *gorm.DB) {
 		
 		return c.JSON(stats)
 	})
-}
\ No newline at end of file
+}
+
+func domainVerificationError(c fiber.Ctx, err error) error {
+	switch err {
+	case application.ErrTenantNotFound:
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tenant not found",
+		})
+	case application.ErrDomainNotSet:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Set a custom domain before verifying it",
+		})
+	case application.ErrDomainVerificationNotRequested:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Request a verification record first",
+		})
+	case application.ErrDomainNotVerified:
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "Verification record not found, DNS changes may take a while to propagate",
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to verify domain",
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package routes
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
+	"gorm.io/gorm"
+)
+
+func SetupUsageRoutes(router fiber.Router, db *gorm.DB) {
+	// Initialize repositories and services
+	usageRepo := repository.NewUsageRepository(db)
+	usageService := application.NewUsageService(db, usageRepo)
+
+	usage := router.Group("/tenant/usage", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
+
+	// Metered usage of the current tenant.
+	// Query: from, to (RFC3339 or YYYY-MM-DD), granularity (hour|day), metric (comma separated)
+	usage.Get("/", func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+
+		now := time.Now().UTC()
+		to := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+		from := to.AddDate(0, 0, -30)
+
+		if value := c.Query("from"); value != "" {
+			if from, err = parseUsageDate(value); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid from date",
+				})
+			}
+		}
+		if value := c.Query("to"); value != "" {
+			if to, err = parseUsageDate(value); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid to date",
+				})
+			}
+		}
+
+		var metrics []string
+		if value := c.Query("metric"); value != "" {
+			metrics = strings.Split(value, ",")
+		}
+
+		report, err := usageService.GetUsage(tenantID, from, to, c.Query("granularity", domain.GranularityDay), metrics)
+		if err != nil {
+			switch err {
+			case application.ErrInvalidUsageMetric:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   "Invalid metric",
+					"metrics": domain.UsageMetrics,
+				})
+			case application.ErrInvalidGranularity:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Granularity must be hour or day",
+				})
+			case application.ErrInvalidUsageRange:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "The to date must be after the from date",
+				})
+			case application.ErrUsageRangeTooLarge:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Date range too large for this granularity",
+				})
+			default:
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to get usage",
+				})
+			}
+		}
+
+		return c.JSON(report)
+	})
+}
+
+// parseUsageDate accepts a full timestamp or a UTC date
+func parseUsageDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
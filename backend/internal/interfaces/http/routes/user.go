@@ -1,9 +1,17 @@
 package routes
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gofiber/fiber/v3"
 	"github.com/google/uuid"
 	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/domain"
 	"github.com/widia/widia-connect/internal/infrastructure/repository"
 	"github.com/widia/widia-connect/internal/interfaces/http/middleware"
 	"gorm.io/gorm"
@@ -12,17 +20,26 @@ import (
 func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 	// Initialize repositories and services
 	userRepo := repository.NewUserRepository(db)
-	userService := application.NewUserService(db, userRepo)
+	tenantRepo := repository.NewTenantRepository(db)
+	planRepo := repository.NewPlanRepository(db)
+	usageRepo := repository.NewUsageRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	quotaService := application.NewQuotaService(db, tenantRepo, planRepo, userRepo, usageRepo)
+	roleService := application.NewRoleService(db, roleRepo)
+	userService := application.NewUserService(db, userRepo, roleService, quotaService)
+	teamRepo := repository.NewTeamRepository(db)
+	importService := application.NewUserImportService(db, userRepo, tenantRepo, teamRepo, userService, roleService, quotaService)
+	chatwootClients := application.NewChatwootClientFactory(repository.NewTenantIntegrationRepository(db))
+	teamService := application.NewTeamService(db, teamRepo, userRepo, roleService, chatwootClients)
+	offboardingService := application.NewUserOffboardingService(db, userRepo, teamRepo, userService, teamService, chatwootClients)
 	
 	// User management routes (require authentication)
-	users := router.Group("/tenant/users", middleware.AuthMiddleware(db))
-	
-	// Admin-only user management routes
-	adminUsers := users.Group("/")
-	adminUsers.Use(middleware.RequireAdmin())
+	users := router.Group("/tenant/users", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
 	
-	// List all users in tenant
-	users.Get("/", func(c fiber.Ctx) error {
+	// List users in tenant. Query parameters: q (name or email), role
+	// (comma separated), is_active, last_login_after, last_login_before,
+	// never_logged_in, email_bounced, sort (field, "-" prefix for descending), limit and cursor.
+	users.Get("/", middleware.RequirePermission(roleService, domain.PermUsersRead), func(c fiber.Ctx) error {
 		tenantID, err := middleware.GetTenantID(c)
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -30,23 +47,30 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 			})
 		}
 		
-		usersList, err := userService.ListTenantUsers(tenantID)
+		params, err := userListParams(c)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to list users",
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
 			})
 		}
 		
-		// Remove password hashes from response
-		for _, user := range usersList {
-			user.PasswordHash = ""
+		result, err := userService.QueryUsers(tenantID, params)
+		if err != nil {
+			if errors.Is(err, application.ErrInvalidUserQuery) || errors.Is(err, application.ErrInvalidCursor) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to list users",
+			})
 		}
 		
-		return c.JSON(usersList)
+		return c.JSON(result)
 	})
 	
-	// Get user statistics (admin only)
-	adminUsers.Get("/stats", func(c fiber.Ctx) error {
+	// Get user statistics
+	users.Get("/stats", middleware.RequirePermission(roleService, domain.PermUsersRead), func(c fiber.Ctx) error {
 		tenantID, err := middleware.GetTenantID(c)
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -64,8 +88,103 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 		return c.JSON(stats)
 	})
 	
-	// Create new user (admin only)
-	adminUsers.Post("/", func(c fiber.Ctx) error {
+	// Export the tenant users as CSV
+	users.Get("/export", middleware.RequirePermission(roleService, domain.PermUsersRead), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		
+		var buf bytes.Buffer
+		if err := importService.Export(tenantID, &buf); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to export users",
+			})
+		}
+		
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Attachment(fmt.Sprintf("users-%s.csv", time.Now().UTC().Format("2006-01-02")))
+		return c.Send(buf.Bytes())
+	})
+	
+	// Import users from a CSV with the columns name, email, role and team.
+	// Multipart form: file, dry_run, mode (atomic or partial) and send_invitations.
+	users.Post("/import", middleware.RequirePermission(roleService, domain.PermUsersInvite), func(c fiber.Ctx) error {
+		tenantID, err := middleware.GetTenantID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Tenant ID not found",
+			})
+		}
+		
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "CSV file is required",
+			})
+		}
+		
+		opts := application.UserImportOptions{}
+		opts.DryRun, _ = strconv.ParseBool(c.FormValue("dry_run"))
+		opts.SendInvitations, _ = strconv.ParseBool(c.FormValue("send_invitations"))
+		switch c.FormValue("mode", "atomic") {
+		case "atomic":
+			opts.Atomic = true
+		case "partial":
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Mode must be atomic or partial",
+			})
+		}
+		opts.ActorID, _ = middleware.GetUserID(c)
+		opts.ActorRole, _ = middleware.GetUserRole(c)
+		
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to read CSV file",
+			})
+		}
+		defer file.Close()
+		
+		report, err := importService.Import(c.Context(), tenantID, file, opts)
+		if err != nil {
+			var quotaErr *application.QuotaExceededError
+			switch {
+			case errors.As(err, &quotaErr):
+				return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
+					"error":    "User limit reached for current plan",
+					"resource": quotaErr.Resource,
+					"usage":    quotaErr.Usage,
+					"limit":    quotaErr.Limit,
+					"plan":     quotaErr.Plan,
+				})
+			case errors.Is(err, application.ErrInvalidUserCSV):
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			case errors.Is(err, application.ErrUserImportFailed):
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+					"error":  "Import has invalid rows, nothing was created",
+					"report": report,
+				})
+			default:
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to import users",
+				})
+			}
+		}
+		
+		if report.DryRun || report.Created == 0 {
+			return c.JSON(report)
+		}
+		return c.Status(fiber.StatusCreated).JSON(report)
+	})
+	
+	// Create new user
+	users.Post("/", middleware.RequirePermission(roleService, domain.PermUsersInvite), func(c fiber.Ctx) error {
 		tenantID, err := middleware.GetTenantID(c)
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -95,11 +214,28 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 		
 		// Default role if not provided
 		if req.Role == "" {
-			req.Role = "agent"
+			req.Role = domain.RoleAgent
+		}
+		
+		// The new user cannot get permissions the current user does not have
+		actorRole, _ := middleware.GetUserRole(c)
+		if err := roleService.CheckAssign(tenantID, actorRole, req.Role); err != nil {
+			return roleError(c, err)
 		}
 		
 		user, err := userService.CreateUser(tenantID, req.Email, req.Password, req.Name, req.Role)
 		if err != nil {
+			var quotaErr *application.QuotaExceededError
+			if errors.As(err, &quotaErr) {
+				return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
+					"error":    "User limit reached for current plan",
+					"resource": quotaErr.Resource,
+					"usage":    quotaErr.Usage,
+					"limit":    quotaErr.Limit,
+					"plan":     quotaErr.Plan,
+				})
+			}
+			
 			switch err {
 			case application.ErrInvalidEmail:
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -111,16 +247,16 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 				})
 			case application.ErrInvalidRole:
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error": "Invalid role. Must be one of: owner, admin, agent, viewer",
+					"error": "Invalid role. Must be a system role or a custom role of the tenant",
+				})
+			case application.ErrOwnerAssignment:
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "The owner role can only be given through an ownership transfer",
 				})
 			case application.ErrUserEmailExists:
 				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 					"error": "Email already exists for this tenant",
 				})
-			case application.ErrUserLimitReached:
-				return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
-					"error": "User limit reached for current plan",
-				})
 			default:
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"error": err.Error(),
@@ -135,7 +271,7 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 	})
 	
 	// Get user by ID
-	users.Get("/:id", func(c fiber.Ctx) error {
+	users.Get("/:id", middleware.RequirePermission(roleService, domain.PermUsersRead), func(c fiber.Ctx) error {
 		userID, err := uuid.Parse(c.Params("id"))
 		if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -169,8 +305,8 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 		return c.JSON(user)
 	})
 	
-	// Update user (admin only)
-	adminUsers.Patch("/:id", func(c fiber.Ctx) error {
+	// Update user
+	users.Patch("/:id", middleware.RequirePermission(roleService, domain.PermUsersUpdate), func(c fiber.Ctx) error {
 		userID, err := uuid.Parse(c.Params("id"))
 		if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -202,6 +338,17 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 			})
 		}
 		
+		// Changing a role requires the permissions of both the current and the new role
+		if role, ok := updates["role"].(string); ok && role != existingUser.Role {
+			actorRole, _ := middleware.GetUserRole(c)
+			if err := roleService.CheckAssign(tenantID, actorRole, existingUser.Role); err != nil {
+				return roleError(c, err)
+			}
+			if err := roleService.CheckAssign(tenantID, actorRole, role); err != nil {
+				return roleError(c, err)
+			}
+		}
+		
 		updatedUser, err := userService.UpdateUser(userID, updates)
 		if err != nil {
 			switch err {
@@ -213,10 +360,18 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 					"error": "Invalid email format",
 				})
+			case application.ErrInvalidLocale:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Unsupported locale",
+				})
 			case application.ErrInvalidRole:
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 					"error": "Invalid role",
 				})
+			case application.ErrOwnerAssignment:
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "The owner role can only be given through an ownership transfer",
+				})
 			case application.ErrUserEmailExists:
 				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 					"error": "Email already exists",
@@ -225,6 +380,10 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 					"error": "Cannot change role or deactivate the last admin",
 				})
+			case application.ErrOwnerProtected:
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "The tenant owner cannot be demoted or deactivated",
+				})
 			default:
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"error": err.Error(),
@@ -238,8 +397,8 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 		return c.JSON(updatedUser)
 	})
 	
-	// Delete user (admin only)
-	adminUsers.Delete("/:id", func(c fiber.Ctx) error {
+	// Delete user
+	users.Delete("/:id", middleware.RequirePermission(roleService, domain.PermUsersDelete), func(c fiber.Ctx) error {
 		userID, err := uuid.Parse(c.Params("id"))
 		if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -273,6 +432,10 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 					"error": "Cannot delete the last admin",
 				})
+			case application.ErrOwnerProtected:
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "The tenant owner cannot be deleted",
+				})
 			default:
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"error": err.Error(),
@@ -285,8 +448,71 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 		})
 	})
 	
-	// Reset user password (admin only)
-	adminUsers.Post("/:id/reset-password", func(c fiber.Ctx) error {
+	// Offboard a user: deactivate, revoke sessions and API keys and move their
+	// open work to another user, a team or the routing queue. dry_run previews it.
+	users.Post("/:id/offboard", middleware.RequirePermission(roleService, domain.PermUsersUpdate, domain.PermConversationsAssign), func(c fiber.Ctx) error {
+		userID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid user ID",
+			})
+		}
+		
+		currentUserID, _ := middleware.GetUserID(c)
+		currentRole, _ := middleware.GetUserRole(c)
+		tenantID, _ := middleware.GetTenantID(c)
+		
+		var input application.UserOffboardingInput
+		if err := c.Bind().JSON(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+		
+		summary, err := offboardingService.Offboard(tenantID, currentUserID, currentRole, userID, input, auditContext(c))
+		if err != nil {
+			if errors.Is(err, application.ErrInvalidReassignment) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			switch err {
+			case application.ErrUserNotFound:
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "User not found",
+				})
+			case application.ErrTeamNotFound:
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Team not found",
+				})
+			case application.ErrAssignmentForbidden:
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Not allowed to reassign the work of these users",
+				})
+			case application.ErrCannotDeleteSelf:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Cannot offboard your own account",
+				})
+			case application.ErrLastAdmin:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Cannot offboard the last admin",
+				})
+			case application.ErrOwnerProtected:
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "The tenant owner cannot be offboarded",
+				})
+			default:
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+		}
+		
+		return c.JSON(summary)
+	})
+	
+	// Reset user password
+	users.Post("/:id/reset-password", middleware.RequirePermission(roleService, domain.PermUsersUpdate), func(c fiber.Ctx) error {
 		userID, err := uuid.Parse(c.Params("id"))
 		if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -343,7 +569,7 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 	})
 	
 	// Profile routes (require authentication)
-	profile := router.Group("/profile", middleware.AuthMiddleware(db))
+	profile := router.Group("/profile", middleware.AuthMiddleware(db), middleware.SubscriptionGuard(db))
 	
 	// Get current user profile
 	profile.Get("/", func(c fiber.Ctx) error {
@@ -383,10 +609,11 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 			})
 		}
 		
-		// Only allow updating name and email for own profile
+		// Only allow updating name, email and locale for own profile
 		allowedFields := map[string]bool{
-			"name":  true,
-			"email": true,
+			"name":   true,
+			"email":  true,
+			"locale": true,
 		}
 		
 		filteredUpdates := make(map[string]interface{})
@@ -409,6 +636,10 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 					"error": "Invalid email format",
 				})
+			case application.ErrInvalidLocale:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Unsupported locale",
+				})
 			case application.ErrUserEmailExists:
 				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 					"error": "Email already exists",
@@ -474,4 +705,77 @@ func SetupUserRoutes(router fiber.Router, db *gorm.DB) {
 			"message": "Password changed successfully",
 		})
 	})
-}
\ No newline at end of file
+}
+
+
+// userListParams reads the filters of the user list from the query string
+func userListParams(c fiber.Ctx) (application.UserListParams, error) {
+	params := application.UserListParams{
+		Search: c.Query("q"),
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+	}
+	
+	for _, role := range strings.Split(c.Query("role"), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			params.Roles = append(params.Roles, role)
+		}
+	}
+	
+	if value := c.Query("is_active"); value != "" {
+		isActive, err := strconv.ParseBool(value)
+		if err != nil {
+			return params, errors.New("is_active must be true or false")
+		}
+		params.IsActive = &isActive
+	}
+	
+	if value := c.Query("never_logged_in"); value != "" {
+		neverLoggedIn, err := strconv.ParseBool(value)
+		if err != nil {
+			return params, errors.New("never_logged_in must be true or false")
+		}
+		params.NeverLoggedIn = neverLoggedIn
+	}
+	
+	if value := c.Query("email_bounced"); value != "" {
+		emailBounced, err := strconv.ParseBool(value)
+		if err != nil {
+			return params, errors.New("email_bounced must be true or false")
+		}
+		params.EmailBounced = emailBounced
+	}
+	
+	for name, target := range map[string]**time.Time{
+		"last_login_after":  &params.LastLoginAfter,
+		"last_login_before": &params.LastLoginBefore,
+	} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		at, err := parseQueryTime(value)
+		if err != nil {
+			return params, fmt.Errorf("%s must be a date (YYYY-MM-DD) or an RFC 3339 timestamp", name)
+		}
+		*target = &at
+	}
+	
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return params, errors.New("limit must be a positive integer")
+		}
+		params.Limit = limit
+	}
+	
+	return params, nil
+}
+
+// parseQueryTime accepts a date or an RFC 3339 timestamp
+func parseQueryTime(value string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
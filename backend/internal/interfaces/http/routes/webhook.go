@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+	"github.com/widia/widia-connect/internal/application"
+	"github.com/widia/widia-connect/internal/infrastructure/repository"
+	"github.com/widia/widia-connect/pkg/chatwoot"
+	"gorm.io/gorm"
+)
+
+// SetupWebhookRoutes registers the unauthenticated receivers called by external services
+func SetupWebhookRoutes(router fiber.Router, db *gorm.DB) {
+	billingService := newBillingService(db)
+	deliveryService := newEmailDeliveryService(db)
+	chatwootService := application.NewChatwootWebhookService(repository.NewChatwootWebhookRepository(db), repository.NewTenantIntegrationRepository(db))
+
+	webhooks := router.Group("/webhooks")
+
+	// Billing provider events, authenticated by the payload signature
+	webhooks.Post("/billing", func(c fiber.Ctx) error {
+		duplicate, err := billingService.HandleWebhook(c.Body(), c.Get(billingService.SignatureHeader()))
+		if err != nil {
+			if err == application.ErrInvalidWebhookSignature {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid signature",
+				})
+			}
+			log.Printf("Failed to process billing webhook: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to process event",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"received":  true,
+			"duplicate": duplicate,
+		})
+	})
+
+	// Bounces and complaints reported by the email provider, signed like the
+	// billing events with EMAIL_WEBHOOK_SECRET
+	webhooks.Post("/email", func(c fiber.Ctx) error {
+		recorded, err := deliveryService.HandleWebhook(c.Body(), c.Get(deliveryService.SignatureHeader()))
+		if err != nil {
+			switch err {
+			case application.ErrInvalidWebhookSignature:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid signature",
+				})
+			case application.ErrInvalidDeliveryEvent:
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			log.Printf("Failed to process email webhook: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to process events",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"received": true,
+			"recorded": recorded,
+		})
+	})
+
+	// Events of the Chatwoot account of a tenant, signed with the webhook
+	// secret of the tenant or authenticated by the token of the URL. Events are
+	// published in the background so Chatwoot gets its answer at once.
+	webhooks.Post("/chatwoot/:tenant", func(c fiber.Ctx) error {
+		tenantID, err := uuid.Parse(c.Params("tenant"))
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Tenant not found",
+			})
+		}
+
+		duplicate, err := chatwootService.HandleWebhook(tenantID, application.ChatwootWebhookRequest{
+			Body:      c.Body(),
+			Signature: c.Get(chatwoot.SignatureHeader),
+			Timestamp: c.Get(chatwoot.TimestampHeader),
+			Delivery:  c.Get(chatwoot.DeliveryHeader),
+			Token:     c.Query("token"),
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, application.ErrChatwootNotConnected):
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			case errors.Is(err, application.ErrInvalidWebhookSignature):
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid signature",
+				})
+			case errors.Is(err, application.ErrInvalidChatwootEvent):
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			log.Printf("Failed to process chatwoot webhook: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to process event",
+			})
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"received":  true,
+			"duplicate": duplicate,
+		})
+	})
+}
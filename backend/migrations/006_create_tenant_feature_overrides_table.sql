@@ -0,0 +1,35 @@
+-- Create tenant_feature_overrides table for per-tenant feature flag overrides
+CREATE TABLE IF NOT EXISTS tenant_feature_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    feature VARCHAR(100) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    reason TEXT,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(tenant_id, feature)
+);
+
+-- Create indexes
+CREATE INDEX idx_tenant_feature_overrides_tenant_id ON tenant_feature_overrides(tenant_id);
+
+-- Add trigger to update updated_at
+CREATE TRIGGER update_tenant_feature_overrides_updated_at
+    BEFORE UPDATE ON tenant_feature_overrides
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Flags set in the tenant settings ("chat_enabled": true, ...) become
+-- overrides, so tenants keep the features they had
+INSERT INTO tenant_feature_overrides (tenant_id, feature, enabled, reason)
+SELECT t.id, regexp_replace(f.key, '_enabled$', ''), (f.value)::text::boolean, 'Migrated from tenant settings'
+FROM tenants t, jsonb_each(t.settings->'features') AS f
+WHERE jsonb_typeof(t.settings->'features') = 'object'
+  AND jsonb_typeof(f.value) = 'boolean'
+ON CONFLICT (tenant_id, feature) DO NOTHING;
+
+-- Feature flags are now resolved from plan defaults and overrides
+UPDATE tenants SET settings = settings - 'features' WHERE settings ? 'features';
+
+COMMENT ON TABLE tenant_feature_overrides IS 'Super admin overrides of plan-based feature flags';
@@ -0,0 +1,48 @@
+-- Create plans table with usage limits and feature entitlements
+-- A limit of -1 means unlimited
+CREATE TABLE IF NOT EXISTS plans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    price_cents BIGINT DEFAULT 0,
+    currency VARCHAR(3) DEFAULT 'BRL',
+    max_seats INTEGER NOT NULL DEFAULT -1,
+    max_inboxes INTEGER NOT NULL DEFAULT -1,
+    max_monthly_conversations INTEGER NOT NULL DEFAULT -1,
+    max_bot_flows INTEGER NOT NULL DEFAULT -1,
+    max_api_keys INTEGER NOT NULL DEFAULT -1,
+    features JSONB DEFAULT '[]',
+    is_public BOOLEAN DEFAULT true,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Add trigger to update updated_at
+CREATE TRIGGER update_plans_updated_at
+    BEFORE UPDATE ON plans
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Seed default plans
+INSERT INTO plans (code, name, description, price_cents, max_seats, max_inboxes, max_monthly_conversations, max_bot_flows, max_api_keys, features, is_public)
+VALUES
+    ('trial', 'Trial', '14-day evaluation', 0, 5, 2, 500, 2, 1, '["chat"]', false),
+    ('starter', 'Starter', '', 19900, 10, 3, 2000, 5, 2, '["chat"]', true),
+    ('pro', 'Pro', '', 59900, 50, 10, 10000, 20, 10, '["chat", "crm", "calendar"]', true),
+    ('enterprise', 'Enterprise', '', 0, -1, -1, -1, -1, -1, '["chat", "crm", "calendar"]', true)
+ON CONFLICT (code) DO NOTHING;
+
+-- Link tenants to plans
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS plan_id UUID REFERENCES plans(id);
+CREATE INDEX IF NOT EXISTS idx_tenants_plan_id ON tenants(plan_id);
+
+-- Existing tenants keep trial when still in trial, otherwise start on starter
+UPDATE tenants SET plan_id = (SELECT id FROM plans WHERE code = 'trial')
+WHERE plan_id IS NULL AND subscription_status = 'trial';
+
+UPDATE tenants SET plan_id = (SELECT id FROM plans WHERE code = 'starter')
+WHERE plan_id IS NULL;
+
+COMMENT ON TABLE plans IS 'Subscription plans with usage limits and feature entitlements';
+COMMENT ON COLUMN tenants.plan_id IS 'Current subscription plan';
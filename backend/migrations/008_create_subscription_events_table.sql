@@ -0,0 +1,23 @@
+-- Extend subscription lifecycle states
+ALTER TYPE subscription_status ADD VALUE IF NOT EXISTS 'suspended';
+ALTER TYPE subscription_status ADD VALUE IF NOT EXISTS 'expired';
+
+-- Create subscription_events table to record lifecycle transitions and reminders
+CREATE TABLE IF NOT EXISTS subscription_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    from_status VARCHAR(50),
+    to_status VARCHAR(50),
+    reason TEXT,
+    metadata JSONB DEFAULT '{}',
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_subscription_events_tenant_id ON subscription_events(tenant_id);
+CREATE INDEX idx_subscription_events_created_at ON subscription_events(created_at);
+CREATE INDEX idx_tenants_subscription_ends_at ON tenants(subscription_ends_at);
+
+COMMENT ON TABLE subscription_events IS 'Subscription lifecycle history (transitions and expiry reminders)';
@@ -0,0 +1,66 @@
+-- Link plans to billing provider prices
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS provider_price_id VARCHAR(255) UNIQUE;
+
+-- Create billing_accounts table linking tenants to provider customers
+CREATE TABLE IF NOT EXISTS billing_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL UNIQUE REFERENCES tenants(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL,
+    subscription_id VARCHAR(255),
+    price_id VARCHAR(255),
+    status VARCHAR(50),
+    current_period_end TIMESTAMPTZ,
+    cancel_at_period_end BOOLEAN DEFAULT false,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Create invoices table with a local copy of provider invoices
+CREATE TABLE IF NOT EXISTS invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    provider_invoice_id VARCHAR(255) NOT NULL UNIQUE,
+    number VARCHAR(100),
+    status VARCHAR(50),
+    amount_due BIGINT DEFAULT 0,
+    amount_paid BIGINT DEFAULT 0,
+    currency VARCHAR(3),
+    hosted_url TEXT,
+    pdf_url TEXT,
+    period_start TIMESTAMPTZ,
+    period_end TIMESTAMPTZ,
+    issued_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Create billing_webhook_events table to process provider events once
+CREATE TABLE IF NOT EXISTS billing_webhook_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider VARCHAR(50) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    type VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    payload JSONB,
+    processed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_billing_accounts_customer_id ON billing_accounts(customer_id);
+CREATE INDEX idx_billing_accounts_subscription_id ON billing_accounts(subscription_id);
+CREATE INDEX idx_invoices_tenant_id ON invoices(tenant_id);
+CREATE UNIQUE INDEX idx_billing_webhook_events_provider_event ON billing_webhook_events(provider, event_id);
+
+-- Create triggers for updated_at
+CREATE TRIGGER update_billing_accounts_updated_at BEFORE UPDATE ON billing_accounts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_invoices_updated_at BEFORE UPDATE ON invoices
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE billing_accounts IS 'Billing provider customer and subscription of each tenant';
+COMMENT ON TABLE billing_webhook_events IS 'Received billing webhooks, used to process each event once';
@@ -0,0 +1,17 @@
+-- Create usage_rollups table with hourly and daily usage counters per tenant
+CREATE TABLE IF NOT EXISTS usage_rollups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    metric VARCHAR(50) NOT NULL,
+    granularity VARCHAR(10) NOT NULL CHECK (granularity IN ('hour', 'day')),
+    period_start TIMESTAMPTZ NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX idx_usage_rollups_period ON usage_rollups(tenant_id, metric, granularity, period_start);
+CREATE INDEX idx_usage_rollups_granularity_period ON usage_rollups(granularity, period_start);
+
+COMMENT ON TABLE usage_rollups IS 'Metered usage per tenant aggregated by hour and day';
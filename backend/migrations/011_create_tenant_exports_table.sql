@@ -0,0 +1,27 @@
+-- Create tenant_exports table tracking asynchronous tenant data exports
+CREATE TABLE IF NOT EXISTS tenant_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    format_version INTEGER NOT NULL,
+    storage_key VARCHAR(512),
+    size_bytes BIGINT DEFAULT 0,
+    checksum VARCHAR(64),
+    error TEXT,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_tenant_exports_tenant_id ON tenant_exports(tenant_id);
+CREATE INDEX idx_tenant_exports_status ON tenant_exports(status);
+
+-- Create trigger for updated_at
+CREATE TRIGGER update_tenant_exports_updated_at BEFORE UPDATE ON tenant_exports
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE tenant_exports IS 'Tenant data export archives (LGPD/GDPR portability)';
@@ -0,0 +1,13 @@
+-- Add tenant lifecycle status used by offboarding (pending deletion, purged)
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS status VARCHAR(30) NOT NULL DEFAULT 'active';
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMPTZ;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMPTZ;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS deletion_requested_by UUID;
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tenants_status ON tenants(status);
+CREATE INDEX IF NOT EXISTS idx_tenants_deletion_scheduled_at ON tenants(deletion_scheduled_at)
+    WHERE status = 'pending_deletion';
+
+COMMENT ON COLUMN tenants.status IS 'Tenant lifecycle: active, pending_deletion (restorable) or purged';
+COMMENT ON COLUMN tenants.deletion_scheduled_at IS 'When the purge job permanently deletes the tenant data';
@@ -0,0 +1,13 @@
+-- Create tenant_slug_aliases table keeping former slugs of renamed tenants resolvable
+CREATE TABLE IF NOT EXISTS tenant_slug_aliases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    slug VARCHAR(63) NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_tenant_slug_aliases_tenant_id ON tenant_slug_aliases(tenant_id);
+
+COMMENT ON TABLE tenant_slug_aliases IS 'Former tenant slugs redirecting to the current one until they expire';
@@ -0,0 +1,20 @@
+-- Create tenant_onboarding_steps table tracking the onboarding checklist
+CREATE TABLE IF NOT EXISTS tenant_onboarding_steps (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    step VARCHAR(50) NOT NULL,
+    completed_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT idx_tenant_onboarding_step UNIQUE (tenant_id, step)
+);
+
+-- Create onboarding_nudges table recording the reminder emails sent
+CREATE TABLE IF NOT EXISTS onboarding_nudges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    day INTEGER NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT idx_onboarding_nudge UNIQUE (tenant_id, day)
+);
+
+COMMENT ON TABLE tenant_onboarding_steps IS 'Onboarding checklist steps completed by each tenant';
+COMMENT ON TABLE onboarding_nudges IS 'Onboarding reminder emails sent to trial tenants';
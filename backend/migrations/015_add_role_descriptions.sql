@@ -0,0 +1,5 @@
+-- Custom roles: describe the role and reference permissions from the catalog
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS description VARCHAR(255);
+
+COMMENT ON TABLE roles IS 'Tenant defined roles; users reference them by name like the built-in roles';
+COMMENT ON COLUMN roles.permissions IS 'Permission keys from the catalog, e.g. ["users.invite", "crm.deals.delete"]';
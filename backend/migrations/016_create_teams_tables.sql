@@ -0,0 +1,31 @@
+-- Create teams table grouping the agents of a tenant
+CREATE TABLE IF NOT EXISTS teams (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(255),
+    chatwoot_team_id INTEGER,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_teams_tenant_name UNIQUE (tenant_id, name)
+);
+
+-- Create team_members table with the team leads
+CREATE TABLE IF NOT EXISTS team_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    is_lead BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_team_members_team_user UNIQUE (team_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_members_tenant_id ON team_members(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_team_members_user_id ON team_members(user_id);
+
+-- Link users to their Chatwoot agent
+ALTER TABLE users ADD COLUMN IF NOT EXISTS chatwoot_agent_id INTEGER;
+
+COMMENT ON TABLE teams IS 'Agent teams of each tenant, mirrored as Chatwoot teams';
+COMMENT ON TABLE team_members IS 'Team memberships; leads manage the assignments of their team';
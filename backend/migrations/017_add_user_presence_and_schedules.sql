@@ -0,0 +1,23 @@
+-- Presence of users, kept alive by client heartbeats
+ALTER TABLE users ADD COLUMN IF NOT EXISTS presence VARCHAR(20) NOT NULL DEFAULT 'offline';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS max_concurrent_conversations INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_users_tenant_presence ON users(tenant_id, presence);
+
+-- Create user_schedules table with the working hours of each user
+CREATE TABLE IF NOT EXISTS user_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'America/Sao_Paulo',
+    weekly JSONB NOT NULL DEFAULT '{}',
+    holidays JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_schedules_tenant_id ON user_schedules(tenant_id);
+
+COMMENT ON COLUMN users.max_concurrent_conversations IS 'Maximum conversations routed to the user at once, 0 for no limit';
+COMMENT ON TABLE user_schedules IS 'Working hours and holidays of each user, in their time zone';
@@ -0,0 +1,10 @@
+-- Roles are now the built-in owner, admin, agent and viewer roles and the
+-- custom roles of each tenant: users.role becomes a plain string instead of
+-- the user_role enum, which knows neither owner nor custom roles
+ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN role TYPE VARCHAR(50) USING role::text;
+UPDATE users SET role = 'agent' WHERE role IS NULL;
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'agent';
+ALTER TABLE users ALTER COLUMN role SET NOT NULL;
+
+DROP TYPE IF EXISTS user_role;
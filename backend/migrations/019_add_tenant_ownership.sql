@@ -0,0 +1,43 @@
+-- Keep a single owner per tenant: the earliest one stays owner, the others become admins
+UPDATE users SET role = 'admin'
+WHERE role = 'owner' AND deleted_at IS NULL
+  AND id NOT IN (
+    SELECT DISTINCT ON (tenant_id) id
+    FROM users
+    WHERE role = 'owner' AND deleted_at IS NULL
+    ORDER BY tenant_id, created_at, id
+  );
+
+-- Tenants registered before ownership existed: promote the earliest admin, active ones first
+UPDATE users SET role = 'owner'
+WHERE id IN (
+    SELECT DISTINCT ON (u.tenant_id) u.id
+    FROM users u
+    WHERE u.role = 'admin' AND u.deleted_at IS NULL
+      AND NOT EXISTS (
+        SELECT 1 FROM users o
+        WHERE o.tenant_id = u.tenant_id AND o.role = 'owner' AND o.deleted_at IS NULL
+      )
+    ORDER BY u.tenant_id, u.is_active DESC, u.created_at, u.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_owner ON users(tenant_id) WHERE role = 'owner' AND deleted_at IS NULL;
+
+-- Create ownership_transfers table with the transfers awaiting the target confirmation
+CREATE TABLE IF NOT EXISTS ownership_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    from_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ownership_transfers_tenant_id ON ownership_transfers(tenant_id);
+
+COMMENT ON TABLE ownership_transfers IS 'Transfers of the tenant owner role, confirmed by the target with an emailed token';
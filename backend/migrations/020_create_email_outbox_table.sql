@@ -0,0 +1,26 @@
+-- Create email_outbox table with the emails awaiting delivery, written in the
+-- same transaction as the change that triggered them
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID REFERENCES tenants(id) ON DELETE CASCADE,
+    from_address VARCHAR(320) NOT NULL,
+    to_address VARCHAR(320) NOT NULL,
+    reply_to VARCHAR(320),
+    subject VARCHAR(998) NOT NULL,
+    text_body TEXT,
+    html_body TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    sent_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_outbox_tenant_id ON email_outbox(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_due ON email_outbox(next_attempt_at) WHERE status IN ('pending', 'failed');
+CREATE INDEX IF NOT EXISTS idx_email_outbox_status ON email_outbox(status, created_at DESC);
+
+COMMENT ON TABLE email_outbox IS 'Transactional emails delivered by the outbox worker with retries';
+COMMENT ON COLUMN email_outbox.status IS 'pending, sending, sent, failed (retried with backoff) or dead (retried by hand)';
@@ -0,0 +1,4 @@
+-- Locale of the emails sent to each user; NULL uses the tenant locale
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(10);
+
+COMMENT ON COLUMN users.locale IS 'Locale of the emails sent to the user (pt-BR, en or es), NULL for the tenant locale';
@@ -0,0 +1,33 @@
+-- Create tenant_mail_settings table with the SMTP relay and sender address
+-- tenants use to send emails from their own domain
+CREATE TABLE IF NOT EXISTS tenant_mail_settings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL UNIQUE REFERENCES tenants(id) ON DELETE CASCADE,
+    mode VARCHAR(20) NOT NULL DEFAULT 'platform',
+    smtp_host VARCHAR(255),
+    smtp_port INTEGER,
+    smtp_tls_mode VARCHAR(20),
+    smtp_username VARCHAR(255),
+    smtp_password_encrypted TEXT,
+    from_email VARCHAR(320),
+    from_name VARCHAR(100),
+    sender_verified_at TIMESTAMPTZ,
+    sender_verification_token_hash VARCHAR(64),
+    sender_verification_expires_at TIMESTAMPTZ,
+    health_status VARCHAR(20) NOT NULL DEFAULT 'unknown',
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_checked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_mail_settings_token ON tenant_mail_settings(sender_verification_token_hash);
+CREATE INDEX IF NOT EXISTS idx_tenant_mail_settings_mode ON tenant_mail_settings(mode);
+
+-- Enterprise tenants may send emails from their own domain
+UPDATE plans SET features = features || '["custom_mail"]'::jsonb
+WHERE code = 'enterprise' AND NOT features ? 'custom_mail';
+
+COMMENT ON COLUMN tenant_mail_settings.smtp_password_encrypted IS 'AES-GCM sealed with ENCRYPTION_KEY, bound to the tenant ID';
+COMMENT ON COLUMN tenant_mail_settings.health_status IS 'unknown, healthy or failing; only healthy relays are used';
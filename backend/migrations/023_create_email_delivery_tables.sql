@@ -0,0 +1,39 @@
+-- Create email_delivery_events table with the bounces and complaints reported
+-- about sent emails and the sends skipped for suppressed recipients
+CREATE TABLE IF NOT EXISTS email_delivery_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID REFERENCES tenants(id) ON DELETE CASCADE,
+    recipient VARCHAR(320) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    bounce_type VARCHAR(20),
+    source VARCHAR(20) NOT NULL,
+    diagnostic TEXT,
+    email_id UUID,
+    external_id VARCHAR(255),
+    occurred_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_delivery_events_recipient ON email_delivery_events(tenant_id, recipient);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_delivery_events_external ON email_delivery_events(external_id) WHERE external_id <> '';
+
+-- Create email_suppressions table with the addresses each tenant no longer emails
+CREATE TABLE IF NOT EXISTS email_suppressions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    email VARCHAR(320) NOT NULL,
+    reason VARCHAR(20) NOT NULL,
+    detail TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_email_suppressions_tenant_email ON email_suppressions(tenant_id, email);
+
+-- Users whose emails bounce are flagged in the user list
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_bounced_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN email_delivery_events.type IS 'bounce, complaint or suppressed';
+COMMENT ON COLUMN email_delivery_events.source IS 'webhook, dsn (bounce mailbox) or sender (suppressed send)';
+COMMENT ON COLUMN email_delivery_events.email_id IS 'Outbox email the event is about, from the Message-ID of the original email';
+COMMENT ON COLUMN email_suppressions.reason IS 'hard_bounce, complaint or manual';
+COMMENT ON COLUMN users.email_bounced_at IS 'When emails to the user hard bounced or were reported as spam';
@@ -0,0 +1,22 @@
+-- Create tenant_integrations table with the connection of each tenant to
+-- external services, such as its Chatwoot account
+CREATE TABLE IF NOT EXISTS tenant_integrations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    base_url VARCHAR(500) NOT NULL,
+    account_id INTEGER NOT NULL,
+    credentials_encrypted TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'connected',
+    last_error TEXT,
+    last_checked_at TIMESTAMPTZ,
+    connected_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_integrations_provider ON tenant_integrations(tenant_id, provider);
+
+COMMENT ON COLUMN tenant_integrations.account_id IS 'Account of the tenant at the provider, such as the Chatwoot account ID';
+COMMENT ON COLUMN tenant_integrations.credentials_encrypted IS 'AES-GCM sealed with ENCRYPTION_KEY, bound to the tenant ID and the provider';
+COMMENT ON COLUMN tenant_integrations.status IS 'connected or failing, from the last connection check';
@@ -0,0 +1,30 @@
+-- Chatwoot resources created for the tenant by the provisioning saga
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS chatwoot_account_id INTEGER;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS chatwoot_agent_id INTEGER;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS chatwoot_inbox_id INTEGER;
+
+-- Create chatwoot_provisionings table with the saga creating the Chatwoot
+-- account, agent and inbox of new tenants, written with the tenant
+CREATE TABLE IF NOT EXISTS chatwoot_provisionings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    step VARCHAR(30) NOT NULL,
+    account_id INTEGER,
+    agent_id INTEGER,
+    inbox_id INTEGER,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    locked_until TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chatwoot_provisionings_tenant_id ON chatwoot_provisionings(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_chatwoot_provisionings_due ON chatwoot_provisionings(next_attempt_at) WHERE status IN ('pending', 'compensating');
+
+COMMENT ON COLUMN chatwoot_provisionings.status IS 'pending (retried with backoff), completed, compensating (deleting the account after a step kept failing) or failed (retried by hand)';
+COMMENT ON COLUMN chatwoot_provisionings.step IS 'create_account, create_agent, create_inbox or connect: the next step, or the step that failed';
@@ -0,0 +1,24 @@
+-- Secret Chatwoot signs the webhooks of the tenant with, when set
+ALTER TABLE tenant_integrations ADD COLUMN IF NOT EXISTS webhook_secret_encrypted TEXT;
+
+-- Create chatwoot_webhook_deliveries table with the webhooks received from the
+-- Chatwoot accounts of tenants, so a delivery retried by Chatwoot is published once
+CREATE TABLE IF NOT EXISTS chatwoot_webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    delivery_id VARCHAR(255) NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    payload JSONB NOT NULL,
+    failed_handlers INTEGER NOT NULL DEFAULT 0,
+    processed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_chatwoot_webhook_deliveries_delivery ON chatwoot_webhook_deliveries(tenant_id, delivery_id);
+CREATE INDEX IF NOT EXISTS idx_chatwoot_webhook_deliveries_pending ON chatwoot_webhook_deliveries(updated_at) WHERE status = 'pending';
+
+COMMENT ON COLUMN tenant_integrations.webhook_secret_encrypted IS 'AES-GCM sealed with ENCRYPTION_KEY; without it webhooks are authenticated by the token of their URL';
+COMMENT ON COLUMN chatwoot_webhook_deliveries.delivery_id IS 'X-Chatwoot-Delivery header, or the SHA-256 of the body';
+COMMENT ON COLUMN chatwoot_webhook_deliveries.status IS 'pending (published again by the sweep), processed or failed (a handler failed)';
@@ -0,0 +1,12 @@
+-- Platform admins reach the /admin routes across tenants. The flag is only
+-- set here or by the operators, e.g.
+--   UPDATE users SET is_platform_admin = true WHERE id = '<user id>';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_platform_admin BOOLEAN NOT NULL DEFAULT false;
+
+-- Users created with the former super_admin role become platform admins and
+-- tenant admins
+UPDATE users SET is_platform_admin = true, role = 'admin' WHERE role::text = 'super_admin';
+
+CREATE INDEX IF NOT EXISTS idx_users_platform_admin ON users(id) WHERE is_platform_admin;
+
+COMMENT ON COLUMN users.is_platform_admin IS 'Grants the cross-tenant /admin routes; never set through the API';
@@ -0,0 +1,98 @@
+package chatwoot
+
+import "fmt"
+
+// Agent availability states
+const (
+	AvailabilityOnline  = "online"
+	AvailabilityBusy    = "busy"
+	AvailabilityOffline = "offline"
+)
+
+// Agent represents a Chatwoot agent
+type Agent struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	Email              string `json:"email"`
+	Role               string `json:"role"`
+	AvailabilityStatus string `json:"availability_status"`
+}
+
+// ListAgents returns the agents of the account
+func (c *Client) ListAgents() ([]Agent, error) {
+	var agents []Agent
+	if err := c.doJSON("GET", "/agents", nil, &agents); err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
+
+// UpdateAgentAvailability sets the availability of an agent (online, busy or offline)
+func (c *Client) UpdateAgentAvailability(agentID int, availability string) error {
+	payload := map[string]interface{}{
+		"availability": availability,
+	}
+	return c.doJSON("PATCH", fmt.Sprintf("/agents/%d", agentID), payload, nil)
+}
+
+// UnassignConversation removes the agent assigned to a conversation, returning it to the unassigned queue
+func (c *Client) UnassignConversation(conversationID int) error {
+	payload := map[string]interface{}{
+		"assignee_id": nil,
+	}
+	return c.doJSON("POST", fmt.Sprintf("/conversations/%d/assignments", conversationID), payload, nil)
+}
+
+// AgentBot is a bot of the account that answers conversations of the inboxes
+// it is connected to
+type AgentBot struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	OutgoingURL string `json:"outgoing_url"`
+}
+
+// ListAgentBots returns the agent bots of the account
+func (c *Client) ListAgentBots() ([]AgentBot, error) {
+	var bots []AgentBot
+	if err := c.doJSON("GET", "/agent_bots", nil, &bots); err != nil {
+		return nil, err
+	}
+	return bots, nil
+}
+
+// CountOpenConversations returns the number of open conversations assigned
+// to each agent of the account, by agent ID. It pages through the assigned
+// open conversations.
+func (c *Client) CountOpenConversations() (map[int]int, error) {
+	counts := make(map[int]int)
+	seen := 0
+	for page := 1; ; page++ {
+		var response struct {
+			Data struct {
+				Meta struct {
+					AssignedCount int `json:"assigned_count"`
+				} `json:"meta"`
+				Payload []struct {
+					Meta struct {
+						Assignee *Agent `json:"assignee"`
+					} `json:"meta"`
+				} `json:"payload"`
+			} `json:"data"`
+		}
+		path := fmt.Sprintf("/conversations?status=open&assignee_type=assigned&page=%d", page)
+		if err := c.doJSON("GET", path, nil, &response); err != nil {
+			return nil, err
+		}
+
+		for _, conversation := range response.Data.Payload {
+			if conversation.Meta.Assignee != nil {
+				counts[conversation.Meta.Assignee.ID]++
+			}
+		}
+		seen += len(response.Data.Payload)
+		if len(response.Data.Payload) == 0 || seen >= response.Data.Meta.AssignedCount {
+			return counts, nil
+		}
+	}
+}
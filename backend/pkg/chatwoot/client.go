@@ -6,27 +6,35 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
-// Client represents a Chatwoot API client
+// Client represents a Chatwoot API client acting on one account
 type Client struct {
 	BaseURL    string
+	AccountID  int
 	APIKey     string
 	HTTPClient *http.Client
 }
 
-// NewClient creates a new Chatwoot client
-func NewClient(baseURL, apiKey string) *Client {
+// NewClient creates a new Chatwoot client for an account
+func NewClient(baseURL string, accountID int, apiKey string) *Client {
 	return &Client{
-		BaseURL: baseURL,
-		APIKey:  apiKey,
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		AccountID: accountID,
+		APIKey:    apiKey,
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 	}
 }
 
+// accountURL returns the URL of an endpoint of the account
+func (c *Client) accountURL(path string) string {
+	return fmt.Sprintf("%s/api/v1/accounts/%d%s", c.BaseURL, c.AccountID, path)
+}
+
 // Inbox represents a Chatwoot inbox
 type Inbox struct {
 	ID              int    `json:"id"`
@@ -71,7 +79,7 @@ type Conversation struct {
 
 // CreateInbox creates a new inbox for a tenant
 func (c *Client) CreateInbox(req CreateInboxRequest) (*Inbox, error) {
-	url := fmt.Sprintf("%s/api/v1/accounts/1/inboxes", c.BaseURL)
+	url := c.accountURL("/inboxes")
 	
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -105,9 +113,20 @@ func (c *Client) CreateInbox(req CreateInboxRequest) (*Inbox, error) {
 	return &inbox, nil
 }
 
+// ListInboxes returns the inboxes of the account
+func (c *Client) ListInboxes() ([]Inbox, error) {
+	var response struct {
+		Payload []Inbox `json:"payload"`
+	}
+	if err := c.doJSON("GET", "/inboxes", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Payload, nil
+}
+
 // SendMessage sends a message to a conversation
 func (c *Client) SendMessage(conversationID int, content string, private bool) (*Message, error) {
-	url := fmt.Sprintf("%s/api/v1/accounts/1/conversations/%d/messages", c.BaseURL, conversationID)
+	url := c.accountURL(fmt.Sprintf("/conversations/%d/messages", conversationID))
 	
 	payload := map[string]interface{}{
 		"content": content,
@@ -149,7 +168,7 @@ func (c *Client) SendMessage(conversationID int, content string, private bool) (
 
 // GetConversation retrieves a conversation by ID
 func (c *Client) GetConversation(conversationID int) (*Conversation, error) {
-	url := fmt.Sprintf("%s/api/v1/accounts/1/conversations/%d", c.BaseURL, conversationID)
+	url := c.accountURL(fmt.Sprintf("/conversations/%d", conversationID))
 	
 	httpReq, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -179,7 +198,7 @@ func (c *Client) GetConversation(conversationID int) (*Conversation, error) {
 
 // AssignAgent assigns an agent to a conversation
 func (c *Client) AssignAgent(conversationID int, agentID int) error {
-	url := fmt.Sprintf("%s/api/v1/accounts/1/conversations/%d/assignments", c.BaseURL, conversationID)
+	url := c.accountURL(fmt.Sprintf("/conversations/%d/assignments", conversationID))
 	
 	payload := map[string]interface{}{
 		"assignee_id": agentID,
@@ -214,7 +233,7 @@ func (c *Client) AssignAgent(conversationID int, agentID int) error {
 
 // UpdateConversationStatus updates the status of a conversation
 func (c *Client) UpdateConversationStatus(conversationID int, status string) error {
-	url := fmt.Sprintf("%s/api/v1/accounts/1/conversations/%d", c.BaseURL, conversationID)
+	url := c.accountURL(fmt.Sprintf("/conversations/%d", conversationID))
 	
 	payload := map[string]interface{}{
 		"status": status, // "open", "resolved", "pending"
@@ -2,7 +2,10 @@ package chatwoot
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 )
 
@@ -21,9 +24,13 @@ func TestChatwootIntegration(t *testing.T) {
 	if baseURL == "" || apiKey == "" {
 		t.Skip("CHATWOOT_BASE_URL and CHATWOOT_API_KEY must be set")
 	}
+	accountID, err := strconv.Atoi(os.Getenv("CHATWOOT_ACCOUNT_ID"))
+	if err != nil {
+		accountID = 1
+	}
 
 	// Create client
-	client := NewClient(baseURL, apiKey)
+	client := NewClient(baseURL, accountID, apiKey)
 
 	t.Run("CreateInbox", func(t *testing.T) {
 		req := CreateInboxRequest{
@@ -68,7 +75,7 @@ func TestChatwootIntegration(t *testing.T) {
 // Example usage function for documentation
 func ExampleClient_CreateInbox() {
 	// Initialize client
-	client := NewClient("http://localhost:3001", "your-api-key")
+	client := NewClient("http://localhost:3001", 1, "your-api-key")
 
 	// Create an inbox
 	req := CreateInboxRequest{
@@ -91,7 +98,7 @@ func ExampleClient_CreateInbox() {
 // Example of sending a message
 func ExampleClient_SendMessage() {
 	// Initialize client
-	client := NewClient("http://localhost:3001", "your-api-key")
+	client := NewClient("http://localhost:3001", 1, "your-api-key")
 
 	// Send a message to conversation ID 123
 	message, err := client.SendMessage(123, "Hello, how can I help you?", false)
@@ -101,4 +108,82 @@ func ExampleClient_SendMessage() {
 	}
 
 	fmt.Printf("Sent message with ID: %d\n", message.ID)
-}
\ No newline at end of file
+}
+func TestClientUsesAccountID(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("api_access_token") != "token" {
+			t.Errorf("missing access token on %s", r.URL.Path)
+		}
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", 42, "token")
+	if _, err := client.ListAgents(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.UpdateConversationStatus(7, "resolved"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"/api/v1/accounts/42/agents", "/api/v1/accounts/42/conversations/7"}
+	if len(paths) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], paths[i])
+		}
+	}
+}
+
+func TestCountOpenConversations(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "1" {
+			w.Write([]byte(`{"data": {"meta": {"assigned_count": 3}, "payload": [
+				{"id": 1, "meta": {"assignee": {"id": 5}}},
+				{"id": 2, "meta": {"assignee": {"id": 6}}}
+			]}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"meta": {"assigned_count": 3}, "payload": [
+			{"id": 3, "meta": {"assignee": {"id": 5}}}
+		]}}`))
+	}))
+	defer server.Close()
+
+	counts, err := NewClient(server.URL, 42, "token").CountOpenConversations()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(queries) != 2 || counts[5] != 2 || counts[6] != 1 {
+		t.Errorf("unexpected counts %v after %v", counts, queries)
+	}
+}
+
+func TestPlatformClientDeleteAccountNotFound(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("api_access_token") != "platform-token" {
+			t.Errorf("missing platform token on %s", r.URL.Path)
+		}
+		path = r.URL.Path
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	platform := NewPlatformClient(server.URL, "platform-token")
+	err := platform.DeleteAccount(42)
+	if path != "/platform/api/v1/accounts/42" {
+		t.Errorf("expected /platform/api/v1/accounts/42, got %s", path)
+	}
+	if !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
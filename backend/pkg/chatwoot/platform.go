@@ -0,0 +1,116 @@
+package chatwoot
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Account roles of a user
+const (
+	RoleAgent         = "agent"
+	RoleAdministrator = "administrator"
+)
+
+// PlatformClient calls the Platform API of a Chatwoot installation with the
+// token of a platform app, to manage the accounts and users it created
+type PlatformClient struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewPlatformClient creates a new Chatwoot Platform API client
+func NewPlatformClient(baseURL, token string) *PlatformClient {
+	return &PlatformClient{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// PlatformAccount is an account created by the platform app
+type PlatformAccount struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// PlatformUser is a user created by the platform app. AccessToken lets the
+// user call the account API.
+type PlatformUser struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	AccessToken string `json:"access_token"`
+}
+
+// CreatePlatformUserRequest represents the request to create a user
+type CreatePlatformUserRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// CreateAccount creates an account
+func (p *PlatformClient) CreateAccount(name string) (*PlatformAccount, error) {
+	var account PlatformAccount
+	payload := map[string]interface{}{
+		"name": name,
+	}
+	if err := p.do("POST", "/accounts", payload, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// DeleteAccount deletes an account with its inboxes and conversations
+func (p *PlatformClient) DeleteAccount(accountID int) error {
+	return p.do("DELETE", fmt.Sprintf("/accounts/%d", accountID), nil, nil)
+}
+
+// CreateUser creates a user. Chatwoot returns the existing user when the
+// email is already taken, so the call can be repeated safely.
+func (p *PlatformClient) CreateUser(req CreatePlatformUserRequest) (*PlatformUser, error) {
+	var user PlatformUser
+	if err := p.do("POST", "/users", req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUser returns a user with its access token
+func (p *PlatformClient) GetUser(userID int) (*PlatformUser, error) {
+	var user PlatformUser
+	if err := p.do("GET", fmt.Sprintf("/users/%d", userID), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// AddAccountUser adds a user to an account with a role, updating the role
+// when the user already belongs to the account
+func (p *PlatformClient) AddAccountUser(accountID, userID int, role string) error {
+	payload := map[string]interface{}{
+		"user_id": userID,
+		"role":    role,
+	}
+	return p.do("POST", fmt.Sprintf("/accounts/%d/account_users", accountID), payload, nil)
+}
+
+// RemoveAccountUser removes a user from an account
+func (p *PlatformClient) RemoveAccountUser(accountID, userID int) error {
+	payload := map[string]interface{}{
+		"user_id": userID,
+	}
+	return p.do("DELETE", fmt.Sprintf("/accounts/%d/account_users", accountID), payload, nil)
+}
+
+// do sends a request to a Platform API endpoint, authenticated like the
+// account API
+func (p *PlatformClient) do(method, path string, payload, out interface{}) error {
+	client := &Client{BaseURL: p.BaseURL, APIKey: p.Token, HTTPClient: p.HTTPClient}
+	return client.do(method, p.BaseURL+"/platform/api/v1"+path, payload, out)
+}
@@ -0,0 +1,36 @@
+package chatwoot
+
+// Profile is the Chatwoot user owning the access token of the client
+type Profile struct {
+	ID       int              `json:"id"`
+	Name     string           `json:"name"`
+	Email    string           `json:"email"`
+	Accounts []ProfileAccount `json:"accounts"`
+}
+
+// ProfileAccount is an account the user belongs to
+type ProfileAccount struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// GetProfile returns the user owning the access token, to check the token
+func (c *Client) GetProfile() (*Profile, error) {
+	var profile Profile
+	if err := c.do("GET", c.BaseURL+"/api/v1/profile", nil, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Account returns the account of the client among the accounts of the user,
+// nil when the user cannot access it
+func (p *Profile) Account(accountID int) *ProfileAccount {
+	for i := range p.Accounts {
+		if p.Accounts[i].ID == accountID {
+			return &p.Accounts[i]
+		}
+	}
+	return nil
+}
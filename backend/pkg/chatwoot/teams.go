@@ -0,0 +1,138 @@
+package chatwoot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Team represents a Chatwoot team
+type Team struct {
+	ID              int    `json:"id"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	AllowAutoAssign bool   `json:"allow_auto_assign"`
+}
+
+// TeamRequest represents the request to create or update a team
+type TeamRequest struct {
+	Name            string `json:"name"`
+	Description     string `json:"description,omitempty"`
+	AllowAutoAssign bool   `json:"allow_auto_assign"`
+}
+
+// ListTeams returns the teams of the account
+func (c *Client) ListTeams() ([]Team, error) {
+	var teams []Team
+	if err := c.doJSON("GET", "/teams", nil, &teams); err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+// CreateTeam creates a team
+func (c *Client) CreateTeam(req TeamRequest) (*Team, error) {
+	var team Team
+	if err := c.doJSON("POST", "/teams", req, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// UpdateTeam updates the name and settings of a team
+func (c *Client) UpdateTeam(teamID int, req TeamRequest) (*Team, error) {
+	var team Team
+	if err := c.doJSON("PATCH", fmt.Sprintf("/teams/%d", teamID), req, &team); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// DeleteTeam deletes a team
+func (c *Client) DeleteTeam(teamID int) error {
+	return c.doJSON("DELETE", fmt.Sprintf("/teams/%d", teamID), nil, nil)
+}
+
+// ListTeamMembers returns the agents of a team
+func (c *Client) ListTeamMembers(teamID int) ([]Agent, error) {
+	var agents []Agent
+	if err := c.doJSON("GET", fmt.Sprintf("/teams/%d/team_members", teamID), nil, &agents); err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
+
+// SetTeamMembers replaces the agents of a team
+func (c *Client) SetTeamMembers(teamID int, agentIDs []int) error {
+	payload := map[string]interface{}{
+		"user_ids": agentIDs,
+	}
+	return c.doJSON("PATCH", fmt.Sprintf("/teams/%d/team_members", teamID), payload, nil)
+}
+
+// APIError is returned for a response with an error status
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// IsNotFound reports whether the error is a 404 response, such as for a
+// resource that was already deleted
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// doJSON sends a request to an account endpoint and decodes the JSON response into out
+func (c *Client) doJSON(method, path string, payload, out interface{}) error {
+	return c.do(method, c.accountURL(path), payload, out)
+}
+
+// do sends a request to a URL of the API and decodes the JSON response into out
+func (c *Client) do(method, url string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewBuffer(data)
+	}
+
+	httpReq, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if payload != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	httpReq.Header.Set("api_access_token", c.APIKey)
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
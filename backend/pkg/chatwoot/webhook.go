@@ -0,0 +1,249 @@
+package chatwoot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook events decoded by ParseWebhookEvent
+const (
+	WebhookMessageCreated            = "message_created"
+	WebhookConversationCreated       = "conversation_created"
+	WebhookConversationStatusChanged = "conversation_status_changed"
+	WebhookConversationUpdated       = "conversation_updated"
+	WebhookContactCreated            = "contact_created"
+)
+
+// Headers of the webhook requests signed by Chatwoot
+const (
+	SignatureHeader = "X-Chatwoot-Signature"
+	TimestampHeader = "X-Chatwoot-Timestamp"
+	DeliveryHeader  = "X-Chatwoot-Delivery"
+)
+
+var (
+	ErrUnsupportedWebhookEvent = errors.New("unsupported chatwoot webhook event")
+	ErrInvalidWebhookSignature = errors.New("invalid chatwoot webhook signature")
+)
+
+// Timestamp is a time sent either as unix seconds or as an RFC 3339 string,
+// as Chatwoot uses both depending on the payload
+type Timestamp struct {
+	time.Time
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		return nil
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		t.Time = time.Unix(int64(seconds), 0).UTC()
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", value)
+	}
+	t.Time = parsed
+	return nil
+}
+
+// WebhookAccount is the account an event belongs to
+type WebhookAccount struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// WebhookInbox is the inbox of a message
+type WebhookInbox struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// WebhookContact is a contact of the account
+type WebhookContact struct {
+	ID                   int                    `json:"id"`
+	Name                 string                 `json:"name"`
+	Email                string                 `json:"email"`
+	PhoneNumber          string                 `json:"phone_number"`
+	Identifier           string                 `json:"identifier"`
+	AdditionalAttributes map[string]interface{} `json:"additional_attributes"`
+	CustomAttributes     map[string]interface{} `json:"custom_attributes"`
+}
+
+// WebhookSender is the author of a message or the assignee of a
+// conversation; Type is "contact", "user" for agents or "agent_bot"
+type WebhookSender struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Type  string `json:"type"`
+}
+
+// WebhookConversation is a conversation as sent in webhook payloads
+type WebhookConversation struct {
+	ID        int      `json:"id"`
+	AccountID int      `json:"account_id"`
+	InboxID   int      `json:"inbox_id"`
+	Status    string   `json:"status"`
+	Channel   string   `json:"channel"`
+	Labels    []string `json:"labels"`
+	Meta      struct {
+		Sender   *WebhookContact `json:"sender"`
+		Assignee *WebhookSender  `json:"assignee"`
+	} `json:"meta"`
+	AdditionalAttributes map[string]interface{} `json:"additional_attributes"`
+	CustomAttributes     map[string]interface{} `json:"custom_attributes"`
+	CreatedAt            Timestamp              `json:"created_at"`
+}
+
+// WebhookMessage is a message as sent in webhook payloads
+type WebhookMessage struct {
+	ID      int    `json:"id"`
+	Content string `json:"content"`
+	// MessageType is incoming, outgoing, activity or template
+	MessageType  string               `json:"message_type"`
+	ContentType  string               `json:"content_type"`
+	Private      bool                 `json:"private"`
+	SourceID     string               `json:"source_id"`
+	Sender       *WebhookSender       `json:"sender"`
+	Inbox        *WebhookInbox        `json:"inbox"`
+	Conversation *WebhookConversation `json:"conversation"`
+	CreatedAt    Timestamp            `json:"created_at"`
+}
+
+// Incoming reports whether the message was written by the contact
+func (m *WebhookMessage) Incoming() bool {
+	return m.MessageType == "incoming"
+}
+
+// Outgoing reports whether the message was sent to the contact, by an agent,
+// a bot or as a template; private notes are not sent
+func (m *WebhookMessage) Outgoing() bool {
+	return (m.MessageType == "outgoing" || m.MessageType == "template") && !m.Private
+}
+
+// SentByBot reports whether the message was written by an agent bot
+func (m *WebhookMessage) SentByBot() bool {
+	return m.Sender != nil && m.Sender.Type == "agent_bot"
+}
+
+// AttributeChange is the previous and current value of a changed attribute
+type AttributeChange struct {
+	PreviousValue interface{} `json:"previous_value"`
+	CurrentValue  interface{} `json:"current_value"`
+}
+
+// WebhookEvent is a decoded webhook. Exactly one of Message, Conversation
+// and Contact is set, depending on the event.
+type WebhookEvent struct {
+	Event     string `json:"event"`
+	AccountID int    `json:"account_id"`
+
+	Message      *WebhookMessage      `json:"message,omitempty"`
+	Conversation *WebhookConversation `json:"conversation,omitempty"`
+	Contact      *WebhookContact      `json:"contact,omitempty"`
+	// Changes are the attributes changed by conversation_updated and
+	// conversation_status_changed, by name
+	Changes map[string]AttributeChange `json:"changes,omitempty"`
+}
+
+// ParseWebhookEvent decodes the body of a webhook. Events other than the
+// supported ones return ErrUnsupportedWebhookEvent.
+func ParseWebhookEvent(body []byte) (*WebhookEvent, error) {
+	var envelope struct {
+		Event     string          `json:"event"`
+		Account   *WebhookAccount `json:"account"`
+		AccountID int             `json:"account_id"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook: %w", err)
+	}
+
+	event := &WebhookEvent{Event: envelope.Event, AccountID: envelope.AccountID}
+	if envelope.Account != nil {
+		event.AccountID = envelope.Account.ID
+	}
+
+	switch envelope.Event {
+	case WebhookMessageCreated:
+		var message WebhookMessage
+		if err := json.Unmarshal(body, &message); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", envelope.Event, err)
+		}
+		event.Message = &message
+
+	case WebhookConversationCreated, WebhookConversationStatusChanged, WebhookConversationUpdated:
+		var conversation struct {
+			WebhookConversation
+			ChangedAttributes []map[string]AttributeChange `json:"changed_attributes"`
+		}
+		if err := json.Unmarshal(body, &conversation); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", envelope.Event, err)
+		}
+		event.Conversation = &conversation.WebhookConversation
+		if event.AccountID == 0 {
+			event.AccountID = conversation.AccountID
+		}
+		for _, changed := range conversation.ChangedAttributes {
+			for name, change := range changed {
+				if event.Changes == nil {
+					event.Changes = make(map[string]AttributeChange)
+				}
+				event.Changes[name] = change
+			}
+		}
+
+	case WebhookContactCreated:
+		var contact WebhookContact
+		if err := json.Unmarshal(body, &contact); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", envelope.Event, err)
+		}
+		event.Contact = &contact
+
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedWebhookEvent, envelope.Event)
+	}
+
+	return event, nil
+}
+
+// SignWebhook returns the signature of a webhook body sent at timestamp, an
+// HMAC-SHA256 of "<timestamp>.<body>" with the webhook secret
+func SignWebhook(body []byte, timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks the signature of a webhook and rejects
+// timestamps further than tolerance from now, so captured requests cannot be
+// replayed
+func VerifyWebhookSignature(body []byte, signature, timestamp, secret string, tolerance time.Duration, now time.Time) error {
+	if secret == "" || signature == "" || timestamp == "" {
+		return ErrInvalidWebhookSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidWebhookSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(SignWebhook(body, timestamp, secret))) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package chatwoot
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseWebhookEvent(t *testing.T) {
+	message, err := ParseWebhookEvent([]byte(`{
+		"event": "message_created",
+		"id": 12,
+		"content": "Hello",
+		"message_type": "incoming",
+		"created_at": "2024-05-02T10:00:00.000Z",
+		"account": {"id": 7, "name": "Acme"},
+		"sender": {"id": 3, "name": "Ana", "type": "contact"},
+		"conversation": {"id": 5, "inbox_id": 2, "status": "open", "created_at": 1714644000}
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if message.AccountID != 7 || message.Message == nil || !message.Message.Incoming() {
+		t.Fatalf("unexpected event %+v", message)
+	}
+	if message.Message.Conversation.ID != 5 || message.Message.Conversation.CreatedAt.Unix() != 1714644000 {
+		t.Errorf("unexpected conversation %+v", message.Message.Conversation)
+	}
+	if message.Message.CreatedAt.IsZero() {
+		t.Error("expected the message time to be decoded")
+	}
+	if message.Message.Outgoing() || message.Message.SentByBot() {
+		t.Error("expected an incoming message from the contact")
+	}
+
+	status, err := ParseWebhookEvent([]byte(`{
+		"event": "conversation_status_changed",
+		"id": 5,
+		"account_id": 7,
+		"status": "resolved",
+		"meta": {"sender": {"id": 3, "email": "ana@acme.test"}},
+		"changed_attributes": [{"status": {"previous_value": "open", "current_value": "resolved"}}]
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.AccountID != 7 || status.Conversation == nil || status.Conversation.Meta.Sender.Email != "ana@acme.test" {
+		t.Fatalf("unexpected event %+v", status)
+	}
+	if change := status.Changes["status"]; change.PreviousValue != "open" || change.CurrentValue != "resolved" {
+		t.Errorf("unexpected changes %+v", status.Changes)
+	}
+
+	if _, err := ParseWebhookEvent([]byte(`{"event": "webwidget_triggered"}`)); !errors.Is(err, ErrUnsupportedWebhookEvent) {
+		t.Errorf("expected ErrUnsupportedWebhookEvent, got %v", err)
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"contact_created"}`)
+	now := time.Unix(1714644000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := SignWebhook(body, timestamp, "secret")
+
+	if err := VerifyWebhookSignature(body, signature, timestamp, "secret", 5*time.Minute, now); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+	if err := VerifyWebhookSignature([]byte(`{"event":"other"}`), signature, timestamp, "secret", 5*time.Minute, now); err == nil {
+		t.Error("expected a tampered body to be rejected")
+	}
+	if err := VerifyWebhookSignature(body, signature, timestamp, "other", 5*time.Minute, now); err == nil {
+		t.Error("expected another secret to be rejected")
+	}
+	if err := VerifyWebhookSignature(body, signature, timestamp, "secret", 5*time.Minute, now.Add(time.Hour)); err == nil {
+		t.Error("expected a replayed request to be rejected")
+	}
+}
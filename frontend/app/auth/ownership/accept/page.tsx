@@ -0,0 +1,99 @@
+'use client'
+
+import { useState } from 'react'
+import { useSearchParams } from 'next/navigation'
+import Link from 'next/link'
+import { useMutation } from '@tanstack/react-query'
+import { Crown, CheckCircle, XCircle } from 'lucide-react'
+import { tenantService } from '@/lib/api/services/tenant.service'
+import { useAuthStore } from '@/lib/stores/auth-store'
+import { Button } from '@/components/ui/button'
+import { useToast } from '@/hooks/use-toast'
+
+export default function AcceptOwnershipPage() {
+  const searchParams = useSearchParams()
+  const { toast } = useToast()
+  const token = searchParams.get('token') || ''
+  const isAuthenticated = useAuthStore((state) => state.isAuthenticated)
+  const logout = useAuthStore((state) => state.logout)
+  const [accepted, setAccepted] = useState(false)
+
+  const acceptMutation = useMutation({
+    mutationFn: () => tenantService.acceptOwnership(token),
+    onSuccess: () => {
+      // The session still carries the previous role, so the new owner signs in again
+      logout()
+      setAccepted(true)
+    },
+    onError: (error: any) => {
+      toast({
+        title: 'Erro ao aceitar a propriedade',
+        description: error.response?.data?.error || 'Não foi possível aceitar a transferência.',
+        variant: 'destructive',
+      })
+    },
+  })
+
+  const renderMessage = (icon: React.ReactNode, title: string, description: string, action: React.ReactNode) => (
+    <div className="min-h-screen flex items-center justify-center bg-gray-50 py-12 px-4 sm:px-6 lg:px-8">
+      <div className="max-w-md w-full space-y-8">
+        <div className="text-center">
+          {icon}
+          <h2 className="mt-6 text-3xl font-bold text-gray-900">{title}</h2>
+          <p className="mt-2 text-sm text-gray-600">{description}</p>
+          <div className="mt-4">{action}</div>
+        </div>
+      </div>
+    </div>
+  )
+
+  if (!token) {
+    return renderMessage(
+      <div className="mx-auto h-12 w-12 rounded-full bg-red-100 flex items-center justify-center">
+        <XCircle className="h-6 w-6 text-red-600" />
+      </div>,
+      'Link inválido',
+      'O link de transferência de propriedade é inválido ou está faltando o token.',
+      <Link href="/dashboard">
+        <Button>Ir para o painel</Button>
+      </Link>
+    )
+  }
+
+  if (accepted) {
+    return renderMessage(
+      <div className="mx-auto h-12 w-12 rounded-full bg-green-100 flex items-center justify-center">
+        <CheckCircle className="h-6 w-6 text-green-600" />
+      </div>,
+      'Você é o novo proprietário!',
+      'A propriedade da conta foi transferida para você. Entre novamente para continuar.',
+      <Link href="/auth/login">
+        <Button className="w-full">Ir para o login</Button>
+      </Link>
+    )
+  }
+
+  if (!isAuthenticated) {
+    return renderMessage(
+      <div className="mx-auto h-12 w-12 rounded-full bg-yellow-100 flex items-center justify-center">
+        <Crown className="h-6 w-6 text-yellow-600" />
+      </div>,
+      'Entre para continuar',
+      'Entre com a conta que recebeu o convite e abra este link novamente para aceitar a propriedade.',
+      <Link href="/auth/login">
+        <Button>Entrar</Button>
+      </Link>
+    )
+  }
+
+  return renderMessage(
+    <div className="mx-auto h-12 w-12 rounded-full bg-yellow-100 flex items-center justify-center">
+      <Crown className="h-6 w-6 text-yellow-600" />
+    </div>,
+    'Transferência de propriedade',
+    'Ao aceitar, você passa a ser o proprietário da conta e o proprietário atual passa a ser administrador.',
+    <Button onClick={() => acceptMutation.mutate()} disabled={acceptMutation.isPending}>
+      {acceptMutation.isPending ? 'Aceitando...' : 'Aceitar propriedade'}
+    </Button>
+  )
+}
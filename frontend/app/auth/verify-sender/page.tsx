@@ -0,0 +1,64 @@
+'use client'
+
+import { useEffect } from 'react'
+import { useSearchParams } from 'next/navigation'
+import { useMutation } from '@tanstack/react-query'
+import { Mail, CheckCircle, XCircle } from 'lucide-react'
+import { tenantService } from '@/lib/api/services/tenant.service'
+
+export default function VerifySenderPage() {
+  const searchParams = useSearchParams()
+  const token = searchParams.get('token') || ''
+
+  const verifyMutation = useMutation({
+    mutationFn: () => tenantService.verifySender(token),
+  })
+
+  useEffect(() => {
+    if (token) {
+      verifyMutation.mutate()
+    }
+    // Verify once when the page opens
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [token])
+
+  const renderMessage = (icon: React.ReactNode, title: string, description: string) => (
+    <div className="min-h-screen flex items-center justify-center bg-gray-50 py-12 px-4 sm:px-6 lg:px-8">
+      <div className="max-w-md w-full space-y-8">
+        <div className="text-center">
+          {icon}
+          <h2 className="mt-6 text-3xl font-bold text-gray-900">{title}</h2>
+          <p className="mt-2 text-sm text-gray-600">{description}</p>
+        </div>
+      </div>
+    </div>
+  )
+
+  if (!token || verifyMutation.isError) {
+    return renderMessage(
+      <div className="mx-auto h-12 w-12 rounded-full bg-red-100 flex items-center justify-center">
+        <XCircle className="h-6 w-6 text-red-600" />
+      </div>,
+      'Link inválido',
+      'O link de confirmação é inválido ou expirou. Peça a um administrador para reenviar a confirmação.'
+    )
+  }
+
+  if (verifyMutation.isSuccess) {
+    return renderMessage(
+      <div className="mx-auto h-12 w-12 rounded-full bg-green-100 flex items-center justify-center">
+        <CheckCircle className="h-6 w-6 text-green-600" />
+      </div>,
+      'Endereço confirmado!',
+      `Os emails da conta agora podem ser enviados a partir de ${verifyMutation.data.from_email}.`
+    )
+  }
+
+  return renderMessage(
+    <div className="mx-auto h-12 w-12 rounded-full bg-blue-100 flex items-center justify-center">
+      <Mail className="h-6 w-6 text-blue-600" />
+    </div>,
+    'Confirmando endereço...',
+    'Aguarde enquanto confirmamos o endereço de envio.'
+  )
+}
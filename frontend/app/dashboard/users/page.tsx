@@ -2,7 +2,7 @@
 
 import { useState, useEffect } from 'react'
 import { useSearchParams } from 'next/navigation'
-import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
+import { useQuery, useInfiniteQuery, useMutation, useQueryClient } from '@tanstack/react-query'
 import {
   Plus,
   Search,
@@ -13,6 +13,7 @@ import {
   UserX,
   Key,
   Filter,
+  MailWarning,
 } from 'lucide-react'
 import { userService, type User, type CreateUserRequest } from '@/lib/api/services/user.service'
 import { useAuthStore } from '@/lib/stores/auth-store'
@@ -67,11 +68,29 @@ export default function UsersPage() {
     }
   }, [searchParams])
 
-  // Fetch users
-  const { data: users = [], isLoading } = useQuery({
-    queryKey: ['users'],
-    queryFn: userService.listUsers,
+  // Fetch users, filtered and paginated by the server
+  const {
+    data: usersData,
+    isLoading,
+    fetchNextPage,
+    hasNextPage,
+    isFetchingNextPage,
+  } = useInfiniteQuery({
+    queryKey: ['users', searchTerm, filterRole, filterStatus],
+    queryFn: ({ pageParam }) =>
+      userService.listUsers({
+        q: searchTerm || undefined,
+        role: filterRole === 'all' ? undefined : [filterRole],
+        is_active: filterStatus === 'active' || filterStatus === 'inactive' ? filterStatus === 'active' : undefined,
+        email_bounced: filterStatus === 'bounced' || undefined,
+        sort: 'name',
+        cursor: pageParam,
+      }),
+    initialPageParam: undefined as string | undefined,
+    getNextPageParam: (lastPage) => lastPage.next_cursor,
   })
+  const users = usersData?.pages.flatMap((page) => page.data) ?? []
+  const totalUsers = usersData?.pages[0]?.total ?? 0
 
   // Fetch user stats
   const { data: stats } = useQuery({
@@ -181,19 +200,6 @@ export default function UsersPage() {
     },
   })
 
-  // Filter users
-  const filteredUsers = users.filter((user) => {
-    const matchesSearch =
-      user.name.toLowerCase().includes(searchTerm.toLowerCase()) ||
-      user.email.toLowerCase().includes(searchTerm.toLowerCase())
-    const matchesRole = filterRole === 'all' || user.role === filterRole
-    const matchesStatus =
-      filterStatus === 'all' ||
-      (filterStatus === 'active' && user.is_active) ||
-      (filterStatus === 'inactive' && !user.is_active)
-    return matchesSearch && matchesRole && matchesStatus
-  })
-
   const handleDeleteUser = (userId: string) => {
     if (confirm('Tem certeza que deseja remover este usuário?')) {
       deleteMutation.mutate(userId)
@@ -294,6 +300,7 @@ export default function UsersPage() {
               <option value="all">Todos os status</option>
               <option value="active">Ativo</option>
               <option value="inactive">Inativo</option>
+              <option value="bounced">Email com falha</option>
             </select>
             <Button onClick={() => setShowCreateModal(true)}>
               <Plus className="h-4 w-4 mr-2" />
@@ -315,12 +322,12 @@ export default function UsersPage() {
                     className="rounded border-gray-300"
                     onChange={(e) => {
                       if (e.target.checked) {
-                        setSelectedUsers(new Set(filteredUsers.map((u) => u.id)))
+                        setSelectedUsers(new Set(users.map((u) => u.id)))
                       } else {
                         setSelectedUsers(new Set())
                       }
                     }}
-                    checked={selectedUsers.size === filteredUsers.length && filteredUsers.length > 0}
+                    checked={selectedUsers.size === users.length && users.length > 0}
                   />
                 </th>
                 <th className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">
@@ -347,14 +354,14 @@ export default function UsersPage() {
                     Carregando...
                   </td>
                 </tr>
-              ) : filteredUsers.length === 0 ? (
+              ) : users.length === 0 ? (
                 <tr>
                   <td colSpan={6} className="px-6 py-4 text-center text-gray-500">
                     Nenhum usuário encontrado
                   </td>
                 </tr>
               ) : (
-                filteredUsers.map((user) => (
+                users.map((user) => (
                   <tr key={user.id} className="hover:bg-gray-50">
                     <td className="px-6 py-4">
                       <input
@@ -376,6 +383,15 @@ export default function UsersPage() {
                       <div>
                         <div className="text-sm font-medium text-gray-900">{user.name}</div>
                         <div className="text-sm text-gray-500">{user.email}</div>
+                        {user.email_bounced_at && (
+                          <div
+                            className="mt-1 inline-flex items-center text-xs text-red-700"
+                            title="Os emails para este endereço retornaram ou foram marcados como spam e não são mais enviados"
+                          >
+                            <MailWarning className="h-3 w-3 mr-1" />
+                            Email com falha
+                          </div>
+                        )}
                       </div>
                     </td>
                     <td className="px-6 py-4">{getRoleBadge(user.role)}</td>
@@ -444,6 +460,23 @@ export default function UsersPage() {
             </tbody>
           </table>
         </div>
+        {users.length > 0 && (
+          <div className="flex items-center justify-between px-6 py-3 border-t border-gray-200 text-sm text-gray-500">
+            <span>
+              {users.length} de {totalUsers} usuários
+            </span>
+            {hasNextPage && (
+              <Button
+                variant="outline"
+                size="sm"
+                onClick={() => fetchNextPage()}
+                disabled={isFetchingNextPage}
+              >
+                {isFetchingNextPage ? 'Carregando...' : 'Carregar mais'}
+              </Button>
+            )}
+          </div>
+        )}
       </div>
 
       {/* Create User Modal */}
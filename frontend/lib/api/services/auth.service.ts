@@ -12,6 +12,8 @@ export interface LoginResponse {
   tenant: Tenant
   token: string
   refresh_token: string
+  // Set when the login used a former slug of a renamed tenant
+  redirect_slug?: string
 }
 
 export interface RegisterRequest {
@@ -76,6 +78,14 @@ class AuthService {
     })
   }
   
+  async restoreTenant(tenantSlug: string, email: string, password: string): Promise<void> {
+    await apiClient.post('/auth/restore-tenant', {
+      tenant_slug: tenantSlug,
+      email,
+      password,
+    })
+  }
+  
   async validateToken(): Promise<boolean> {
     try {
       await apiClient.get('/auth/validate')
@@ -0,0 +1,46 @@
+import apiClient from '../client'
+
+export type ExportStatus = 'pending' | 'running' | 'completed' | 'failed' | 'expired'
+
+export interface TenantExport {
+  id: string
+  tenant_id: string
+  requested_by: string | null
+  status: ExportStatus
+  format_version: number
+  size_bytes: number
+  checksum: string
+  error?: string
+  started_at: string | null
+  completed_at: string | null
+  expires_at: string | null
+  created_at: string
+  updated_at: string
+}
+
+export interface ExportDownload {
+  url: string
+  expires_at: string
+}
+
+class ExportService {
+  // Request a new export of all tenant data
+  async requestExport(): Promise<TenantExport> {
+    const response = await apiClient.post<TenantExport>('/tenant/exports')
+    return response.data
+  }
+
+  // List the latest exports
+  async listExports(): Promise<TenantExport[]> {
+    const response = await apiClient.get<TenantExport[]>('/tenant/exports')
+    return response.data
+  }
+
+  // Get a short lived download link for a completed export
+  async getDownloadUrl(id: string): Promise<ExportDownload> {
+    const response = await apiClient.get<ExportDownload>(`/tenant/exports/${id}/download`)
+    return response.data
+  }
+}
+
+export const exportService = new ExportService()
@@ -0,0 +1,34 @@
+import apiClient from '../client'
+
+export type FeatureKey = 'chat' | 'crm' | 'calendar'
+
+export interface ResolvedFeature {
+  key: FeatureKey
+  enabled: boolean
+  source: 'plan' | 'override' | 'rollout'
+}
+
+export interface TenantFeatures {
+  features: Record<FeatureKey, boolean>
+  details: ResolvedFeature[]
+}
+
+class FeatureService {
+  // Get the effective feature flags for the current tenant
+  async getFeatures(): Promise<TenantFeatures> {
+    const response = await apiClient.get<TenantFeatures>('/tenant/features')
+    return response.data
+  }
+
+  // Check a single flag
+  async isEnabled(feature: FeatureKey): Promise<boolean> {
+    try {
+      const { features } = await this.getFeatures()
+      return Boolean(features[feature])
+    } catch {
+      return false
+    }
+  }
+}
+
+export const featureService = new FeatureService()
@@ -8,13 +8,18 @@ export interface Profile {
   role: 'owner' | 'admin' | 'agent' | 'viewer'
   is_active: boolean
   last_login_at?: string
+  // Empty when the user receives emails in the tenant locale
+  locale?: EmailLocale | ''
   created_at: string
   updated_at: string
 }
 
+export type EmailLocale = 'pt-BR' | 'en' | 'es'
+
 export interface UpdateProfileRequest {
   name?: string
   email?: string
+  locale?: EmailLocale | ''
 }
 
 export interface ChangePasswordRequest {
@@ -32,6 +37,42 @@ export interface ResetPasswordRequest {
   new_password: string
 }
 
+export type PresenceStatus = 'online' | 'away' | 'busy' | 'offline'
+
+export interface Presence {
+  user_id: string
+  name: string
+  // Falls back to offline when heartbeats stop
+  presence: PresenceStatus
+  last_seen_at?: string
+  within_schedule: boolean
+  max_concurrent_conversations: number
+}
+
+export interface TimeRange {
+  start: string // HH:MM
+  end: string // HH:MM, exclusive
+}
+
+export type Weekday = 'sunday' | 'monday' | 'tuesday' | 'wednesday' | 'thursday' | 'friday' | 'saturday'
+
+export interface Schedule {
+  time_zone: string
+  weekly: Partial<Record<Weekday, TimeRange[]>>
+  holidays: string[] // YYYY-MM-DD
+  // Without a schedule the user is considered working at any time
+  configured: boolean
+  // 0 means no limit
+  max_concurrent_conversations: number
+}
+
+export interface UpdateScheduleRequest {
+  time_zone?: string
+  weekly?: Partial<Record<Weekday, TimeRange[]>>
+  holidays?: string[]
+  max_concurrent_conversations?: number
+}
+
 export interface ValidateResetTokenResponse {
   valid: boolean
   message?: string
@@ -75,6 +116,32 @@ class ProfileService {
     return response.data
   }
 
+  async getPresence(): Promise<Presence> {
+    const response = await apiClient.get<Presence>('/profile/presence')
+    return response.data
+  }
+
+  async setPresence(presence: PresenceStatus): Promise<Presence> {
+    const response = await apiClient.put<Presence>('/profile/presence', { presence })
+    return response.data
+  }
+
+  // Keeps the presence alive; call about once a minute while the app is open
+  async heartbeat(): Promise<Presence> {
+    const response = await apiClient.post<Presence>('/profile/presence/heartbeat')
+    return response.data
+  }
+
+  async getSchedule(): Promise<Schedule> {
+    const response = await apiClient.get<Schedule>('/profile/schedule')
+    return response.data
+  }
+
+  async updateSchedule(data: UpdateScheduleRequest): Promise<Schedule> {
+    const response = await apiClient.put<Schedule>('/profile/schedule', data)
+    return response.data
+  }
+
   // Update profile name
   async updateName(name: string): Promise<Profile> {
     return this.updateProfile({ name })
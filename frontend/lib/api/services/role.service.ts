@@ -0,0 +1,65 @@
+import apiClient from '../client'
+
+export interface Role {
+  id?: string
+  name: string
+  description: string
+  permissions: string[]
+  system: boolean
+  user_count: number
+}
+
+export interface Permission {
+  key: string
+  description: string
+}
+
+export interface RoleInput {
+  name?: string
+  description?: string
+  permissions?: string[]
+}
+
+class RoleService {
+  // List system and custom roles of the tenant
+  async listRoles(): Promise<Role[]> {
+    const response = await apiClient.get<Role[]>('/tenant/roles')
+    return response.data
+  }
+
+  // Catalog of permissions that can be granted to custom roles
+  async listPermissions(): Promise<Permission[]> {
+    const response = await apiClient.get<Permission[]>('/tenant/roles/permissions')
+    return response.data
+  }
+
+  // Permissions of the current user
+  async getMyPermissions(): Promise<{ role: string; permissions: string[] }> {
+    const response = await apiClient.get('/tenant/roles/me')
+    return response.data
+  }
+
+  async getRole(roleId: string): Promise<Role> {
+    const response = await apiClient.get<Role>(`/tenant/roles/${roleId}`)
+    return response.data
+  }
+
+  // Only permissions the current user has can be granted
+  async createRole(data: RoleInput): Promise<Role> {
+    const response = await apiClient.post<Role>('/tenant/roles', data)
+    return response.data
+  }
+
+  // Renaming a role moves its users to the new name
+  async updateRole(roleId: string, data: RoleInput): Promise<Role> {
+    const response = await apiClient.put<Role>(`/tenant/roles/${roleId}`, data)
+    return response.data
+  }
+
+  // Fails while the role is assigned to users
+  async deleteRole(roleId: string): Promise<void> {
+    await apiClient.delete(`/tenant/roles/${roleId}`)
+  }
+}
+
+export const roleService = new RoleService()
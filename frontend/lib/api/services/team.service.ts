@@ -0,0 +1,99 @@
+import apiClient from '../client'
+import { User } from './user.service'
+
+export interface TeamMember {
+  id: string
+  tenant_id: string
+  team_id: string
+  user_id: string
+  is_lead: boolean
+  created_at: string
+  user?: User
+}
+
+export interface Team {
+  id: string
+  tenant_id: string
+  name: string
+  description: string
+  chatwoot_team_id?: number | null
+  created_at: string
+  updated_at: string
+  members?: TeamMember[]
+}
+
+export interface TeamInput {
+  name?: string
+  description?: string
+}
+
+export interface TeamScope {
+  all: boolean
+  user_ids: string[]
+}
+
+class TeamService {
+  async listTeams(): Promise<Team[]> {
+    const response = await apiClient.get<Team[]>('/tenant/teams')
+    return response.data
+  }
+
+  // Teams the current user belongs to
+  async listMyTeams(): Promise<TeamMember[]> {
+    const response = await apiClient.get<TeamMember[]>('/tenant/teams/mine')
+    return response.data
+  }
+
+  // Users whose conversations and leads the current user can see
+  async getScope(): Promise<TeamScope> {
+    const response = await apiClient.get<TeamScope>('/tenant/teams/scope')
+    return response.data
+  }
+
+  // Whether the current user can manage the assignments of another user
+  async canManageAssignments(userId: string): Promise<boolean> {
+    const response = await apiClient.get<{ allowed: boolean }>(`/tenant/teams/assignments/${userId}`)
+    return response.data.allowed
+  }
+
+  async getTeam(teamId: string): Promise<Team> {
+    const response = await apiClient.get<Team>(`/tenant/teams/${teamId}`)
+    return response.data
+  }
+
+  async createTeam(data: TeamInput): Promise<Team> {
+    const response = await apiClient.post<Team>('/tenant/teams', data)
+    return response.data
+  }
+
+  async updateTeam(teamId: string, data: TeamInput): Promise<Team> {
+    const response = await apiClient.put<Team>(`/tenant/teams/${teamId}`, data)
+    return response.data
+  }
+
+  async deleteTeam(teamId: string): Promise<void> {
+    await apiClient.delete(`/tenant/teams/${teamId}`)
+  }
+
+  // Adds a member, or changes whether they lead the team
+  async addMember(teamId: string, userId: string, isLead = false): Promise<Team> {
+    const response = await apiClient.post<Team>(`/tenant/teams/${teamId}/members`, {
+      user_id: userId,
+      is_lead: isLead,
+    })
+    return response.data
+  }
+
+  async removeMember(teamId: string, userId: string): Promise<Team> {
+    const response = await apiClient.delete<Team>(`/tenant/teams/${teamId}/members/${userId}`)
+    return response.data
+  }
+
+  // Pushes the team and its members to Chatwoot again
+  async syncChatwoot(teamId: string): Promise<Team> {
+    const response = await apiClient.post<Team>(`/tenant/teams/${teamId}/sync`)
+    return response.data
+  }
+}
+
+export const teamService = new TeamService()
@@ -8,16 +8,252 @@ export interface Tenant {
   settings?: Record<string, any>
   subscription_status: string
   subscription_ends_at?: string
+  status: 'active' | 'pending_deletion' | 'purged'
+  deletion_scheduled_at?: string | null
+  chatwoot_account_id?: number | null
+  chatwoot_agent_id?: number | null
+  chatwoot_inbox_id?: number | null
   created_at: string
   updated_at: string
 }
 
+export interface TenantSlugAlias {
+  id: string
+  tenant_id: string
+  slug: string
+  expires_at: string
+  created_at: string
+}
+
+export interface TenantBranding {
+  display_name: string
+  logo_url?: string
+  primary_color: string
+  secondary_color: string
+  support_email?: string
+  footer?: string
+}
+
+export type UpdateBrandingRequest = Partial<Omit<TenantBranding, 'logo_url'>>
+
+export interface EmailTemplateOverride {
+  subject?: string
+  intro?: string
+}
+
+export interface EmailTemplate {
+  name: string
+  default_subject: string
+  default_intro: string
+  override?: EmailTemplateOverride
+}
+
+export interface EmailTemplateSettings {
+  locale: string
+  locales: string[]
+  placeholders: string[]
+  templates: EmailTemplate[]
+}
+
+export type MailMode = 'platform' | 'smtp'
+
+export type SMTPTLSMode = '' | 'none' | 'starttls' | 'tls'
+
+export interface MailSettings {
+  tenant_id: string
+  mode: MailMode
+  smtp_host?: string
+  smtp_port?: number
+  smtp_tls_mode?: SMTPTLSMode
+  smtp_username?: string
+  has_password: boolean
+  from_email?: string
+  from_name?: string
+  sender_verified_at?: string
+  health_status: 'unknown' | 'healthy' | 'failing'
+  consecutive_failures: number
+  last_error?: string
+  last_checked_at?: string
+  // Emails go through the tenant relay only while it is healthy
+  using_relay: boolean
+}
+
+export interface UpdateMailSettingsRequest {
+  mode: MailMode
+  smtp_host?: string
+  smtp_port?: number
+  smtp_tls_mode?: SMTPTLSMode
+  smtp_username?: string
+  // Omit to keep the stored password
+  smtp_password?: string
+  from_email?: string
+  from_name?: string
+}
+
+export interface MailTestResult {
+  transport: MailMode
+  recipient: string
+  delivered: boolean
+  error?: string
+}
+
+export interface ChatwootIntegration {
+  id: string
+  tenant_id: string
+  provider: 'chatwoot'
+  base_url: string
+  account_id: number
+  status: 'connected' | 'failing'
+  last_error?: string
+  last_checked_at?: string | null
+  connected_by?: string | null
+  // Account reached by the connection check of the request
+  account?: { id: number; name: string; role: string }
+  // URL to set on the webhook of the Chatwoot account
+  webhook_url: string
+  // Webhooks are checked with the secret rather than the token of the URL
+  webhook_signed: boolean
+  created_at: string
+  updated_at: string
+}
+
+export interface ConnectChatwootRequest {
+  // Defaults to the platform Chatwoot URL
+  base_url?: string
+  account_id: number
+  // Omit to keep the stored token
+  access_token?: string
+  // Secret Chatwoot signs the webhooks with; omit to keep the stored one
+  webhook_secret?: string
+}
+
+export type EmailDeliveryEventType = 'bounce' | 'complaint' | 'suppressed'
+
+export interface EmailDeliveryEvent {
+  id: string
+  tenant_id?: string | null
+  recipient: string
+  type: EmailDeliveryEventType
+  bounce_type?: 'hard' | 'soft'
+  // webhook, dsn (returned mail) or sender (send skipped)
+  source: 'webhook' | 'dsn' | 'sender'
+  diagnostic?: string
+  email_id?: string | null
+  occurred_at: string
+  created_at: string
+}
+
+export interface EmailSuppression {
+  id: string
+  tenant_id: string
+  email: string
+  reason: 'hard_bounce' | 'complaint' | 'manual'
+  detail?: string
+  created_at: string
+}
+
+export interface RecipientDeliveryLog {
+  recipient: string
+  suppression: EmailSuppression | null
+  events: EmailDeliveryEvent[]
+  emails: {
+    id: string
+    subject: string
+    status: 'pending' | 'sending' | 'sent' | 'failed' | 'dead'
+    attempts: number
+    last_error?: string
+    sent_at?: string | null
+    created_at: string
+  }[]
+}
+
+export interface EmailPreview {
+  subject: string
+  html: string
+  text: string
+}
+
+export interface OnboardingStep {
+  key: string
+  title: string
+  completed: boolean
+  completed_at?: string
+}
+
+export interface ChatwootProvisioning {
+  id: string
+  tenant_id: string
+  user_id: string
+  status: 'pending' | 'completed' | 'compensating' | 'failed'
+  // Next step to run, or the step that failed
+  step: 'create_account' | 'create_agent' | 'create_inbox' | 'connect'
+  account_id?: number | null
+  agent_id?: number | null
+  inbox_id?: number | null
+  attempts: number
+  next_attempt_at: string
+  last_error?: string
+  completed_at?: string | null
+  created_at: string
+  updated_at: string
+}
+
+export interface OnboardingProgress {
+  steps: OnboardingStep[]
+  completed_count: number
+  total_count: number
+  completed: boolean
+  // Absent when the tenant was not provisioned automatically
+  chatwoot?: ChatwootProvisioning
+}
+
+export interface DomainVerification {
+  domain: string
+  record_type: string
+  record_name: string
+  record_value: string
+  verified: boolean
+  verified_at?: string
+}
+
+export interface OwnershipTransfer {
+  id: string
+  tenant_id: string
+  from_user_id: string
+  to_user_id: string
+  status: 'pending' | 'accepted' | 'cancelled'
+  expires_at: string
+  accepted_at?: string | null
+  cancelled_at?: string | null
+  created_at: string
+}
+
 export interface UpdateTenantRequest {
   name?: string
   domain?: string
   settings?: Record<string, any>
 }
 
+export interface Plan {
+  id: string
+  code: string
+  name: string
+  max_seats: number
+  max_inboxes: number
+  max_monthly_conversations: number
+  max_bot_flows: number
+  max_api_keys: number
+  features: string[]
+}
+
+export interface QuotaUsage {
+  resource: 'seats' | 'inboxes' | 'monthly_conversations' | 'bot_flows' | 'api_keys'
+  used: number
+  limit: number
+  remaining: number
+  unlimited: boolean
+}
+
 export interface TenantStats {
   user_count: number
   active_users: number
@@ -28,6 +264,38 @@ export interface TenantStats {
   subscription_status: string
   subscription_ends_at: string
   created_at: string
+  plan?: Plan
+  usage: QuotaUsage[]
+}
+
+export type UsageMetric =
+  | 'messages_sent'
+  | 'conversations_started'
+  | 'bot_runs'
+  | 'api_calls'
+  | 'emails_sent'
+
+export interface UsageRollup {
+  tenant_id: string
+  metric: UsageMetric
+  granularity: 'hour' | 'day'
+  period_start: string
+  count: number
+}
+
+export interface UsageReport {
+  from: string
+  to: string
+  granularity: 'hour' | 'day'
+  totals: Record<string, number>
+  series: UsageRollup[]
+}
+
+export interface UsageQuery {
+  from?: string
+  to?: string
+  granularity?: 'hour' | 'day'
+  metric?: UsageMetric[]
 }
 
 class TenantService {
@@ -59,20 +327,134 @@ class TenantService {
       days_remaining: tenantResponse.data.days_remaining || 0,
       subscription_status: tenantResponse.data.subscription_status || 'trial',
       subscription_ends_at: tenantResponse.data.subscription_ends_at || '',
-      created_at: tenantResponse.data.created_at || ''
+      created_at: tenantResponse.data.created_at || '',
+      plan: tenantResponse.data.plan,
+      usage: tenantResponse.data.usage || []
     }
   }
 
+  // Get metered usage over a date range
+  async getUsage(query: UsageQuery = {}): Promise<UsageReport> {
+    const params: Record<string, string> = {}
+    if (query.from) params.from = query.from
+    if (query.to) params.to = query.to
+    if (query.granularity) params.granularity = query.granularity
+    if (query.metric?.length) params.metric = query.metric.join(',')
+
+    const response = await apiClient.get<UsageReport>('/tenant/usage', { params })
+    return response.data
+  }
+
   // Update tenant name
   async updateTenantName(name: string): Promise<Tenant> {
     return this.updateTenant({ name })
   }
 
+  // Rename the tenant slug; the former slug redirects until the alias expires
+  async changeSlug(slug: string): Promise<{ tenant: Tenant; alias: TenantSlugAlias | null }> {
+    const response = await apiClient.put('/tenant/slug', { slug })
+    return response.data
+  }
+
+  // List former slugs still redirecting to the tenant
+  async getSlugAliases(): Promise<TenantSlugAlias[]> {
+    const response = await apiClient.get<TenantSlugAlias[]>('/tenant/slug/aliases')
+    return response.data
+  }
+
+  // Get the branding of the current tenant
+  async getBranding(): Promise<TenantBranding> {
+    const response = await apiClient.get<TenantBranding>('/tenant/branding')
+    return response.data
+  }
+
+  // Update branding fields; empty strings reset a field to the platform default
+  async updateBranding(data: UpdateBrandingRequest): Promise<TenantBranding> {
+    const response = await apiClient.put<TenantBranding>('/tenant/branding', data)
+    return response.data
+  }
+
+  // Upload a PNG, JPEG or WebP logo (max 1 MB)
+  async uploadLogo(file: File): Promise<TenantBranding> {
+    const form = new FormData()
+    form.append('logo', file)
+    const response = await apiClient.post<TenantBranding>('/tenant/branding/logo', form, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+    })
+    return response.data
+  }
+
+  async removeLogo(): Promise<TenantBranding> {
+    const response = await apiClient.delete<TenantBranding>('/tenant/branding/logo')
+    return response.data
+  }
+
+  // Public branding of a tenant, available before login
+  async getPublicBranding(slug: string): Promise<TenantBranding> {
+    const response = await apiClient.get<TenantBranding>(`/public/tenants/${slug}/branding`)
+    return response.data
+  }
+
+  // Email locale and template overrides of the current tenant
+  async getEmailTemplates(): Promise<EmailTemplateSettings> {
+    const response = await apiClient.get<EmailTemplateSettings>('/tenant/email-templates')
+    return response.data
+  }
+
+  // Locale of the emails of users who did not choose one; empty resets to the default
+  async updateEmailLocale(locale: string): Promise<EmailTemplateSettings> {
+    const response = await apiClient.put<EmailTemplateSettings>('/tenant/email-templates/locale', { locale })
+    return response.data
+  }
+
+  // Override the subject and intro of a template; placeholders like {{.UserName}} are allowed
+  async updateEmailTemplate(name: string, data: EmailTemplateOverride): Promise<EmailTemplateSettings> {
+    const response = await apiClient.put<EmailTemplateSettings>(`/tenant/email-templates/${name}`, data)
+    return response.data
+  }
+
+  async resetEmailTemplate(name: string): Promise<EmailTemplateSettings> {
+    const response = await apiClient.delete<EmailTemplateSettings>(`/tenant/email-templates/${name}`)
+    return response.data
+  }
+
+  // Render a template with sample data
+  async previewEmailTemplate(name: string, locale?: string): Promise<EmailPreview> {
+    const response = await apiClient.get<EmailPreview>(`/tenant/email-templates/${name}/preview`, {
+      params: locale ? { locale } : undefined,
+    })
+    return response.data
+  }
+
   // Update tenant domain
   async updateTenantDomain(domain: string): Promise<Tenant> {
     return this.updateTenant({ domain })
   }
 
+  // Onboarding checklist of the current tenant
+  async getOnboarding(): Promise<OnboardingProgress> {
+    const response = await apiClient.get<OnboardingProgress>('/tenant/onboarding')
+    return response.data
+  }
+
+  // Retry the Chatwoot provisioning of the current tenant after it failed
+  async retryChatwootProvisioning(): Promise<ChatwootProvisioning> {
+    const response = await apiClient.post<ChatwootProvisioning>('/tenant/onboarding/chatwoot/retry')
+    return response.data
+  }
+
+  // Get the TXT record that proves ownership of the custom domain
+  async requestDomainVerification(): Promise<DomainVerification> {
+    const response = await apiClient.post<DomainVerification>('/tenant/domain/verification')
+    return response.data
+  }
+
+  // Check the TXT record of the custom domain
+  async verifyDomain(): Promise<DomainVerification> {
+    const response = await apiClient.post<DomainVerification>('/tenant/domain/verify')
+    return response.data
+  }
+
   // Update tenant settings
   async updateTenantSettings(settings: Record<string, any>): Promise<Tenant> {
     const currentTenant = await this.getCurrentTenant()
@@ -80,6 +462,124 @@ class TenantService {
     return this.updateTenant({ settings: mergedSettings })
   }
 
+  // Get the ownership transfer awaiting confirmation, if any
+  async getOwnershipTransfer(): Promise<OwnershipTransfer | null> {
+    const response = await apiClient.get<{ transfer: OwnershipTransfer | null }>('/tenant/ownership')
+    return response.data.transfer
+  }
+
+  // Start an ownership transfer; the owner confirms with their password and the target by email
+  async transferOwnership(userId: string, password: string): Promise<OwnershipTransfer> {
+    const response = await apiClient.post('/tenant/ownership/transfer', { user_id: userId, password })
+    return response.data.transfer
+  }
+
+  async cancelOwnershipTransfer(): Promise<void> {
+    await apiClient.delete('/tenant/ownership/transfer')
+  }
+
+  // Accept the ownership with the token sent by email
+  async acceptOwnership(token: string): Promise<OwnershipTransfer> {
+    const response = await apiClient.post('/tenant/ownership/accept', { token })
+    return response.data.transfer
+  }
+
+  // SMTP relay and sender address of the current tenant
+  async getMailSettings(): Promise<MailSettings> {
+    const response = await apiClient.get<MailSettings>('/tenant/mail-settings')
+    return response.data
+  }
+
+  async updateMailSettings(data: UpdateMailSettingsRequest): Promise<MailSettings> {
+    const response = await apiClient.put<MailSettings>('/tenant/mail-settings', data)
+    return response.data
+  }
+
+  // Go back to the platform transport and sender
+  async resetMailSettings(): Promise<void> {
+    await apiClient.delete('/tenant/mail-settings')
+  }
+
+  async resendSenderVerification(): Promise<MailSettings> {
+    const response = await apiClient.post<MailSettings>('/tenant/mail-settings/verification')
+    return response.data
+  }
+
+  async checkMailRelay(): Promise<MailSettings> {
+    const response = await apiClient.post<MailSettings>('/tenant/mail-settings/check')
+    return response.data
+  }
+
+  // Send a test email to the address, or to the current user
+  async sendTestEmail(to?: string): Promise<MailTestResult> {
+    const response = await apiClient.post<MailTestResult>('/tenant/mail-settings/test', { to })
+    return response.data
+  }
+
+  // Confirm a sender address from its verification email; no login required
+  async verifySender(token: string): Promise<{ from_email: string; verified_at: string }> {
+    const response = await apiClient.post('/public/mail-sender/verify', { token })
+    return response.data
+  }
+
+  // Chatwoot account of the current tenant
+  async getChatwootIntegration(): Promise<ChatwootIntegration> {
+    const response = await apiClient.get<ChatwootIntegration>('/tenant/integrations/chatwoot')
+    return response.data
+  }
+
+  // Connect the account; the token is checked before it is stored
+  async connectChatwoot(data: ConnectChatwootRequest): Promise<ChatwootIntegration> {
+    const response = await apiClient.put<ChatwootIntegration>('/tenant/integrations/chatwoot', data)
+    return response.data
+  }
+
+  async testChatwoot(): Promise<ChatwootIntegration> {
+    const response = await apiClient.post<ChatwootIntegration>('/tenant/integrations/chatwoot/test')
+    return response.data
+  }
+
+  async disconnectChatwoot(): Promise<void> {
+    await apiClient.delete('/tenant/integrations/chatwoot')
+  }
+
+  // Latest bounces, complaints and skipped sends of the current tenant
+  async getEmailDeliveryEvents(type?: EmailDeliveryEventType, limit?: number): Promise<EmailDeliveryEvent[]> {
+    const response = await apiClient.get<EmailDeliveryEvent[]>('/tenant/email-deliveries/events', {
+      params: { type, limit },
+    })
+    return response.data
+  }
+
+  // Emails sent to an address, their delivery events and its suppression
+  async getRecipientDeliveryLog(email: string): Promise<RecipientDeliveryLog> {
+    const response = await apiClient.get<RecipientDeliveryLog>(
+      `/tenant/email-deliveries/recipients/${encodeURIComponent(email)}`
+    )
+    return response.data
+  }
+
+  async getEmailSuppressions(): Promise<EmailSuppression[]> {
+    const response = await apiClient.get<EmailSuppression[]>('/tenant/email-deliveries/suppressions')
+    return response.data
+  }
+
+  async addEmailSuppression(email: string, detail?: string): Promise<EmailSuppression> {
+    const response = await apiClient.post<EmailSuppression>('/tenant/email-deliveries/suppressions', { email, detail })
+    return response.data
+  }
+
+  // Let emails reach the address again
+  async removeEmailSuppression(email: string): Promise<void> {
+    await apiClient.delete(`/tenant/email-deliveries/suppressions/${encodeURIComponent(email)}`)
+  }
+
+  // Schedule the tenant for deletion; the caller confirms with their password
+  async requestDeletion(password: string, reason?: string): Promise<{ status: string; deletion_scheduled_at: string }> {
+    const response = await apiClient.post('/tenant/deletion', { password, reason })
+    return response.data
+  }
+
   // Check if user has admin privileges
   async canManageTenant(): Promise<boolean> {
     try {
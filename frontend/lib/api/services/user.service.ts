@@ -1,13 +1,24 @@
 import apiClient from '../client'
 
+// System roles; tenants can also define custom roles
+export type SystemRoleName = 'owner' | 'admin' | 'agent' | 'viewer'
+export type RoleName = SystemRoleName | (string & {})
+
 export interface User {
   id: string
   tenant_id: string
   email: string
   name: string
-  role: 'owner' | 'admin' | 'agent' | 'viewer'
+  role: RoleName
   is_active: boolean
   last_login_at?: string
+  chatwoot_agent_id?: number | null
+  presence: 'online' | 'away' | 'busy' | 'offline'
+  last_seen_at?: string
+  max_concurrent_conversations: number
+  locale?: string
+  // Set when emails to the user bounce; the address no longer receives emails
+  email_bounced_at?: string | null
   created_at: string
   updated_at: string
 }
@@ -16,14 +27,16 @@ export interface CreateUserRequest {
   email: string
   password: string
   name: string
-  role: 'admin' | 'agent' | 'viewer'
+  role: RoleName
 }
 
 export interface UpdateUserRequest {
   email?: string
   name?: string
-  role?: 'admin' | 'agent' | 'viewer'
+  role?: RoleName
   is_active?: boolean
+  chatwoot_agent_id?: number | null
+  max_concurrent_conversations?: number
 }
 
 export interface ResetPasswordRequest {
@@ -42,10 +55,97 @@ export interface UserStats {
   recent_signups: number
 }
 
+export type UserSortField = 'name' | 'email' | 'created_at' | 'last_login_at'
+
+export interface UserListParams {
+  q?: string
+  role?: RoleName[]
+  is_active?: boolean
+  last_login_after?: string
+  last_login_before?: string
+  never_logged_in?: boolean
+  email_bounced?: boolean
+  // Prefix with "-" for descending order
+  sort?: UserSortField | `-${UserSortField}`
+  // Capped at 100 by the server
+  limit?: number
+  cursor?: string
+}
+
+export interface UserList {
+  data: User[]
+  next_cursor?: string
+  total: number
+  limit: number
+}
+
+export type UserImportStatus = 'valid' | 'invalid' | 'duplicate' | 'created' | 'failed' | 'skipped'
+
+export interface UserImportRow {
+  line: number
+  name: string
+  email: string
+  role: RoleName
+  teams: string[]
+  status: UserImportStatus
+  errors?: string[]
+  user_id?: string
+  invited?: boolean
+}
+
+export interface UserImportReport {
+  dry_run: boolean
+  atomic: boolean
+  total: number
+  valid: number
+  created: number
+  failed: number
+  invited: number
+  rows: UserImportRow[]
+}
+
+export interface UserImportOptions {
+  dryRun?: boolean
+  // atomic creates every user or none; partial creates the valid rows only
+  mode?: 'atomic' | 'partial'
+  sendInvitations?: boolean
+}
+
+export interface UserOffboardingRequest {
+  // user and team receive the open work; queue returns it to routing
+  reassign_to: 'user' | 'team' | 'queue'
+  user_id?: string
+  team_id?: string
+  dry_run?: boolean
+}
+
+export interface ReassignedTable {
+  table: string
+  count: number
+  items: { id: string; assigned_to: string | null }[]
+}
+
+export interface UserOffboardingSummary {
+  user_id: string
+  dry_run: boolean
+  reassign_to: UserOffboardingRequest['reassign_to']
+  target_user_id?: string
+  target_team_id?: string
+  deactivated: boolean
+  sessions_revoked: number
+  api_keys_revoked: number
+  reassigned: ReassignedTable[]
+  chatwoot_updated: number
+  chatwoot_failed: number[]
+  skipped_tables: string[]
+}
+
 class UserService {
-  // List all users in the tenant
-  async listUsers(): Promise<User[]> {
-    const response = await apiClient.get<User[]>('/tenant/users')
+  // List users of the tenant, one page at a time; pass next_cursor to get the next page
+  async listUsers(params: UserListParams = {}): Promise<UserList> {
+    const response = await apiClient.get<UserList>('/tenant/users', {
+      params: { ...params, role: params.role?.join(',') || undefined },
+    })
     return response.data
   }
 
@@ -99,8 +199,14 @@ class UserService {
     return this.updateUser(userId, { is_active: false })
   }
 
+  // Offboard a user, moving their open work; dry_run returns the summary without changes
+  async offboardUser(userId: string, data: UserOffboardingRequest): Promise<UserOffboardingSummary> {
+    const response = await apiClient.post<UserOffboardingSummary>(`/tenant/users/${userId}/offboard`, data)
+    return response.data
+  }
+
   // Change user role
-  async changeUserRole(userId: string, role: 'admin' | 'agent' | 'viewer'): Promise<User> {
+  async changeUserRole(userId: string, role: RoleName): Promise<User> {
     return this.updateUser(userId, { role })
   }
 
@@ -111,6 +217,26 @@ class UserService {
     })
     return response.data
   }
+
+  // Import users from a CSV with the columns name, email, role and team.
+  // An atomic import with invalid rows is rejected with the report in the error response.
+  async importUsers(file: File, options: UserImportOptions = {}): Promise<UserImportReport> {
+    const form = new FormData()
+    form.append('file', file)
+    form.append('dry_run', String(options.dryRun ?? false))
+    form.append('mode', options.mode ?? 'atomic')
+    form.append('send_invitations', String(options.sendInvitations ?? false))
+    const response = await apiClient.post<UserImportReport>('/tenant/users/import', form, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+    })
+    return response.data
+  }
+
+  // Export the tenant users as CSV
+  async exportUsers(): Promise<Blob> {
+    const response = await apiClient.get('/tenant/users/export', { responseType: 'blob' })
+    return response.data
+  }
 }
 
 export const userService = new UserService()
\ No newline at end of file
@@ -8,6 +8,8 @@ const publicRoutes = [
   '/auth/register',
   '/auth/forgot-password',
   '/auth/reset-password',
+  '/auth/ownership/accept',
+  '/auth/verify-sender',
 ]
 
 // Routes that should redirect to dashboard if already authenticated